@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,47 +12,214 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 
+	"github.com/heptapegon/localpickup/internal/breaker"
 	"github.com/heptapegon/localpickup/internal/config"
 	"github.com/heptapegon/localpickup/internal/handler"
+	"github.com/heptapegon/localpickup/internal/health"
 	"github.com/heptapegon/localpickup/internal/infra"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/metrics"
 	custMiddleware "github.com/heptapegon/localpickup/internal/middleware"
 	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
 	redisrepo "github.com/heptapegon/localpickup/internal/repository/redis"
 	"github.com/heptapegon/localpickup/internal/service"
+	"github.com/heptapegon/localpickup/internal/storage"
+	"github.com/heptapegon/localpickup/internal/telemetry"
 	"github.com/heptapegon/localpickup/pkg/fcm"
 )
 
+// Usage:
+//
+//	server                         run the HTTP API (same as `server serve`)
+//	server migrate <up|down|status|to N>
+//	server reindex [--dry-run]       reconcile the Redis geo index with Postgres
+//	server menu import <business-id> <file> [--dry-run]
+//	server menu export <business-id> <file>
 func main() {
 	cfg := config.Load()
 
+	level, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger, err := logging.New(os.Stdout, logging.Config{Level: level, Format: cfg.LogFormat})
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Code without an injected logger (middleware, infra, the stdlib log
+	// package) goes through the same handler.
+	slog.SetDefault(logger)
+
+	cmd := "serve"
+	if len(os.Args) > 1 {
+		cmd = os.Args[1]
+	}
+
+	switch cmd {
+	case "serve":
+		serve(cfg, logger)
+	case "migrate":
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			fatal("migrate failed", err)
+		}
+	case "reindex":
+		if err := runReindex(cfg, logger, os.Args[2:]); err != nil {
+			fatal("reindex failed", err)
+		}
+	case "menu":
+		if err := runMenu(cfg, logger, os.Args[2:]); err != nil {
+			fatal("menu failed", err)
+		}
+	default:
+		fatal("unknown command", fmt.Errorf("%q (want serve, migrate, reindex or menu)", cmd))
+	}
+}
+
+// fatal logs err and exits; slog has no Fatal level.
+func fatal(msg string, err error) {
+	slog.Error(msg, logging.Error, err)
+	os.Exit(1)
+}
+
+// After geoBreakerFailures consecutive errors the primary geo backend is
+// skipped for geoBreakerCooldown before a single probe is let through.
+const (
+	geoBreakerFailures = 5
+	geoBreakerCooldown = 30 * time.Second
+
+	// stockResetInterval is how soon after midnight daily stock is refilled.
+	stockResetInterval = time.Minute
+
+	// idempotencyPurgeInterval is how often expired idempotency records are
+	// deleted; until then they are only ignored.
+	idempotencyPurgeInterval = time.Hour
+)
+
+func serve(cfg *config.Config, logger *slog.Logger) {
 	// ── Infrastructure ──────────────────────────────────────────────────────
-	db := infra.NewPostgres(cfg.DatabaseURL)
-	rdb := infra.NewRedis(cfg.RedisURL)
-	fcmClient := fcm.NewClient(cfg.FirebaseCredentialsPath)
+	shutdownTracing, err := telemetry.Setup(context.Background(), telemetry.Config{
+		Exporter:    cfg.TracingExporter,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		fatal("tracing setup failed", err)
+	}
+
+	startupCtx, cancelStartup := context.WithTimeout(context.Background(), cfg.StartupTimeout)
+	db, err := infra.NewPostgres(startupCtx, cfg.DatabaseURL)
+	if err != nil {
+		fatal("postgres unavailable", err)
+	}
+	ensureSchema(cfg, db)
+	rdb, err := infra.NewRedis(startupCtx, cfg.RedisURL)
+	if err != nil {
+		fatal("redis unavailable", err)
+	}
+	cancelStartup()
+	metrics.RegisterPools(db, rdb)
+	fcmClient := fcm.NewClient(cfg.FirebaseCredentialsPath, logger)
+	images, err := newStorage(cfg)
+	if err != nil {
+		fatal("invalid storage configuration", err)
+	}
 
 	// ── Repositories ────────────────────────────────────────────────────────
 	businessRepo := postgresrepo.NewBusinessRepository(db)
+	categoryRepo := postgresrepo.NewCategoryRepository(db)
 	orderRepo := postgresrepo.NewOrderRepository(db)
+	productRepo := postgresrepo.NewProductRepository(db)
+	reviewRepo := postgresrepo.NewReviewRepository(db)
+	favoriteRepo := postgresrepo.NewFavoriteRepository(db)
+	promotionRepo := postgresrepo.NewPromotionRepository(db)
+	searchRepo := postgresrepo.NewSearchRepository(db)
+	idempotencyRepo := postgresrepo.NewIdempotencyRepository(db)
 	geoRepo := redisrepo.NewGeoRepository(rdb)
+	postgisRepo := postgresrepo.NewGeoRepository(db)
+	pinRepo := redisrepo.NewPINRepository(rdb)
+	cartRepo := redisrepo.NewCartRepository(rdb)
+
+	var nearby service.GeoIndex
+	switch cfg.GeoPrimary {
+	case "redis":
+		nearby = service.NewFailoverGeoIndex(geoRepo, postgisRepo, breaker.New(geoBreakerFailures, geoBreakerCooldown), logger)
+	case "postgis":
+		nearby = service.NewFailoverGeoIndex(postgisRepo, geoRepo, breaker.New(geoBreakerFailures, geoBreakerCooldown), logger)
+	default:
+		fatal("invalid GEO_PRIMARY", fmt.Errorf("%q is not redis or postgis", cfg.GeoPrimary))
+	}
 
 	// ── Services ────────────────────────────────────────────────────────────
-	businessSvc := service.NewBusinessService(businessRepo, geoRepo)
-	paymentSvc := service.NewPaymentService(cfg.StripeSecretKey)
-	notifSvc := service.NewNotificationService(fcmClient)
-	orderSvc := service.NewOrderService(orderRepo, businessRepo, paymentSvc, notifSvc, rdb)
+	categorySvc := service.NewCategoryService(categoryRepo, logger)
+	businessSvc := service.NewBusinessService(businessRepo, nearby, categorySvc, images, logger)
+	productSvc := service.NewProductService(productRepo, businessRepo, images, logger)
+	menuSvc := service.NewMenuService(productRepo, businessRepo, images, logger)
+	searchSvc := service.NewSearchService(searchRepo, images, logger)
+	paymentSvc := service.NewPaymentService(cfg.StripeSecretKey, logger)
+	notifSvc := service.NewNotificationService(fcmClient, logger)
+	pricing := service.Pricing{
+		TaxRate:          cfg.TaxRate,
+		ServiceFee:       cfg.ServiceFee,
+		PrepTime:         cfg.PickupPrepTime,
+		PrepTimePerOrder: cfg.PickupPrepTimePerOrder,
+	}
+	promotionTZ, err := time.LoadLocation(cfg.PromotionTimezone)
+	if err != nil {
+		fatal("invalid PROMOTION_TIMEZONE", err)
+	}
+	promotionSvc := service.NewPromotionService(promotionRepo, orderRepo, businessRepo, categorySvc, promotionTZ, logger)
+	orderSvc := service.NewOrderService(orderRepo, businessRepo, productRepo, paymentSvc, notifSvc, pinRepo, promotionSvc, pricing, logger)
+	cartSvc := service.NewCartService(cartRepo, orderSvc, businessRepo, cfg.CartTTL, logger)
+	reviewSvc := service.NewReviewService(reviewRepo, orderRepo, businessRepo, logger)
+	favoriteSvc := service.NewFavoriteService(favoriteRepo, businessRepo, images, logger)
+	geoReconciler := service.NewGeoReconciler(businessRepo, geoRepo, logger)
+	stockTZ, err := time.LoadLocation(cfg.StockResetTimezone)
+	if err != nil {
+		fatal("invalid STOCK_RESET_TIMEZONE", err)
+	}
+	stockResetter := service.NewStockResetter(productRepo, stockTZ, logger)
 
 	// ── Handlers ────────────────────────────────────────────────────────────
 	authHandler := handler.NewAuthHandler(db, cfg.JWTSecret)
 	businessHandler := handler.NewBusinessHandler(businessSvc)
+	categoryHandler := handler.NewCategoryHandler(categorySvc)
 	orderHandler := handler.NewOrderHandler(orderSvc)
+	cartHandler := handler.NewCartHandler(cartSvc)
+	productHandler := handler.NewProductHandler(productSvc)
+	menuHandler := handler.NewMenuHandler(menuSvc)
+	searchHandler := handler.NewSearchHandler(searchSvc)
+	reviewHandler := handler.NewReviewHandler(reviewSvc)
+	promotionHandler := handler.NewPromotionHandler(promotionSvc)
+	favoriteHandler := handler.NewFavoriteHandler(favoriteSvc)
+	adminHandler := handler.NewAdminHandler(geoReconciler)
+	healthHandler := handler.NewHealthHandler(health.NewChecker(
+		health.Check{Name: "postgres", Critical: true, Run: db.Ping},
+		health.Check{Name: "redis", Critical: true, Run: func(ctx context.Context) error { return rdb.Ping(ctx).Err() }},
+		health.Check{Name: "geo_index", Run: health.GeoIndexCheck(geoRepo.Count, businessRepo.CountActive)},
+		health.Check{Name: "notifications", Run: health.EnabledCheck(fcmClient.Enabled, "push notifications disabled")},
+		health.Check{Name: "storage", Run: images.Check},
+	))
 
 	// ── Echo ─────────────────────────────────────────────────────────────────
 	e := echo.New()
 	e.HideBanner = true
+	e.HidePort = true
+	e.Validator = handler.NewValidator()
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
+	if e.IPExtractor, err = custMiddleware.IPExtractor(cfg.TrustedProxies); err != nil {
+		fatal("invalid TRUSTED_PROXIES", err)
+	}
+	rateLimits, err := custMiddleware.ParseRateLimits(cfg.RateLimits)
+	if err != nil {
+		fatal("invalid RATE_LIMITS", err)
+	}
+	rl := custMiddleware.NewRateLimiter(rdb, rateLimits)
 
 	e.Use(middleware.RequestID())
-	e.Use(middleware.Logger())
+	e.Use(otelecho.Middleware(telemetry.ServiceName, otelecho.WithSkipper(skipProbes)))
+	e.Use(metrics.Middleware())
+	e.Use(custMiddleware.RequestLogger(logger))
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"},
@@ -58,48 +227,184 @@ func main() {
 	}))
 
 	// ── Health ───────────────────────────────────────────────────────────────
-	e.GET("/health", func(c echo.Context) error {
-		return c.JSON(http.StatusOK, echo.Map{"status": "ok", "time": time.Now().UTC()})
-	})
+	e.GET("/livez", healthHandler.Livez)
+	e.GET("/readyz", healthHandler.Readyz)
+	// Kept for existing load balancer configs; same as /livez.
+	e.GET("/health", healthHandler.Livez)
+	e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
 
 	// ── Public routes ────────────────────────────────────────────────────────
 	auth := e.Group("/auth")
-	auth.POST("/register", authHandler.Register)
-	auth.POST("/login", authHandler.Login)
+	auth.POST("/register", authHandler.Register, rl.Group(custMiddleware.RateLimitAuth))
+	auth.POST("/login", authHandler.Login, rl.Group(custMiddleware.RateLimitAuth))
+	// The taxonomy is needed before sign-up (e.g. to register a business).
+	e.GET("/api/v1/categories", categoryHandler.List, rl.Group(custMiddleware.RateLimitSearch))
+	// Pictures stored by the local driver; S3 buckets are served directly.
+	if local, ok := images.(*storage.Local); ok {
+		e.Group(mediaPath, cacheForever).Static("", local.Dir())
+	}
 
 	// ── Protected routes (require JWT) ───────────────────────────────────────
 	api := e.Group("/api/v1", custMiddleware.JWT(cfg.JWTSecret))
+	// Replays stored responses for retried POST/PUT/PATCH/DELETE requests.
+	// Uploads keep their own, larger body limits.
+	api.Use(custMiddleware.Idempotency(idempotencyRepo, map[string]int64{
+		"/api/v1/businesses/:id/image":                     handler.ImageMaxBytes,
+		"/api/v1/businesses/:id/products/:productId/image": handler.ImageMaxBytes,
+		"/api/v1/businesses/:id/menu/import":               handler.MenuMaxBytes,
+	}))
 
 	// Businesses
 	api.POST("/businesses", businessHandler.Create)
-	api.GET("/businesses/nearby", businessHandler.GetNearby)
+	api.GET("/businesses/nearby", businessHandler.GetNearby, rl.Group(custMiddleware.RateLimitSearch))
+	api.GET("/businesses/in-bounds", businessHandler.InBounds, rl.Group(custMiddleware.RateLimitSearch))
 	api.GET("/businesses/:id", businessHandler.GetByID)
+	api.PUT("/businesses/:id/image", businessHandler.UploadImage)
+	api.DELETE("/businesses/:id/image", businessHandler.DeleteImage)
+
+	// Catalog
+	api.GET("/businesses/:id/products", productHandler.List)
+	api.POST("/businesses/:id/products", productHandler.Create)
+	api.PUT("/businesses/:id/products/:productId", productHandler.Update)
+	api.DELETE("/businesses/:id/products/:productId", productHandler.Delete)
+	api.PUT("/businesses/:id/products/:productId/image", productHandler.UploadImage)
+	api.DELETE("/businesses/:id/products/:productId/image", productHandler.DeleteImage)
+	api.GET("/businesses/:id/menu", menuHandler.Export)
+	api.POST("/businesses/:id/menu/import", menuHandler.Import)
+	api.GET("/businesses/:id/orders", orderHandler.Queue)
+	api.GET("/businesses/:id/promotions", promotionHandler.ListForBusiness)
+	api.POST("/businesses/:id/promotions", promotionHandler.CreateForBusiness)
+	api.PUT("/businesses/:id/promotions/:promotionId/status", promotionHandler.SetActiveForBusiness)
+
+	// Search
+	api.GET("/search", searchHandler.Search, rl.Group(custMiddleware.RateLimitSearch))
 
 	// Orders
-	api.POST("/orders", orderHandler.Create)
+	api.POST("/orders", orderHandler.Create, rl.Group(custMiddleware.RateLimitOrders))
 	api.GET("/orders", orderHandler.ListByUser)
 	api.GET("/orders/:id", orderHandler.GetByID)
-	api.POST("/orders/:id/validate-pin", orderHandler.ValidatePIN)
+	api.POST("/orders/:id/validate-pin", orderHandler.ValidatePIN, rl.Group(custMiddleware.RateLimitOrders))
 	api.POST("/orders/:id/cancel", orderHandler.Cancel)
+	api.POST("/orders/:id/reorder", orderHandler.Reorder)
+
+	// Carts
+	api.PUT("/carts", cartHandler.Save)
+	api.GET("/carts", cartHandler.List)
+	api.GET("/carts/:id", cartHandler.Get)
+	api.DELETE("/carts/:id", cartHandler.Delete)
+	api.POST("/carts/:id/quote", cartHandler.Quote)
+	api.POST("/carts/:id/checkout", cartHandler.Checkout, rl.Group(custMiddleware.RateLimitOrders))
+
+	// Favorites
+	api.GET("/favorites", favoriteHandler.List)
+	api.PUT("/favorites/:id", favoriteHandler.Add)
+	api.DELETE("/favorites/:id", favoriteHandler.Remove)
 
-	// Stripe webhook — auth is handled via Stripe-Signature header, not JWT
+	// Reviews
+	api.POST("/orders/:id/review", reviewHandler.Create, rl.Group(custMiddleware.RateLimitOrders))
+	api.GET("/businesses/:id/reviews", reviewHandler.ListByBusiness)
+	api.PUT("/reviews/:id/reply", reviewHandler.Reply)
+	api.POST("/reviews/:id/flag", reviewHandler.Flag)
+
+	// Admin
+	admin := api.Group("/admin", custMiddleware.RequireRole(custMiddleware.RoleAdmin))
+	admin.POST("/geo/reindex", adminHandler.ReindexGeo)
+	admin.GET("/reviews/flagged", reviewHandler.Flagged)
+	admin.PUT("/reviews/:id/status", reviewHandler.Moderate)
+	admin.GET("/promotions", promotionHandler.List)
+	admin.POST("/promotions", promotionHandler.Create)
+	admin.PUT("/promotions/:id/status", promotionHandler.SetActive)
+
+	// Stripe webhook — auth is handled via Stripe-Signature header, not JWT.
+	// Not rate limited: Stripe retries on 429 and bursts after outages.
 	e.POST("/webhooks/stripe", orderHandler.StripeWebhook)
 
+	// ── Background jobs ──────────────────────────────────────────────────────
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if cfg.GeoReconcileInterval > 0 {
+		go geoReconciler.Run(jobsCtx, cfg.GeoReconcileInterval)
+	}
+	go stockResetter.Run(jobsCtx, stockResetInterval)
+	go purgeIdempotencyKeys(jobsCtx, idempotencyRepo, logger)
+
 	// ── Graceful shutdown ────────────────────────────────────────────────────
 	go func() {
+		logger.Info("listening", "port", cfg.Port)
 		if err := e.Start(":" + cfg.Port); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("server error: %v", err)
+			fatal("server error", err)
 		}
 	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 	<-quit
-	log.Println("shutting down…")
+	logger.Info("shutting down")
+	healthHandler.Drain()
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := e.Shutdown(ctx); err != nil {
-		log.Fatal(err)
+		fatal("shutdown failed", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Warn("tracing: flush on shutdown failed", logging.Error, err)
+	}
+}
+
+// purgeIdempotencyKeys deletes expired idempotency records every
+// idempotencyPurgeInterval until ctx is cancelled. Replicas may all run it.
+func purgeIdempotencyKeys(ctx context.Context, repo *postgresrepo.IdempotencyRepository, logger *slog.Logger) {
+	ticker := time.NewTicker(idempotencyPurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if n, err := repo.DeleteExpired(ctx); err != nil && ctx.Err() == nil {
+			logger.ErrorContext(ctx, "idempotency key purge failed", logging.Error, err)
+		} else if n > 0 {
+			logger.InfoContext(ctx, "expired idempotency keys purged", "keys", n)
+		}
+	}
+}
+
+// skipProbes keeps health checks and scrapes out of traces.
+func skipProbes(c echo.Context) bool {
+	switch c.Path() {
+	case "/livez", "/readyz", "/health", "/metrics":
+		return true
+	}
+	return false
+}
+
+// mediaPath is where the local storage driver's files are served.
+const mediaPath = "/media"
+
+// newStorage builds the picture storage. Without MEDIA_BASE_URL, local files
+// are linked at this server's mediaPath on localhost.
+func newStorage(cfg *config.Config) (storage.Storage, error) {
+	base := cfg.MediaBaseURL
+	if base == "" && cfg.StorageDriver == "local" {
+		base = "http://localhost:" + cfg.Port + mediaPath
+	}
+	return storage.New(cfg.StorageDriver, cfg.MediaDir, base, storage.S3Config{
+		Endpoint:  cfg.S3Endpoint,
+		Region:    cfg.S3Region,
+		Bucket:    cfg.S3Bucket,
+		AccessKey: cfg.S3AccessKey,
+		SecretKey: cfg.S3SecretKey,
+		UseSSL:    cfg.S3UseSSL,
+	})
+}
+
+// cacheForever lets clients keep stored pictures; a key never changes content.
+func cacheForever(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		return next(c)
 	}
 }
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/config"
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/handler"
+	"github.com/heptapegon/localpickup/internal/infra"
+	"github.com/heptapegon/localpickup/internal/menu"
+	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
+	"github.com/heptapegon/localpickup/internal/service"
+)
+
+const menuUsage = "usage: server menu import <business-id> <file.csv|file.json> [--dry-run]\n" +
+	"       server menu export <business-id> <file.csv|file.json>   (- for stdout as JSON)"
+
+// runMenu imports or exports a business's catalog on behalf of its owner.
+func runMenu(cfg *config.Config, logger *slog.Logger, args []string) error {
+	dryRun := false
+	var positional []string
+	for _, a := range args {
+		switch a {
+		case "--dry-run", "-n":
+			dryRun = true
+		default:
+			positional = append(positional, a)
+		}
+	}
+	if len(positional) != 3 || (dryRun && positional[0] != "import") {
+		return errors.New(menuUsage)
+	}
+	action, path := positional[0], positional[2]
+	businessID, err := uuid.Parse(positional[1])
+	if err != nil {
+		return fmt.Errorf("invalid business id %q", positional[1])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.StartupTimeout)
+	defer cancel()
+	db, err := infra.NewPostgres(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	businesses := postgresrepo.NewBusinessRepository(db)
+	business, err := businesses.GetByID(ctx, businessID)
+	if err != nil {
+		return err
+	}
+	images, err := newStorage(cfg)
+	if err != nil {
+		return err
+	}
+	svc := service.NewMenuService(postgresrepo.NewProductRepository(db), businesses, images, logger)
+
+	switch action {
+	case "import":
+		return importMenu(svc, business, path, dryRun)
+	case "export":
+		return exportMenu(svc, business, path)
+	}
+	return errors.New(menuUsage)
+}
+
+func importMenu(svc *service.MenuService, business *domain.Business, path string, dryRun bool) error {
+	format, err := menu.ParseFormat(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	items, rowErrs, err := menu.Decode(f, format)
+	if err != nil {
+		return err
+	}
+	rowErrs = append(rowErrs, handler.ValidateMenu(handler.NewValidator(), items)...)
+	if len(rowErrs) == 0 {
+		report, err := svc.Import(context.Background(), business.OwnerID, business.ID, items, dryRun)
+		if err != nil {
+			return err
+		}
+		if rowErrs = report.Errors; len(rowErrs) == 0 {
+			printMenuReport(report)
+			return nil
+		}
+	}
+	for _, e := range rowErrs {
+		field := ""
+		if e.Field != "" {
+			field = " " + e.Field
+		}
+		fmt.Printf("row %d%s: %s\n", e.Row, field, e.Message)
+	}
+	return fmt.Errorf("%s has %d problems; nothing was imported", path, len(rowErrs))
+}
+
+func printMenuReport(r *domain.MenuReport) {
+	verb := "applied"
+	if r.DryRun {
+		verb = "dry run, nothing applied"
+	}
+	for _, group := range []struct {
+		label   string
+		changes []domain.MenuChange
+	}{{"create", r.Created}, {"update", r.Updated}, {"delete", r.Deleted}} {
+		fmt.Printf("%-8s %d\n", group.label, len(group.changes))
+		for _, c := range group.changes {
+			line := "  " + c.Name
+			if len(c.Fields) > 0 {
+				line += " (" + strings.Join(c.Fields, ", ") + ")"
+			}
+			fmt.Println(line)
+		}
+	}
+	fmt.Printf("%-8s %d\n%s\n", "same", r.Unchanged, verb)
+}
+
+func exportMenu(svc *service.MenuService, business *domain.Business, path string) error {
+	format := menu.JSON
+	if path != "-" {
+		var err error
+		if format, err = menu.ParseFormat(path); err != nil {
+			return err
+		}
+	}
+	products, err := svc.Export(context.Background(), business.OwnerID, business.ID)
+	if err != nil {
+		return err
+	}
+
+	if path == "-" {
+		return menu.Encode(os.Stdout, format, products)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := menu.Encode(f, format, products); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("exported %d products to %s\n", len(products), path)
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/heptapegon/localpickup/internal/config"
+	"github.com/heptapegon/localpickup/internal/infra"
+	"github.com/heptapegon/localpickup/internal/migrations"
+)
+
+const migrateUsage = "usage: server migrate <up|down|status|to VERSION>"
+
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	connectCtx, cancel := context.WithTimeout(context.Background(), cfg.StartupTimeout)
+	db, err := infra.NewPostgres(connectCtx, cfg.DatabaseURL)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	var done []migrations.Migration
+	switch args[0] {
+	case "up":
+		done, err = migrator.Up(ctx)
+	case "down":
+		done, err = migrator.Down(ctx)
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			return fmt.Errorf("migrate: invalid version %q", args[1])
+		}
+		done, err = migrator.To(ctx, version)
+	case "status":
+		return printMigrationStatus(ctx, migrator)
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	for _, m := range done {
+		fmt.Printf("migrated %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(done) == 0 {
+		fmt.Println("nothing to do")
+	}
+	return nil
+}
+
+func printMigrationStatus(ctx context.Context, migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		state := "pending"
+		switch {
+		case s.Unknown:
+			state = "applied " + s.AppliedAt.Format(time.RFC3339) + " (not in this binary)"
+		case s.Applied:
+			state = "applied " + s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%04d %-32s %s\n", s.Version, s.Name, state)
+	}
+	return nil
+}
+
+// ensureSchema applies pending migrations when AUTO_MIGRATE is set and refuses
+// to start when REQUIRE_CURRENT_SCHEMA is set and the database is behind.
+func ensureSchema(cfg *config.Config, db *pgxpool.Pool) {
+	if !cfg.AutoMigrate && !cfg.RequireCurrentSchema {
+		return
+	}
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		fatal("migrate: load migrations", err)
+	}
+	ctx := context.Background()
+
+	if cfg.AutoMigrate {
+		done, err := migrator.Up(ctx)
+		if err != nil {
+			fatal("migrate: apply", err)
+		}
+		for _, m := range done {
+			slog.Info("migrate: applied", "version", m.Version, "name", m.Name)
+		}
+	}
+
+	if cfg.RequireCurrentSchema {
+		if err := migrator.CheckCurrent(ctx); err != nil {
+			fatal("migrate: schema check", err)
+		}
+	}
+}
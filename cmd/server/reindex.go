@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/heptapegon/localpickup/internal/config"
+	"github.com/heptapegon/localpickup/internal/infra"
+	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
+	redisrepo "github.com/heptapegon/localpickup/internal/repository/redis"
+	"github.com/heptapegon/localpickup/internal/service"
+)
+
+const reindexUsage = "usage: server reindex [--dry-run]"
+
+// runReindex reconciles the geo index once and prints the drift it found.
+func runReindex(cfg *config.Config, logger *slog.Logger, args []string) error {
+	dryRun := false
+	for _, a := range args {
+		switch a {
+		case "--dry-run", "-n":
+			dryRun = true
+		default:
+			return errors.New(reindexUsage)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.StartupTimeout)
+	defer cancel()
+	db, err := infra.NewPostgres(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	rdb, err := infra.NewRedis(ctx, cfg.RedisURL)
+	if err != nil {
+		return err
+	}
+	defer rdb.Close()
+
+	reconciler := service.NewGeoReconciler(
+		postgresrepo.NewBusinessRepository(db), redisrepo.NewGeoRepository(rdb), logger,
+	)
+	report, err := reconciler.Reconcile(context.Background(), dryRun)
+	if err != nil {
+		return err
+	}
+
+	verb := "fixed"
+	if dryRun {
+		verb = "would fix"
+	}
+	fmt.Printf("active businesses: %d, indexed: %d\n", report.Active, report.Indexed)
+	for _, group := range []struct {
+		label string
+		ids   []string
+	}{{"missing", report.Added}, {"moved", report.Moved}, {"stale", report.Removed}} {
+		fmt.Printf("%-8s %d\n", group.label, len(group.ids))
+		for _, id := range group.ids {
+			fmt.Printf("  %s\n", id)
+		}
+	}
+	fmt.Printf("%s %d entries in %.1f ms\n", verb, report.Drift(), report.DurationMs)
+	return nil
+}
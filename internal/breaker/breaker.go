@@ -0,0 +1,110 @@
+// Package breaker is a small consecutive-failure circuit breaker.
+//
+// A Breaker starts closed. After Threshold consecutive failures it opens and
+// rejects calls for Cooldown; it then lets a single probe through (half-open).
+// A successful probe closes it again, a failed one re-opens it, and one that
+// never reached the backend is released for the next call to make.
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+}
+
+func New(threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Breaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Allow reports whether a call may proceed. Once the cooldown has elapsed an
+// open breaker admits exactly one probe; further calls are rejected until that
+// probe reports back through Success, Failure or Release.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case Closed:
+		return true
+	case Open:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = HalfOpen
+		return true
+	default:
+		return false
+	}
+}
+
+// Success records a successful call and closes the breaker.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = Closed
+	b.failures = 0
+}
+
+// Failure records a failed call. It reports whether this call opened the
+// breaker, so callers can log the transition once.
+func (b *Breaker) Failure() (opened bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == HalfOpen || (b.state == Closed && b.failures >= b.threshold) {
+		b.state = Open
+		b.openedAt = b.now()
+		return true
+	}
+	return false
+}
+
+// Release gives back a call admitted by Allow that never reached the backend,
+// e.g. because the caller gave up first, so it says nothing either way. A
+// released probe leaves the breaker open with its cooldown already over, so
+// the next call probes instead. On a closed breaker Release does nothing.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == HalfOpen {
+		b.state = Open
+	}
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -0,0 +1,47 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := New(3, 10*time.Second)
+	b.now = func() time.Time { return now }
+
+	steps := []struct {
+		name      string
+		advance   time.Duration
+		do        func() bool // returns Allow() or Failure()'s opened flag
+		want      bool
+		wantState State
+	}{
+		{"closed allows", 0, b.Allow, true, Closed},
+		{"first failure", 0, b.Failure, false, Closed},
+		{"second failure", 0, b.Failure, false, Closed},
+		{"third failure opens", 0, b.Failure, true, Open},
+		{"open rejects", 5 * time.Second, b.Allow, false, Open},
+		{"cooldown admits probe", 5 * time.Second, b.Allow, true, HalfOpen},
+		{"only one probe", 0, b.Allow, false, HalfOpen},
+		{"failed probe re-opens", 0, b.Failure, true, Open},
+		{"re-opened rejects", 9 * time.Second, b.Allow, false, Open},
+		{"second probe", time.Second, b.Allow, true, HalfOpen},
+		{"released probe", 0, func() bool { b.Release(); return true }, true, Open},
+		{"next call probes", 0, b.Allow, true, HalfOpen},
+		{"probe succeeds", 0, func() bool { b.Success(); return true }, true, Closed},
+		{"closed again", 0, b.Allow, true, Closed},
+		{"release while closed", 0, func() bool { b.Release(); return true }, true, Closed},
+		{"failures were reset", 0, b.Failure, false, Closed},
+	}
+
+	for _, s := range steps {
+		now = now.Add(s.advance)
+		if got := s.do(); got != s.want {
+			t.Errorf("%s: got %v, want %v", s.name, got, s.want)
+		}
+		if st := b.State(); st != s.wantState {
+			t.Errorf("%s: state = %s, want %s", s.name, st, s.wantState)
+		}
+	}
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -12,6 +15,74 @@ type Config struct {
 	JWTSecret               string
 	StripeSecretKey         string
 	FirebaseCredentialsPath string
+
+	// AutoMigrate applies pending migrations when the server starts.
+	AutoMigrate bool
+	// RequireCurrentSchema makes the server refuse to start when the database
+	// is missing migrations embedded in the binary.
+	RequireCurrentSchema bool
+	// StartupTimeout bounds how long the server keeps retrying Postgres and
+	// Redis at boot before giving up.
+	StartupTimeout time.Duration
+
+	// TracingExporter is one of none, otlp or stdout. The OTLP endpoint is
+	// taken from OTEL_EXPORTER_OTLP_ENDPOINT.
+	TracingExporter string
+	// TracingSampleRatio is the fraction of new traces recorded (0–1).
+	TracingSampleRatio float64
+
+	// GeoPrimary picks the backend for nearby searches: redis (GEOSEARCH) or
+	// postgis (ST_DWithin). The other one serves as the fallback.
+	GeoPrimary string
+	// GeoReconcileInterval is how often the geo index is repaired from
+	// Postgres. Zero disables the background job.
+	GeoReconcileInterval time.Duration
+
+	// StockResetTimezone is the IANA zone whose midnight refills products
+	// with a daily stock.
+	StockResetTimezone string
+
+	// TaxRate (e.g. 0.16) and ServiceFee (a flat amount in the charge
+	// currency) are added to every order's subtotal.
+	TaxRate    float64
+	ServiceFee float64
+	// PickupPrepTime is how long a business needs for an order when nothing
+	// else is queued; PickupPrepTimePerOrder is added per paid order ahead.
+	// They only feed the earliest pickup estimate of quotes.
+	PickupPrepTime         time.Duration
+	PickupPrepTimePerOrder time.Duration
+	// CartTTL is how long an untouched cart is kept.
+	CartTTL time.Duration
+	// PromotionTimezone is the IANA zone in which promotion weekdays and
+	// time windows are evaluated.
+	PromotionTimezone string
+
+	// RateLimits lists per-group limits with optional per-role overrides,
+	// e.g. "auth=10/m,search=60/m,search:business_owner=120/m". Unset uses the
+	// defaults; set but empty disables rate limiting.
+	RateLimits string
+	// TrustedProxies are CIDRs whose X-Forwarded-For header is believed when
+	// resolving the client IP.
+	TrustedProxies []string
+
+	// StorageDriver is local or s3 and decides where uploaded pictures go.
+	// Local files are written to MediaDir and served by this server under
+	// /media. MediaBaseURL is the public address of that path or, for s3, of
+	// the bucket (e.g. a CDN); empty means the bucket's own URL.
+	StorageDriver string
+	MediaDir      string
+	MediaBaseURL  string
+	// S3Endpoint is host[:port] of any S3-compatible service.
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+
+	// LogLevel is debug, info, warn or error; LogFormat is json or text.
+	LogLevel  string
+	LogFormat string
 }
 
 func Load() *Config {
@@ -22,6 +93,33 @@ func Load() *Config {
 		JWTSecret:               mustGetEnv("JWT_SECRET"),
 		StripeSecretKey:         getEnv("STRIPE_SECRET_KEY", "sk_test_placeholder"),
 		FirebaseCredentialsPath: getEnv("FIREBASE_CREDENTIALS_PATH", "firebase-credentials.json"),
+		AutoMigrate:             getEnvBool("AUTO_MIGRATE", false),
+		RequireCurrentSchema:    getEnvBool("REQUIRE_CURRENT_SCHEMA", false),
+		StartupTimeout:          getEnvDuration("STARTUP_TIMEOUT", time.Minute),
+		TracingExporter:         getEnv("TRACING_EXPORTER", "none"),
+		TracingSampleRatio:      getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+		GeoPrimary:              getEnv("GEO_PRIMARY", "redis"),
+		GeoReconcileInterval:    getEnvDuration("GEO_RECONCILE_INTERVAL", 10*time.Minute),
+		StockResetTimezone:      getEnv("STOCK_RESET_TIMEZONE", "America/Mexico_City"),
+		TaxRate:                 getEnvFloat("TAX_RATE", 0),
+		ServiceFee:              getEnvFloat("SERVICE_FEE", 0),
+		PickupPrepTime:          getEnvDuration("PICKUP_PREP_TIME", 15*time.Minute),
+		PickupPrepTimePerOrder:  getEnvDuration("PICKUP_PREP_TIME_PER_ORDER", 3*time.Minute),
+		CartTTL:                 getEnvDuration("CART_TTL", 24*time.Hour),
+		PromotionTimezone:       getEnv("PROMOTION_TIMEZONE", "America/Mexico_City"),
+		RateLimits:              getEnvAllowEmpty("RATE_LIMITS", "auth=10/m,search=60/m,orders=20/m"),
+		TrustedProxies:          getEnvList("TRUSTED_PROXIES"),
+		StorageDriver:           getEnv("STORAGE_DRIVER", "local"),
+		MediaDir:                getEnv("MEDIA_DIR", "media"),
+		MediaBaseURL:            os.Getenv("MEDIA_BASE_URL"),
+		S3Endpoint:              os.Getenv("S3_ENDPOINT"),
+		S3Region:                os.Getenv("S3_REGION"),
+		S3Bucket:                getEnv("S3_BUCKET", "localpickup"),
+		S3AccessKey:             os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:             os.Getenv("S3_SECRET_KEY"),
+		S3UseSSL:                getEnvBool("S3_USE_SSL", true),
+		LogLevel:                getEnv("LOG_LEVEL", "info"),
+		LogFormat:               getEnv("LOG_FORMAT", "json"),
 	}
 }
 
@@ -32,6 +130,61 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvAllowEmpty is getEnv for variables where an explicitly empty value
+// means something; only an unset variable takes the fallback.
+func getEnvAllowEmpty(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
+}
+
+func getEnvList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("environment variable %q must be a boolean, got %q", key, v)
+	}
+	return b
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Fatalf("environment variable %q must be a number, got %q", key, v)
+	}
+	return f
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("environment variable %q must be a duration (e.g. 30s), got %q", key, v)
+	}
+	return d
+}
+
 func mustGetEnv(key string) string {
 	v := os.Getenv(key)
 	if v == "" {
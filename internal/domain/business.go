@@ -17,16 +17,114 @@ type Business struct {
 	Category    string    `json:"category"     db:"category"`
 	FCMToken    string    `json:"-"            db:"fcm_token"`
 	IsActive    bool      `json:"is_active"    db:"is_active"`
+	// ImageKey is the storage prefix of the business picture; Image is
+	// derived from it and is nil when there is no picture.
+	ImageKey string `json:"-"            db:"image_key"`
+	Image    *Image `json:"image"`
+	// Rating is the average of the published reviews, nil before the first;
+	// RatingCount is how many there are.
+	Rating      *float64  `json:"rating"`
+	RatingCount int       `json:"rating_count" db:"rating_count"`
 	CreatedAt   time.Time `json:"created_at"   db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"   db:"updated_at"`
 }
 
-// NearbyBusiness extends Business with the geo distance returned by Redis.
+// NearbyBusiness extends Business with the distance from the search point.
 type NearbyBusiness struct {
 	Business
 	DistanceKm float64 `json:"distance_km"`
 }
 
+// NearbyPage is one page of a nearby search. NextCursor is empty on the last page.
+type NearbyPage struct {
+	Data       []*NearbyBusiness `json:"data"`
+	Count      int               `json:"count"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// GeoResult combines a business ID with its distance from the search point.
+type GeoResult struct {
+	ID         string
+	DistanceKm float64
+	// Index names the index that served the result when there is a choice
+	// (see FailoverGeoIndex); empty otherwise.
+	Index string
+}
+
+// GeoQuery is a lookup against the geo index. Results are ordered by
+// (DistanceKm, ID) and, when After is set, start strictly after it.
+type GeoQuery struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+	// Categories restricts results to these categories; empty means all.
+	Categories []string
+	Limit      int
+	After      *GeoCursor
+}
+
+// GeoCursor is the position of the last result of the previous page.
+type GeoCursor struct {
+	DistanceKm float64 `json:"d"`
+	ID         string  `json:"id"`
+	// Seen counts the results on earlier pages; indexes that cannot seek
+	// (Redis GEOSEARCH) use it to size their scan.
+	Seen int `json:"n"`
+	// Index is GeoResult.Index of the last result. Indexes measure distances
+	// slightly differently, so a position is only meaningful to the index
+	// that produced it.
+	Index string `json:"i,omitempty"`
+}
+
+// Before reports whether r sorts at or before the cursor.
+func (c *GeoCursor) Before(r GeoResult) bool {
+	if r.DistanceKm != c.DistanceKm {
+		return r.DistanceKm < c.DistanceKm
+	}
+	return r.ID <= c.ID
+}
+
+// Bounds is a latitude/longitude rectangle. West may be greater than East when
+// the rectangle crosses the antimeridian.
+type Bounds struct {
+	North float64
+	South float64
+	East  float64
+	West  float64
+}
+
+// Cluster aggregates the businesses of one grid cell on the map.
+type Cluster struct {
+	// Latitude and Longitude are the centroid of the businesses in the cell.
+	Latitude      float64         `json:"latitude"`
+	Longitude     float64         `json:"longitude"`
+	Count         int             `json:"count"`
+	TopCategories []CategoryCount `json:"top_categories"`
+}
+
+type CategoryCount struct {
+	Category string `json:"category"`
+	Count    int    `json:"count"`
+}
+
+// BoundsResult is a map viewport: individual businesses when zoomed in (or
+// sparse enough), grid clusters otherwise.
+type BoundsResult struct {
+	Zoom       int         `json:"zoom"`
+	Clustered  bool        `json:"clustered"`
+	Count      int         `json:"count"`
+	Businesses []*Business `json:"businesses,omitempty"`
+	Clusters   []Cluster   `json:"clusters,omitempty"`
+}
+
+// GeoLocation is a business position as the geo index stores it.
+type GeoLocation struct {
+	ID        string
+	Category  string
+	Latitude  float64
+	Longitude float64
+}
+
 type CreateBusinessRequest struct {
 	Name        string  `json:"name"        validate:"required,min=2,max=100"`
 	Description string  `json:"description" validate:"required"`
@@ -37,9 +135,27 @@ type CreateBusinessRequest struct {
 	FCMToken    string  `json:"fcm_token"`
 }
 
+type BoundsQuery struct {
+	North    float64 `query:"north"    validate:"min=-90,max=90,gtfield=South"`
+	South    float64 `query:"south"    validate:"min=-90,max=90"`
+	East     float64 `query:"east"     validate:"min=-180,max=180"`
+	West     float64 `query:"west"     validate:"min=-180,max=180"`
+	Zoom     int     `query:"zoom"     validate:"min=0,max=22"`
+	Category string  `query:"category"`
+}
+
+// SortRating orders nearby and search results best rated first, unrated
+// businesses last, instead of by distance or relevance.
+const SortRating = "rating"
+
 type NearbyQuery struct {
-	Latitude  float64 `query:"lat"      validate:"required"`
-	Longitude float64 `query:"lng"      validate:"required"`
-	RadiusKm  float64 `query:"radius"`
+	Latitude  float64 `query:"lat"      validate:"min=-90,max=90"`
+	Longitude float64 `query:"lng"      validate:"min=-180,max=180"`
+	RadiusKm  float64 `query:"radius"   validate:"min=0"`
 	Category  string  `query:"category"`
+	Limit     int     `query:"limit"    validate:"min=0,max=50"`
+	// Sort is distance (the default) or rating.
+	Sort string `query:"sort"     validate:"omitempty,oneof=distance rating"`
+	// Cursor is the next_cursor of the previous page.
+	Cursor string `query:"cursor"`
 }
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cart is what a customer is about to order from one business. It lives in
+// Redis and expires when left alone; each customer has at most one per
+// business.
+type Cart struct {
+	ID         uuid.UUID            `json:"id"`
+	CustomerID uuid.UUID            `json:"customer_id"`
+	BusinessID uuid.UUID            `json:"business_id"`
+	Items      []CreateOrderItemReq `json:"items"`
+	PromoCode  string               `json:"promo_code,omitempty"`
+	// Quote is the last price computed for Items and PromoCode; saving the cart
+	// drops it.
+	Quote     *Quote    `json:"quote"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Quote is the authoritative price of a list of items. Checking out a quoted
+// cart charges exactly Total or fails with ErrPriceChanged.
+type Quote struct {
+	Lines    []QuoteLine `json:"lines"`
+	Subtotal float64     `json:"subtotal"`
+	Discount float64     `json:"discount"`
+	Tax      float64     `json:"tax"`
+	Fees     float64     `json:"fees"`
+	Total    float64     `json:"total"`
+	// Discounts are the promotions that make up Discount.
+	Discounts []OrderDiscount `json:"discounts"`
+	// EarliestPickup estimates when the order could be ready if placed now.
+	EarliestPickup time.Time `json:"earliest_pickup"`
+	QuotedAt       time.Time `json:"quoted_at"`
+}
+
+// QuoteLine is one priced item. UnitPrice includes the modifiers.
+type QuoteLine struct {
+	ProductID   uuid.UUID           `json:"product_id"`
+	ProductName string              `json:"product_name"`
+	Quantity    int                 `json:"quantity"`
+	UnitPrice   float64             `json:"unit_price"`
+	LineTotal   float64             `json:"line_total"`
+	Modifiers   []OrderItemModifier `json:"modifiers,omitempty"`
+}
+
+// CartRequest creates the customer's cart for a business or replaces its
+// items.
+type CartRequest struct {
+	BusinessID uuid.UUID            `json:"business_id" validate:"required"`
+	Items      []CreateOrderItemReq `json:"items"       validate:"required,min=1,max=100,dive"`
+	PromoCode  string               `json:"promo_code"  validate:"omitempty,max=40"`
+}
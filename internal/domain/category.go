@@ -0,0 +1,105 @@
+package domain
+
+import "sort"
+
+// DefaultLanguage is used for category names when the client asks for a
+// language that has no translation.
+const DefaultLanguage = "es"
+
+type Category struct {
+	Slug string `json:"slug"`
+	// ParentSlug is empty for top-level categories.
+	ParentSlug string            `json:"parent,omitempty"`
+	Names      map[string]string `json:"names"`
+	Icon       string            `json:"icon"`
+	SortOrder  int               `json:"-"`
+}
+
+// Name returns the display name in lang, falling back to DefaultLanguage and
+// then the slug.
+func (c *Category) Name(lang string) string {
+	if n := c.Names[lang]; n != "" {
+		return n
+	}
+	if n := c.Names[DefaultLanguage]; n != "" {
+		return n
+	}
+	return c.Slug
+}
+
+// CategoryNode is a category as served to clients, with its subcategories.
+type CategoryNode struct {
+	Slug     string          `json:"slug"`
+	Name     string          `json:"name"`
+	Icon     string          `json:"icon"`
+	Children []*CategoryNode `json:"children,omitempty"`
+}
+
+// CategoryTree indexes the taxonomy by slug and parent.
+type CategoryTree struct {
+	bySlug   map[string]*Category
+	children map[string][]*Category // "" holds the roots
+}
+
+func NewCategoryTree(categories []Category) *CategoryTree {
+	t := &CategoryTree{
+		bySlug:   make(map[string]*Category, len(categories)),
+		children: make(map[string][]*Category),
+	}
+	for i := range categories {
+		c := &categories[i]
+		t.bySlug[c.Slug] = c
+		t.children[c.ParentSlug] = append(t.children[c.ParentSlug], c)
+	}
+	for _, list := range t.children {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].SortOrder != list[j].SortOrder {
+				return list[i].SortOrder < list[j].SortOrder
+			}
+			return list[i].Slug < list[j].Slug
+		})
+	}
+	return t
+}
+
+func (t *CategoryTree) Has(slug string) bool {
+	_, ok := t.bySlug[slug]
+	return ok
+}
+
+// Subtree returns slug followed by all of its descendants, or nil if slug is
+// unknown.
+func (t *CategoryTree) Subtree(slug string) []string {
+	if !t.Has(slug) {
+		return nil
+	}
+	out := []string{slug}
+	for i := 0; i < len(out); i++ {
+		for _, c := range t.children[out[i]] {
+			out = append(out, c.Slug)
+		}
+	}
+	return out
+}
+
+// Nodes returns the taxonomy as nested nodes named in lang.
+func (t *CategoryTree) Nodes(lang string) []*CategoryNode {
+	return t.nodes("", lang)
+}
+
+func (t *CategoryTree) nodes(parent, lang string) []*CategoryNode {
+	list := t.children[parent]
+	if len(list) == 0 {
+		return nil
+	}
+	out := make([]*CategoryNode, 0, len(list))
+	for _, c := range list {
+		out = append(out, &CategoryNode{
+			Slug:     c.Slug,
+			Name:     c.Name(lang),
+			Icon:     c.Icon,
+			Children: t.nodes(c.Slug, lang),
+		})
+	}
+	return out
+}
@@ -0,0 +1,113 @@
+package domain
+
+import "errors"
+
+// Error kinds. Every *Error carries one of these, so callers can branch with
+// errors.Is(err, domain.ErrNotFound) without knowing the specific code.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrForbidden       = errors.New("forbidden")
+	ErrConflict        = errors.New("conflict")
+	ErrInvalidState    = errors.New("invalid state")
+	ErrInvalidInput    = errors.New("invalid input")
+	ErrPaymentDeclined = errors.New("payment declined")
+	ErrRateLimited     = errors.New("rate limited")
+)
+
+// Error is a domain failure with a stable, machine-readable Code that the HTTP
+// layer exposes to clients. Message is safe to show to users; Err is the
+// underlying cause and is only ever logged.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Err     error
+}
+
+func NewError(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Is matches both the kind sentinel (via Unwrap) and any *Error with the same
+// code, so errors.Is(err, domain.ErrOrderNotFound) works on wrapped copies.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// WithCause returns a copy of e that wraps err.
+func (e *Error) WithCause(err error) *Error {
+	cp := *e
+	cp.Err = err
+	return &cp
+}
+
+// WithMessage returns a copy of e with a more specific user-facing message.
+func (e *Error) WithMessage(msg string) *Error {
+	cp := *e
+	cp.Message = msg
+	return &cp
+}
+
+var (
+	ErrBusinessNotFound  = NewError(ErrNotFound, "business_not_found", "business not found")
+	ErrBusinessInactive  = NewError(ErrInvalidState, "business_inactive", "business is not accepting orders")
+	ErrBusinessForbidden = NewError(ErrForbidden, "business_forbidden", "business does not belong to you")
+	ErrInvalidCursor     = NewError(ErrInvalidInput, "invalid_cursor", "cursor is malformed or belongs to a different search")
+	ErrStaleCursor       = NewError(ErrConflict, "stale_cursor", "search results were reordered; start again from the first page")
+
+	ErrUnknownCategory = NewError(ErrInvalidInput, "unknown_category", "category does not exist; see GET /categories")
+
+	ErrProductNotFound    = NewError(ErrNotFound, "product_not_found", "product not found")
+	ErrProductUnavailable = NewError(ErrInvalidState, "product_unavailable", "product is not available")
+	ErrOutOfStock         = NewError(ErrConflict, "out_of_stock", "not enough stock")
+	ErrInvalidModifiers   = NewError(ErrInvalidInput, "invalid_modifiers", "invalid modifier selection")
+
+	ErrUnsupportedImage = NewError(ErrInvalidInput, "unsupported_image", "images must be JPEG, PNG or WebP")
+
+	ErrSearchQueryTooShort = NewError(ErrInvalidInput, "query_too_short", "search query must have at least 2 characters")
+
+	ErrOrderNotFound       = NewError(ErrNotFound, "order_not_found", "order not found")
+	ErrOrderForbidden      = NewError(ErrForbidden, "order_forbidden", "order does not belong to your business")
+	ErrOrderNotCompletable = NewError(ErrInvalidState, "order_not_completable", "order cannot be completed in its current status")
+	ErrOrderNotCancellable = NewError(ErrInvalidState, "order_not_cancellable", "order can no longer be cancelled")
+	ErrInvalidPIN          = NewError(ErrInvalidInput, "invalid_pin", "invalid PIN")
+	ErrInvalidOrderStatus  = NewError(ErrInvalidInput, "invalid_order_status", "unknown order status")
+
+	ErrCartNotFound  = NewError(ErrNotFound, "cart_not_found", "cart not found or expired")
+	ErrCartForbidden = NewError(ErrForbidden, "cart_forbidden", "cart does not belong to you")
+	ErrCartNotQuoted = NewError(ErrInvalidState, "cart_not_quoted", "quote the cart before checking out")
+	ErrPriceChanged  = NewError(ErrConflict, "price_changed", "prices changed since the cart was quoted; quote it again")
+
+	ErrPromotionNotFound      = NewError(ErrNotFound, "promotion_not_found", "promotion not found")
+	ErrPromotionForbidden     = NewError(ErrForbidden, "promotion_forbidden", "promotion does not belong to your business")
+	ErrInvalidPromotion       = NewError(ErrInvalidInput, "invalid_promotion", "invalid promotion")
+	ErrPromoCodeTaken         = NewError(ErrConflict, "promo_code_taken", "promo code is already in use")
+	ErrInvalidPromoCode       = NewError(ErrInvalidInput, "invalid_promo_code", "promo code does not exist or has ended")
+	ErrPromotionNotApplicable = NewError(ErrInvalidState, "promotion_not_applicable", "promo code does not apply to this order")
+	ErrPromotionExhausted     = NewError(ErrConflict, "promotion_exhausted", "promotion has reached its redemption limit")
+
+	ErrReviewNotFound     = NewError(ErrNotFound, "review_not_found", "review not found")
+	ErrReviewExists       = NewError(ErrConflict, "review_exists", "this order has already been reviewed")
+	ErrReviewForbidden    = NewError(ErrForbidden, "review_forbidden", "review is not about your business")
+	ErrOrderNotReviewable = NewError(ErrInvalidState, "order_not_reviewable", "only completed orders can be reviewed")
+
+	ErrAmountBelowMinimum = NewError(ErrInvalidInput, "amount_below_minimum", "minimum charge amount is $0.50")
+	ErrPaymentFailed      = NewError(ErrPaymentDeclined, "payment_declined", "payment was declined")
+
+	ErrEmailTaken = NewError(ErrConflict, "email_taken", "email already registered")
+)
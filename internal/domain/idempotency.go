@@ -0,0 +1,10 @@
+package domain
+
+// IdempotencyRecord is the outcome of a request made with an Idempotency-Key,
+// replayed to retries of it. Status is 0 while the request is in flight.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
+}
@@ -0,0 +1,9 @@
+package domain
+
+// Image holds the public URLs of an uploaded picture's resized variants.
+// Thumbnail suits list rows, Medium cards and Large detail screens.
+type Image struct {
+	Thumbnail string `json:"thumbnail"`
+	Medium    string `json:"medium"`
+	Large     string `json:"large"`
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "github.com/google/uuid"
+
+// MenuItem is one product of an imported menu. Rows with an ID update that
+// product; rows without one update the product with the same name, or create
+// it. A nil ModifierGroups keeps the product's groups as they are, so that
+// formats without modifiers (CSV) do not wipe them.
+type MenuItem struct {
+	// Row locates the item in the file: the spreadsheet row for CSV (the
+	// header is row 1) and the 1-based position in "products" for JSON.
+	Row int        `json:"-"`
+	ID  *uuid.UUID `json:"id,omitempty"`
+	ProductRequest
+}
+
+// MenuRowError is a problem with one row of an imported menu. Field is empty
+// when the row as a whole is at fault.
+type MenuRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// MenuChange is a product the import creates, updates or deletes. ID is
+// omitted for products a dry run would create. Fields lists what an update
+// changes.
+type MenuChange struct {
+	Row    int        `json:"row,omitempty"`
+	ID     *uuid.UUID `json:"id,omitempty"`
+	Name   string     `json:"name"`
+	Fields []string   `json:"fields,omitempty"`
+}
+
+// MenuReport is the diff between an imported menu and the catalog. Products
+// missing from the menu are deleted. When Errors is not empty nothing was
+// applied.
+type MenuReport struct {
+	DryRun    bool           `json:"dry_run"`
+	Created   []MenuChange   `json:"created"`
+	Updated   []MenuChange   `json:"updated"`
+	Deleted   []MenuChange   `json:"deleted"`
+	Unchanged int            `json:"unchanged"`
+	Errors    []MenuRowError `json:"errors,omitempty"`
+}
@@ -17,24 +17,47 @@ const (
 )
 
 type Order struct {
-	ID              uuid.UUID   `json:"id"                         db:"id"`
-	CustomerID      uuid.UUID   `json:"customer_id"                db:"customer_id"`
-	BusinessID      uuid.UUID   `json:"business_id"                db:"business_id"`
-	Items           []OrderItem `json:"items"`
+	ID         uuid.UUID   `json:"id"                         db:"id"`
+	CustomerID uuid.UUID   `json:"customer_id"                db:"customer_id"`
+	BusinessID uuid.UUID   `json:"business_id"                db:"business_id"`
+	Items      []OrderItem `json:"items"`
+	// Subtotal is the sum of the items; TotalAmount, what was charged, takes
+	// Discount off it and adds Tax and Fees.
+	Subtotal        float64     `json:"subtotal"                   db:"subtotal"`
+	Discount        float64     `json:"discount"                   db:"discount_amount"`
+	Tax             float64     `json:"tax"                        db:"tax_amount"`
+	Fees            float64     `json:"fees"                       db:"fee_amount"`
 	TotalAmount     float64     `json:"total_amount"               db:"total_amount"`
 	Status          OrderStatus `json:"status"                     db:"status"`
 	PIN             string      `json:"-"                          db:"pin"`
 	StripePaymentID string      `json:"stripe_payment_id,omitempty" db:"stripe_payment_id"`
 	CreatedAt       time.Time   `json:"created_at"                 db:"created_at"`
 	UpdatedAt       time.Time   `json:"updated_at"                 db:"updated_at"`
+	// Discounts are the promotions that make up Discount.
+	Discounts []OrderDiscount `json:"discounts,omitempty"`
 }
 
 type OrderItem struct {
-	ID          uuid.UUID `json:"id"           db:"id"`
-	OrderID     uuid.UUID `json:"order_id"     db:"order_id"`
-	ProductName string    `json:"product_name" db:"product_name"`
-	Quantity    int       `json:"quantity"     db:"quantity"`
-	UnitPrice   float64   `json:"unit_price"   db:"unit_price"`
+	ID      uuid.UUID `json:"id"           db:"id"`
+	OrderID uuid.UUID `json:"order_id"     db:"order_id"`
+	// ProductID is nil for lines placed before orders referenced the catalog,
+	// or whose product has since been deleted.
+	ProductID   *uuid.UUID `json:"product_id,omitempty" db:"product_id"`
+	ProductName string     `json:"product_name" db:"product_name"`
+	Quantity    int        `json:"quantity"     db:"quantity"`
+	// UnitPrice is the product price plus the price deltas of Modifiers.
+	UnitPrice float64             `json:"unit_price"   db:"unit_price"`
+	Modifiers []OrderItemModifier `json:"modifiers,omitempty"`
+}
+
+// OrderItemModifier is an option chosen for an order line, copied from the
+// catalog when the order was placed. OptionID is nil once the option has been
+// removed from the catalog.
+type OrderItemModifier struct {
+	OptionID   *uuid.UUID `json:"option_id,omitempty"`
+	GroupName  string     `json:"group_name"`
+	Name       string     `json:"name"`
+	PriceDelta float64    `json:"price_delta"`
 }
 
 // OrderResponse is returned to the customer after payment — includes the PIN once.
@@ -46,14 +69,63 @@ type OrderResponse struct {
 type CreateOrderRequest struct {
 	BusinessID uuid.UUID            `json:"business_id" validate:"required"`
 	Items      []CreateOrderItemReq `json:"items"       validate:"required,min=1,dive"`
+	// PromoCode applies a code-based promotion on top of the automatic ones.
+	PromoCode string `json:"promo_code" validate:"omitempty,max=40"`
+
+	// ExpectedTotal, set when checking out a quoted cart, makes the order fail
+	// with ErrPriceChanged instead of charging a different amount.
+	ExpectedTotal *float64 `json:"-"`
 }
 
+// CreateOrderItemReq orders a catalog product. Name and price are taken from
+// the catalog, never from the client. Modifiers are the IDs of the chosen
+// modifier options.
 type CreateOrderItemReq struct {
-	ProductName string  `json:"product_name" validate:"required"`
-	Quantity    int     `json:"quantity"     validate:"required,min=1"`
-	UnitPrice   float64 `json:"unit_price"   validate:"required,gt=0"`
+	ProductID uuid.UUID   `json:"product_id" validate:"required"`
+	Quantity  int         `json:"quantity"   validate:"required,min=1"`
+	Modifiers []uuid.UUID `json:"modifiers"  validate:"max=50"`
 }
 
 type ValidatePINRequest struct {
-	PIN string `json:"pin" validate:"required,len=6"`
+	PIN string `json:"pin" validate:"required,len=6,numeric"`
+}
+
+// ReorderStatus says how a line of a past order compares with the catalog now.
+type ReorderStatus string
+
+const (
+	ReorderUnchanged    ReorderStatus = "unchanged"
+	ReorderPriceChanged ReorderStatus = "price_changed"
+	// ReorderOptionsChanged lines chose options that were removed or made
+	// unavailable, or no longer satisfy the product's groups.
+	ReorderOptionsChanged ReorderStatus = "options_changed"
+	ReorderSoldOut        ReorderStatus = "sold_out"
+	// ReorderUnavailable lines are for products that were deleted or are
+	// not available.
+	ReorderUnavailable ReorderStatus = "unavailable"
+)
+
+// Reorder rebuilds a past order against the current catalog. Items holds the
+// lines that can still be ordered, ready to be posted to POST /orders;
+// nothing is charged until then.
+type Reorder struct {
+	BusinessID    uuid.UUID            `json:"business_id"`
+	Items         []CreateOrderItemReq `json:"items"`
+	Lines         []ReorderLine        `json:"lines"`
+	TotalAmount   float64              `json:"total_amount"`
+	PreviousTotal float64              `json:"previous_total"`
+	// Changed is true when any line is not ReorderUnchanged.
+	Changed bool `json:"changed"`
+}
+
+// ReorderLine is one line of the past order. UnitPrice and Modifiers are the
+// current ones and are only set for lines that can be ordered.
+type ReorderLine struct {
+	ProductID         *uuid.UUID          `json:"product_id,omitempty"`
+	ProductName       string              `json:"product_name"`
+	Quantity          int                 `json:"quantity"`
+	UnitPrice         float64             `json:"unit_price"`
+	PreviousUnitPrice float64             `json:"previous_unit_price"`
+	Modifiers         []OrderItemModifier `json:"modifiers,omitempty"`
+	Status            ReorderStatus       `json:"status"`
 }
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Product struct {
+	ID          uuid.UUID `json:"id"           db:"id"`
+	BusinessID  uuid.UUID `json:"business_id"  db:"business_id"`
+	Name        string    `json:"name"         db:"name"`
+	Description string    `json:"description"  db:"description"`
+	Price       float64   `json:"price"        db:"price"`
+	IsAvailable bool      `json:"is_available" db:"is_available"`
+	// Stock is the number of units left; nil means unlimited.
+	Stock *int `json:"stock"        db:"stock"`
+	// DailyStock, when set, is what Stock is reset to at the start of each day.
+	DailyStock *int `json:"daily_stock"  db:"daily_stock"`
+	// SoldOut is set when the product is tracked and has no units left.
+	SoldOut bool `json:"sold_out"`
+	// ModifierGroups are the choices offered with the product, in display order.
+	ModifierGroups []ModifierGroup `json:"modifier_groups"`
+	// ImageKey is the storage prefix of the product picture; Image is derived
+	// from it and is nil when there is no picture.
+	ImageKey  string    `json:"-"           db:"image_key"`
+	Image     *Image    `json:"image"`
+	CreatedAt time.Time `json:"created_at"  db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"  db:"updated_at"`
+}
+
+// InStock reports whether quantity units can be sold.
+func (p *Product) InStock(quantity int) bool {
+	return p.Stock == nil || *p.Stock >= quantity
+}
+
+// ModifierGroup is a choice offered with a product, such as "Milk" or
+// "Extras". The customer picks between MinSelect and MaxSelect of its options;
+// a group with MaxSelect 1 is single-select.
+type ModifierGroup struct {
+	ID        uuid.UUID        `json:"id"`
+	Name      string           `json:"name"`
+	MinSelect int              `json:"min_select"`
+	MaxSelect int              `json:"max_select"`
+	Options   []ModifierOption `json:"options"`
+}
+
+// SingleSelect reports whether at most one option may be picked.
+func (g *ModifierGroup) SingleSelect() bool { return g.MaxSelect == 1 }
+
+// ModifierOption is one option of a group. PriceDelta is added to the product
+// price for each unit ordered with the option, and may be negative.
+type ModifierOption struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	PriceDelta  float64   `json:"price_delta"`
+	IsAvailable bool      `json:"is_available"`
+}
+
+// SelectModifiers checks the chosen option IDs against p's groups and returns
+// the chosen options in display order together with the sum of their price
+// deltas. Every group must get between MinSelect and MaxSelect available
+// options, and an option cannot be chosen twice.
+func (p *Product) SelectModifiers(optionIDs []uuid.UUID) ([]OrderItemModifier, float64, error) {
+	chosen := make(map[uuid.UUID]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		if chosen[id] {
+			return nil, 0, ErrInvalidModifiers.WithMessage(fmt.Sprintf("option %s of %q was chosen twice", id, p.Name))
+		}
+		chosen[id] = true
+	}
+
+	var out []OrderItemModifier
+	var delta float64
+	for _, g := range p.ModifierGroups {
+		n := 0
+		for _, o := range g.Options {
+			if !chosen[o.ID] {
+				continue
+			}
+			if !o.IsAvailable {
+				return nil, 0, ErrInvalidModifiers.WithMessage(fmt.Sprintf("%q is not available", o.Name))
+			}
+			delete(chosen, o.ID)
+			n++
+			optionID := o.ID
+			out = append(out, OrderItemModifier{OptionID: &optionID, GroupName: g.Name, Name: o.Name, PriceDelta: o.PriceDelta})
+			delta += o.PriceDelta
+		}
+		switch {
+		case n < g.MinSelect && g.MinSelect == 1:
+			return nil, 0, ErrInvalidModifiers.WithMessage(fmt.Sprintf("choose an option for %q of %q", g.Name, p.Name))
+		case n < g.MinSelect:
+			return nil, 0, ErrInvalidModifiers.WithMessage(fmt.Sprintf("choose at least %d options for %q of %q", g.MinSelect, g.Name, p.Name))
+		case n > g.MaxSelect && g.SingleSelect():
+			return nil, 0, ErrInvalidModifiers.WithMessage(fmt.Sprintf("choose only one option for %q of %q", g.Name, p.Name))
+		case n > g.MaxSelect:
+			return nil, 0, ErrInvalidModifiers.WithMessage(fmt.Sprintf("choose at most %d options for %q of %q", g.MaxSelect, g.Name, p.Name))
+		}
+	}
+	for id := range chosen {
+		return nil, 0, ErrInvalidModifiers.WithMessage(fmt.Sprintf("option %s does not belong to %q", id, p.Name))
+	}
+	return out, delta, nil
+}
+
+// ProductUpdate is an edited product and the stock it had when it was read;
+// see ProductStore.Update.
+type ProductUpdate struct {
+	Product   *Product
+	ReadStock *int
+}
+
+// StockLine is a quantity of one product held for (or returned by) an order.
+type StockLine struct {
+	ProductID uuid.UUID
+	Quantity  int
+}
+
+// ProductRequest creates or replaces a product. IsAvailable defaults to true.
+// Leaving Stock and DailyStock out makes the product unlimited; setting only
+// DailyStock starts today with that many units.
+type ProductRequest struct {
+	Name        string  `json:"name"         validate:"required,max=100"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"        validate:"required,gt=0"`
+	IsAvailable *bool   `json:"is_available"`
+	Stock       *int    `json:"stock"        validate:"omitempty,min=0"`
+	DailyStock  *int    `json:"daily_stock"  validate:"omitempty,min=0"`
+	// ModifierGroups replaces the product's groups. Groups and options sent
+	// with the ID of an existing one keep it; the rest get new IDs.
+	ModifierGroups []ModifierGroupRequest `json:"modifier_groups" validate:"max=20,dive"`
+}
+
+// ModifierGroupRequest describes one modifier group. MaxSelect defaults to 1
+// (single-select) and must not be below MinSelect.
+type ModifierGroupRequest struct {
+	ID        *uuid.UUID              `json:"id"`
+	Name      string                  `json:"name"       validate:"required,max=100"`
+	MinSelect int                     `json:"min_select" validate:"min=0"`
+	MaxSelect int                     `json:"max_select" validate:"omitempty,min=1,gtefield=MinSelect"`
+	Options   []ModifierOptionRequest `json:"options"    validate:"required,min=1,max=50,dive"`
+}
+
+// ModifierOptionRequest describes one option. IsAvailable defaults to true.
+type ModifierOptionRequest struct {
+	ID          *uuid.UUID `json:"id"`
+	Name        string     `json:"name"         validate:"required,max=100"`
+	PriceDelta  float64    `json:"price_delta"`
+	IsAvailable *bool      `json:"is_available"`
+}
@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PromotionKind string
+
+const (
+	// PromotionPercent takes Value percent off the subtotal, up to
+	// MaxDiscount when set.
+	PromotionPercent PromotionKind = "percent"
+	// PromotionFixed takes Value dollars off the subtotal.
+	PromotionFixed PromotionKind = "fixed"
+	// PromotionBuyXGetY makes GetQuantity units free for every BuyQuantity +
+	// GetQuantity units ordered; the cheapest units are the free ones. A
+	// 2x1 is buy 1, get 1.
+	PromotionBuyXGetY PromotionKind = "buy_x_get_y"
+)
+
+// PromotionFunder says who pays for a discount.
+type PromotionFunder string
+
+const (
+	FundedByPlatform PromotionFunder = "platform"
+	FundedByBusiness PromotionFunder = "business"
+)
+
+// Promotion is a discount rule. Promotions with a Code apply only when the
+// customer enters it; the others apply by themselves to every eligible order.
+type Promotion struct {
+	ID          uuid.UUID `json:"id"`
+	Code        *string   `json:"code"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	// BusinessID limits the promotion to one business, which funds it.
+	// Promotions without one are funded by the platform.
+	BusinessID  *uuid.UUID     `json:"business_id"`
+	Kind        PromotionKind  `json:"kind"`
+	Value       float64        `json:"value"`
+	MaxDiscount *float64       `json:"max_discount"`
+	BuyQuantity int            `json:"buy_quantity,omitempty"`
+	GetQuantity int            `json:"get_quantity,omitempty"`
+	Rules       PromotionRules `json:"rules"`
+	// MaxRedemptions caps uses overall and MaxPerCustomer uses by one
+	// customer; nil means unlimited.
+	MaxRedemptions  *int      `json:"max_redemptions"`
+	MaxPerCustomer  *int      `json:"max_per_customer"`
+	RedemptionCount int       `json:"redemption_count"`
+	IsActive        bool      `json:"is_active"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// PromotionRules decide which orders a promotion applies to. The zero value
+// accepts every order.
+type PromotionRules struct {
+	// FirstOrder limits the promotion to customers with no orders yet,
+	// cancelled ones aside.
+	FirstOrder  bool    `json:"first_order"`
+	MinSubtotal float64 `json:"min_subtotal"  validate:"min=0"`
+	// Category limits the promotion to businesses of that category.
+	Category string     `json:"category"      validate:"max=50"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+	// Weekdays limits the promotion to those days (0 is Sunday) in the
+	// platform's timezone; empty means every day.
+	Weekdays []time.Weekday `json:"weekdays"  validate:"max=7,dive,min=0,max=6"`
+}
+
+// FundedBy says who pays for the promotion's discounts.
+func (p *Promotion) FundedBy() PromotionFunder {
+	if p.BusinessID != nil {
+		return FundedByBusiness
+	}
+	return FundedByPlatform
+}
+
+// Running reports whether the promotion can be used at t, a time in the
+// platform's timezone.
+func (p *Promotion) Running(t time.Time) bool {
+	if !p.IsActive {
+		return false
+	}
+	if p.Rules.StartsAt != nil && t.Before(*p.Rules.StartsAt) {
+		return false
+	}
+	if p.Rules.EndsAt != nil && !t.Before(*p.Rules.EndsAt) {
+		return false
+	}
+	return len(p.Rules.Weekdays) == 0 || slices.Contains(p.Rules.Weekdays, t.Weekday())
+}
+
+// Discount is what the promotion takes off items costing subtotal, never
+// more than the subtotal. Amounts are rounded to the cent.
+func (p *Promotion) Discount(items []OrderItem, subtotal float64) float64 {
+	var d float64
+	switch p.Kind {
+	case PromotionPercent:
+		d = subtotal * p.Value / 100
+		if p.MaxDiscount != nil {
+			d = math.Min(d, *p.MaxDiscount)
+		}
+	case PromotionFixed:
+		d = p.Value
+	case PromotionBuyXGetY:
+		d = freeUnitsValue(items, p.BuyQuantity, p.GetQuantity)
+	}
+	return math.Round(math.Min(d, subtotal)*100) / 100
+}
+
+// freeUnitsValue is the price of the cheapest get units out of every
+// buy + get units ordered.
+func freeUnitsValue(items []OrderItem, buy, get int) float64 {
+	if buy < 1 || get < 1 {
+		return 0
+	}
+	units := 0
+	for _, i := range items {
+		units += i.Quantity
+	}
+	free := units / (buy + get) * get
+	cheapest := slices.Clone(items)
+	slices.SortStableFunc(cheapest, func(a, b OrderItem) int {
+		switch {
+		case a.UnitPrice < b.UnitPrice:
+			return -1
+		case a.UnitPrice > b.UnitPrice:
+			return 1
+		}
+		return 0
+	})
+	var value float64
+	for _, i := range cheapest {
+		if free == 0 {
+			break
+		}
+		n := min(free, i.Quantity)
+		value += float64(n) * i.UnitPrice
+		free -= n
+	}
+	return value
+}
+
+// OrderDiscount is a promotion applied to an order, copied from it when the
+// order was placed. PromotionID is nil once the promotion has been deleted.
+type OrderDiscount struct {
+	PromotionID *uuid.UUID      `json:"promotion_id,omitempty"`
+	Code        string          `json:"code,omitempty"`
+	Name        string          `json:"name"`
+	Amount      float64         `json:"amount"`
+	FundedBy    PromotionFunder `json:"funded_by"`
+}
+
+// PromotionRequest creates a promotion. BusinessID is only honoured for
+// admins; promotions created by owners are always for their business.
+// Value is a percentage for percent promotions and dollars for fixed ones.
+type PromotionRequest struct {
+	Code           *string        `json:"code"             validate:"omitempty,min=3,max=40,alphanum"`
+	Name           string         `json:"name"             validate:"required,max=100"`
+	Description    string         `json:"description"      validate:"max=500"`
+	BusinessID     *uuid.UUID     `json:"business_id"`
+	Kind           PromotionKind  `json:"kind"             validate:"required,oneof=percent fixed buy_x_get_y"`
+	Value          float64        `json:"value"            validate:"min=0"`
+	MaxDiscount    *float64       `json:"max_discount"     validate:"omitempty,gt=0"`
+	BuyQuantity    int            `json:"buy_quantity"     validate:"min=0,max=20"`
+	GetQuantity    int            `json:"get_quantity"     validate:"min=0,max=20"`
+	Rules          PromotionRules `json:"rules"`
+	MaxRedemptions *int           `json:"max_redemptions"  validate:"omitempty,min=1"`
+	MaxPerCustomer *int           `json:"max_per_customer" validate:"omitempty,min=1"`
+}
+
+type PromotionStatusRequest struct {
+	IsActive *bool `json:"is_active" validate:"required"`
+}
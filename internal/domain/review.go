@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReviewStatus string
+
+const (
+	ReviewPublished ReviewStatus = "published"
+	// ReviewHidden reviews were taken down by a moderator; they are not
+	// listed and do not count towards the business rating.
+	ReviewHidden ReviewStatus = "hidden"
+)
+
+// Review is a customer's rating of a business for one completed order.
+type Review struct {
+	ID         uuid.UUID    `json:"id"`
+	OrderID    uuid.UUID    `json:"order_id"`
+	BusinessID uuid.UUID    `json:"business_id"`
+	CustomerID uuid.UUID    `json:"customer_id"`
+	Rating     int          `json:"rating"`
+	Comment    string       `json:"comment"`
+	Reply      *ReviewReply `json:"reply"`
+	Status     ReviewStatus `json:"status"`
+	// FlagCount is the number of users who reported the review since it was
+	// last moderated.
+	FlagCount int       `json:"flag_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ReviewReply is the business's public answer to a review.
+type ReviewReply struct {
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReviewPage is one page of a business's reviews, newest first. NextCursor is
+// empty on the last page.
+type ReviewPage struct {
+	Data       []*Review `json:"data"`
+	Count      int       `json:"count"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// ReviewCursor is the position of the last review of the previous page.
+type ReviewCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// AverageRating is sum / count rounded to two decimals, or nil when there are
+// no ratings.
+func AverageRating(sum, count int) *float64 {
+	if count == 0 {
+		return nil
+	}
+	avg := math.Round(float64(sum)/float64(count)*100) / 100
+	return &avg
+}
+
+type CreateReviewRequest struct {
+	Rating  int    `json:"rating"  validate:"required,min=1,max=5"`
+	Comment string `json:"comment" validate:"max=2000"`
+}
+
+type ReviewReplyRequest struct {
+	Text string `json:"text" validate:"required,max=2000"`
+}
+
+type ReviewFlagRequest struct {
+	Reason string `json:"reason" validate:"required,oneof=spam offensive off_topic other"`
+}
+
+type ReviewModerationRequest struct {
+	Status ReviewStatus `json:"status" validate:"required,oneof=published hidden"`
+}
+
+type ReviewQuery struct {
+	Limit int `query:"limit" validate:"min=0,max=50"`
+	// Cursor is the next_cursor of the previous page.
+	Cursor string `query:"cursor"`
+}
@@ -0,0 +1,35 @@
+package domain
+
+// SearchQuery is a free-text search over businesses and their products. The
+// origin (lat/lng) is optional; when present results are limited to RadiusKm
+// and ranked by a blend of relevance and proximity.
+type SearchQuery struct {
+	Query     string  `query:"q"      validate:"required,min=2,max=100"`
+	Latitude  float64 `query:"lat"    validate:"min=-90,max=90"`
+	Longitude float64 `query:"lng"    validate:"min=-180,max=180"`
+	RadiusKm  float64 `query:"radius" validate:"min=0"`
+	Limit     int     `query:"limit"  validate:"min=0,max=50"`
+	// Sort is relevance (the default) or rating.
+	Sort string `query:"sort"   validate:"omitempty,oneof=relevance rating"`
+}
+
+// HasOrigin reports whether the caller sent a location; (0, 0) means none,
+// as for nearby searches.
+func (q *SearchQuery) HasOrigin() bool {
+	return q.Latitude != 0 || q.Longitude != 0
+}
+
+type SearchResult struct {
+	Business
+	// DistanceKm is set only when the query has an origin.
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+	// Score is the blended ranking score in [0, 1].
+	Score float64 `json:"score"`
+	// MatchedProducts names up to three products that matched the query.
+	MatchedProducts []string `json:"matched_products,omitempty"`
+}
+
+type SearchPage struct {
+	Data  []*SearchResult `json:"data"`
+	Count int             `json:"count"`
+}
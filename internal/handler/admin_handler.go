@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/service"
+)
+
+type AdminHandler struct {
+	reconciler *service.GeoReconciler
+}
+
+func NewAdminHandler(reconciler *service.GeoReconciler) *AdminHandler {
+	return &AdminHandler{reconciler: reconciler}
+}
+
+// ReindexGeo reconciles the Redis geo index with Postgres and reports the
+// drift. With dry_run=true nothing is changed.
+//
+// POST /api/v1/admin/geo/reindex?dry_run=true
+func (h *AdminHandler) ReindexGeo(c echo.Context) error {
+	dryRun := false
+	if v := c.QueryParam("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "dry_run must be a boolean")
+		}
+	}
+
+	report, err := h.reconciler.Reconcile(c.Request().Context(), dryRun)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, report)
+}
@@ -2,15 +2,18 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/heptapegon/localpickup/internal/domain"
 	custMiddleware "github.com/heptapegon/localpickup/internal/middleware"
 )
 
@@ -37,8 +40,8 @@ type loginRequest struct {
 
 func (h *AuthHandler) Register(c echo.Context) error {
 	var req registerRequest
-	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
@@ -52,8 +55,12 @@ func (h *AuthHandler) Register(c echo.Context) error {
 		 VALUES ($1,$2,$3,$4,$5,NOW())`,
 		userID, req.Name, req.Email, string(hash), req.Role,
 	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+		return domain.ErrEmailTaken
+	}
 	if err != nil {
-		return echo.NewHTTPError(http.StatusConflict, "email already registered")
+		return err
 	}
 
 	token, err := h.issueToken(userID.String(), req.Email, req.Role)
@@ -70,8 +77,8 @@ func (h *AuthHandler) Register(c echo.Context) error {
 
 func (h *AuthHandler) Login(c echo.Context) error {
 	var req loginRequest
-	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
 	}
 
 	var userID, role, passwordHash string
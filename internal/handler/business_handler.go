@@ -19,27 +19,49 @@ func NewBusinessHandler(svc *service.BusinessService) *BusinessHandler {
 	return &BusinessHandler{svc: svc}
 }
 
-// GetNearby returns active businesses within a given radius of the caller's location.
+// GetNearby returns active businesses within a given radius of the caller's
+// location, nearest first (or best rated first with sort=rating), with their
+// distance. Pass the response's next_cursor back as cursor (with the same
+// lat/lng/radius/category/sort) for the next page; limit defaults to 20 and is
+// capped at 50. A 409 stale_cursor means the search moved to another geo index
+// mid-way; start again from the first page.
 //
-// GET /api/v1/businesses/nearby?lat=19.4326&lng=-99.1332&radius=5&category=food
+// GET /api/v1/businesses/nearby?lat=19.4326&lng=-99.1332&radius=5&category=food&sort=rating&limit=20&cursor=...
 func (h *BusinessHandler) GetNearby(c echo.Context) error {
 	var q domain.NearbyQuery
-	if err := c.Bind(&q); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	if err := bindAndValidate(c, &q); err != nil {
+		return err
 	}
 	if q.Latitude == 0 && q.Longitude == 0 {
 		return echo.NewHTTPError(http.StatusBadRequest, "query params 'lat' and 'lng' are required")
 	}
 
-	businesses, err := h.svc.GetNearby(c.Request().Context(), &q)
+	page, err := h.svc.GetNearby(c.Request().Context(), &q)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{
-		"data":  businesses,
-		"count": len(businesses),
-	})
+	return c.JSON(http.StatusOK, page)
+}
+
+// InBounds returns the businesses inside the visible map rectangle, or grid
+// clusters (count, centroid, top categories) below zoom 15 or when the
+// viewport is too dense. west > east means the viewport crosses the
+// antimeridian.
+//
+// GET /api/v1/businesses/in-bounds?north=19.45&south=19.40&east=-99.10&west=-99.18&zoom=14&category=food
+func (h *BusinessHandler) InBounds(c echo.Context) error {
+	var q domain.BoundsQuery
+	if err := bindAndValidate(c, &q); err != nil {
+		return err
+	}
+
+	result, err := h.svc.InBounds(c.Request().Context(), &q)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
 }
 
 // Create registers a new business for the authenticated owner.
@@ -53,13 +75,13 @@ func (h *BusinessHandler) Create(c echo.Context) error {
 	}
 
 	var req domain.CreateBusinessRequest
-	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
 	}
 
 	business, err := h.svc.Create(c.Request().Context(), ownerID, &req)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, business)
@@ -76,8 +98,47 @@ func (h *BusinessHandler) GetByID(c echo.Context) error {
 
 	business, err := h.svc.GetByID(c.Request().Context(), id)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, "business not found")
+		return err
+	}
+
+	return c.JSON(http.StatusOK, business)
+}
+
+// UploadImage replaces the business picture with a JPEG, PNG or WebP file
+// of up to 8 MB, sent as the body or the "file" field of a multipart form.
+// The response carries the URLs of the resized variants.
+//
+// PUT /api/v1/businesses/:id/image
+func (h *BusinessHandler) UploadImage(c echo.Context) error {
+	ownerID, businessID, err := ownerAndBusiness(c)
+	if err != nil {
+		return err
+	}
+	data, err := readImage(c)
+	if err != nil {
+		return err
+	}
+
+	business, err := h.svc.SetImage(c.Request().Context(), ownerID, businessID, data)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, business)
 }
+
+// DeleteImage removes the business picture.
+//
+// DELETE /api/v1/businesses/:id/image
+func (h *BusinessHandler) DeleteImage(c echo.Context) error {
+	ownerID, businessID, err := ownerAndBusiness(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.svc.SetImage(c.Request().Context(), ownerID, businessID, nil); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
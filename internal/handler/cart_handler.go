@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	custMiddleware "github.com/heptapegon/localpickup/internal/middleware"
+	"github.com/heptapegon/localpickup/internal/service"
+)
+
+type CartHandler struct {
+	svc *service.CartService
+}
+
+func NewCartHandler(svc *service.CartService) *CartHandler {
+	return &CartHandler{svc: svc}
+}
+
+// Save sets the items of the caller's cart for a business, creating it if
+// needed. Saving drops the cart's quote.
+//
+// PUT /api/v1/carts
+func (h *CartHandler) Save(c echo.Context) error {
+	claims := custMiddleware.GetClaims(c)
+	customerID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user id in token")
+	}
+
+	var req domain.CartRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	cart, err := h.svc.Save(c.Request().Context(), customerID, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, cart)
+}
+
+// List returns the caller's carts that have not expired.
+//
+// GET /api/v1/carts
+func (h *CartHandler) List(c echo.Context) error {
+	claims := custMiddleware.GetClaims(c)
+	customerID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user id in token")
+	}
+
+	carts, err := h.svc.List(c.Request().Context(), customerID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"data": carts, "count": len(carts)})
+}
+
+// Get returns one of the caller's carts.
+//
+// GET /api/v1/carts/:id
+func (h *CartHandler) Get(c echo.Context) error {
+	customerID, cartID, err := userAndParam(c, "invalid cart id")
+	if err != nil {
+		return err
+	}
+
+	cart, err := h.svc.Get(c.Request().Context(), customerID, cartID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, cart)
+}
+
+// Delete discards a cart.
+//
+// DELETE /api/v1/carts/:id
+func (h *CartHandler) Delete(c echo.Context) error {
+	customerID, cartID, err := userAndParam(c, "invalid cart id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.Delete(c.Request().Context(), customerID, cartID); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Quote prices the cart with the current catalog, taxes and fees, estimates
+// the earliest pickup and returns the cart with its quote.
+//
+// POST /api/v1/carts/:id/quote
+func (h *CartHandler) Quote(c echo.Context) error {
+	customerID, cartID, err := userAndParam(c, "invalid cart id")
+	if err != nil {
+		return err
+	}
+
+	cart, err := h.svc.Quote(c.Request().Context(), customerID, cartID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, cart)
+}
+
+// Checkout orders a quoted cart, charging exactly the quoted total. When
+// prices changed since the quote it fails with 409 price_changed and the
+// cart is kept so it can be quoted again.
+//
+// POST /api/v1/carts/:id/checkout
+func (h *CartHandler) Checkout(c echo.Context) error {
+	customerID, cartID, err := userAndParam(c, "invalid cart id")
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.svc.Checkout(c.Request().Context(), customerID, cartID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/service"
+)
+
+type CategoryHandler struct {
+	svc *service.CategoryService
+}
+
+func NewCategoryHandler(svc *service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{svc: svc}
+}
+
+// List returns the category taxonomy as a tree, named in the language given by
+// lang or, failing that, the Accept-Language header (Spanish by default). Use
+// a category's slug as the category filter of nearby and in-bounds searches; a
+// parent category matches all of its subcategories.
+//
+// GET /api/v1/categories?lang=en
+func (h *CategoryHandler) List(c echo.Context) error {
+	nodes, err := h.svc.List(c.Request().Context(), requestLanguage(c))
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, echo.Map{"data": nodes, "count": len(nodes)})
+}
+
+// requestLanguage returns the primary subtag of the lang query parameter or of
+// the first Accept-Language entry ("es-MX,es;q=0.9" → "es").
+func requestLanguage(c echo.Context) string {
+	lang := c.QueryParam("lang")
+	if lang == "" {
+		lang, _, _ = strings.Cut(c.Request().Header.Get("Accept-Language"), ",")
+	}
+	lang, _, _ = strings.Cut(lang, ";")
+	lang, _, _ = strings.Cut(strings.TrimSpace(lang), "-")
+	if lang == "" || lang == "*" {
+		return domain.DefaultLanguage
+	}
+	return strings.ToLower(lang)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+)
+
+const mimeProblemJSON = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Code is the stable,
+// machine-readable identifier the mobile app switches on.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// HTTPErrorHandler is installed as echo.HTTPErrorHandler. It is the single
+// place where errors become HTTP responses: domain errors map to their status
+// and code, validation errors list the failing fields, and anything unknown is
+// reported as a generic 500 so internal details never reach the client.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	p := problemFor(err)
+	ctx := c.Request().Context()
+	if p.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(ctx, "request failed", "method", c.Request().Method, "path", c.Request().URL.Path, logging.Error, err)
+	}
+	p.Instance = c.Request().URL.Path
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(p.Status)
+	} else {
+		c.Response().Header().Set(echo.HeaderContentType, mimeProblemJSON)
+		err = c.JSON(p.Status, p)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "write error response", logging.Error, err)
+	}
+}
+
+func problemFor(err error) *Problem {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		p := newProblem(http.StatusUnprocessableEntity, "validation_failed", "one or more fields are invalid")
+		p.Errors = verr.Fields
+		return p
+	}
+
+	var derr *domain.Error
+	if errors.As(err, &derr) {
+		return newProblem(statusForKind(derr.Kind), derr.Code, derr.Message)
+	}
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		code := codeForStatus(he.Code)
+		detail, _ := he.Message.(string)
+		if code == "internal_error" {
+			detail = ""
+		}
+		return newProblem(he.Code, code, detail)
+	}
+
+	return newProblem(http.StatusInternalServerError, "internal_error", "")
+}
+
+func newProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "urn:localpickup:problem:" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func statusForKind(kind error) int {
+	switch {
+	case errors.Is(kind, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(kind, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(kind, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(kind, domain.ErrInvalidState):
+		return http.StatusConflict
+	case errors.Is(kind, domain.ErrInvalidInput):
+		return http.StatusUnprocessableEntity
+	case errors.Is(kind, domain.ErrPaymentDeclined):
+		return http.StatusPaymentRequired
+	case errors.Is(kind, domain.ErrRateLimited):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// codeForStatus derives a code for plain echo.HTTPErrors (routing, auth,
+// binding), e.g. 404 → "not_found", 401 → "unauthorized".
+func codeForStatus(status int) string {
+	if status >= http.StatusInternalServerError && status != http.StatusNotImplemented && status != http.StatusServiceUnavailable {
+		return "internal_error"
+	}
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+		wantFields int
+	}{
+		{
+			name:       "not found",
+			err:        domain.ErrOrderNotFound,
+			wantStatus: http.StatusNotFound, wantCode: "order_not_found", wantDetail: "order not found",
+		},
+		{
+			name:       "wrapped forbidden",
+			err:        fmt.Errorf("validate: %w", domain.ErrOrderForbidden),
+			wantStatus: http.StatusForbidden, wantCode: "order_forbidden", wantDetail: "order does not belong to your business",
+		},
+		{
+			name:       "invalid state",
+			err:        domain.ErrOrderNotCompletable,
+			wantStatus: http.StatusConflict, wantCode: "order_not_completable", wantDetail: "order cannot be completed in its current status",
+		},
+		{
+			name:       "payment declined hides cause",
+			err:        domain.ErrPaymentFailed.WithCause(errors.New("stripe: card_declined req_123")),
+			wantStatus: http.StatusPaymentRequired, wantCode: "payment_declined", wantDetail: "payment was declined",
+		},
+		{
+			name:       "rate limited",
+			err:        domain.NewError(domain.ErrRateLimited, "rate_limited", "too many requests, please retry later"),
+			wantStatus: http.StatusTooManyRequests, wantCode: "rate_limited", wantDetail: "too many requests, please retry later",
+		},
+		{
+			name:       "validation",
+			err:        &ValidationError{Fields: []FieldError{{Field: "pin", Rule: "len", Message: "must be exactly 6 characters long"}}},
+			wantStatus: http.StatusUnprocessableEntity, wantCode: "validation_failed", wantDetail: "one or more fields are invalid", wantFields: 1,
+		},
+		{
+			name:       "echo http error",
+			err:        echo.NewHTTPError(http.StatusUnauthorized, "invalid or expired token"),
+			wantStatus: http.StatusUnauthorized, wantCode: "unauthorized", wantDetail: "invalid or expired token",
+		},
+		{
+			name:       "unknown error does not leak",
+			err:        errors.New(`pq: relation "orders" does not exist`),
+			wantStatus: http.StatusInternalServerError, wantCode: "internal_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v1/orders/1", nil), rec)
+
+			HTTPErrorHandler(tt.err, c)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if ct := rec.Header().Get(echo.HeaderContentType); ct != mimeProblemJSON {
+				t.Errorf("content-type = %q, want %q", ct, mimeProblemJSON)
+			}
+
+			var p Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if p.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", p.Code, tt.wantCode)
+			}
+			if p.Detail != tt.wantDetail {
+				t.Errorf("detail = %q, want %q", p.Detail, tt.wantDetail)
+			}
+			if p.Status != tt.wantStatus {
+				t.Errorf("body status = %d, want %d", p.Status, tt.wantStatus)
+			}
+			if len(p.Errors) != tt.wantFields {
+				t.Errorf("field errors = %d, want %d", len(p.Errors), tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestDomainErrorIs(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", domain.ErrOrderNotFound.WithCause(errors.New("no rows")))
+
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Error("expected errors.Is(err, ErrNotFound)")
+	}
+	if !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Error("expected errors.Is(err, ErrOrderNotFound)")
+	}
+	if errors.Is(err, domain.ErrBusinessNotFound) {
+		t.Error("did not expect errors.Is(err, ErrBusinessNotFound)")
+	}
+	if errors.Is(err, domain.ErrForbidden) {
+		t.Error("did not expect errors.Is(err, ErrForbidden)")
+	}
+}
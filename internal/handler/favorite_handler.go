@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	custMiddleware "github.com/heptapegon/localpickup/internal/middleware"
+	"github.com/heptapegon/localpickup/internal/service"
+)
+
+type FavoriteHandler struct {
+	svc *service.FavoriteService
+}
+
+func NewFavoriteHandler(svc *service.FavoriteService) *FavoriteHandler {
+	return &FavoriteHandler{svc: svc}
+}
+
+// List returns the caller's favorite businesses, most recently added first.
+//
+// GET /api/v1/favorites
+func (h *FavoriteHandler) List(c echo.Context) error {
+	claims := custMiddleware.GetClaims(c)
+	customerID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user id in token")
+	}
+
+	businesses, err := h.svc.List(c.Request().Context(), customerID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"data": businesses, "count": len(businesses)})
+}
+
+// Add marks a business as a favorite of the caller.
+//
+// PUT /api/v1/favorites/:id
+func (h *FavoriteHandler) Add(c echo.Context) error {
+	customerID, businessID, err := userAndParam(c, "invalid business id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.Add(c.Request().Context(), customerID, businessID); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Remove unmarks a favorite business.
+//
+// DELETE /api/v1/favorites/:id
+func (h *FavoriteHandler) Remove(c echo.Context) error {
+	customerID, businessID, err := userAndParam(c, "invalid business id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.svc.Remove(c.Request().Context(), customerID, businessID); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
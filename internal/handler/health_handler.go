@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/health"
+)
+
+type HealthHandler struct {
+	checker  *health.Checker
+	draining atomic.Bool
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// Drain makes Readyz fail so load balancers stop sending traffic while the
+// server finishes in-flight requests during shutdown.
+func (h *HealthHandler) Drain() { h.draining.Store(true) }
+
+// Livez reports that the process is up. It never touches dependencies, so a
+// database outage doesn't get every replica restarted at once.
+//
+// GET /livez
+func (h *HealthHandler) Livez(c echo.Context) error {
+	return c.JSON(http.StatusOK, echo.Map{"status": health.StatusOK, "time": time.Now().UTC()})
+}
+
+// Readyz runs the dependency checks and answers 503 when a critical one fails.
+//
+// GET /readyz
+func (h *HealthHandler) Readyz(c echo.Context) error {
+	if h.draining.Load() {
+		return c.JSON(http.StatusServiceUnavailable, health.Report{Status: health.StatusFail, Time: time.Now().UTC()})
+	}
+
+	report := h.checker.Run(c.Request().Context())
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	return c.JSON(status, report)
+}
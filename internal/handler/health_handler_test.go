@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/health"
+)
+
+func TestHealthHandlerReadyz(t *testing.T) {
+	down := func(context.Context) error { return errors.New("dial tcp: connection refused") }
+	up := func(context.Context) error { return nil }
+
+	tests := []struct {
+		name       string
+		checks     []health.Check
+		drain      bool
+		wantStatus int
+		wantBody   health.Status
+	}{
+		{
+			name:       "ready",
+			checks:     []health.Check{{Name: "postgres", Critical: true, Run: up}},
+			wantStatus: http.StatusOK, wantBody: health.StatusOK,
+		},
+		{
+			name:       "degraded is still ready",
+			checks:     []health.Check{{Name: "postgres", Critical: true, Run: up}, {Name: "notifications", Run: down}},
+			wantStatus: http.StatusOK, wantBody: health.StatusDegraded,
+		},
+		{
+			name:       "critical dependency down",
+			checks:     []health.Check{{Name: "postgres", Critical: true, Run: down}},
+			wantStatus: http.StatusServiceUnavailable, wantBody: health.StatusFail,
+		},
+		{
+			name:       "draining",
+			checks:     []health.Check{{Name: "postgres", Critical: true, Run: up}},
+			drain:      true,
+			wantStatus: http.StatusServiceUnavailable, wantBody: health.StatusFail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthHandler(health.NewChecker(tt.checks...))
+			if tt.drain {
+				h.Drain()
+			}
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/readyz", nil), rec)
+
+			if err := h.Readyz(c); err != nil {
+				t.Fatalf("Readyz() error: %v", err)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var report health.Report
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("decode report: %v", err)
+			}
+			if report.Status != tt.wantBody {
+				t.Errorf("body status = %q, want %q", report.Status, tt.wantBody)
+			}
+		})
+	}
+}
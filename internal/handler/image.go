@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ImageMaxBytes bounds an uploaded picture.
+const ImageMaxBytes = 8 << 20
+
+// readImage returns the uploaded picture: the request body, or the "file"
+// field of a multipart form. Its type is sniffed later from the content, so
+// the declared Content-Type does not matter.
+func readImage(c echo.Context) ([]byte, error) {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, ImageMaxBytes)
+
+	var file io.ReadCloser = req.Body
+	if strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return nil, imageReadError(err, `multipart uploads need a "file" field`)
+		}
+		if file, err = fh.Open(); err != nil {
+			return nil, err
+		}
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, imageReadError(err, "could not read the upload")
+	}
+	if len(data) == 0 {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "the upload is empty")
+	}
+	return data, nil
+}
+
+func imageReadError(err error, msg string) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("images are limited to %d MB", ImageMaxBytes>>20))
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, msg)
+}
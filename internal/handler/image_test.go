@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestReadImage(t *testing.T) {
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	fw, _ := mw.CreateFormFile("file", "concha.png")
+	fw.Write([]byte("picture"))
+	mw.Close()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        string
+		wantStatus  int
+	}{
+		{name: "raw body", contentType: "image/png", body: []byte("picture"), want: "picture"},
+		{name: "multipart file field", contentType: mw.FormDataContentType(), body: form.Bytes(), want: "picture"},
+		{name: "multipart without file", contentType: "multipart/form-data; boundary=x", body: []byte("--x--\r\n"), wantStatus: http.StatusBadRequest},
+		{name: "empty", contentType: "image/png", wantStatus: http.StatusBadRequest},
+		{name: "too large", contentType: "image/png", body: bytes.Repeat([]byte("x"), ImageMaxBytes+1), wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/", bytes.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, tt.contentType)
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			got, err := readImage(c)
+			if tt.wantStatus != 0 {
+				var herr *echo.HTTPError
+				if !errors.As(err, &herr) || herr.Code != tt.wantStatus {
+					t.Fatalf("readImage() error = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil || string(got) != tt.want {
+				t.Errorf("readImage() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/menu"
+	"github.com/heptapegon/localpickup/internal/service"
+)
+
+// MenuMaxBytes bounds an uploaded menu file.
+const MenuMaxBytes = 5 << 20
+
+type MenuHandler struct {
+	svc *service.MenuService
+}
+
+func NewMenuHandler(svc *service.MenuService) *MenuHandler {
+	return &MenuHandler{svc: svc}
+}
+
+// Export downloads the owner's catalog as a menu file that Import accepts
+// back. ?format=csv|json, default json.
+//
+// GET /api/v1/businesses/:id/menu
+func (h *MenuHandler) Export(c echo.Context) error {
+	ownerID, businessID, err := ownerAndBusiness(c)
+	if err != nil {
+		return err
+	}
+	format := menu.JSON
+	if v := c.QueryParam("format"); v != "" {
+		if format, err = menu.ParseFormat(v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	products, err := h.svc.Export(c.Request().Context(), ownerID, businessID)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := menu.Encode(&buf, format, products); err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="menu.%s"`, format))
+	return c.Blob(http.StatusOK, format.ContentType(), buf.Bytes())
+}
+
+// Import replaces the owner's catalog with an uploaded menu and returns the
+// diff; with dry_run=true the diff is only reported. The file is the request
+// body or the "file" field of a multipart form, and its format is taken from
+// ?format=, the file name or the Content-Type. Any invalid row rejects the
+// whole file with a validation error listing every problem.
+//
+// POST /api/v1/businesses/:id/menu/import?dry_run=true
+func (h *MenuHandler) Import(c echo.Context) error {
+	ownerID, businessID, err := ownerAndBusiness(c)
+	if err != nil {
+		return err
+	}
+	dryRun := false
+	if v := c.QueryParam("dry_run"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "dry_run must be a boolean")
+		}
+	}
+
+	file, format, err := menuUpload(c)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	items, rowErrs, err := menu.Decode(file, format)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("menu files are limited to %d MB", MenuMaxBytes>>20))
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if rowErrs = append(rowErrs, ValidateMenu(c.Echo().Validator, items)...); len(rowErrs) > 0 {
+		return menuValidationError(rowErrs)
+	}
+
+	report, err := h.svc.Import(c.Request().Context(), ownerID, businessID, items, dryRun)
+	if err != nil {
+		return err
+	}
+	if len(report.Errors) > 0 {
+		return menuValidationError(report.Errors)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// menuUpload returns the uploaded menu file and its format.
+func menuUpload(c echo.Context) (io.ReadCloser, menu.Format, error) {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, MenuMaxBytes)
+	name := c.QueryParam("format")
+
+	var file io.ReadCloser = req.Body
+	if strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", echo.NewHTTPError(http.StatusBadRequest, `multipart uploads need a "file" field`)
+		}
+		if file, err = fh.Open(); err != nil {
+			return nil, "", err
+		}
+		if name == "" {
+			name = fh.Filename
+		}
+	} else if name == "" {
+		name = req.Header.Get(echo.HeaderContentType)
+	}
+
+	format, err := menu.ParseFormat(name)
+	if err != nil {
+		file.Close()
+		return nil, "", echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return file, format, nil
+}
+
+// ValidateMenu checks every item against the ProductRequest rules and
+// reports the failures by row. The menu CLI shares it with Import.
+func ValidateMenu(v echo.Validator, items []domain.MenuItem) []domain.MenuRowError {
+	var out []domain.MenuRowError
+	for _, item := range items {
+		err := v.Validate(&item.ProductRequest)
+		var verr *ValidationError
+		switch {
+		case errors.As(err, &verr):
+			for _, f := range verr.Fields {
+				out = append(out, domain.MenuRowError{Row: item.Row, Field: f.Field, Message: f.Message})
+			}
+		case err != nil:
+			out = append(out, domain.MenuRowError{Row: item.Row, Message: err.Error()})
+		}
+	}
+	return out
+}
+
+// menuValidationError reports row errors as fields named rows[N].field.
+func menuValidationError(rowErrs []domain.MenuRowError) *ValidationError {
+	fields := make([]FieldError, 0, len(rowErrs))
+	for _, e := range rowErrs {
+		field := fmt.Sprintf("rows[%d]", e.Row)
+		if e.Field != "" {
+			field += "." + e.Field
+		}
+		fields = append(fields, FieldError{Field: field, Rule: "invalid_row", Message: e.Message})
+	}
+	return &ValidationError{Fields: fields}
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+func TestValidateMenu(t *testing.T) {
+	items := []domain.MenuItem{
+		{Row: 2, ProductRequest: domain.ProductRequest{Name: "Concha", Price: 18.5}},
+		{Row: 3, ProductRequest: domain.ProductRequest{Price: -1}},
+		{Row: 4, ProductRequest: domain.ProductRequest{Name: "Dona", Price: 12, Stock: ptr(-2)}},
+	}
+	rowErrs := ValidateMenu(NewValidator(), items)
+	rowErrs = append(rowErrs, domain.MenuRowError{Row: 5, Message: "wrong number of fields"})
+
+	assertRules(t, fieldRules(t, menuValidationError(rowErrs)), []string{
+		"rows[3].name:invalid_row", "rows[3].price:invalid_row",
+		"rows[4].stock:invalid_row", "rows[5]:invalid_row",
+	})
+}
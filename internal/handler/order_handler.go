@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -20,6 +21,7 @@ func NewOrderHandler(svc *service.OrderService) *OrderHandler {
 }
 
 // Create places a new order and triggers payment.
+// Clients should send an Idempotency-Key header so timeouts can be retried safely.
 //
 // POST /api/v1/orders
 func (h *OrderHandler) Create(c echo.Context) error {
@@ -30,13 +32,13 @@ func (h *OrderHandler) Create(c echo.Context) error {
 	}
 
 	var req domain.CreateOrderRequest
-	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
 	}
 
 	resp, err := h.svc.Create(c.Request().Context(), customerID, &req)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, resp)
@@ -53,7 +55,7 @@ func (h *OrderHandler) GetByID(c echo.Context) error {
 
 	order, err := h.svc.GetByID(c.Request().Context(), id)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, "order not found")
+		return err
 	}
 
 	return c.JSON(http.StatusOK, order)
@@ -71,7 +73,32 @@ func (h *OrderHandler) ListByUser(c echo.Context) error {
 
 	orders, err := h.svc.ListByCustomer(c.Request().Context(), customerID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"data": orders, "count": len(orders)})
+}
+
+// Queue returns the business's open orders, oldest first, with the options
+// chosen for each item. ?status=paid,ready narrows the queue.
+//
+// GET /api/v1/businesses/:id/orders
+func (h *OrderHandler) Queue(c echo.Context) error {
+	ownerID, businessID, err := ownerAndBusiness(c)
+	if err != nil {
+		return err
+	}
+
+	var statuses []domain.OrderStatus
+	for _, st := range strings.Split(c.QueryParam("status"), ",") {
+		if st = strings.TrimSpace(st); st != "" {
+			statuses = append(statuses, domain.OrderStatus(st))
+		}
+	}
+
+	orders, err := h.svc.Queue(c.Request().Context(), ownerID, businessID, statuses)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, echo.Map{"data": orders, "count": len(orders)})
@@ -83,7 +110,7 @@ func (h *OrderHandler) ListByUser(c echo.Context) error {
 // Body: { "pin": "123456" }
 func (h *OrderHandler) ValidatePIN(c echo.Context) error {
 	claims := custMiddleware.GetClaims(c)
-	businessID, err := uuid.Parse(claims.UserID)
+	ownerID, err := uuid.Parse(claims.UserID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid user id in token")
 	}
@@ -94,23 +121,59 @@ func (h *OrderHandler) ValidatePIN(c echo.Context) error {
 	}
 
 	var req domain.ValidatePINRequest
-	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
 	}
 
-	if err := h.svc.ValidatePIN(c.Request().Context(), orderID, req.PIN, businessID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	if err := h.svc.ValidatePIN(c.Request().Context(), orderID, req.PIN, ownerID); err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, echo.Map{"message": "order completed successfully"})
 }
 
-// Cancel allows a customer to cancel a pending order.
+// Cancel lets a customer cancel a paid order until the business marks it
+// ready. The payment is refunded and the items go back in stock.
 //
 // POST /api/v1/orders/:id/cancel
 func (h *OrderHandler) Cancel(c echo.Context) error {
-	// TODO: implement cancellation policy (time window, refund via Stripe, etc.)
-	return echo.NewHTTPError(http.StatusNotImplemented, "cancellation not yet implemented")
+	claims := custMiddleware.GetClaims(c)
+	customerID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user id in token")
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order id")
+	}
+
+	order, err := h.svc.Cancel(c.Request().Context(), orderID, customerID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+// Reorder rebuilds a past order of the caller from the current catalog. The
+// response lists every line with its current price and a status
+// (unchanged, price_changed, options_changed, sold_out or unavailable), and
+// items ready to post to POST /api/v1/orders. Nothing is charged.
+//
+// POST /api/v1/orders/:id/reorder
+func (h *OrderHandler) Reorder(c echo.Context) error {
+	customerID, orderID, err := userAndParam(c, "invalid order id")
+	if err != nil {
+		return err
+	}
+
+	reorder, err := h.svc.Reorder(c.Request().Context(), customerID, orderID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, reorder)
 }
 
 // StripeWebhook handles events from Stripe (e.g. payment_intent.succeeded).
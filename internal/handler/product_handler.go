@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	custMiddleware "github.com/heptapegon/localpickup/internal/middleware"
+	"github.com/heptapegon/localpickup/internal/service"
+)
+
+type ProductHandler struct {
+	svc *service.ProductService
+}
+
+func NewProductHandler(svc *service.ProductService) *ProductHandler {
+	return &ProductHandler{svc: svc}
+}
+
+// List returns a business's catalog.
+//
+// GET /api/v1/businesses/:id/products
+func (h *ProductHandler) List(c echo.Context) error {
+	businessID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid business id")
+	}
+
+	products, err := h.svc.ListByBusiness(c.Request().Context(), businessID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"data": products, "count": len(products)})
+}
+
+// Create adds a product to the authenticated owner's business.
+//
+// POST /api/v1/businesses/:id/products
+func (h *ProductHandler) Create(c echo.Context) error {
+	ownerID, businessID, err := ownerAndBusiness(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.ProductRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	product, err := h.svc.Create(c.Request().Context(), ownerID, businessID, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, product)
+}
+
+// Update replaces a product.
+//
+// PUT /api/v1/businesses/:id/products/:productId
+func (h *ProductHandler) Update(c echo.Context) error {
+	ownerID, businessID, err := ownerAndBusiness(c)
+	if err != nil {
+		return err
+	}
+	productID, err := uuid.Parse(c.Param("productId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+
+	var req domain.ProductRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	product, err := h.svc.Update(c.Request().Context(), ownerID, businessID, productID, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
+
+// Delete removes a product from the catalog.
+//
+// DELETE /api/v1/businesses/:id/products/:productId
+func (h *ProductHandler) Delete(c echo.Context) error {
+	ownerID, businessID, err := ownerAndBusiness(c)
+	if err != nil {
+		return err
+	}
+	productID, err := uuid.Parse(c.Param("productId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+
+	if err := h.svc.Delete(c.Request().Context(), ownerID, businessID, productID); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// UploadImage replaces the product picture; see BusinessHandler.UploadImage.
+//
+// PUT /api/v1/businesses/:id/products/:productId/image
+func (h *ProductHandler) UploadImage(c echo.Context) error {
+	ownerID, businessID, err := ownerAndBusiness(c)
+	if err != nil {
+		return err
+	}
+	productID, err := uuid.Parse(c.Param("productId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	data, err := readImage(c)
+	if err != nil {
+		return err
+	}
+
+	product, err := h.svc.SetImage(c.Request().Context(), ownerID, businessID, productID, data)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
+
+// DeleteImage removes the product picture.
+//
+// DELETE /api/v1/businesses/:id/products/:productId/image
+func (h *ProductHandler) DeleteImage(c echo.Context) error {
+	ownerID, businessID, err := ownerAndBusiness(c)
+	if err != nil {
+		return err
+	}
+	productID, err := uuid.Parse(c.Param("productId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+
+	if _, err := h.svc.SetImage(c.Request().Context(), ownerID, businessID, productID, nil); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ownerAndBusiness parses the caller's user ID and the :id business param.
+func ownerAndBusiness(c echo.Context) (ownerID, businessID uuid.UUID, err error) {
+	claims := custMiddleware.GetClaims(c)
+	if ownerID, err = uuid.Parse(claims.UserID); err != nil {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusBadRequest, "invalid user id in token")
+	}
+	if businessID, err = uuid.Parse(c.Param("id")); err != nil {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusBadRequest, "invalid business id")
+	}
+	return ownerID, businessID, nil
+}
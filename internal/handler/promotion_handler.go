@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/service"
+)
+
+type PromotionHandler struct {
+	svc *service.PromotionService
+}
+
+func NewPromotionHandler(svc *service.PromotionService) *PromotionHandler {
+	return &PromotionHandler{svc: svc}
+}
+
+// Create adds a platform promotion, or one funded by business_id when set.
+// Promotions without a code apply automatically to eligible orders.
+//
+// POST /api/v1/admin/promotions
+func (h *PromotionHandler) Create(c echo.Context) error {
+	var req domain.PromotionRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	promotion, err := h.svc.Create(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, promotion)
+}
+
+// List returns every promotion, newest first.
+//
+// GET /api/v1/admin/promotions
+func (h *PromotionHandler) List(c echo.Context) error {
+	promotions, err := h.svc.List(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"data": promotions, "count": len(promotions)})
+}
+
+// SetActive pauses or resumes a promotion.
+//
+// PUT /api/v1/admin/promotions/:id/status
+// Body: { "is_active": false }
+func (h *PromotionHandler) SetActive(c echo.Context) error {
+	promotionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid promotion id")
+	}
+
+	var req domain.PromotionStatusRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	promotion, err := h.svc.SetActive(c.Request().Context(), promotionID, *req.IsActive)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, promotion)
+}
+
+// CreateForBusiness adds a coupon or automatic promotion funded by the
+// caller's business. business_id in the body is ignored.
+//
+// POST /api/v1/businesses/:id/promotions
+func (h *PromotionHandler) CreateForBusiness(c echo.Context) error {
+	ownerID, businessID, err := ownerAndBusiness(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.PromotionRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	promotion, err := h.svc.CreateForBusiness(c.Request().Context(), ownerID, businessID, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, promotion)
+}
+
+// ListForBusiness returns the promotions funded by the caller's business.
+//
+// GET /api/v1/businesses/:id/promotions
+func (h *PromotionHandler) ListForBusiness(c echo.Context) error {
+	ownerID, businessID, err := ownerAndBusiness(c)
+	if err != nil {
+		return err
+	}
+
+	promotions, err := h.svc.ListForBusiness(c.Request().Context(), ownerID, businessID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"data": promotions, "count": len(promotions)})
+}
+
+// SetActiveForBusiness pauses or resumes a promotion of the caller's business.
+//
+// PUT /api/v1/businesses/:id/promotions/:promotionId/status
+// Body: { "is_active": false }
+func (h *PromotionHandler) SetActiveForBusiness(c echo.Context) error {
+	ownerID, businessID, err := ownerAndBusiness(c)
+	if err != nil {
+		return err
+	}
+	promotionID, err := uuid.Parse(c.Param("promotionId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid promotion id")
+	}
+
+	var req domain.PromotionStatusRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	promotion, err := h.svc.SetActiveForBusiness(c.Request().Context(), ownerID, businessID, promotionID, *req.IsActive)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, promotion)
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	custMiddleware "github.com/heptapegon/localpickup/internal/middleware"
+	"github.com/heptapegon/localpickup/internal/service"
+)
+
+type ReviewHandler struct {
+	svc *service.ReviewService
+}
+
+func NewReviewHandler(svc *service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{svc: svc}
+}
+
+// Create rates (1–5) and optionally reviews the business of a completed order.
+// Each order can be reviewed once.
+//
+// POST /api/v1/orders/:id/review
+// Body: { "rating": 5, "comment": "..." }
+func (h *ReviewHandler) Create(c echo.Context) error {
+	customerID, orderID, err := userAndParam(c, "invalid order id")
+	if err != nil {
+		return err
+	}
+
+	var req domain.CreateReviewRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	review, err := h.svc.Create(c.Request().Context(), customerID, orderID, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, review)
+}
+
+// ListByBusiness returns a business's published reviews, newest first. Pass
+// the response's next_cursor back as cursor for the next page; limit defaults
+// to 20 and is capped at 50.
+//
+// GET /api/v1/businesses/:id/reviews?limit=20&cursor=...
+func (h *ReviewHandler) ListByBusiness(c echo.Context) error {
+	businessID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid business id")
+	}
+
+	var q domain.ReviewQuery
+	if err := bindAndValidate(c, &q); err != nil {
+		return err
+	}
+
+	page, err := h.svc.ListByBusiness(c.Request().Context(), businessID, &q)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// Reply lets the owner of the reviewed business answer a review publicly.
+// Replying again replaces the earlier reply.
+//
+// PUT /api/v1/reviews/:id/reply
+// Body: { "text": "..." }
+func (h *ReviewHandler) Reply(c echo.Context) error {
+	ownerID, reviewID, err := userAndParam(c, "invalid review id")
+	if err != nil {
+		return err
+	}
+
+	var req domain.ReviewReplyRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	review, err := h.svc.Reply(c.Request().Context(), ownerID, reviewID, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, review)
+}
+
+// Flag reports a review to the moderators.
+//
+// POST /api/v1/reviews/:id/flag
+// Body: { "reason": "spam" | "offensive" | "off_topic" | "other" }
+func (h *ReviewHandler) Flag(c echo.Context) error {
+	userID, reviewID, err := userAndParam(c, "invalid review id")
+	if err != nil {
+		return err
+	}
+
+	var req domain.ReviewFlagRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.svc.Flag(c.Request().Context(), userID, reviewID, &req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Flagged lists the reviews waiting for moderation, most flagged first.
+//
+// GET /api/v1/admin/reviews/flagged
+func (h *ReviewHandler) Flagged(c echo.Context) error {
+	reviews, err := h.svc.Flagged(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"data": reviews, "count": len(reviews)})
+}
+
+// Moderate hides a review (removing it from the business rating) or restores
+// it, and dismisses its flags.
+//
+// PUT /api/v1/admin/reviews/:id/status
+// Body: { "status": "hidden" | "published" }
+func (h *ReviewHandler) Moderate(c echo.Context) error {
+	reviewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid review id")
+	}
+
+	var req domain.ReviewModerationRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	review, err := h.svc.Moderate(c.Request().Context(), reviewID, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, review)
+}
+
+// userAndParam returns the caller's ID and the :id path parameter.
+func userAndParam(c echo.Context, invalidID string) (userID, id uuid.UUID, err error) {
+	claims := custMiddleware.GetClaims(c)
+	if userID, err = uuid.Parse(claims.UserID); err != nil {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusBadRequest, "invalid user id in token")
+	}
+	if id, err = uuid.Parse(c.Param("id")); err != nil {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusBadRequest, invalidID)
+	}
+	return userID, id, nil
+}
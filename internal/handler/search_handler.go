@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/service"
+)
+
+type SearchHandler struct {
+	svc *service.SearchService
+}
+
+func NewSearchHandler(svc *service.SearchService) *SearchHandler {
+	return &SearchHandler{svc: svc}
+}
+
+// Search finds businesses by name, category, description or product names,
+// tolerating typos and accents. With lat/lng, results are limited to radius
+// (default 10 km) and nearer businesses rank higher. sort=rating puts the
+// best rated matches first.
+//
+// GET /api/v1/search?q=tacos&lat=19.4326&lng=-99.1332&radius=10&sort=rating&limit=20
+func (h *SearchHandler) Search(c echo.Context) error {
+	var q domain.SearchQuery
+	if err := bindAndValidate(c, &q); err != nil {
+		return err
+	}
+
+	page, err := h.svc.Search(c.Request().Context(), &q)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// Validator adapts go-playground/validator to echo.Validator so that the
+// `validate:"..."` tags on request types are enforced by c.Validate.
+type Validator struct {
+	v *validator.Validate
+}
+
+func NewValidator() *Validator {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// Report fields by the name the client actually sent (json/query tag).
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, tag := range []string{"json", "query", "param"} {
+			name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return f.Name
+	})
+	return &Validator{v: v}
+}
+
+// FieldError describes a single failed validation rule.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by Validator.Validate when one or more fields
+// fail their rules.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+" "+f.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (cv *Validator) Validate(i interface{}) error {
+	err := cv.v.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	fields := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Message: ruleMessage(fe),
+		})
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// bindAndValidate binds the request into req and runs the registered validator.
+// Validation failures are returned as *ValidationError for HTTPErrorHandler.
+func bindAndValidate(c echo.Context, req interface{}) error {
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	return c.Validate(req)
+}
+
+// fieldPath strips the root struct name from the namespace, yielding paths
+// like "items[0].quantity".
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
+
+func ruleMessage(fe validator.FieldError) string {
+	kind := fe.Kind()
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "numeric":
+		return "must contain only digits"
+	case "len":
+		if kind == reflect.String {
+			return fmt.Sprintf("must be exactly %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must have exactly %s items", fe.Param())
+	case "min":
+		switch kind {
+		case reflect.String:
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fmt.Sprintf("must contain at least %s items", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		switch kind {
+		case reflect.String:
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fmt.Sprintf("must contain at most %s items", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s", fe.Param())
+	case "gtfield":
+		return fmt.Sprintf("must be greater than %s", strings.ToLower(fe.Param()))
+	case "lt":
+		return fmt.Sprintf("must be less than %s", fe.Param())
+	case "lte":
+		return fmt.Sprintf("must be less than or equal to %s", fe.Param())
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	}
+	return fmt.Sprintf("failed the %q rule", fe.Tag())
+}
@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+// fieldRules flattens a validation result into "field:rule" pairs.
+func fieldRules(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %T: %v", err, err)
+	}
+	out := make([]string, 0, len(verr.Fields))
+	for _, f := range verr.Fields {
+		if f.Message == "" {
+			t.Errorf("field %q has empty message", f.Field)
+		}
+		out = append(out, f.Field+":"+f.Rule)
+	}
+	return out
+}
+
+func assertRules(t *testing.T, got, want []string) {
+	t.Helper()
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("violations = %v, want %v", got, want)
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func validBusiness() domain.CreateBusinessRequest {
+	return domain.CreateBusinessRequest{
+		Name:        "Panadería Rosa",
+		Description: "Pan dulce",
+		Address:     "Av. Reforma 1",
+		Latitude:    19.43,
+		Longitude:   -99.13,
+		Category:    "bakery",
+	}
+}
+
+func TestValidateCreateBusinessRequest(t *testing.T) {
+	v := NewValidator()
+	tests := []struct {
+		name   string
+		mutate func(r *domain.CreateBusinessRequest)
+		want   []string
+	}{
+		{name: "valid", mutate: func(r *domain.CreateBusinessRequest) {}},
+		{name: "short name", mutate: func(r *domain.CreateBusinessRequest) { r.Name = "P" }, want: []string{"name:min"}},
+		{name: "missing address", mutate: func(r *domain.CreateBusinessRequest) { r.Address = "" }, want: []string{"address:required"}},
+		{name: "latitude out of range", mutate: func(r *domain.CreateBusinessRequest) { r.Latitude = 91 }, want: []string{"latitude:max"}},
+		{name: "longitude out of range", mutate: func(r *domain.CreateBusinessRequest) { r.Longitude = -181 }, want: []string{"longitude:min"}},
+		{name: "missing category", mutate: func(r *domain.CreateBusinessRequest) { r.Category = "" }, want: []string{"category:required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validBusiness()
+			tt.mutate(&req)
+			assertRules(t, fieldRules(t, v.Validate(&req)), tt.want)
+		})
+	}
+}
+
+func TestValidateNearbyQuery(t *testing.T) {
+	v := NewValidator()
+	tests := []struct {
+		name string
+		q    domain.NearbyQuery
+		want []string
+	}{
+		{name: "valid", q: domain.NearbyQuery{Latitude: 19.4, Longitude: -99.1, RadiusKm: 5}},
+		{name: "latitude out of range", q: domain.NearbyQuery{Latitude: -95, Longitude: -99.1}, want: []string{"lat:min"}},
+		{name: "negative radius", q: domain.NearbyQuery{Latitude: 19.4, Longitude: -99.1, RadiusKm: -1}, want: []string{"radius:min"}},
+		{name: "limit above cap", q: domain.NearbyQuery{Latitude: 19.4, Longitude: -99.1, Limit: 51}, want: []string{"limit:max"}},
+		{name: "sort by rating", q: domain.NearbyQuery{Latitude: 19.4, Longitude: -99.1, Sort: "rating"}},
+		{name: "unknown sort", q: domain.NearbyQuery{Latitude: 19.4, Longitude: -99.1, Sort: "name"}, want: []string{"sort:oneof"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertRules(t, fieldRules(t, v.Validate(&tt.q)), tt.want)
+		})
+	}
+}
+
+func TestValidateBoundsQuery(t *testing.T) {
+	v := NewValidator()
+	valid := domain.BoundsQuery{North: 19.45, South: 19.40, East: -99.10, West: -99.18, Zoom: 14}
+	tests := []struct {
+		name   string
+		mutate func(*domain.BoundsQuery)
+		want   []string
+	}{
+		{name: "valid", mutate: func(*domain.BoundsQuery) {}},
+		{name: "antimeridian is valid", mutate: func(q *domain.BoundsQuery) { q.West, q.East = 178, -179 }},
+		{name: "north below south", mutate: func(q *domain.BoundsQuery) { q.North = 19.30 }, want: []string{"north:gtfield"}},
+		{name: "missing bounds", mutate: func(q *domain.BoundsQuery) { *q = domain.BoundsQuery{} }, want: []string{"north:gtfield"}},
+		{name: "zoom too deep", mutate: func(q *domain.BoundsQuery) { q.Zoom = 23 }, want: []string{"zoom:max"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := valid
+			tt.mutate(&q)
+			assertRules(t, fieldRules(t, v.Validate(&q)), tt.want)
+		})
+	}
+}
+
+func TestValidateSearchQuery(t *testing.T) {
+	v := NewValidator()
+	tests := []struct {
+		name string
+		q    domain.SearchQuery
+		want []string
+	}{
+		{name: "valid", q: domain.SearchQuery{Query: "tacos"}},
+		{name: "missing query", q: domain.SearchQuery{}, want: []string{"q:required"}},
+		{name: "query too short", q: domain.SearchQuery{Query: "t"}, want: []string{"q:min"}},
+		{name: "limit above cap", q: domain.SearchQuery{Query: "tacos", Limit: 100}, want: []string{"limit:max"}},
+		{name: "sort by rating", q: domain.SearchQuery{Query: "tacos", Sort: "rating"}},
+		{name: "unknown sort", q: domain.SearchQuery{Query: "tacos", Sort: "distance"}, want: []string{"sort:oneof"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertRules(t, fieldRules(t, v.Validate(&tt.q)), tt.want)
+		})
+	}
+}
+
+func TestValidateProductRequest(t *testing.T) {
+	v := NewValidator()
+	tests := []struct {
+		name string
+		req  domain.ProductRequest
+		want []string
+	}{
+		{name: "valid", req: domain.ProductRequest{Name: "Croissant", Price: 35}},
+		{name: "missing name and price", req: domain.ProductRequest{}, want: []string{"name:required", "price:required"}},
+		{name: "negative price", req: domain.ProductRequest{Name: "Croissant", Price: -1}, want: []string{"price:gt"}},
+		{name: "sold out", req: domain.ProductRequest{Name: "Croissant", Price: 35, Stock: ptr(0), DailyStock: ptr(12)}},
+		{name: "negative stock", req: domain.ProductRequest{Name: "Croissant", Price: 35, Stock: ptr(-1), DailyStock: ptr(-3)}, want: []string{"stock:min", "daily_stock:min"}},
+		{
+			name: "modifier groups",
+			req: domain.ProductRequest{Name: "Café", Price: 30, ModifierGroups: []domain.ModifierGroupRequest{
+				{Name: "Tamaño", MinSelect: 1, Options: []domain.ModifierOptionRequest{{Name: "Chico"}, {Name: "Grande", PriceDelta: 8}}},
+				{Name: "Extras", MaxSelect: 2, Options: []domain.ModifierOptionRequest{{Name: "Canela", PriceDelta: 2}}},
+			}},
+		},
+		{
+			name: "invalid modifier groups",
+			req: domain.ProductRequest{Name: "Café", Price: 30, ModifierGroups: []domain.ModifierGroupRequest{
+				{MinSelect: 2, MaxSelect: 1, Options: []domain.ModifierOptionRequest{{}}},
+				{Name: "Extras"},
+			}},
+			want: []string{
+				"modifier_groups[0].name:required", "modifier_groups[0].max_select:gtefield",
+				"modifier_groups[0].options[0].name:required", "modifier_groups[1].options:required",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertRules(t, fieldRules(t, v.Validate(&tt.req)), tt.want)
+		})
+	}
+}
+
+func TestValidateCreateOrderRequest(t *testing.T) {
+	v := NewValidator()
+	item := domain.CreateOrderItemReq{ProductID: uuid.New(), Quantity: 2}
+	tests := []struct {
+		name string
+		req  domain.CreateOrderRequest
+		want []string
+	}{
+		{name: "valid", req: domain.CreateOrderRequest{BusinessID: uuid.New(), Items: []domain.CreateOrderItemReq{item}}},
+		{name: "missing business", req: domain.CreateOrderRequest{Items: []domain.CreateOrderItemReq{item}}, want: []string{"business_id:required"}},
+		{name: "no items", req: domain.CreateOrderRequest{BusinessID: uuid.New(), Items: []domain.CreateOrderItemReq{}}, want: []string{"items:min"}},
+		{
+			name: "zero quantity",
+			req: domain.CreateOrderRequest{BusinessID: uuid.New(), Items: []domain.CreateOrderItemReq{
+				item, {ProductID: uuid.New(), Quantity: 0},
+			}},
+			want: []string{"items[1].quantity:required"},
+		},
+		{
+			name: "missing product",
+			req: domain.CreateOrderRequest{BusinessID: uuid.New(), Items: []domain.CreateOrderItemReq{
+				{Quantity: 1},
+			}},
+			want: []string{"items[0].product_id:required"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertRules(t, fieldRules(t, v.Validate(&tt.req)), tt.want)
+		})
+	}
+}
+
+func TestValidateValidatePINRequest(t *testing.T) {
+	v := NewValidator()
+	tests := []struct {
+		name string
+		pin  string
+		want []string
+	}{
+		{name: "valid", pin: "012345"},
+		{name: "missing", pin: "", want: []string{"pin:required"}},
+		{name: "too short", pin: "123", want: []string{"pin:len"}},
+		{name: "non-numeric", pin: "12a456", want: []string{"pin:numeric"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := domain.ValidatePINRequest{PIN: tt.pin}
+			assertRules(t, fieldRules(t, v.Validate(&req)), tt.want)
+		})
+	}
+}
+
+func TestValidateRegisterRequest(t *testing.T) {
+	v := NewValidator()
+	valid := registerRequest{Name: "Ana", Email: "ana@example.com", Password: "s3cretpass", Role: "customer"}
+	tests := []struct {
+		name   string
+		mutate func(r *registerRequest)
+		want   []string
+	}{
+		{name: "valid", mutate: func(r *registerRequest) {}},
+		{name: "malformed email", mutate: func(r *registerRequest) { r.Email = "ana@" }, want: []string{"email:email"}},
+		{name: "short password", mutate: func(r *registerRequest) { r.Password = "short" }, want: []string{"password:min"}},
+		{name: "unknown role", mutate: func(r *registerRequest) { r.Role = "admin" }, want: []string{"role:oneof"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.mutate(&req)
+			assertRules(t, fieldRules(t, v.Validate(&req)), tt.want)
+		})
+	}
+}
+
+func TestValidateLoginRequest(t *testing.T) {
+	v := NewValidator()
+	tests := []struct {
+		name string
+		req  loginRequest
+		want []string
+	}{
+		{name: "valid", req: loginRequest{Email: "ana@example.com", Password: "x"}},
+		{name: "malformed email", req: loginRequest{Email: "not-an-email", Password: "x"}, want: []string{"email:email"}},
+		{name: "missing password", req: loginRequest{Email: "ana@example.com"}, want: []string{"password:required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertRules(t, fieldRules(t, v.Validate(&tt.req)), tt.want)
+		})
+	}
+}
+
+func TestBindAndValidateReturnsFieldErrors(t *testing.T) {
+	e := echo.New()
+	e.Validator = NewValidator()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"bad","password":""}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	var body loginRequest
+	err := bindAndValidate(c, &body)
+	assertRules(t, fieldRules(t, err), []string{"email:email", "password:required"})
+}
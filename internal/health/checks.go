@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// GeoIndexCheck compares the number of businesses in the geo index with the
+// number of active businesses in the database. A short index means nearby
+// searches are silently missing results.
+func GeoIndexCheck(indexed, active func(ctx context.Context) (int64, error)) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		n, err := indexed(ctx)
+		if err != nil {
+			return fmt.Errorf("count geo index: %w", err)
+		}
+		want, err := active(ctx)
+		if err != nil {
+			return fmt.Errorf("count active businesses: %w", err)
+		}
+		if n < want {
+			return fmt.Errorf("geo index has %d of %d active businesses", n, want)
+		}
+		return nil
+	}
+}
+
+// EnabledCheck fails when an optional integration is switched off.
+func EnabledCheck(enabled func() bool, reason string) func(ctx context.Context) error {
+	return func(context.Context) error {
+		if !enabled() {
+			return errors.New(reason)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,120 @@
+// Package health runs dependency checks for the readiness probe.
+//
+// Every check runs concurrently under its own timeout. A failing critical
+// check makes the instance unready; a failing non-critical check only marks
+// the report as degraded so the load balancer keeps routing traffic to it.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultTimeout = 2 * time.Second
+
+// Status is the outcome of a single check or of the whole report.
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusFail     Status = "fail"
+)
+
+// Check is a named probe of one dependency.
+type Check struct {
+	Name string
+	// Critical checks decide readiness; the rest are informational.
+	Critical bool
+	// Timeout bounds Run. Zero means defaultTimeout.
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// Result is the outcome of one Check.
+type Result struct {
+	Name       string  `json:"name"`
+	Status     Status  `json:"status"`
+	Critical   bool    `json:"critical"`
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Report aggregates the results of every check, in registration order.
+type Report struct {
+	Status Status    `json:"status"`
+	Checks []Result  `json:"checks"`
+	Time   time.Time `json:"time"`
+}
+
+// Ready reports whether every critical check passed.
+func (r Report) Ready() bool { return r.Status != StatusFail }
+
+type Checker struct {
+	checks []Check
+}
+
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{checks: checks}
+}
+
+// Run executes all checks concurrently and waits for them to finish.
+func (c *Checker) Run(ctx context.Context) Report {
+	results := make([]Result, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results, Time: time.Now().UTC()}
+	for _, r := range results {
+		switch {
+		case r.Status == StatusOK:
+		case r.Critical:
+			report.Status = StatusFail
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func run(ctx context.Context, check Check) Result {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	// Run in a goroutine so a probe that ignores ctx still can't hang the report.
+	done := make(chan error, 1)
+	go func() { done <- check.Run(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	r := Result{
+		Name:       check.Name,
+		Status:     StatusOK,
+		Critical:   check.Critical,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		r.Status = StatusFail
+		r.Error = err.Error()
+	}
+	return r
+}
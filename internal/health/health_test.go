@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func ok(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("connection refused") }
+
+func hang(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestCheckerRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     []Check
+		wantStatus Status
+		wantReady  bool
+		wantFailed []string
+	}{
+		{
+			name:       "all healthy",
+			checks:     []Check{{Name: "postgres", Critical: true, Run: ok}, {Name: "fcm", Run: ok}},
+			wantStatus: StatusOK, wantReady: true,
+		},
+		{
+			name:       "non-critical failure degrades",
+			checks:     []Check{{Name: "postgres", Critical: true, Run: ok}, {Name: "fcm", Run: failing}},
+			wantStatus: StatusDegraded, wantReady: true, wantFailed: []string{"fcm"},
+		},
+		{
+			name:       "critical failure fails",
+			checks:     []Check{{Name: "postgres", Critical: true, Run: failing}, {Name: "fcm", Run: failing}},
+			wantStatus: StatusFail, wantReady: false, wantFailed: []string{"postgres", "fcm"},
+		},
+		{
+			name:       "timeout counts as failure",
+			checks:     []Check{{Name: "redis", Critical: true, Timeout: 10 * time.Millisecond, Run: hang}},
+			wantStatus: StatusFail, wantReady: false, wantFailed: []string{"redis"},
+		},
+		{
+			name: "probe ignoring ctx is abandoned",
+			checks: []Check{{Name: "stuck", Timeout: 10 * time.Millisecond, Run: func(context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			}}},
+			wantStatus: StatusDegraded, wantReady: true, wantFailed: []string{"stuck"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			report := NewChecker(tt.checks...).Run(context.Background())
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("Run took %s, want checks bounded by their timeouts", elapsed)
+			}
+
+			if report.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", report.Status, tt.wantStatus)
+			}
+			if report.Ready() != tt.wantReady {
+				t.Errorf("Ready() = %v, want %v", report.Ready(), tt.wantReady)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Fatalf("got %d results, want %d", len(report.Checks), len(tt.checks))
+			}
+
+			var failed []string
+			for i, r := range report.Checks {
+				if r.Name != tt.checks[i].Name {
+					t.Errorf("result[%d] = %q, want registration order", i, r.Name)
+				}
+				if r.Status == StatusFail {
+					failed = append(failed, r.Name)
+					if r.Error == "" {
+						t.Errorf("%s failed without an error message", r.Name)
+					}
+				}
+			}
+			if len(failed) != len(tt.wantFailed) {
+				t.Errorf("failed = %v, want %v", failed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestGeoIndexCheck(t *testing.T) {
+	count := func(n int64) func(context.Context) (int64, error) {
+		return func(context.Context) (int64, error) { return n, nil }
+	}
+	tests := []struct {
+		name    string
+		indexed int64
+		active  int64
+		wantErr bool
+	}{
+		{name: "in sync", indexed: 12, active: 12},
+		{name: "stale extra entries are tolerated", indexed: 14, active: 12},
+		{name: "missing businesses", indexed: 3, active: 12, wantErr: true},
+		{name: "empty after redis flush", indexed: 0, active: 12, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := GeoIndexCheck(count(tt.indexed), count(tt.active))(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Package imaging checks uploaded pictures and renders the resized variants
+// that clients download instead of the original.
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png" // register decoders for image.Decode
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+// Variant names; they are also the file names of the variants in storage.
+const (
+	Thumbnail = "thumbnail"
+	Medium    = "medium"
+	Large     = "large"
+)
+
+// Variant is a rendition whose longest side is at most MaxSide pixels.
+// Smaller pictures are not enlarged.
+type Variant struct {
+	Name    string
+	MaxSide int
+}
+
+// Variants are rendered for every upload.
+var Variants = []Variant{
+	{Name: Thumbnail, MaxSide: 200},
+	{Name: Medium, MaxSide: 640},
+	{Name: Large, MaxSide: 1280},
+}
+
+const (
+	// ContentType and Ext describe every rendered variant.
+	ContentType = "image/jpeg"
+	Ext         = ".jpg"
+
+	// MaxPixels bounds the decoded size of an upload, so that a small but
+	// highly compressed file cannot exhaust memory.
+	MaxPixels = 40_000_000
+
+	jpegQuality = 85
+)
+
+// accepted are the sniffed content types that can be decoded.
+var accepted = map[string]bool{"image/jpeg": true, "image/png": true, "image/webp": true}
+
+// Sniff returns the content type of data, judged from its first bytes rather
+// than from what the client claims, or ErrUnsupportedImage.
+func Sniff(data []byte) (string, error) {
+	ct := http.DetectContentType(data)
+	if !accepted[ct] {
+		return "", domain.ErrUnsupportedImage.WithMessage(fmt.Sprintf("images must be JPEG, PNG or WebP, got %s", ct))
+	}
+	return ct, nil
+}
+
+// Render decodes an upload and returns each variant encoded as JPEG, keyed by
+// variant name. Transparent areas are flattened onto white.
+func Render(data []byte) (map[string][]byte, error) {
+	if _, err := Sniff(data); err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, domain.ErrUnsupportedImage.WithMessage("image is corrupt").WithCause(err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, domain.ErrUnsupportedImage.WithMessage(
+			fmt.Sprintf("image is %d×%d; at most %d megapixels are accepted", cfg.Width, cfg.Height, MaxPixels/1_000_000))
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, domain.ErrUnsupportedImage.WithMessage("image is corrupt").WithCause(err)
+	}
+
+	out := make(map[string][]byte, len(Variants))
+	for _, v := range Variants {
+		b, err := encode(resize(src, v.MaxSide))
+		if err != nil {
+			return nil, fmt.Errorf("encode %s: %w", v.Name, err)
+		}
+		out[v.Name] = b
+	}
+	return out, nil
+}
+
+// Fit scales w×h down, keeping the aspect ratio, so that neither side
+// exceeds maxSide.
+func Fit(w, h, maxSide int) (int, int) {
+	if w <= maxSide && h <= maxSide {
+		return w, h
+	}
+	if w >= h {
+		return maxSide, max(1, h*maxSide/w)
+	}
+	return max(1, w*maxSide/h), maxSide
+}
+
+func resize(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := Fit(b.Dx(), b.Dy(), maxSide)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+	return dst
+}
+
+func encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
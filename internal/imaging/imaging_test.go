@@ -0,0 +1,91 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+func pngBytes(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.NRGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"png", pngBytes(t, 2, 2), "image/png"},
+		{"webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), "image/webp"},
+		{"gif", []byte("GIF89a......"), ""},
+		{"html with an image name", []byte("<html><body>menu.jpg</body></html>"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Sniff(tt.data)
+			if got != tt.want || (err != nil) != (tt.want == "") {
+				t.Errorf("Sniff() = %q, %v, want %q", got, err, tt.want)
+			}
+			if err != nil && !errors.Is(err, domain.ErrUnsupportedImage) {
+				t.Errorf("Sniff() error = %v, want ErrUnsupportedImage", err)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	variants, err := Render(pngBytes(t, 1600, 800))
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	want := map[string]image.Point{Thumbnail: {200, 100}, Medium: {640, 320}, Large: {1280, 640}}
+	if len(variants) != len(want) {
+		t.Fatalf("Render() returned %d variants, want %d", len(variants), len(want))
+	}
+	for name, size := range want {
+		img, err := jpeg.Decode(bytes.NewReader(variants[name]))
+		if err != nil {
+			t.Fatalf("%s is not a JPEG: %v", name, err)
+		}
+		if got := img.Bounds().Size(); got != size {
+			t.Errorf("%s is %v, want %v", name, got, size)
+		}
+	}
+
+	if _, err := Render([]byte("\x89PNG\r\n\x1a\ntruncated")); !errors.Is(err, domain.ErrUnsupportedImage) {
+		t.Errorf("Render(corrupt) error = %v, want ErrUnsupportedImage", err)
+	}
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		w, h, max    int
+		wantW, wantH int
+	}{
+		{100, 50, 200, 100, 50}, // never enlarged
+		{1600, 800, 200, 200, 100},
+		{800, 1600, 200, 100, 200},
+		{5000, 1, 200, 200, 1}, // never zero
+	}
+	for _, tt := range tests {
+		if w, h := Fit(tt.w, tt.h, tt.max); w != tt.wantW || h != tt.wantH {
+			t.Errorf("Fit(%d, %d, %d) = %d×%d, want %d×%d", tt.w, tt.h, tt.max, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
@@ -2,19 +2,29 @@ package infra
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
+	"github.com/exaring/otelpgx"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewPostgres(databaseURL string) *pgxpool.Pool {
-	pool, err := pgxpool.New(context.Background(), databaseURL)
+// NewPostgres creates a pool and waits for the database to answer, retrying
+// with backoff until ctx expires.
+func NewPostgres(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
-		log.Fatalf("postgres: unable to create pool: %v", err)
+		return nil, err
 	}
-	if err := pool.Ping(context.Background()); err != nil {
-		log.Fatalf("postgres: ping failed: %v", err)
+	cfg.ConnConfig.Tracer = otelpgx.NewTracer()
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := retry(ctx, "postgres", pool.Ping); err != nil {
+		pool.Close()
+		return nil, err
 	}
-	log.Println("postgres: connected")
-	return pool
+	slog.Info("postgres: connected")
+	return pool, nil
 }
@@ -2,20 +2,28 @@ package infra
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
-func NewRedis(addr string) *redis.Client {
+// NewRedis creates a client and waits for the server to answer, retrying
+// with backoff until ctx expires.
+func NewRedis(ctx context.Context, addr string) (*redis.Client, error) {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     addr,
 		Password: "",
 		DB:       0,
 	})
-	if err := rdb.Ping(context.Background()).Err(); err != nil {
-		log.Fatalf("redis: ping failed: %v", err)
+	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		return nil, err
 	}
-	log.Println("redis: connected")
-	return rdb
+	ping := func(ctx context.Context) error { return rdb.Ping(ctx).Err() }
+	if err := retry(ctx, "redis", ping); err != nil {
+		rdb.Close()
+		return nil, err
+	}
+	slog.Info("redis: connected")
+	return rdb, nil
 }
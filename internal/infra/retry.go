@@ -0,0 +1,44 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 10 * time.Second
+	attemptTimeout = 5 * time.Second
+)
+
+// retry calls connect until it succeeds or ctx expires, doubling the wait
+// between attempts. Dependencies started by the same docker-compose up are
+// often a few seconds behind the API, so failing on the first attempt just
+// causes a restart loop.
+func retry(ctx context.Context, name string, connect func(ctx context.Context) error) error {
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, attemptTimeout)
+		err := connect(attemptCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: giving up after %d attempts: %w", name, attempt, err)
+		default:
+		}
+
+		slog.Warn(name+": connection attempt failed", "attempt", attempt, "retry_in", backoff.String(), "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: giving up after %d attempts: %w", name, attempt, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
@@ -0,0 +1,139 @@
+// Package logging builds the service's slog logger.
+//
+// Request-scoped fields (request, user, business and order IDs) travel in the
+// context: middleware and services add them with With, and every record
+// logged through a *Context method picks them up together with the active
+// trace and span IDs. Sensitive attributes are redacted before output.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys shared across the codebase.
+const (
+	RequestID  = "request_id"
+	UserID     = "user_id"
+	BusinessID = "business_id"
+	OrderID    = "order_id"
+	Error      = "error"
+)
+
+// Output formats accepted by New.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type Config struct {
+	Level  slog.Level
+	Format string
+}
+
+// New returns a logger writing to w that adds context fields and redacts
+// secrets and personal data.
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: cfg.Level, ReplaceAttr: redact}
+
+	var h slog.Handler
+	switch cfg.Format {
+	case "", FormatJSON:
+		h = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("logging: unknown format %q (want json or text)", cfg.Format)
+	}
+	return slog.New(&contextHandler{Handler: h}), nil
+}
+
+// ParseLevel accepts debug, info, warn or error (case-insensitive).
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("logging: invalid level %q", s)
+	}
+	return l, nil
+}
+
+// Nop returns a logger that discards everything, for tests.
+func Nop() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+type ctxKey struct{}
+
+// With returns a copy of ctx carrying additional log fields, given as
+// alternating keys and values like slog.Logger.With.
+func With(ctx context.Context, args ...any) context.Context {
+	prev, _ := ctx.Value(ctxKey{}).([]any)
+	fields := make([]any, 0, len(prev)+len(args))
+	fields = append(fields, prev...)
+	fields = append(fields, args...)
+	return context.WithValue(ctx, ctxKey{}, fields)
+}
+
+// contextHandler adds the fields stored by With plus trace correlation IDs.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if fields, ok := ctx.Value(ctxKey{}).([]any); ok {
+		r.Add(fields...)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+const redacted = "[REDACTED]"
+
+// secretKeys are attribute keys whose values never reach the log.
+var secretKeys = map[string]bool{
+	"pin":           true,
+	"password":      true,
+	"authorization": true,
+	"secret":        true,
+	"token":         true,
+	"fcm_token":     true,
+	"access_token":  true,
+}
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	switch {
+	case secretKeys[key], strings.HasSuffix(key, "_token"), strings.HasSuffix(key, "_secret"):
+		return slog.String(a.Key, redacted)
+	case key == "email":
+		return slog.String(a.Key, maskEmail(a.Value.String()))
+	}
+	return a
+}
+
+// maskEmail keeps the first character and the domain: jane@x.com → j***@x.com.
+func maskEmail(email string) string {
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 {
+		return redacted
+	}
+	return email[:1] + "***" + email[at:]
+}
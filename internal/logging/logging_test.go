@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	return m
+}
+
+func TestRedaction(t *testing.T) {
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want string
+	}{
+		{name: "pin", attr: slog.String("pin", "123456"), want: redacted},
+		{name: "fcm token", attr: slog.String("fcm_token", "dGhpcyBpcyBh"), want: redacted},
+		{name: "any token suffix", attr: slog.String("refresh_token", "abc"), want: redacted},
+		{name: "case insensitive", attr: slog.String("Authorization", "Bearer eyJ"), want: redacted},
+		{name: "email masked", attr: slog.String("email", "jane.doe@example.com"), want: "j***@example.com"},
+		{name: "malformed email", attr: slog.String("email", "not-an-email"), want: redacted},
+		{name: "other keys untouched", attr: slog.String("order_id", "3f2a"), want: "3f2a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, _ := New(&buf, Config{})
+			logger.LogAttrs(context.Background(), slog.LevelInfo, "msg", tt.attr)
+
+			if got := decode(t, &buf)[tt.attr.Key]; got != tt.want {
+				t.Errorf("%s = %v, want %q", tt.attr.Key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(&buf, Config{})
+
+	ctx := With(context.Background(), RequestID, "req-1", UserID, "user-1")
+	ctx = With(ctx, OrderID, "order-1")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}, TraceFlags: trace.FlagsSampled,
+	})
+	ctx = trace.ContextWithSpanContext(ctx, sc)
+
+	logger.With("component", "test").InfoContext(ctx, "order completed", "pin", "654321")
+
+	got := decode(t, &buf)
+	want := map[string]any{
+		RequestID:   "req-1",
+		UserID:      "user-1",
+		OrderID:     "order-1",
+		"component": "test",
+		"pin":       redacted,
+		"trace_id":  sc.TraceID().String(),
+		"span_id":   sc.SpanID().String(),
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestLevelAndFormat(t *testing.T) {
+	level, err := ParseLevel("WARN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	logger, _ := New(&buf, Config{Level: level})
+	logger.Info("dropped")
+	if buf.Len() != 0 {
+		t.Errorf("info logged at warn level: %s", buf.String())
+	}
+	logger.Warn("kept")
+	if decode(t, &buf)["msg"] != "kept" {
+		t.Errorf("warn not logged: %s", buf.String())
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(verbose) succeeded, want error")
+	}
+	if _, err := New(&buf, Config{Format: "xml"}); err == nil {
+		t.Error("New(format=xml) succeeded, want error")
+	}
+}
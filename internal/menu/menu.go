@@ -0,0 +1,307 @@
+// Package menu reads and writes business catalogs as CSV or JSON files.
+//
+// JSON holds everything a product has, modifier groups included:
+//
+//	{"products": [{"id": "…", "name": "Concha", "price": 18.5, "modifier_groups": […]}]}
+//
+// CSV has one product per row under a header naming the columns, in any
+// order: id, name, description, price, is_available, stock, daily_stock.
+// Only name and price are required. An empty stock or daily_stock means
+// unlimited; an empty is_available means available. CSV cannot express
+// modifier groups, so importing it leaves them untouched.
+//
+// A file exported in either format imports back without changes.
+package menu
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+)
+
+// MaxRows bounds the products in one file.
+const MaxRows = 2000
+
+var columns = []string{"id", "name", "description", "price", "is_available", "stock", "daily_stock"}
+
+// ParseFormat accepts a format name ("csv", "json"), a file name or a media
+// type.
+func ParseFormat(s string) (Format, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.IndexByte(s, ';'); i >= 0 {
+		s = strings.TrimSpace(s[:i]) // media type parameters
+	}
+	switch {
+	case s == "csv", s == "text/csv", filepath.Ext(s) == ".csv":
+		return CSV, nil
+	case s == "json", s == "application/json", filepath.Ext(s) == ".json":
+		return JSON, nil
+	}
+	return "", fmt.Errorf("unknown menu format %q (want csv or json)", s)
+}
+
+// ContentType is the media type of files in f.
+func (f Format) ContentType() string {
+	if f == CSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/json"
+}
+
+// Decode reads a menu. Problems confined to a row (a price that is not a
+// number, say) are returned as row errors so that the whole file can be
+// reported at once; a file that cannot be read at all is an error.
+func Decode(r io.Reader, f Format) ([]domain.MenuItem, []domain.MenuRowError, error) {
+	var items []domain.MenuItem
+	var rowErrs []domain.MenuRowError
+	var err error
+	switch f {
+	case CSV:
+		items, rowErrs, err = decodeCSV(r)
+	case JSON:
+		items, err = decodeJSON(r)
+	default:
+		err = fmt.Errorf("unknown menu format %q", f)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(items)+len(rowErrs) > MaxRows {
+		return nil, nil, fmt.Errorf("menu has more than %d products", MaxRows)
+	}
+	return items, rowErrs, nil
+}
+
+type jsonMenu struct {
+	Products []domain.MenuItem `json:"products"`
+}
+
+func decodeJSON(r io.Reader) ([]domain.MenuItem, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var m jsonMenu
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid JSON menu: %w", err)
+	}
+	if m.Products == nil {
+		return nil, errors.New(`invalid JSON menu: missing "products"`)
+	}
+	for i := range m.Products {
+		m.Products[i].Row = i + 1
+	}
+	return m.Products, nil
+}
+
+func decodeCSV(r io.Reader) ([]domain.MenuItem, []domain.MenuRowError, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("empty CSV menu")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV menu: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, h := range header {
+		// Spreadsheets often save CSV with a byte order mark.
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if !slices.Contains(columns, h) {
+			return nil, nil, fmt.Errorf("invalid CSV menu: unknown column %q", h)
+		}
+		if _, dup := col[h]; dup {
+			return nil, nil, fmt.Errorf("invalid CSV menu: column %q appears twice", h)
+		}
+		col[h] = i
+	}
+	for _, required := range []string{"name", "price"} {
+		if _, ok := col[required]; !ok {
+			return nil, nil, fmt.Errorf("invalid CSV menu: missing column %q", required)
+		}
+	}
+
+	var items []domain.MenuItem
+	var rowErrs []domain.MenuRowError
+	for row := 2; ; row++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) && errors.Is(perr.Err, csv.ErrFieldCount) {
+				rowErrs = append(rowErrs, domain.MenuRowError{Row: row, Message: "wrong number of fields"})
+				continue
+			}
+			return nil, nil, fmt.Errorf("invalid CSV menu: %w", err)
+		}
+		if len(items)+len(rowErrs) > MaxRows {
+			break // Decode reports it
+		}
+		item, errs := parseRow(row, rec, col)
+		if len(errs) > 0 {
+			rowErrs = append(rowErrs, errs...)
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, rowErrs, nil
+}
+
+func parseRow(row int, rec []string, col map[string]int) (domain.MenuItem, []domain.MenuRowError) {
+	item := domain.MenuItem{Row: row}
+	var errs []domain.MenuRowError
+	get := func(name string) string {
+		if i, ok := col[name]; ok {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	fail := func(field, msg string) {
+		errs = append(errs, domain.MenuRowError{Row: row, Field: field, Message: msg})
+	}
+
+	if v := get("id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			fail("id", "must be a UUID")
+		}
+		item.ID = &id
+	}
+	item.Name = get("name")
+	item.Description = get("description")
+	if v := get("price"); v != "" {
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			fail("price", "must be a number")
+		}
+		item.Price = price
+	}
+	if v := get("is_available"); v != "" {
+		available, err := strconv.ParseBool(v)
+		if err != nil {
+			fail("is_available", "must be true or false")
+		}
+		item.IsAvailable = &available
+	}
+	for _, f := range []struct {
+		name string
+		dst  **int
+	}{{"stock", &item.Stock}, {"daily_stock", &item.DailyStock}} {
+		if v := get(f.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				fail(f.name, "must be a whole number")
+			}
+			*f.dst = &n
+		}
+	}
+	return item, errs
+}
+
+// Encode writes products as a menu that Decode reads back unchanged.
+func Encode(w io.Writer, f Format, products []*domain.Product) error {
+	switch f {
+	case CSV:
+		return encodeCSV(w, products)
+	case JSON:
+		return encodeJSON(w, products)
+	}
+	return fmt.Errorf("unknown menu format %q", f)
+}
+
+func encodeJSON(w io.Writer, products []*domain.Product) error {
+	m := jsonMenu{Products: make([]domain.MenuItem, 0, len(products))}
+	for _, p := range products {
+		m.Products = append(m.Products, Item(p))
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func encodeCSV(w io.Writer, products []*domain.Product) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	optInt := func(n *int) string {
+		if n == nil {
+			return ""
+		}
+		return strconv.Itoa(*n)
+	}
+	for _, p := range products {
+		if err := cw.Write([]string{
+			p.ID.String(),
+			p.Name,
+			p.Description,
+			strconv.FormatFloat(p.Price, 'f', 2, 64),
+			strconv.FormatBool(p.IsAvailable),
+			optInt(p.Stock),
+			optInt(p.DailyStock),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Item is the menu row describing p, modifier groups included.
+func Item(p *domain.Product) domain.MenuItem {
+	id, available := p.ID, p.IsAvailable
+	item := domain.MenuItem{ID: &id, ProductRequest: domain.ProductRequest{
+		Name:           p.Name,
+		Description:    p.Description,
+		Price:          p.Price,
+		IsAvailable:    &available,
+		Stock:          p.Stock,
+		DailyStock:     p.DailyStock,
+		ModifierGroups: make([]domain.ModifierGroupRequest, 0, len(p.ModifierGroups)),
+	}}
+	for _, g := range p.ModifierGroups {
+		groupID := g.ID
+		gr := domain.ModifierGroupRequest{
+			ID:        &groupID,
+			Name:      g.Name,
+			MinSelect: g.MinSelect,
+			MaxSelect: g.MaxSelect,
+			Options:   make([]domain.ModifierOptionRequest, 0, len(g.Options)),
+		}
+		for _, o := range g.Options {
+			optionID, optionAvailable := o.ID, o.IsAvailable
+			gr.Options = append(gr.Options, domain.ModifierOptionRequest{
+				ID:          &optionID,
+				Name:        o.Name,
+				PriceDelta:  o.PriceDelta,
+				IsAvailable: &optionAvailable,
+			})
+		}
+		item.ModifierGroups = append(item.ModifierGroups, gr)
+	}
+	return item
+}
@@ -0,0 +1,146 @@
+package menu
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func catalog() []*domain.Product {
+	return []*domain.Product{
+		{ID: uuid.New(), Name: "Bolillo", Description: "Crujiente, \"recién\" horneado", Price: 4, IsAvailable: true, Stock: ptr(3), DailyStock: ptr(40)},
+		{
+			ID: uuid.New(), Name: "Café de olla", Price: 30.5,
+			ModifierGroups: []domain.ModifierGroup{{
+				ID: uuid.New(), Name: "Tamaño", MinSelect: 1, MaxSelect: 1,
+				Options: []domain.ModifierOption{{ID: uuid.New(), Name: "Chico", IsAvailable: true}, {ID: uuid.New(), Name: "Grande", PriceDelta: 8}},
+			}},
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{CSV, JSON} {
+		t.Run(string(format), func(t *testing.T) {
+			products := catalog()
+			var buf bytes.Buffer
+			if err := Encode(&buf, format, products); err != nil {
+				t.Fatalf("Encode() error: %v", err)
+			}
+			items, rowErrs, err := Decode(&buf, format)
+			if err != nil || len(rowErrs) > 0 {
+				t.Fatalf("Decode() = %v, %v", rowErrs, err)
+			}
+			if len(items) != len(products) {
+				t.Fatalf("decoded %d items, want %d", len(items), len(products))
+			}
+			for i, p := range products {
+				want := Item(p)
+				want.Row = items[i].Row
+				if format == CSV {
+					want.ModifierGroups = nil // not expressible in CSV
+				}
+				if !reflect.DeepEqual(items[i], want) {
+					t.Errorf("item %d = %+v, want %+v", i, items[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		wantErr  string
+		wantRows []int
+		wantErrs []domain.MenuRowError
+	}{
+		{
+			name:     "columns in any order, optional ones left out",
+			in:       "\ufeffPrice,Name\n18.5,Concha\n4,Bolillo\n",
+			wantRows: []int{2, 3},
+		},
+		{
+			name: "bad cells are reported by row",
+			in:   "name,price,stock,is_available,id\nConcha,18.5,,,\nBolillo,cuatro,-,si,nope\nDona,12\n",
+			wantErrs: []domain.MenuRowError{
+				{Row: 3, Field: "id", Message: "must be a UUID"},
+				{Row: 3, Field: "price", Message: "must be a number"},
+				{Row: 3, Field: "is_available", Message: "must be true or false"},
+				{Row: 3, Field: "stock", Message: "must be a whole number"},
+				{Row: 4, Message: "wrong number of fields"},
+			},
+			wantRows: []int{2},
+		},
+		{name: "unknown column", in: "name,price,colour\n", wantErr: `unknown column "colour"`},
+		{name: "missing price column", in: "name\nConcha\n", wantErr: `missing column "price"`},
+		{name: "empty file", in: "", wantErr: "empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, rowErrs, err := Decode(strings.NewReader(tt.in), CSV)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Decode() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() error: %v", err)
+			}
+			var rows []int
+			for _, it := range items {
+				rows = append(rows, it.Row)
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("rows = %v, want %v", rows, tt.wantRows)
+			}
+			if !reflect.DeepEqual(rowErrs, tt.wantErrs) {
+				t.Errorf("row errors = %+v, want %+v", rowErrs, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	items, _, err := Decode(strings.NewReader(`{"products":[{"name":"Concha","price":18.5},{"name":"Bolillo","price":4,"modifier_groups":[]}]}`), JSON)
+	if err != nil {
+		t.Fatalf("Decode() error: %v", err)
+	}
+	if items[0].Row != 1 || items[1].Row != 2 {
+		t.Errorf("rows = %d, %d, want 1, 2", items[0].Row, items[1].Row)
+	}
+	if items[0].ModifierGroups != nil || items[1].ModifierGroups == nil {
+		t.Error("an absent modifier_groups must stay nil (keep) and an empty one non-nil (clear)")
+	}
+
+	for _, in := range []string{`{"products":[{"name":"Concha","prize":18.5}]}`, `{"items":[]}`, `[`} {
+		if _, _, err := Decode(strings.NewReader(in), JSON); err == nil {
+			t.Errorf("Decode(%s) succeeded, want error", in)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Format
+	}{
+		{"csv", CSV}, {"JSON", JSON}, {"menu.CSV", CSV}, {"/tmp/menu.json", JSON},
+		{"text/csv; charset=utf-8", CSV}, {"application/json", JSON}, {"menu.xlsx", ""},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.in)
+		if got != tt.want || (err != nil) != (tt.want == "") {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
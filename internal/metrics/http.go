@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// unmatchedRoute labels requests that didn't match any route, so scanners
+// probing random paths can't blow up the route label's cardinality.
+const unmatchedRoute = "unmatched"
+
+// Handler serves Registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Middleware records request count and latency labelled by the route template
+// (e.g. /api/v1/orders/:id), not the raw path.
+//
+// Errors are rendered here via c.Error so the recorded status is the one the
+// client actually received.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" {
+				route = unmatchedRoute
+			}
+			method := c.Request().Method
+			httpRequests.WithLabelValues(method, route, strconv.Itoa(c.Response().Status)).Inc()
+			httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+			return nil
+		}
+	}
+}
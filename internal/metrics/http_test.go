@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareLabelsByRouteTemplate(t *testing.T) {
+	httpRequests.Reset()
+	httpDuration.Reset()
+
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/api/v1/orders/:id", func(c echo.Context) error {
+		if c.Param("id") == "missing" {
+			return echo.NewHTTPError(http.StatusNotFound, "order not found")
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/boom", func(c echo.Context) error {
+		return echo.ErrInternalServerError
+	})
+
+	for _, path := range []string{"/api/v1/orders/1", "/api/v1/orders/2", "/api/v1/orders/missing", "/boom", "/wp-admin.php", "/.env"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	tests := []struct {
+		route  string
+		status string
+		want   float64
+	}{
+		{route: "/api/v1/orders/:id", status: "200", want: 2},
+		{route: "/api/v1/orders/:id", status: "404", want: 1},
+		{route: "/boom", status: "500", want: 1},
+		{route: unmatchedRoute, status: "404", want: 2},
+	}
+	for _, tt := range tests {
+		got := testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, tt.route, tt.status))
+		if got != tt.want {
+			t.Errorf("requests{route=%q,status=%s} = %v, want %v", tt.route, tt.status, got, tt.want)
+		}
+	}
+
+	// One series per route template, never per raw path.
+	if n := testutil.CollectAndCount(httpDuration); n != 3 {
+		t.Errorf("duration series = %d, want 3", n)
+	}
+}
@@ -0,0 +1,163 @@
+// Package metrics holds every Prometheus collector the service exports, plus
+// small helpers so call sites stay one line long.
+//
+// Label values must come from bounded sets (route templates, business
+// categories, provider error codes) — never IDs or free text.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "localpickup"
+
+// Registry is the registry served on /metrics. It is separate from
+// prometheus.DefaultRegisterer so third-party libraries can't add series
+// behind our back.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	ordersCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_created_total",
+		Help:      "Orders paid and persisted, by business category.",
+	}, []string{"category"})
+
+	ordersCompleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_completed_total",
+		Help:      "Orders picked up with a valid PIN, by business category.",
+	}, []string{"category"})
+
+	ordersCancelled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_cancelled_total",
+		Help:      "Orders cancelled, by business category.",
+	}, []string{"category"})
+
+	paymentFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payment_failures_total",
+		Help:      "Rejected charges by reason (Stripe decline code or local rule).",
+	}, []string{"reason"})
+
+	pinFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pin_validation_failures_total",
+		Help:      "Failed pickup PIN validations by reason.",
+	}, []string{"reason"})
+
+	notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Push notification attempts by type and outcome (sent, failed, skipped, disabled).",
+	}, []string{"type", "outcome"})
+
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by the rate limiter, by policy group.",
+	}, []string{"group"})
+
+	geoFallbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "geo_search_fallbacks_total",
+		Help:      "Nearby lookups served by the fallback geo backend, by reason.",
+	}, []string{"reason"})
+
+	geoReconciled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "geo_reconcile_changes_total",
+		Help:      "Geo index entries repaired by the reconciler, by change (added, moved, removed).",
+	}, []string{"change"})
+
+	geoSearchDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "geo_search_duration_seconds",
+		Help:      "Latency of nearby-business lookups against the geo index.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25},
+	})
+
+	geoSearchResults = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "geo_search_results",
+		Help:      "Number of businesses returned by the geo index per lookup.",
+		Buckets:   []float64{0, 1, 5, 10, 20, 30, 40, 50},
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		ordersCreated, ordersCompleted, ordersCancelled,
+		paymentFailures, pinFailures, notifications, rateLimited,
+		geoFallbacks, geoReconciled, geoSearchDuration, geoSearchResults,
+	)
+}
+
+// Notification outcomes.
+const (
+	NotificationSent     = "sent"
+	NotificationFailed   = "failed"
+	NotificationSkipped  = "skipped"  // recipient has no FCM token
+	NotificationDisabled = "disabled" // FCM credentials not configured
+)
+
+// PIN validation failure reasons.
+const (
+	PINWrong          = "wrong_pin"
+	PINForbidden      = "forbidden"
+	PINNotCompletable = "not_completable"
+)
+
+// Geo fallback reasons.
+const (
+	GeoFallbackError = "error" // primary backend returned an error
+	GeoFallbackOpen  = "open"  // circuit breaker is open
+	GeoFallbackCold  = "cold"  // primary index is empty
+)
+
+func OrderCreated(category string)   { ordersCreated.WithLabelValues(category).Inc() }
+func OrderCompleted(category string) { ordersCompleted.WithLabelValues(category).Inc() }
+func OrderCancelled(category string) { ordersCancelled.WithLabelValues(category).Inc() }
+
+func PaymentFailed(reason string) { paymentFailures.WithLabelValues(reason).Inc() }
+
+func PINValidationFailed(reason string) { pinFailures.WithLabelValues(reason).Inc() }
+
+func Notification(kind, outcome string) { notifications.WithLabelValues(kind, outcome).Inc() }
+
+func RateLimited(group string) { rateLimited.WithLabelValues(group).Inc() }
+
+// GeoSearch records one geo index lookup.
+func GeoSearch(took time.Duration, results int) {
+	geoSearchDuration.Observe(took.Seconds())
+	geoSearchResults.Observe(float64(results))
+}
+
+func GeoFallback(reason string) { geoFallbacks.WithLabelValues(reason).Inc() }
+
+// GeoReconciled records the entries one reconciliation pass repaired.
+func GeoReconciled(added, moved, removed int) {
+	geoReconciled.WithLabelValues("added").Add(float64(added))
+	geoReconciled.WithLabelValues("moved").Add(float64(moved))
+	geoReconciled.WithLabelValues("removed").Add(float64(removed))
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// RegisterPools exports connection pool statistics for Postgres and Redis.
+// Values are read from the pools at scrape time.
+func RegisterPools(db *pgxpool.Pool, rdb *redis.Client) {
+	Registry.MustRegister(&poolCollector{db: db, rdb: rdb})
+}
+
+func poolDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, []string{"pool"}, nil)
+}
+
+var (
+	poolTotalConns    = poolDesc("pool_total_connections", "Open connections.")
+	poolIdleConns     = poolDesc("pool_idle_connections", "Idle connections.")
+	poolAcquiredConns = poolDesc("pool_acquired_connections", "Connections currently checked out (Postgres only).")
+	poolMaxConns      = poolDesc("pool_max_connections", "Configured pool size (Postgres only).")
+	poolAcquires      = poolDesc("pool_acquires_total", "Successful acquires (Postgres) or pool hits (Redis).")
+	poolEmptyAcquires = poolDesc("pool_empty_acquires_total", "Acquires that had to wait for or dial a connection (Postgres) or pool misses (Redis).")
+	poolWaitSeconds   = poolDesc("pool_acquire_wait_seconds_total", "Cumulative time spent acquiring connections (Postgres only).")
+	poolTimeouts      = poolDesc("pool_timeouts_total", "Canceled acquires (Postgres) or pool wait timeouts (Redis).")
+)
+
+type poolCollector struct {
+	db  *pgxpool.Pool
+	rdb *redis.Client
+}
+
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		poolTotalConns, poolIdleConns, poolAcquiredConns, poolMaxConns,
+		poolAcquires, poolEmptyAcquires, poolWaitSeconds, poolTimeouts,
+	} {
+		ch <- d
+	}
+}
+
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	gauge := func(d *prometheus.Desc, v float64, pool string) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v, pool)
+	}
+	counter := func(d *prometheus.Desc, v float64, pool string) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v, pool)
+	}
+
+	if p.db != nil {
+		s := p.db.Stat()
+		gauge(poolTotalConns, float64(s.TotalConns()), "postgres")
+		gauge(poolIdleConns, float64(s.IdleConns()), "postgres")
+		gauge(poolAcquiredConns, float64(s.AcquiredConns()), "postgres")
+		gauge(poolMaxConns, float64(s.MaxConns()), "postgres")
+		counter(poolAcquires, float64(s.AcquireCount()), "postgres")
+		counter(poolEmptyAcquires, float64(s.EmptyAcquireCount()), "postgres")
+		counter(poolWaitSeconds, s.AcquireDuration().Seconds(), "postgres")
+		counter(poolTimeouts, float64(s.CanceledAcquireCount()), "postgres")
+	}
+
+	if p.rdb != nil {
+		s := p.rdb.PoolStats()
+		gauge(poolTotalConns, float64(s.TotalConns), "redis")
+		gauge(poolIdleConns, float64(s.IdleConns), "redis")
+		counter(poolAcquires, float64(s.Hits), "redis")
+		counter(poolEmptyAcquires, float64(s.Misses), "redis")
+		counter(poolTimeouts, float64(s.Timeouts), "redis")
+	}
+}
@@ -6,12 +6,21 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/logging"
+)
+
+// User roles carried in JWTClaims.Role.
+const (
+	RoleCustomer      = "customer"
+	RoleBusinessOwner = "business_owner"
+	RoleAdmin         = "admin"
 )
 
 type JWTClaims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
-	// "customer" | "business_owner"
+	// RoleCustomer | RoleBusinessOwner | RoleAdmin
 	Role string `json:"role"`
 	jwt.RegisteredClaims
 }
@@ -44,11 +53,32 @@ func JWT(secret string) echo.MiddlewareFunc {
 			}
 
 			c.Set(claimsKey, claims)
+			req := c.Request()
+			c.SetRequest(req.WithContext(logging.With(req.Context(), logging.UserID, claims.UserID)))
 			return next(c)
 		}
 	}
 }
 
+// RequireRole rejects requests whose token doesn't carry one of roles. It must
+// run after JWT.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims := GetClaims(c)
+			if claims == nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing authorization header")
+			}
+			for _, r := range roles {
+				if claims.Role == r {
+					return next(c)
+				}
+			}
+			return echo.NewHTTPError(http.StatusForbidden, "insufficient role")
+		}
+	}
+}
+
 // GetClaims extracts the JWT claims stored by the JWT middleware.
 func GetClaims(c echo.Context) *JWTClaims {
 	claims, _ := c.Get(claimsKey).(*JWTClaims)
@@ -14,6 +14,16 @@ import (
 
 const testSecret = "test-secret-key"
 
+func makeTokenWithRole(secret, userID, role string) string {
+	claims := &middleware.JWTClaims{
+		UserID:           userID,
+		Role:             role,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	return token
+}
+
 func makeToken(secret string, expired bool) string {
 	exp := time.Now().Add(time.Hour)
 	if expired {
@@ -98,3 +108,33 @@ func TestJWTMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{name: "admin allowed", token: makeTokenWithRole(testSecret, "admin-1", middleware.RoleAdmin), wantStatus: http.StatusOK},
+		{name: "customer forbidden", token: makeToken(testSecret, false), wantStatus: http.StatusForbidden},
+		{name: "owner forbidden", token: makeTokenWithRole(testSecret, "owner-1", middleware.RoleBusinessOwner), wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.POST("/admin", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, middleware.JWT(testSecret), middleware.RequireRole(middleware.RoleAdmin))
+
+			req := httptest.NewRequest(http.MethodPost, "/admin", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
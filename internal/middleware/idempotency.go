@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+)
+
+const (
+	// IdempotencyHeader is the request header clients use to make retries safe.
+	IdempotencyHeader = "Idempotency-Key"
+
+	idempotencyTTL        = 24 * time.Hour
+	idempotencyLockTTL    = time.Minute
+	idempotencyMaxKeyLen  = 255
+	idempotencyContextKey = "idempotency_key"
+
+	// IdempotencyMaxBody bounds the body buffered for a guarded request on
+	// routes without a limit of their own.
+	IdempotencyMaxBody = 1 << 20
+)
+
+var (
+	errIdempotencyKeyReused = domain.NewError(domain.ErrInvalidInput, "idempotency_key_reused",
+		"Idempotency-Key was already used with a different request payload")
+	errIdempotencyInProgress = domain.NewError(domain.ErrConflict, "idempotency_request_in_progress",
+		"a request with this Idempotency-Key is still being processed")
+)
+
+// IdempotencyStore keeps an idempotency record per (user, key) for its whole
+// TTL: a record lost early lets a late retry run the request again, so
+// production uses postgres.IdempotencyRepository rather than Redis, which
+// evicts keys under memory pressure.
+type IdempotencyStore interface {
+	// Reserve stores rec under key for ttl unless a live record is there, and
+	// returns that record instead. It returns nil when rec was stored.
+	Reserve(ctx context.Context, key string, rec *domain.IdempotencyRecord, ttl time.Duration) (*domain.IdempotencyRecord, error)
+	// Complete replaces the record under key and keeps it for ttl.
+	Complete(ctx context.Context, key string, rec *domain.IdempotencyRecord, ttl time.Duration) error
+	// Release deletes the record under key.
+	Release(ctx context.Context, key string) error
+}
+
+// Idempotency returns an Echo middleware that makes mutating requests carrying
+// an Idempotency-Key header safe to retry. The first request with a given key
+// is executed and its response stored; retries with the same payload replay
+// that response, and retries with a different payload are rejected.
+//
+// Requests without the header, and safe methods, pass straight through.
+// 5xx and 429 responses are not stored so that transient failures can be
+// retried; handlers behind it must only fail with those once their side
+// effects are undone.
+//
+// The body is buffered to fingerprint it, so it is bounded before any
+// handler runs: bodyLimits maps route paths (as registered, e.g.
+// "/api/v1/businesses/:id/image") to the limit the route enforces itself;
+// other routes get IdempotencyMaxBody. Larger bodies are rejected with 413.
+func Idempotency(store IdempotencyStore, bodyLimits map[string]int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			key := req.Header.Get(IdempotencyHeader)
+			if key == "" || !isMutating(req.Method) {
+				return next(c)
+			}
+			if len(key) > idempotencyMaxKeyLen {
+				return echo.NewHTTPError(http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			}
+
+			limit, ok := bodyLimits[c.Path()]
+			if !ok {
+				limit = IdempotencyMaxBody
+			}
+			body, err := io.ReadAll(http.MaxBytesReader(c.Response(), req.Body, limit))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					return echo.NewHTTPError(http.StatusRequestEntityTooLarge,
+						fmt.Sprintf("request bodies are limited to %d bytes", limit))
+				}
+				return echo.NewHTTPError(http.StatusBadRequest, "failed to read request body")
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := req.Context()
+			storeKey := idempotencyScope(c) + ":" + key
+			fingerprint := requestFingerprint(req.Method, req.URL.Path, body)
+
+			existing, err := store.Reserve(ctx, storeKey, &domain.IdempotencyRecord{Fingerprint: fingerprint}, idempotencyLockTTL)
+			if err != nil {
+				// Fail closed: executing without the guard could double-charge.
+				slog.ErrorContext(ctx, "idempotency: failed to reserve key", logging.Error, err)
+				return echo.NewHTTPError(http.StatusServiceUnavailable, "idempotency store unavailable")
+			}
+			if existing != nil {
+				return replayIdempotent(c, existing, fingerprint)
+			}
+
+			c.Set(idempotencyContextKey, key)
+
+			resBody := new(bytes.Buffer)
+			res := c.Response()
+			res.Writer = &bodyCaptureWriter{ResponseWriter: res.Writer, body: resBody}
+
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			// The outcome must be recorded even if the client has gone away,
+			// or its retry would run the side effects again.
+			detached := context.WithoutCancel(ctx)
+
+			// Handlers answer 5xx and 429 only when the request left nothing
+			// behind (OrderService.Create undoes a failed order and only logs
+			// failures once it stands); free the key so the client's retry
+			// runs for real instead of replaying the failure.
+			if res.Status >= http.StatusInternalServerError || res.Status == http.StatusTooManyRequests {
+				if err := store.Release(detached, storeKey); err != nil {
+					// The key stays locked until idempotencyLockTTL expires.
+					slog.WarnContext(ctx, "idempotency: failed to release key", logging.Error, err)
+				}
+				return nil
+			}
+
+			done := &domain.IdempotencyRecord{
+				Fingerprint: fingerprint,
+				Status:      res.Status,
+				ContentType: res.Header().Get(echo.HeaderContentType),
+				Body:        resBody.Bytes(),
+			}
+			if err := store.Complete(detached, storeKey, done, idempotencyTTL); err != nil {
+				slog.ErrorContext(ctx, "idempotency: failed to store response", logging.Error, err)
+			}
+			return nil
+		}
+	}
+}
+
+// GetIdempotencyKey returns the Idempotency-Key accepted for this request, or
+// "" when the request was not guarded.
+func GetIdempotencyKey(c echo.Context) string {
+	key, _ := c.Get(idempotencyContextKey).(string)
+	return key
+}
+
+func replayIdempotent(c echo.Context, rec *domain.IdempotencyRecord, fingerprint string) error {
+	if rec.Fingerprint != fingerprint {
+		return errIdempotencyKeyReused
+	}
+	if rec.Status == 0 {
+		return errIdempotencyInProgress
+	}
+
+	c.Response().Header().Set("Idempotent-Replayed", "true")
+	return c.Blob(rec.Status, rec.ContentType, rec.Body)
+}
+
+// idempotencyScope namespaces keys per authenticated user so two clients can
+// never collide on the same key.
+func idempotencyScope(c echo.Context) string {
+	if claims := GetClaims(c); claims != nil && claims.UserID != "" {
+		return claims.UserID
+	}
+	return "anonymous"
+}
+
+func requestFingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{' '})
+	h.Write([]byte(path))
+	h.Write([]byte{'\n'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// bodyCaptureWriter tees everything written to the client into body.
+type bodyCaptureWriter struct {
+	http.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *bodyCaptureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,206 @@
+package middleware_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/handler"
+	"github.com/heptapegon/localpickup/internal/middleware"
+	"github.com/heptapegon/localpickup/internal/repository/memory"
+)
+
+// liveStore fails like a networked store once ctx is cancelled.
+type liveStore struct {
+	*memory.IdempotencyRepository
+}
+
+func (s liveStore) Reserve(ctx context.Context, key string, rec *domain.IdempotencyRecord, ttl time.Duration) (*domain.IdempotencyRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.IdempotencyRepository.Reserve(ctx, key, rec, ttl)
+}
+
+func (s liveStore) Complete(ctx context.Context, key string, rec *domain.IdempotencyRecord, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.IdempotencyRepository.Complete(ctx, key, rec, ttl)
+}
+
+func (s liveStore) Release(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.IdempotencyRepository.Release(ctx, key)
+}
+
+func newIdempotencyStore() liveStore {
+	return liveStore{memory.NewIdempotencyRepository()}
+}
+
+func newIdempotentServer(t *testing.T, status int) (*echo.Echo, *int) {
+	t.Helper()
+	calls := 0
+	e := echo.New()
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
+	e.POST("/orders", func(c echo.Context) error {
+		calls++
+		if status >= http.StatusBadRequest {
+			return echo.NewHTTPError(status, "boom")
+		}
+		return c.JSON(status, echo.Map{"call": calls, "key": middleware.GetIdempotencyKey(c)})
+	}, middleware.Idempotency(newIdempotencyStore(), nil))
+	return e, &calls
+}
+
+func doPost(e *echo.Echo, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		firstKey    string
+		firstBody   string
+		secondKey   string
+		secondBody  string
+		wantStatus  int
+		wantCalls   int
+		wantReplay  bool
+		wantSameRes bool
+	}{
+		{
+			name:   "retry with same key and payload replays response",
+			status: http.StatusCreated, firstKey: "k1", firstBody: `{"a":1}`,
+			secondKey: "k1", secondBody: `{"a":1}`,
+			wantStatus: http.StatusCreated, wantCalls: 1, wantReplay: true, wantSameRes: true,
+		},
+		{
+			name:   "reused key with different payload is rejected",
+			status: http.StatusCreated, firstKey: "k1", firstBody: `{"a":1}`,
+			secondKey: "k1", secondBody: `{"a":2}`,
+			wantStatus: http.StatusUnprocessableEntity, wantCalls: 1,
+		},
+		{
+			name:   "different keys execute twice",
+			status: http.StatusCreated, firstKey: "k1", firstBody: `{"a":1}`,
+			secondKey: "k2", secondBody: `{"a":1}`,
+			wantStatus: http.StatusCreated, wantCalls: 2,
+		},
+		{
+			name:   "no key executes twice",
+			status: http.StatusCreated, firstBody: `{"a":1}`, secondBody: `{"a":1}`,
+			wantStatus: http.StatusCreated, wantCalls: 2,
+		},
+		{
+			name:   "client errors are replayed",
+			status: http.StatusUnprocessableEntity, firstKey: "k1", firstBody: `{"a":1}`,
+			secondKey: "k1", secondBody: `{"a":1}`,
+			wantStatus: http.StatusUnprocessableEntity, wantCalls: 1, wantReplay: true, wantSameRes: true,
+		},
+		{
+			name:   "server errors release the key",
+			status: http.StatusInternalServerError, firstKey: "k1", firstBody: `{"a":1}`,
+			secondKey: "k1", secondBody: `{"a":1}`,
+			wantStatus: http.StatusInternalServerError, wantCalls: 2,
+		},
+		{
+			name:   "rate limited requests release the key",
+			status: http.StatusTooManyRequests, firstKey: "k1", firstBody: `{"a":1}`,
+			secondKey: "k1", secondBody: `{"a":1}`,
+			wantStatus: http.StatusTooManyRequests, wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, calls := newIdempotentServer(t, tt.status)
+
+			first := doPost(e, tt.firstKey, tt.firstBody)
+			second := doPost(e, tt.secondKey, tt.secondBody)
+
+			if second.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", second.Code, tt.wantStatus)
+			}
+			if *calls != tt.wantCalls {
+				t.Errorf("handler calls = %d, want %d", *calls, tt.wantCalls)
+			}
+			if got := second.Header().Get("Idempotent-Replayed") == "true"; got != tt.wantReplay {
+				t.Errorf("replayed = %v, want %v", got, tt.wantReplay)
+			}
+			if tt.wantSameRes && first.Body.String() != second.Body.String() {
+				t.Errorf("replayed body = %q, want %q", second.Body.String(), first.Body.String())
+			}
+		})
+	}
+}
+
+func TestIdempotencyBodyLimit(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
+	guard := middleware.Idempotency(newIdempotencyStore(), map[string]int64{"/upload": 8})
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusNoContent) }
+	e.POST("/orders", ok, guard)
+	e.POST("/upload", ok, guard)
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "default limit", path: "/orders", body: strings.Repeat("x", middleware.IdempotencyMaxBody), wantStatus: http.StatusNoContent},
+		{name: "over the default limit", path: "/orders", body: strings.Repeat("x", middleware.IdempotencyMaxBody+1), wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "route limit", path: "/upload", body: "12345678", wantStatus: http.StatusNoContent},
+		{name: "over the route limit", path: "/upload", body: "123456789", wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(middleware.IdempotencyHeader, fmt.Sprintf("k%d", i))
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestIdempotencyStoresResponseAfterDisconnect(t *testing.T) {
+	e := echo.New()
+	calls := 0
+	ctx, disconnect := context.WithCancel(context.Background())
+	e.POST("/orders", func(c echo.Context) error {
+		calls++
+		disconnect()
+		return c.JSON(http.StatusCreated, echo.Map{"call": calls})
+	}, middleware.Idempotency(newIdempotencyStore(), nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{}`)).WithContext(ctx)
+	req.Header.Set(middleware.IdempotencyHeader, "k1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	retry := doPost(e, "k1", `{}`)
+	if retry.Code != http.StatusCreated || retry.Header().Get("Idempotent-Replayed") != "true" || calls != 1 {
+		t.Errorf("retry = %d, replayed %q, %d calls; want the stored 201 and 1 call",
+			retry.Code, retry.Header().Get("Idempotent-Replayed"), calls)
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/heptapegon/localpickup/internal/logging"
+)
+
+// RequestLogger stores the request ID in the request context for downstream
+// logging and writes one access log line per request. It must run after
+// echo's RequestID middleware.
+func RequestLogger(logger *slog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			req := c.Request()
+			requestID := c.Response().Header().Get(echo.HeaderXRequestID)
+			c.SetRequest(req.WithContext(logging.With(req.Context(), logging.RequestID, requestID)))
+
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			res := c.Response()
+			level := slog.LevelInfo
+			switch {
+			case res.Status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case res.Status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+			// c.Request() rather than req: JWT adds the user ID to a new request.
+			logger.LogAttrs(c.Request().Context(), level, "request",
+				slog.String("method", req.Method),
+				slog.String("route", c.Path()),
+				slog.String("path", req.URL.Path),
+				slog.Int("status", res.Status),
+				slog.Int64("bytes", res.Size),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.String("remote_ip", c.RealIP()),
+			)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	echomw "github.com/labstack/echo/v4/middleware"
+
+	"github.com/heptapegon/localpickup/internal/handler"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/middleware"
+)
+
+func TestRequestLogger(t *testing.T) {
+	const secret = "test-secret"
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+		wantLevel  string
+		wantUser   any
+	}{
+		{name: "authenticated", token: makeToken(secret, false), wantStatus: http.StatusOK, wantLevel: "INFO", wantUser: "user-123"},
+		{name: "rejected", token: "", wantStatus: http.StatusUnauthorized, wantLevel: "WARN", wantUser: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, _ := logging.New(&buf, logging.Config{})
+
+			e := echo.New()
+			e.HTTPErrorHandler = handler.HTTPErrorHandler
+			e.Use(echomw.RequestID(), middleware.RequestLogger(logger))
+			e.GET("/orders/:id", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, middleware.JWT(secret))
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			var line map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+				t.Fatalf("decode access log %q: %v", buf.String(), err)
+			}
+			if line["status"] != float64(tt.wantStatus) || rec.Code != tt.wantStatus {
+				t.Errorf("status: log %v, response %d, want %d", line["status"], rec.Code, tt.wantStatus)
+			}
+			if line["level"] != tt.wantLevel {
+				t.Errorf("level = %v, want %s", line["level"], tt.wantLevel)
+			}
+			if line["route"] != "/orders/:id" {
+				t.Errorf("route = %v, want /orders/:id", line["route"])
+			}
+			if line[logging.RequestID] != rec.Header().Get(echo.HeaderXRequestID) || line[logging.RequestID] == "" {
+				t.Errorf("request_id = %v, want response header %q", line[logging.RequestID], rec.Header().Get(echo.HeaderXRequestID))
+			}
+			if line[logging.UserID] != tt.wantUser {
+				t.Errorf("user_id = %v, want %v", line[logging.UserID], tt.wantUser)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis_rate/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/metrics"
+)
+
+// Rate limit groups. Routes opt in with RateLimiter.Group; anything not
+// attached to a group (e.g. the Stripe webhook) is never limited.
+const (
+	RateLimitAuth   = "auth"
+	RateLimitSearch = "search"
+	RateLimitOrders = "orders"
+)
+
+var errRateLimited = domain.NewError(domain.ErrRateLimited, "rate_limited",
+	"too many requests, please retry later")
+
+// RateLimitPolicies maps a group, optionally qualified by role
+// ("search" or "search:business_owner"), to its limit.
+type RateLimitPolicies map[string]redis_rate.Limit
+
+// ParseRateLimits parses a comma-separated list of policies such as
+//
+//	auth=10/m,search=60/m,orders=20/m:5,search:business_owner=120/m
+//
+// Each limit is RATE/UNIT with UNIT one of s, m, h and an optional :BURST
+// (defaults to RATE).
+func ParseRateLimits(spec string) (RateLimitPolicies, error) {
+	policies := make(RateLimitPolicies)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, limit, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("rate limit %q: want GROUP[:ROLE]=RATE/UNIT[:BURST]", entry)
+		}
+		l, err := parseLimit(limit)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit %q: %w", entry, err)
+		}
+		policies[name] = l
+	}
+	return policies, nil
+}
+
+func parseLimit(s string) (redis_rate.Limit, error) {
+	rate, rest, ok := strings.Cut(s, "/")
+	if !ok {
+		return redis_rate.Limit{}, errors.New("missing unit")
+	}
+	unit, burst, hasBurst := strings.Cut(rest, ":")
+
+	var l redis_rate.Limit
+	switch unit {
+	case "s":
+		l.Period = time.Second
+	case "m":
+		l.Period = time.Minute
+	case "h":
+		l.Period = time.Hour
+	default:
+		return l, fmt.Errorf("unknown unit %q (want s, m or h)", unit)
+	}
+	var err error
+	if l.Rate, err = strconv.Atoi(rate); err != nil || l.Rate <= 0 {
+		return l, errors.New("rate must be a positive integer")
+	}
+	l.Burst = l.Rate
+	if hasBurst {
+		if l.Burst, err = strconv.Atoi(burst); err != nil || l.Burst <= 0 {
+			return l, errors.New("burst must be a positive integer")
+		}
+	}
+	return l, nil
+}
+
+// RateLimiter enforces GCRA limits stored in Redis, so all replicas share
+// one budget per client.
+type RateLimiter struct {
+	limiter  *redis_rate.Limiter
+	policies RateLimitPolicies
+}
+
+func NewRateLimiter(rdb *redis.Client, policies RateLimitPolicies) *RateLimiter {
+	return &RateLimiter{limiter: redis_rate.NewLimiter(rdb), policies: policies}
+}
+
+// Group returns a middleware enforcing the named policy. Authenticated
+// requests are limited per user (with role overrides); others per client IP
+// as resolved by echo's IPExtractor. It must run after JWT to see the user,
+// so attach it to routes rather than to the group that installs JWT.
+//
+// If Redis is unavailable requests are let through: rate limiting protects
+// capacity and is not worth an outage.
+func (rl *RateLimiter) Group(group string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			subject := "ip:" + c.RealIP()
+			limit, ok := rl.policies[group]
+			if claims := GetClaims(c); claims != nil && claims.UserID != "" {
+				subject = "user:" + claims.UserID
+				if override, found := rl.policies[group+":"+claims.Role]; found {
+					limit, ok = override, true
+				}
+			}
+			if !ok {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+			res, err := rl.limiter.Allow(ctx, "ratelimit:"+group+":"+subject, limit)
+			if err != nil {
+				slog.WarnContext(ctx, "rate limiter unavailable, allowing request", "group", group, logging.Error, err)
+				return next(c)
+			}
+
+			h := c.Response().Header()
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", limit.Rate, int(limit.Period.Seconds()), limit.Burst))
+			h.Set("RateLimit-Limit", strconv.Itoa(limit.Rate))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", ceilSeconds(res.ResetAfter))
+
+			if res.Allowed == 0 {
+				h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+				metrics.RateLimited(group)
+				return errRateLimited
+			}
+			return next(c)
+		}
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// IPExtractor returns how echo resolves the client IP. With no trusted
+// proxies the socket address is used and X-Forwarded-For is ignored, so
+// clients can't pick their own rate limit key. Otherwise X-Forwarded-For is
+// honoured only for hops inside the given CIDRs.
+func IPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+	opts := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range trustedProxies {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", cidr, err)
+		}
+		opts = append(opts, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(opts...), nil
+}
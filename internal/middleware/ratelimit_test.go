@@ -0,0 +1,182 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis_rate/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/heptapegon/localpickup/internal/handler"
+	"github.com/heptapegon/localpickup/internal/middleware"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    middleware.RateLimitPolicies
+		wantErr bool
+	}{
+		{
+			spec: "auth=10/m, search=60/m,orders=20/m:5,search:business_owner=2/s",
+			want: middleware.RateLimitPolicies{
+				"auth":                  {Rate: 10, Burst: 10, Period: time.Minute},
+				"search":                {Rate: 60, Burst: 60, Period: time.Minute},
+				"orders":                {Rate: 20, Burst: 5, Period: time.Minute},
+				"search:business_owner": {Rate: 2, Burst: 2, Period: time.Second},
+			},
+		},
+		{spec: "", want: middleware.RateLimitPolicies{}},
+		{spec: "auth=10", wantErr: true},
+		{spec: "auth=10/d", wantErr: true},
+		{spec: "auth=0/m", wantErr: true},
+		{spec: "auth=10/m:x", wantErr: true},
+		{spec: "=10/m", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := middleware.ParseRateLimits(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %v, want %v", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func newRateLimitedServer(t *testing.T, policies middleware.RateLimitPolicies) *echo.Echo {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rl := middleware.NewRateLimiter(redis.NewClient(&redis.Options{Addr: mr.Addr()}), policies)
+
+	e := echo.New()
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
+	e.IPExtractor, _ = middleware.IPExtractor([]string{"10.0.0.0/8"})
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+
+	e.POST("/auth/login", ok, rl.Group(middleware.RateLimitAuth))
+	e.GET("/businesses/nearby", ok, middleware.JWT(testSecret), rl.Group(middleware.RateLimitSearch))
+	e.POST("/webhooks/stripe", ok)
+	return e
+}
+
+func TestRateLimiter(t *testing.T) {
+	policies := middleware.RateLimitPolicies{
+		"auth":                  redis_rate.PerMinute(2),
+		"search":                redis_rate.PerMinute(2),
+		"search:business_owner": redis_rate.PerMinute(4),
+	}
+	customer := makeToken(testSecret, false)
+	owner := makeTokenWithRole(testSecret, "owner-1", "business_owner")
+
+	type call struct {
+		method, path, token, remoteAddr, xff string
+	}
+	tests := []struct {
+		name     string
+		calls    []call
+		wantLast int
+	}{
+		{
+			name:     "within limit",
+			calls:    []call{{method: "POST", path: "/auth/login", remoteAddr: "203.0.113.1:1"}},
+			wantLast: http.StatusOK,
+		},
+		{
+			name: "ip limit exceeded",
+			calls: []call{
+				{method: "POST", path: "/auth/login", remoteAddr: "203.0.113.1:1"},
+				{method: "POST", path: "/auth/login", remoteAddr: "203.0.113.1:2"},
+				{method: "POST", path: "/auth/login", remoteAddr: "203.0.113.1:3"},
+			},
+			wantLast: http.StatusTooManyRequests,
+		},
+		{
+			name: "xff honoured behind trusted proxy",
+			calls: []call{
+				{method: "POST", path: "/auth/login", remoteAddr: "10.1.1.1:1", xff: "198.51.100.1"},
+				{method: "POST", path: "/auth/login", remoteAddr: "10.1.1.1:1", xff: "198.51.100.2"},
+				{method: "POST", path: "/auth/login", remoteAddr: "10.1.1.1:1", xff: "198.51.100.3"},
+			},
+			wantLast: http.StatusOK,
+		},
+		{
+			name: "xff ignored from untrusted peer",
+			calls: []call{
+				{method: "POST", path: "/auth/login", remoteAddr: "203.0.113.9:1", xff: "198.51.100.1"},
+				{method: "POST", path: "/auth/login", remoteAddr: "203.0.113.9:1", xff: "198.51.100.2"},
+				{method: "POST", path: "/auth/login", remoteAddr: "203.0.113.9:1", xff: "198.51.100.3"},
+			},
+			wantLast: http.StatusTooManyRequests,
+		},
+		{
+			name: "per user regardless of ip",
+			calls: []call{
+				{method: "GET", path: "/businesses/nearby", token: customer, remoteAddr: "203.0.113.1:1"},
+				{method: "GET", path: "/businesses/nearby", token: customer, remoteAddr: "203.0.113.2:1"},
+				{method: "GET", path: "/businesses/nearby", token: customer, remoteAddr: "203.0.113.3:1"},
+			},
+			wantLast: http.StatusTooManyRequests,
+		},
+		{
+			name: "role override",
+			calls: []call{
+				{method: "GET", path: "/businesses/nearby", token: owner, remoteAddr: "203.0.113.1:1"},
+				{method: "GET", path: "/businesses/nearby", token: owner, remoteAddr: "203.0.113.1:1"},
+				{method: "GET", path: "/businesses/nearby", token: owner, remoteAddr: "203.0.113.1:1"},
+			},
+			wantLast: http.StatusOK,
+		},
+		{
+			name: "webhooks exempt",
+			calls: []call{
+				{method: "POST", path: "/webhooks/stripe", remoteAddr: "203.0.113.1:1"},
+				{method: "POST", path: "/webhooks/stripe", remoteAddr: "203.0.113.1:1"},
+				{method: "POST", path: "/webhooks/stripe", remoteAddr: "203.0.113.1:1"},
+			},
+			wantLast: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newRateLimitedServer(t, policies)
+			var rec *httptest.ResponseRecorder
+			for _, c := range tt.calls {
+				req := httptest.NewRequest(c.method, c.path, nil)
+				req.RemoteAddr = c.remoteAddr
+				if c.token != "" {
+					req.Header.Set("Authorization", "Bearer "+c.token)
+				}
+				if c.xff != "" {
+					req.Header.Set(echo.HeaderXForwardedFor, c.xff)
+				}
+				rec = httptest.NewRecorder()
+				e.ServeHTTP(rec, req)
+			}
+
+			if rec.Code != tt.wantLast {
+				t.Fatalf("last status = %d, want %d (body %s)", rec.Code, tt.wantLast, rec.Body)
+			}
+			if tt.wantLast == http.StatusTooManyRequests {
+				if rec.Header().Get("Retry-After") == "" {
+					t.Error("429 without Retry-After")
+				}
+				if rec.Header().Get("RateLimit-Remaining") != "0" {
+					t.Errorf("RateLimit-Remaining = %q, want 0", rec.Header().Get("RateLimit-Remaining"))
+				}
+			}
+		})
+	}
+}
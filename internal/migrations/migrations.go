@@ -0,0 +1,324 @@
+// Package migrations embeds the numbered SQL migrations and applies them.
+//
+// Files in sql/ are named NNNN_description.up.sql / NNNN_description.down.sql.
+// Applied versions are recorded in schema_migrations. Each migration runs in its
+// own transaction while a session-level advisory lock is held, so concurrent
+// replicas starting at the same time apply every migration exactly once.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// advisoryLockID is an arbitrary constant shared by every replica.
+const advisoryLockID int64 = 0x6c6f63616c7075 // "localpu"
+
+var fileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes one migration as seen by the database.
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	// Unknown is set for versions recorded in the database that this binary
+	// does not ship, e.g. after rolling back to an older release.
+	Unknown bool
+}
+
+type Migrator struct {
+	db         *pgxpool.Pool
+	migrations []Migration
+}
+
+func New(db *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest is the highest version embedded in the binary.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.To(ctx, m.Latest())
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		current := maxVersion(applied)
+		if current == 0 {
+			return nil
+		}
+		mig, ok := m.find(current)
+		if !ok {
+			return fmt.Errorf("migrate: version %d is applied but not embedded in this binary", current)
+		}
+		if err := m.rollback(ctx, conn, mig); err != nil {
+			return err
+		}
+		done = append(done, mig)
+		return nil
+	})
+	return done, err
+}
+
+// To migrates up or down until target is the highest applied version.
+func (m *Migrator) To(ctx context.Context, target int) ([]Migration, error) {
+	if target != 0 {
+		if _, ok := m.find(target); !ok {
+			return nil, fmt.Errorf("migrate: unknown version %d", target)
+		}
+	}
+
+	var done []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		// Up: apply every embedded migration ≤ target that isn't applied yet.
+		for _, mig := range m.migrations {
+			if mig.Version > target || applied[mig.Version] != nil {
+				continue
+			}
+			if err := m.apply(ctx, conn, mig); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+
+		// Down: roll back applied migrations above target, newest first.
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if mig.Version <= target || applied[mig.Version] == nil {
+				continue
+			}
+			if err := m.rollback(ctx, conn, mig); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lists every embedded migration plus any unknown applied versions.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		at := applied[mig.Version]
+		out = append(out, Status{Version: mig.Version, Name: mig.Name, Applied: at != nil, AppliedAt: at})
+		delete(applied, mig.Version)
+	}
+	for v, at := range applied {
+		out = append(out, Status{Version: v, Applied: true, AppliedAt: at, Unknown: true})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// CheckCurrent returns an error when the database is missing migrations that
+// this binary expects.
+func (m *Migrator) CheckCurrent(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var pending []int
+	for _, s := range statuses {
+		if !s.Applied {
+			pending = append(pending, s.Version)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("migrate: database schema is behind this binary (pending versions %v, latest %d)", pending, m.Latest())
+	}
+	return nil
+}
+
+func (m *Migrator) find(version int) (Migration, bool) {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return mig, true
+		}
+	}
+	return Migration{}, false
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, advisoryLockID); err != nil {
+		return fmt.Errorf("migrate: acquire lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, advisoryLockID)
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, mig Migration) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, mig.Up); err != nil {
+			return fmt.Errorf("migrate: apply %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		_, err := tx.Exec(ctx,
+			`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, NOW())`,
+			mig.Version, mig.Name,
+		)
+		return err
+	})
+}
+
+func (m *Migrator) rollback(ctx context.Context, conn *pgxpool.Conn, mig Migration) error {
+	if mig.Down == "" {
+		return fmt.Errorf("migrate: %04d_%s has no down migration", mig.Version, mig.Name)
+	}
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, mig.Down); err != nil {
+			return fmt.Errorf("migrate: roll back %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+		return err
+	})
+}
+
+func ensureTable(ctx context.Context, conn *pgxpool.Conn) error {
+	_, err := conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+		    version    BIGINT      PRIMARY KEY,
+		    name       TEXT        NOT NULL,
+		    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`)
+	return err
+}
+
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int]*time.Time, error) {
+	rows, err := conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]*time.Time)
+	for rows.Next() {
+		var v int
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		applied[v] = &at
+	}
+	return applied, rows.Err()
+}
+
+func maxVersion(applied map[int]*time.Time) int {
+	current := 0
+	for v := range applied {
+		if v > current {
+			current = v
+		}
+	}
+	return current
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		match := fileRe.FindStringSubmatch(e.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migrate: unexpected file %q", e.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := fs.ReadFile(fsys, "sql/"+e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mig
+		} else if mig.Name != match[2] {
+			return nil, fmt.Errorf("migrate: version %d has conflicting names %q and %q", version, mig.Name, match[2])
+		}
+		if match[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migrate: version %d has no up migration", mig.Version)
+		}
+		out = append(out, *mig)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
@@ -0,0 +1,85 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadEmbedded(t *testing.T) {
+	migs, err := load(files)
+	if err != nil {
+		t.Fatalf("load() error: %v", err)
+	}
+	if len(migs) == 0 || migs[0].Version != 1 {
+		t.Fatalf("expected embedded migrations starting at version 1, got %+v", migs)
+	}
+	for i, m := range migs {
+		if m.Version != i+1 {
+			t.Errorf("migration %d has version %d; versions must be contiguous", i, m.Version)
+		}
+		if m.Down == "" {
+			t.Errorf("migration %04d_%s has no down migration", m.Version, m.Name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []int
+		wantErr string
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"sql/0002_b.up.sql":   {Data: []byte("SELECT 2")},
+				"sql/0001_a.up.sql":   {Data: []byte("SELECT 1")},
+				"sql/0001_a.down.sql": {Data: []byte("SELECT -1")},
+			},
+			want: []int{1, 2},
+		},
+		{
+			name:    "missing up",
+			files:   fstest.MapFS{"sql/0001_a.down.sql": {Data: []byte("SELECT 1")}},
+			wantErr: "no up migration",
+		},
+		{
+			name: "conflicting names",
+			files: fstest.MapFS{
+				"sql/0001_a.up.sql":   {Data: []byte("SELECT 1")},
+				"sql/0001_b.down.sql": {Data: []byte("SELECT 1")},
+			},
+			wantErr: "conflicting names",
+		},
+		{
+			name:    "bad file name",
+			files:   fstest.MapFS{"sql/init.sql": {Data: []byte("SELECT 1")}},
+			wantErr: "unexpected file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migs, err := load(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("load() error: %v", err)
+			}
+			if len(migs) != len(tt.want) {
+				t.Fatalf("got %d migrations, want %d", len(migs), len(tt.want))
+			}
+			for i, v := range tt.want {
+				if migs[i].Version != v {
+					t.Errorf("migs[%d].Version = %d, want %d", i, migs[i].Version, v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+-- Extensions are left installed: other schemas in the same database may use them.
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS businesses;
+DROP TABLE IF EXISTS users;
@@ -1,6 +1,10 @@
--- Enable required extensions
-CREATE EXTENSION IF NOT EXISTS postgis;
-CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+-- Initial schema. Written with IF NOT EXISTS so databases that were created
+-- from the old scripts/init.sql can be adopted by running `migrate up`.
+
+-- Enable required extensions. Pinned to public so per-schema test databases
+-- share a single installation.
+CREATE EXTENSION IF NOT EXISTS postgis WITH SCHEMA public;
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp" WITH SCHEMA public;
 
 -- ─── Users ──────────────────────────────────────────────────────────────────
 CREATE TABLE IF NOT EXISTS users (
@@ -0,0 +1,4 @@
+-- Fails while admin accounts exist; demote them first.
+ALTER TABLE users DROP CONSTRAINT users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check
+    CHECK (role IN ('customer', 'business_owner'));
@@ -0,0 +1,6 @@
+-- Admins run operational endpoints (e.g. geo reindex). They cannot self-register;
+-- promote an existing account with:
+--   UPDATE users SET role = 'admin' WHERE email = '...';
+ALTER TABLE users DROP CONSTRAINT users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check
+    CHECK (role IN ('customer', 'business_owner', 'admin'));
@@ -0,0 +1,4 @@
+-- Viewport searches compare against a lon/lat rectangle, which is a planar
+-- question; index the geometry form of location so `&&` can use it.
+CREATE INDEX IF NOT EXISTS idx_businesses_location_geom
+    ON businesses USING GIST ((location::geometry));
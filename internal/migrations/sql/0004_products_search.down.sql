@@ -0,0 +1,6 @@
+ALTER TABLE businesses DROP COLUMN IF EXISTS search_vector;
+DROP INDEX IF EXISTS idx_businesses_name_trgm;
+DROP TABLE IF EXISTS products;
+DROP FUNCTION IF EXISTS search_fold(text);
+DROP TEXT SEARCH CONFIGURATION IF EXISTS es_unaccent;
+-- unaccent and pg_trgm are left installed; other objects may use them.
@@ -0,0 +1,46 @@
+-- ─── Products ────────────────────────────────────────────────────────────────
+CREATE TABLE IF NOT EXISTS products (
+    id           UUID          PRIMARY KEY DEFAULT uuid_generate_v4(),
+    business_id  UUID          NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    name         VARCHAR(100)  NOT NULL,
+    description  TEXT          NOT NULL DEFAULT '',
+    price        DECIMAL(10,2) NOT NULL CHECK (price > 0),
+    is_available BOOLEAN       NOT NULL DEFAULT true,
+    created_at   TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_products_business ON products(business_id);
+
+-- ─── Search ──────────────────────────────────────────────────────────────────
+-- Full-text search uses Spanish stemming with accents folded, so "farmacia",
+-- "farmacias" and "fármacia" all match. Fuzzy matching uses trigrams over the
+-- accent-folded, lower-cased names to tolerate typos ("tacso", "farmasia").
+CREATE EXTENSION IF NOT EXISTS unaccent WITH SCHEMA public;
+CREATE EXTENSION IF NOT EXISTS pg_trgm  WITH SCHEMA public;
+
+CREATE TEXT SEARCH CONFIGURATION es_unaccent (COPY = pg_catalog.spanish);
+ALTER TEXT SEARCH CONFIGURATION es_unaccent
+    ALTER MAPPING FOR hword, hword_part, word WITH public.unaccent, spanish_stem;
+
+-- unaccent() is only STABLE (its dictionary could change); this wrapper pins
+-- the dictionary so the result can be indexed.
+CREATE OR REPLACE FUNCTION search_fold(text) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+    AS $$ SELECT lower(public.unaccent('public.unaccent'::regdictionary, $1)) $$;
+
+ALTER TABLE businesses ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('es_unaccent', name), 'A') ||
+    setweight(to_tsvector('es_unaccent', category), 'B') ||
+    setweight(to_tsvector('es_unaccent', COALESCE(description, '')), 'C')
+) STORED;
+
+ALTER TABLE products ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('es_unaccent', name), 'A') ||
+    setweight(to_tsvector('es_unaccent', description), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_businesses_search   ON businesses USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_businesses_name_trgm ON businesses USING GIN (search_fold(name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_search     ON products   USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm  ON products   USING GIN (search_fold(name) gin_trgm_ops);
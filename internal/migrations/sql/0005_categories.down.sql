@@ -0,0 +1,11 @@
+ALTER TABLE businesses DROP COLUMN search_vector;
+ALTER TABLE businesses ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('es_unaccent', name), 'A') ||
+    setweight(to_tsvector('es_unaccent', category), 'B') ||
+    setweight(to_tsvector('es_unaccent', COALESCE(description, '')), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_businesses_search ON businesses USING GIN (search_vector);
+
+-- Businesses keep their normalised slugs; the original free text is not restored.
+ALTER TABLE businesses DROP CONSTRAINT IF EXISTS businesses_category_fkey;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,71 @@
+-- ─── Categories ──────────────────────────────────────────────────────────────
+-- A managed taxonomy replaces free-text businesses.category. Slugs are stable
+-- identifiers; names holds one display name per language ({"es": ..., "en": ...}).
+-- A category may have a parent, and searching a parent includes its children.
+CREATE TABLE IF NOT EXISTS categories (
+    slug        VARCHAR(50) PRIMARY KEY CHECK (slug ~ '^[a-z0-9]+(-[a-z0-9]+)*$'),
+    parent_slug VARCHAR(50) REFERENCES categories(slug) ON UPDATE CASCADE,
+    names       JSONB       NOT NULL CHECK (names ? 'es'),
+    icon        VARCHAR(50) NOT NULL DEFAULT '',
+    sort_order  INTEGER     NOT NULL DEFAULT 0,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (parent_slug <> slug)
+);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories(parent_slug);
+
+-- Icons are Material Symbols names, which is what the apps render.
+INSERT INTO categories (slug, parent_slug, names, icon, sort_order) VALUES
+    ('food',       NULL,   '{"es": "Comida",      "en": "Food"}',        'restaurant',          10),
+    ('restaurant', 'food', '{"es": "Restaurante", "en": "Restaurant"}',  'restaurant_menu',     11),
+    ('tacos',      'food', '{"es": "Taquería",    "en": "Tacos"}',       'lunch_dining',        12),
+    ('coffee',     'food', '{"es": "Cafetería",   "en": "Coffee shop"}', 'local_cafe',          13),
+    ('bakery',     'food', '{"es": "Panadería",   "en": "Bakery"}',      'bakery_dining',       14),
+    ('grocery',    NULL,   '{"es": "Abarrotes",   "en": "Grocery"}',     'local_grocery_store', 20),
+    ('pharmacy',   NULL,   '{"es": "Farmacia",    "en": "Pharmacy"}',    'local_pharmacy',      30),
+    ('flowers',    NULL,   '{"es": "Florería",    "en": "Flowers"}',     'local_florist',       40),
+    ('other',      NULL,   '{"es": "Otros",       "en": "Other"}',       'storefront',          99)
+ON CONFLICT (slug) DO NOTHING;
+
+-- ─── Normalise existing businesses ───────────────────────────────────────────
+-- Free-text values are folded (case, accents, surrounding spaces) and matched
+-- against slugs, display names and the common spellings below; anything left
+-- over becomes 'other'.
+CREATE TEMPORARY TABLE category_aliases (alias TEXT PRIMARY KEY, slug VARCHAR(50) NOT NULL) ON COMMIT DROP;
+INSERT INTO category_aliases (alias, slug) VALUES
+    ('comida', 'food'), ('comidas', 'food'), ('alimentos', 'food'),
+    ('restaurantes', 'restaurant'), ('fonda', 'restaurant'), ('cocina', 'restaurant'),
+    ('taqueria', 'tacos'), ('taco', 'tacos'),
+    ('cafe', 'coffee'), ('cafeteria', 'coffee'), ('cafes', 'coffee'),
+    ('panaderia', 'bakery'), ('pan', 'bakery'), ('pasteleria', 'bakery'),
+    ('tienda', 'grocery'), ('groceries', 'grocery'), ('supermercado', 'grocery'), ('miscelanea', 'grocery'),
+    ('farmacias', 'pharmacy'), ('drugstore', 'pharmacy'),
+    ('floreria', 'flowers'), ('flores', 'flowers'), ('flower', 'flowers'),
+    ('otro', 'other'), ('otros', 'other');
+INSERT INTO category_aliases (alias, slug)
+    SELECT search_fold(n.value), c.slug FROM categories c, jsonb_each_text(c.names) n
+ON CONFLICT (alias) DO NOTHING;
+
+UPDATE businesses b SET category = COALESCE(
+    (SELECT c.slug FROM categories c WHERE c.slug = search_fold(btrim(b.category))),
+    (SELECT a.slug FROM category_aliases a WHERE a.alias = search_fold(btrim(b.category))),
+    'other'
+)
+WHERE NOT EXISTS (SELECT 1 FROM categories c WHERE c.slug = b.category);
+
+ALTER TABLE businesses ADD CONSTRAINT businesses_category_fkey
+    FOREIGN KEY (category) REFERENCES categories(slug) ON UPDATE CASCADE;
+
+-- ─── Search ──────────────────────────────────────────────────────────────────
+-- Slugs are English identifiers, so a business's own search vector no longer
+-- covers its category; search matches the localised names here instead.
+ALTER TABLE categories ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    to_tsvector('es_unaccent', COALESCE(names->>'es', '') || ' ' || COALESCE(names->>'en', ''))
+) STORED;
+
+ALTER TABLE businesses DROP COLUMN search_vector;
+ALTER TABLE businesses ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('es_unaccent', name), 'A') ||
+    setweight(to_tsvector('es_unaccent', COALESCE(description, '')), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_businesses_search ON businesses USING GIN (search_vector);
@@ -0,0 +1,6 @@
+ALTER TABLE order_items DROP COLUMN IF EXISTS product_id;
+DROP INDEX IF EXISTS idx_products_daily_stock;
+ALTER TABLE products
+    DROP COLUMN IF EXISTS stock,
+    DROP COLUMN IF EXISTS daily_stock,
+    DROP COLUMN IF EXISTS stock_reset_at;
@@ -0,0 +1,15 @@
+-- ─── Inventory ───────────────────────────────────────────────────────────────
+-- stock is the number of units left; NULL means unlimited. When daily_stock is
+-- set, stock is reset to it at the start of each day (stock_reset_at records
+-- the last reset).
+ALTER TABLE products
+    ADD COLUMN stock          INT         CHECK (stock >= 0),
+    ADD COLUMN daily_stock    INT         CHECK (daily_stock >= 0),
+    ADD COLUMN stock_reset_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_products_daily_stock ON products(stock_reset_at) WHERE daily_stock IS NOT NULL;
+
+-- Order lines now reference the catalog so stock can be returned when an order
+-- is cancelled. Lines placed before the catalog existed have no product.
+ALTER TABLE order_items
+    ADD COLUMN product_id UUID REFERENCES products(id) ON DELETE SET NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_orders_business_queue;
+DROP TABLE IF EXISTS order_item_modifiers;
+DROP TABLE IF EXISTS modifier_options;
+DROP TABLE IF EXISTS modifier_groups;
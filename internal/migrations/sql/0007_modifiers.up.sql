@@ -0,0 +1,43 @@
+-- ─── Modifiers ───────────────────────────────────────────────────────────────
+-- A product may have groups of options ("Milk": whole, oat +$0.50). A customer
+-- picks between min_select and max_select options of each group; a group with
+-- max_select = 1 is single-select.
+CREATE TABLE IF NOT EXISTS modifier_groups (
+    id         UUID         PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id UUID         NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name       VARCHAR(100) NOT NULL,
+    min_select INT          NOT NULL DEFAULT 0 CHECK (min_select >= 0),
+    max_select INT          NOT NULL DEFAULT 1 CHECK (max_select >= 1),
+    position   INT          NOT NULL DEFAULT 0,
+    CHECK (max_select >= min_select)
+);
+
+CREATE INDEX IF NOT EXISTS idx_modifier_groups_product ON modifier_groups(product_id);
+
+CREATE TABLE IF NOT EXISTS modifier_options (
+    id           UUID          PRIMARY KEY DEFAULT uuid_generate_v4(),
+    group_id     UUID          NOT NULL REFERENCES modifier_groups(id) ON DELETE CASCADE,
+    name         VARCHAR(100)  NOT NULL,
+    price_delta  DECIMAL(10,2) NOT NULL DEFAULT 0,
+    is_available BOOLEAN       NOT NULL DEFAULT true,
+    position     INT           NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_modifier_options_group ON modifier_options(group_id);
+
+-- The options chosen for an order line, copied so that later catalog edits do
+-- not rewrite past orders. order_items.unit_price already includes the deltas.
+CREATE TABLE IF NOT EXISTS order_item_modifiers (
+    id            UUID          PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_item_id UUID          NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    option_id     UUID          REFERENCES modifier_options(id) ON DELETE SET NULL,
+    group_name    VARCHAR(100)  NOT NULL,
+    name          VARCHAR(100)  NOT NULL,
+    price_delta   DECIMAL(10,2) NOT NULL,
+    position      INT           NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_item_modifiers_item ON order_item_modifiers(order_item_id);
+
+-- The business order queue lists open orders oldest first.
+CREATE INDEX IF NOT EXISTS idx_orders_business_queue ON orders(business_id, created_at) WHERE status IN ('paid', 'ready');
@@ -0,0 +1,2 @@
+ALTER TABLE products   DROP COLUMN IF EXISTS image_key;
+ALTER TABLE businesses DROP COLUMN IF EXISTS image_key;
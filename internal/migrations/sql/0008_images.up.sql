@@ -0,0 +1,6 @@
+-- ─── Images ──────────────────────────────────────────────────────────────────
+-- image_key is the storage prefix of the uploaded picture's resized variants
+-- (<prefix>/thumbnail.jpg, …); '' means no picture. URLs are derived from it
+-- when responses are built, so moving the media host needs no data change.
+ALTER TABLE businesses ADD COLUMN image_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE products   ADD COLUMN image_key TEXT NOT NULL DEFAULT '';
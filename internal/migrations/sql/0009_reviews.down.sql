@@ -0,0 +1,5 @@
+ALTER TABLE businesses
+    DROP COLUMN IF EXISTS rating_count,
+    DROP COLUMN IF EXISTS rating_sum;
+DROP TABLE IF EXISTS review_flags;
+DROP TABLE IF EXISTS reviews;
@@ -0,0 +1,36 @@
+-- ─── Reviews ─────────────────────────────────────────────────────────────────
+-- A customer rates (1–5) and may review a business once per completed order;
+-- the business may reply. Users flag reviews for moderation and admins hide
+-- or restore them.
+CREATE TABLE IF NOT EXISTS reviews (
+    id          UUID        PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id    UUID        NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+    business_id UUID        NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    customer_id UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating      SMALLINT    NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment     TEXT        NOT NULL DEFAULT '',
+    reply       TEXT,
+    replied_at  TIMESTAMPTZ,
+    status      VARCHAR(20) NOT NULL DEFAULT 'published' CHECK (status IN ('published', 'hidden')),
+    flag_count  INT         NOT NULL DEFAULT 0 CHECK (flag_count >= 0),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_business ON reviews(business_id, created_at DESC, id DESC) WHERE status = 'published';
+CREATE INDEX IF NOT EXISTS idx_reviews_flagged ON reviews(flag_count DESC, created_at) WHERE status = 'published' AND flag_count > 0;
+
+-- One flag per user and review; cleared when an admin moderates the review.
+CREATE TABLE IF NOT EXISTS review_flags (
+    review_id  UUID        NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+    user_id    UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason     VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (review_id, user_id)
+);
+
+-- The rating of a business is rating_sum / rating_count over its published
+-- reviews, kept up to date as reviews are added, hidden or restored.
+ALTER TABLE businesses
+    ADD COLUMN rating_sum   INT NOT NULL DEFAULT 0 CHECK (rating_sum >= 0),
+    ADD COLUMN rating_count INT NOT NULL DEFAULT 0 CHECK (rating_count >= 0);
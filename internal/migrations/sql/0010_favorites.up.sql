@@ -0,0 +1,10 @@
+-- ─── Favorites ───────────────────────────────────────────────────────────────
+-- Businesses a customer starred, listed most recently starred first.
+CREATE TABLE IF NOT EXISTS favorites (
+    customer_id UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    business_id UUID        NOT NULL REFERENCES businesses(id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (customer_id, business_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_favorites_customer ON favorites(customer_id, created_at DESC);
@@ -0,0 +1,4 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS fee_amount,
+    DROP COLUMN IF EXISTS tax_amount,
+    DROP COLUMN IF EXISTS subtotal;
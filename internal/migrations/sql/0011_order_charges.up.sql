@@ -0,0 +1,10 @@
+-- ─── Order charges ───────────────────────────────────────────────────────────
+-- total_amount = subtotal + tax_amount + fee_amount. Orders placed before this
+-- migration had no tax or fees, so their subtotal is their total.
+ALTER TABLE orders
+    ADD COLUMN subtotal   DECIMAL(10,2),
+    ADD COLUMN tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (tax_amount >= 0),
+    ADD COLUMN fee_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (fee_amount >= 0);
+
+UPDATE orders SET subtotal = total_amount;
+ALTER TABLE orders ALTER COLUMN subtotal SET NOT NULL;
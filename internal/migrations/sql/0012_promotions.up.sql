@@ -0,0 +1,62 @@
+-- ─── Promotions ──────────────────────────────────────────────────────────────
+-- Promotions with a code apply when the customer enters it; the others apply
+-- by themselves. Codes are stored upper-case. Promotions of a business are
+-- funded by it, the rest by the platform.
+CREATE TABLE IF NOT EXISTS promotions (
+    id               UUID          PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code             TEXT          UNIQUE,
+    name             TEXT          NOT NULL,
+    description      TEXT          NOT NULL DEFAULT '',
+    business_id      UUID          REFERENCES businesses(id) ON DELETE CASCADE,
+    kind             TEXT          NOT NULL CHECK (kind IN ('percent', 'fixed', 'buy_x_get_y')),
+    value            DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (value >= 0),
+    max_discount     DECIMAL(10,2) CHECK (max_discount > 0),
+    buy_quantity     INT           NOT NULL DEFAULT 0,
+    get_quantity     INT           NOT NULL DEFAULT 0,
+    first_order      BOOLEAN       NOT NULL DEFAULT FALSE,
+    min_subtotal     DECIMAL(10,2) NOT NULL DEFAULT 0,
+    category         TEXT          NOT NULL DEFAULT '',
+    starts_at        TIMESTAMPTZ,
+    ends_at          TIMESTAMPTZ,
+    -- 0 is Sunday; empty means every day.
+    weekdays         SMALLINT[]    NOT NULL DEFAULT '{}',
+    max_redemptions  INT           CHECK (max_redemptions > 0),
+    max_per_customer INT           CHECK (max_per_customer > 0),
+    redemption_count INT           NOT NULL DEFAULT 0 CHECK (redemption_count >= 0),
+    is_active        BOOLEAN       NOT NULL DEFAULT TRUE,
+    created_at       TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotions_automatic ON promotions(business_id) WHERE code IS NULL AND is_active;
+
+-- One row per promotion used by an order; deleted when the order is cancelled.
+-- order_id has no foreign key because the redemption is taken before the order
+-- is stored.
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    promotion_id UUID          NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    order_id     UUID          NOT NULL,
+    customer_id  UUID          NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount       DECIMAL(10,2) NOT NULL,
+    created_at   TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (promotion_id, order_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_customer ON promotion_redemptions(promotion_id, customer_id);
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_order ON promotion_redemptions(order_id);
+
+-- ─── Order discounts ─────────────────────────────────────────────────────────
+-- total_amount = subtotal - discount_amount + tax_amount + fee_amount.
+ALTER TABLE orders
+    ADD COLUMN discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (discount_amount >= 0);
+
+CREATE TABLE IF NOT EXISTS order_discounts (
+    order_id     UUID          NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    position     INT           NOT NULL,
+    promotion_id UUID          REFERENCES promotions(id) ON DELETE SET NULL,
+    code         TEXT          NOT NULL DEFAULT '',
+    name         TEXT          NOT NULL,
+    amount       DECIMAL(10,2) NOT NULL,
+    funded_by    TEXT          NOT NULL CHECK (funded_by IN ('platform', 'business')),
+    PRIMARY KEY (order_id, position)
+);
@@ -0,0 +1,15 @@
+-- ─── Idempotency keys ────────────────────────────────────────────────────────
+-- Responses to requests made with an Idempotency-Key, replayed to retries
+-- until expires_at. Kept here rather than in Redis, which may evict them
+-- early under memory pressure. key is scoped to the user by the middleware;
+-- status is 0 while the request is in flight.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key          TEXT        PRIMARY KEY,
+    fingerprint  TEXT        NOT NULL,
+    status       INT         NOT NULL DEFAULT 0,
+    content_type TEXT        NOT NULL DEFAULT '',
+    body         BYTEA,
+    expires_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
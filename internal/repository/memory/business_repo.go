@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+// BusinessRepository is an in-memory stand-in for postgres.BusinessRepository.
+type BusinessRepository struct {
+	mu         sync.RWMutex
+	businesses map[uuid.UUID]domain.Business
+	ratingSums map[uuid.UUID]int
+}
+
+func NewBusinessRepository() *BusinessRepository {
+	return &BusinessRepository{
+		businesses: make(map[uuid.UUID]domain.Business),
+		ratingSums: make(map[uuid.UUID]int),
+	}
+}
+
+func (r *BusinessRepository) Create(_ context.Context, b *domain.Business) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.businesses[b.ID]; exists {
+		return domain.NewError(domain.ErrConflict, "business_exists", "business already exists")
+	}
+	r.businesses[b.ID] = *b
+	return nil
+}
+
+func (r *BusinessRepository) GetByID(_ context.Context, id uuid.UUID) (*domain.Business, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	b, ok := r.businesses[id]
+	if !ok {
+		return nil, domain.ErrBusinessNotFound
+	}
+	b.Rating = domain.AverageRating(r.ratingSums[id], b.RatingCount)
+	return &b, nil
+}
+
+func (r *BusinessRepository) SetImage(_ context.Context, id uuid.UUID, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.businesses[id]
+	if !ok {
+		return domain.ErrBusinessNotFound
+	}
+	b.ImageKey = key
+	r.businesses[id] = b
+	return nil
+}
+
+// addRating is how ReviewRepository keeps the aggregate up to date.
+func (r *BusinessRepository) addRating(id uuid.UUID, sum, count int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.businesses[id]
+	if !ok {
+		return
+	}
+	b.RatingCount += count
+	r.businesses[id] = b
+	r.ratingSums[id] += sum
+}
+
+// GetByIDs mirrors the Postgres behaviour: unknown, malformed and inactive IDs
+// are skipped and the input order is preserved.
+func (r *BusinessRepository) GetByIDs(_ context.Context, ids []string) ([]*domain.Business, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*domain.Business, 0, len(ids))
+	for _, id := range ids {
+		u, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+		if b, ok := r.businesses[u]; ok && b.IsActive {
+			b.Rating = domain.AverageRating(r.ratingSums[u], b.RatingCount)
+			out = append(out, &b)
+		}
+	}
+	return out, nil
+}
+
+func (r *BusinessRepository) ActiveLocations(_ context.Context) ([]domain.GeoLocation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []domain.GeoLocation
+	for _, b := range r.businesses {
+		if b.IsActive {
+			out = append(out, domain.GeoLocation{ID: b.ID.String(), Category: b.Category, Latitude: b.Latitude, Longitude: b.Longitude})
+		}
+	}
+	return out, nil
+}
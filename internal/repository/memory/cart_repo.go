@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+type storedCart struct {
+	cart      domain.Cart
+	expiresAt time.Time
+}
+
+// CartRepository is an in-memory stand-in for redisrepo.CartRepository.
+type CartRepository struct {
+	mu    sync.Mutex
+	carts map[uuid.UUID]storedCart
+}
+
+func NewCartRepository() *CartRepository {
+	return &CartRepository{carts: make(map[uuid.UUID]storedCart)}
+}
+
+func (r *CartRepository) Save(_ context.Context, c *domain.Cart, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.put(c, ttl)
+	return nil
+}
+
+// put stores a copy of the cart; callers hold mu.
+func (r *CartRepository) put(c *domain.Cart, ttl time.Duration) {
+	cp := *c
+	cp.Items = append([]domain.CreateOrderItemReq(nil), c.Items...)
+	r.carts[c.ID] = storedCart{cart: cp, expiresAt: time.Now().Add(ttl)}
+}
+
+func (r *CartRepository) Restore(_ context.Context, c *domain.Cart, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, s := range r.carts {
+		if id == c.ID || s.cart.CustomerID != c.CustomerID || s.cart.BusinessID != c.BusinessID {
+			continue
+		}
+		if _, ok := r.live(id); ok {
+			return false, nil
+		}
+	}
+	r.put(c, ttl)
+	return true, nil
+}
+
+// live returns the cart unless it expired; callers hold mu.
+func (r *CartRepository) live(id uuid.UUID) (*domain.Cart, bool) {
+	s, ok := r.carts[id]
+	if !ok {
+		return nil, false
+	}
+	if !time.Now().Before(s.expiresAt) {
+		delete(r.carts, id)
+		return nil, false
+	}
+	c := s.cart
+	c.Items = append([]domain.CreateOrderItemReq(nil), s.cart.Items...)
+	return &c, true
+}
+
+func (r *CartRepository) Get(_ context.Context, id uuid.UUID) (*domain.Cart, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.live(id)
+	if !ok {
+		return nil, domain.ErrCartNotFound
+	}
+	return c, nil
+}
+
+func (r *CartRepository) Find(_ context.Context, customerID, businessID uuid.UUID) (*domain.Cart, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, s := range r.carts {
+		if s.cart.CustomerID != customerID || s.cart.BusinessID != businessID {
+			continue
+		}
+		if c, ok := r.live(id); ok {
+			return c, nil
+		}
+	}
+	return nil, domain.ErrCartNotFound
+}
+
+func (r *CartRepository) ListByCustomer(_ context.Context, customerID uuid.UUID) ([]*domain.Cart, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.Cart
+	for id, s := range r.carts {
+		if s.cart.CustomerID != customerID {
+			continue
+		}
+		if c, ok := r.live(id); ok {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (r *CartRepository) Take(_ context.Context, id uuid.UUID) (*domain.Cart, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.live(id)
+	if !ok {
+		return nil, domain.ErrCartNotFound
+	}
+	delete(r.carts, id)
+	return c, nil
+}
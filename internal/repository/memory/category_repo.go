@@ -0,0 +1,21 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+// CategoryRepository is an in-memory stand-in for postgres.CategoryRepository
+// serving a fixed taxonomy.
+type CategoryRepository struct {
+	categories []domain.Category
+}
+
+func NewCategoryRepository(categories ...domain.Category) *CategoryRepository {
+	return &CategoryRepository{categories: categories}
+}
+
+func (r *CategoryRepository) List(context.Context) ([]domain.Category, error) {
+	return append([]domain.Category(nil), r.categories...), nil
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// FavoriteRepository is an in-memory stand-in for postgres.FavoriteRepository.
+type FavoriteRepository struct {
+	mu sync.RWMutex
+	// favorites holds each customer's business IDs, oldest first.
+	favorites map[uuid.UUID][]uuid.UUID
+}
+
+func NewFavoriteRepository() *FavoriteRepository {
+	return &FavoriteRepository{favorites: make(map[uuid.UUID][]uuid.UUID)}
+}
+
+func (r *FavoriteRepository) Add(_ context.Context, customerID, businessID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !slices.Contains(r.favorites[customerID], businessID) {
+		r.favorites[customerID] = append(r.favorites[customerID], businessID)
+	}
+	return nil
+}
+
+func (r *FavoriteRepository) Remove(_ context.Context, customerID, businessID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.favorites[customerID] = slices.DeleteFunc(r.favorites[customerID], func(id uuid.UUID) bool { return id == businessID })
+	return nil
+}
+
+func (r *FavoriteRepository) ListBusinessIDs(_ context.Context, customerID uuid.UUID) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := r.favorites[customerID]
+	out := make([]string, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		out = append(out, ids[i].String())
+	}
+	return out, nil
+}
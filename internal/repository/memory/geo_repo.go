@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+const earthRadiusKm = 6371.0
+
+type point struct {
+	lat, lng float64
+	category string
+}
+
+// GeoRepository is an in-memory stand-in for redisrepo.GeoRepository using
+// great-circle distances.
+type GeoRepository struct {
+	mu     sync.RWMutex
+	points map[string]point
+}
+
+func NewGeoRepository() *GeoRepository {
+	return &GeoRepository{points: make(map[string]point)}
+}
+
+func (r *GeoRepository) IndexBusiness(_ context.Context, b *domain.Business) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.points[b.ID.String()] = point{lat: b.Latitude, lng: b.Longitude, category: b.Category}
+	return nil
+}
+
+func (r *GeoRepository) FindNearby(_ context.Context, q domain.GeoQuery) ([]domain.GeoResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var results []domain.GeoResult
+	for id, p := range r.points {
+		if len(q.Categories) > 0 && !slices.Contains(q.Categories, p.category) {
+			continue
+		}
+		res := domain.GeoResult{ID: id, DistanceKm: haversineKm(q.Latitude, q.Longitude, p.lat, p.lng)}
+		if res.DistanceKm <= q.RadiusKm && (q.After == nil || !q.After.Before(res)) {
+			results = append(results, res)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].DistanceKm != results[j].DistanceKm {
+			return results[i].DistanceKm < results[j].DistanceKm
+		}
+		return results[i].ID < results[j].ID
+	})
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+func (r *GeoRepository) FindInBounds(_ context.Context, b domain.Bounds, categories []string) ([]domain.GeoLocation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []domain.GeoLocation
+	for id, p := range r.points {
+		if len(categories) > 0 && !slices.Contains(categories, p.category) {
+			continue
+		}
+		if p.lat >= b.South && p.lat <= b.North && p.lng >= b.West && p.lng <= b.East {
+			out = append(out, domain.GeoLocation{ID: id, Category: p.category, Latitude: p.lat, Longitude: p.lng})
+		}
+	}
+	return out, nil
+}
+
+func (r *GeoRepository) RemoveBusiness(_ context.Context, businessID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.points, businessID)
+	return nil
+}
+
+func (r *GeoRepository) Count(_ context.Context) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return int64(len(r.points)), nil
+}
+
+func (r *GeoRepository) Locations(_ context.Context) ([]domain.GeoLocation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]domain.GeoLocation, 0, len(r.points))
+	for id, p := range r.points {
+		out = append(out, domain.GeoLocation{ID: id, Category: p.category, Latitude: p.lat, Longitude: p.lng})
+	}
+	return out, nil
+}
+
+func (r *GeoRepository) AddLocations(_ context.Context, locs []domain.GeoLocation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range locs {
+		r.points[l.ID] = point{lat: l.Latitude, lng: l.Longitude, category: l.Category}
+	}
+	return nil
+}
+
+func (r *GeoRepository) RemoveLocations(_ context.Context, ids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		delete(r.points, id)
+	}
+	return nil
+}
+
+func haversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+type storedIdempotencyRecord struct {
+	rec       domain.IdempotencyRecord
+	expiresAt time.Time
+}
+
+// IdempotencyRepository is an in-memory stand-in for
+// postgres.IdempotencyRepository.
+type IdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]storedIdempotencyRecord
+}
+
+func NewIdempotencyRepository() *IdempotencyRepository {
+	return &IdempotencyRepository{records: make(map[string]storedIdempotencyRecord)}
+}
+
+func (r *IdempotencyRepository) Reserve(_ context.Context, key string, rec *domain.IdempotencyRecord, ttl time.Duration) (*domain.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.records[key]; ok && time.Now().Before(s.expiresAt) {
+		existing := s.rec
+		return &existing, nil
+	}
+	r.records[key] = storedIdempotencyRecord{rec: *rec, expiresAt: time.Now().Add(ttl)}
+	return nil, nil
+}
+
+func (r *IdempotencyRepository) Complete(_ context.Context, key string, rec *domain.IdempotencyRecord, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.records[key]; ok {
+		r.records[key] = storedIdempotencyRecord{rec: *rec, expiresAt: time.Now().Add(ttl)}
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) Release(_ context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, key)
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteExpired(context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for key, s := range r.records {
+		if !time.Now().Before(s.expiresAt) {
+			delete(r.records, key)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+// Notification is a push notification captured by Notifier.
+type Notification struct {
+	Type    string // "new_order" | "order_ready"
+	Token   string
+	OrderID string
+}
+
+// Notifier records notifications instead of sending them.
+type Notifier struct {
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func NewNotifier() *Notifier {
+	return &Notifier{}
+}
+
+func (n *Notifier) SendNewOrderNotification(_ context.Context, fcmToken string, o *domain.Order) {
+	n.record("new_order", fcmToken, o)
+}
+
+func (n *Notifier) SendOrderReadyNotification(_ context.Context, customerFCMToken string, o *domain.Order) {
+	n.record("order_ready", customerFCMToken, o)
+}
+
+// Sent returns a snapshot of everything recorded so far.
+func (n *Notifier) Sent() []Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Notification(nil), n.sent...)
+}
+
+func (n *Notifier) record(kind, token string, o *domain.Order) {
+	// Mirror NotificationService: no token, no push.
+	if token == "" {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, Notification{Type: kind, Token: token, OrderID: o.ID.String()})
+}
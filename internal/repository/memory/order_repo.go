@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+// OrderRepository is an in-memory stand-in for postgres.OrderRepository.
+type OrderRepository struct {
+	mu     sync.RWMutex
+	orders map[uuid.UUID]domain.Order
+}
+
+func NewOrderRepository() *OrderRepository {
+	return &OrderRepository{orders: make(map[uuid.UUID]domain.Order)}
+}
+
+func (r *OrderRepository) Create(_ context.Context, o *domain.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.orders[o.ID]; exists {
+		return domain.NewError(domain.ErrConflict, "order_exists", "order already exists")
+	}
+	cp := *o
+	cp.Items = make([]domain.OrderItem, len(o.Items))
+	for i, item := range o.Items {
+		item.OrderID = o.ID
+		item.Modifiers = append([]domain.OrderItemModifier(nil), item.Modifiers...)
+		cp.Items[i] = item
+	}
+	cp.Discounts = append([]domain.OrderDiscount(nil), o.Discounts...)
+	r.orders[o.ID] = cp
+	return nil
+}
+
+func (r *OrderRepository) GetByID(_ context.Context, id uuid.UUID) (*domain.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	o, ok := r.orders[id]
+	if !ok {
+		return nil, domain.ErrOrderNotFound
+	}
+	o.Items = append([]domain.OrderItem(nil), o.Items...)
+	return &o, nil
+}
+
+func (r *OrderRepository) UpdateStatus(_ context.Context, id uuid.UUID, status domain.OrderStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.orders[id]
+	if !ok {
+		return domain.ErrOrderNotFound
+	}
+	o.Status = status
+	o.UpdatedAt = time.Now().UTC()
+	r.orders[id] = o
+	return nil
+}
+
+func (r *OrderRepository) TransitionStatus(_ context.Context, id uuid.UUID, from, to domain.OrderStatus) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.orders[id]
+	if !ok || o.Status != from {
+		return false, nil
+	}
+	o.Status = to
+	o.UpdatedAt = time.Now().UTC()
+	r.orders[id] = o
+	return true, nil
+}
+
+func (r *OrderRepository) MarkPaid(_ context.Context, id uuid.UUID, paymentID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.orders[id]
+	if !ok || o.Status != domain.OrderStatusPending {
+		return false, nil
+	}
+	o.Status, o.StripePaymentID = domain.OrderStatusPaid, paymentID
+	o.UpdatedAt = time.Now().UTC()
+	r.orders[id] = o
+	return true, nil
+}
+
+// ListByCustomer returns the customer's orders newest first. Like the Postgres
+// query it does not load items or the PIN.
+func (r *OrderRepository) ListByCustomer(_ context.Context, customerID uuid.UUID) ([]*domain.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*domain.Order
+	for _, o := range r.orders {
+		if o.CustomerID != customerID {
+			continue
+		}
+		o.Items = nil
+		o.PIN = ""
+		out = append(out, &o)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// ListByBusiness returns the business's orders in the given statuses oldest
+// first, with items but without the PIN.
+func (r *OrderRepository) ListByBusiness(_ context.Context, businessID uuid.UUID, statuses []domain.OrderStatus) ([]*domain.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []*domain.Order{}
+	for _, o := range r.orders {
+		if o.BusinessID != businessID || !slices.Contains(statuses, o.Status) {
+			continue
+		}
+		o.Items = append([]domain.OrderItem(nil), o.Items...)
+		o.PIN = ""
+		out = append(out, &o)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID.String() < out[j].ID.String()
+	})
+	return out, nil
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type cachedPIN struct {
+	pin       string
+	expiresAt time.Time
+}
+
+// PINStore is an in-memory stand-in for redisrepo.PINRepository.
+type PINStore struct {
+	mu   sync.Mutex
+	pins map[uuid.UUID]cachedPIN
+}
+
+func NewPINStore() *PINStore {
+	return &PINStore{pins: make(map[uuid.UUID]cachedPIN)}
+}
+
+func (s *PINStore) Save(_ context.Context, orderID uuid.UUID, pin string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pins[orderID] = cachedPIN{pin: pin, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *PINStore) Get(_ context.Context, orderID uuid.UUID) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.pins[orderID]
+	if !ok || time.Now().After(p.expiresAt) {
+		delete(s.pins, orderID)
+		return "", false, nil
+	}
+	return p.pin, true, nil
+}
+
+func (s *PINStore) Delete(_ context.Context, orderID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pins, orderID)
+	return nil
+}
+
+// Evict drops a cached PIN as if its TTL had elapsed or Redis evicted it.
+func (s *PINStore) Evict(orderID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pins, orderID)
+}
@@ -0,0 +1,237 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+// ProductRepository is an in-memory stand-in for postgres.ProductRepository.
+type ProductRepository struct {
+	mu       sync.RWMutex
+	products map[uuid.UUID]domain.Product
+	// resetAt is the last daily stock reset of products with a daily stock.
+	resetAt map[uuid.UUID]time.Time
+}
+
+func NewProductRepository() *ProductRepository {
+	return &ProductRepository{
+		products: make(map[uuid.UUID]domain.Product),
+		resetAt:  make(map[uuid.UUID]time.Time),
+	}
+}
+
+// clone copies p so that callers never share the stock counters or
+// modifier groups.
+func clone(p domain.Product) domain.Product {
+	if p.Stock != nil {
+		n := *p.Stock
+		p.Stock = &n
+	}
+	if p.DailyStock != nil {
+		n := *p.DailyStock
+		p.DailyStock = &n
+	}
+	groups := make([]domain.ModifierGroup, len(p.ModifierGroups))
+	for i, g := range p.ModifierGroups {
+		g.Options = append([]domain.ModifierOption(nil), g.Options...)
+		groups[i] = g
+	}
+	p.ModifierGroups = groups
+	return p
+}
+
+func (r *ProductRepository) Create(_ context.Context, p *domain.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.products[p.ID]; exists {
+		return domain.NewError(domain.ErrConflict, "product_exists", "product already exists")
+	}
+	r.products[p.ID] = clone(*p)
+	if p.DailyStock != nil {
+		r.resetAt[p.ID] = time.Now()
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetByID(_ context.Context, id uuid.UUID) (*domain.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.products[id]
+	if !ok {
+		return nil, domain.ErrProductNotFound
+	}
+	p = clone(p)
+	return &p, nil
+}
+
+func (r *ProductRepository) GetByIDs(_ context.Context, ids []uuid.UUID) ([]*domain.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*domain.Product
+	for _, id := range ids {
+		if p, ok := r.products[id]; ok {
+			p = clone(p)
+			out = append(out, &p)
+		}
+	}
+	return out, nil
+}
+
+func (r *ProductRepository) Update(_ context.Context, p *domain.Product, readStock *int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.products[p.ID]; !ok {
+		return domain.ErrProductNotFound
+	}
+	r.replace(p, readStock)
+	return nil
+}
+
+// replace stores p over the existing product with its ID; r.mu must be held.
+// Like the Postgres update, it leaves the picture alone and only moves the
+// stock by what was edited since readStock.
+func (r *ProductRepository) replace(p *domain.Product, readStock *int) {
+	old := r.products[p.ID]
+	switch {
+	case (p.Stock == nil) == (readStock == nil) && (p.Stock == nil || *p.Stock == *readStock):
+		p.Stock = old.Stock
+	case p.Stock != nil && readStock != nil && old.Stock != nil:
+		n := max(*old.Stock+*p.Stock-*readStock, 0)
+		p.Stock = &n
+	}
+	cp := clone(*p)
+	cp.BusinessID, cp.CreatedAt, cp.ImageKey = old.BusinessID, old.CreatedAt, old.ImageKey
+	r.products[p.ID] = cp
+	if cp.Stock != nil {
+		n := *cp.Stock
+		p.Stock = &n
+	}
+	if _, reset := r.resetAt[p.ID]; p.DailyStock == nil {
+		delete(r.resetAt, p.ID)
+	} else if !reset {
+		r.resetAt[p.ID] = time.Now()
+	}
+}
+
+func (r *ProductRepository) SetImage(_ context.Context, id uuid.UUID, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[id]
+	if !ok {
+		return domain.ErrProductNotFound
+	}
+	p.ImageKey = key
+	r.products[id] = p
+	return nil
+}
+
+func (r *ProductRepository) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.products[id]; !ok {
+		return domain.ErrProductNotFound
+	}
+	delete(r.products, id)
+	delete(r.resetAt, id)
+	return nil
+}
+
+func (r *ProductRepository) ApplyMenu(_ context.Context, create []*domain.Product, update []domain.ProductUpdate, remove []uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range update {
+		if _, ok := r.products[u.Product.ID]; !ok {
+			return domain.ErrProductNotFound
+		}
+	}
+	for _, id := range remove {
+		delete(r.products, id)
+		delete(r.resetAt, id)
+	}
+	for _, u := range update {
+		r.replace(u.Product, u.ReadStock)
+	}
+	for _, p := range create {
+		r.products[p.ID] = clone(*p)
+		if p.DailyStock != nil {
+			r.resetAt[p.ID] = time.Now()
+		}
+	}
+	return nil
+}
+
+func (r *ProductRepository) ListByBusiness(_ context.Context, businessID uuid.UUID) ([]*domain.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []*domain.Product{}
+	for _, p := range r.products {
+		if p.BusinessID == businessID {
+			p := clone(p)
+			out = append(out, &p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].ID.String() < out[j].ID.String()
+	})
+	return out, nil
+}
+
+func (r *ProductRepository) ReserveStock(_ context.Context, lines []domain.StockLine) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range lines {
+		p, ok := r.products[l.ProductID]
+		if !ok || !p.InStock(l.Quantity) {
+			return domain.ErrOutOfStock
+		}
+	}
+	for _, l := range lines {
+		if p := r.products[l.ProductID]; p.Stock != nil {
+			*p.Stock -= l.Quantity
+		}
+	}
+	return nil
+}
+
+func (r *ProductRepository) ReleaseStock(_ context.Context, lines []domain.StockLine, reservedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range lines {
+		p, ok := r.products[l.ProductID]
+		if !ok || p.Stock == nil {
+			continue
+		}
+		if reset, tracked := r.resetAt[l.ProductID]; tracked && reset.After(reservedAt) {
+			continue
+		}
+		*p.Stock += l.Quantity
+	}
+	return nil
+}
+
+func (r *ProductRepository) ResetDailyStock(_ context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for id, reset := range r.resetAt {
+		if !reset.Before(before) {
+			continue
+		}
+		p := r.products[id]
+		stock := *p.DailyStock
+		p.Stock = &stock
+		r.products[id] = p
+		r.resetAt[id] = time.Now()
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+type redemption struct {
+	promotionID, orderID, customerID uuid.UUID
+}
+
+// PromotionRepository is an in-memory stand-in for postgres.PromotionRepository.
+type PromotionRepository struct {
+	mu sync.Mutex
+	// promotions is kept in creation order.
+	promotions  []*domain.Promotion
+	redemptions []redemption
+}
+
+func NewPromotionRepository() *PromotionRepository {
+	return &PromotionRepository{}
+}
+
+func (r *PromotionRepository) Create(_ context.Context, p *domain.Promotion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p.Code != nil {
+		for _, other := range r.promotions {
+			if other.Code != nil && *other.Code == *p.Code {
+				return domain.ErrPromoCodeTaken
+			}
+		}
+	}
+	r.promotions = append(r.promotions, copyPromotion(p))
+	return nil
+}
+
+func copyPromotion(p *domain.Promotion) *domain.Promotion {
+	cp := *p
+	cp.Rules.Weekdays = slices.Clone(p.Rules.Weekdays)
+	return &cp
+}
+
+// find returns the stored promotion; callers hold mu.
+func (r *PromotionRepository) find(id uuid.UUID) (*domain.Promotion, bool) {
+	for _, p := range r.promotions {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func (r *PromotionRepository) GetByID(_ context.Context, id uuid.UUID) (*domain.Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.find(id)
+	if !ok {
+		return nil, domain.ErrPromotionNotFound
+	}
+	return copyPromotion(p), nil
+}
+
+func (r *PromotionRepository) GetByCode(_ context.Context, code string) (*domain.Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.promotions {
+		if p.Code != nil && *p.Code == code {
+			return copyPromotion(p), nil
+		}
+	}
+	return nil, domain.ErrPromotionNotFound
+}
+
+func (r *PromotionRepository) ListAutomatic(_ context.Context, businessID uuid.UUID) ([]*domain.Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.Promotion
+	for _, p := range r.promotions {
+		if p.Code == nil && p.IsActive && (p.BusinessID == nil || *p.BusinessID == businessID) {
+			out = append(out, copyPromotion(p))
+		}
+	}
+	return out, nil
+}
+
+func (r *PromotionRepository) List(_ context.Context, businessID *uuid.UUID) ([]*domain.Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.Promotion
+	for i := len(r.promotions) - 1; i >= 0; i-- {
+		p := r.promotions[i]
+		if businessID == nil || (p.BusinessID != nil && *p.BusinessID == *businessID) {
+			out = append(out, copyPromotion(p))
+		}
+	}
+	return out, nil
+}
+
+func (r *PromotionRepository) SetActive(_ context.Context, id uuid.UUID, active bool) (*domain.Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.find(id)
+	if !ok {
+		return nil, domain.ErrPromotionNotFound
+	}
+	p.IsActive = active
+	return copyPromotion(p), nil
+}
+
+func (r *PromotionRepository) CustomerRedemptions(_ context.Context, promotionID, customerID uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.customerRedemptions(promotionID, customerID), nil
+}
+
+func (r *PromotionRepository) customerRedemptions(promotionID, customerID uuid.UUID) int {
+	n := 0
+	for _, rd := range r.redemptions {
+		if rd.promotionID == promotionID && rd.customerID == customerID {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *PromotionRepository) Redeem(_ context.Context, orderID, customerID uuid.UUID, discounts []domain.OrderDiscount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var used []*domain.Promotion
+	for _, d := range discounts {
+		if d.PromotionID == nil {
+			continue
+		}
+		p, ok := r.find(*d.PromotionID)
+		if !ok || !p.IsActive ||
+			(p.MaxRedemptions != nil && p.RedemptionCount >= *p.MaxRedemptions) ||
+			(p.MaxPerCustomer != nil && r.customerRedemptions(p.ID, customerID) >= *p.MaxPerCustomer) {
+			return domain.ErrPromotionExhausted.WithMessage(fmt.Sprintf("%q is no longer available", d.Name))
+		}
+		used = append(used, p)
+	}
+	for _, p := range used {
+		p.RedemptionCount++
+		r.redemptions = append(r.redemptions, redemption{promotionID: p.ID, orderID: orderID, customerID: customerID})
+	}
+	return nil
+}
+
+func (r *PromotionRepository) Release(_ context.Context, orderID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.redemptions = slices.DeleteFunc(r.redemptions, func(rd redemption) bool {
+		if rd.orderID != orderID {
+			return false
+		}
+		if p, ok := r.find(rd.promotionID); ok {
+			p.RedemptionCount--
+		}
+		return true
+	})
+	return nil
+}
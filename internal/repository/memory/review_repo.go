@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+// ReviewRepository is an in-memory stand-in for postgres.ReviewRepository. It
+// keeps the rating aggregate of the businesses in the given repository.
+type ReviewRepository struct {
+	mu         sync.RWMutex
+	reviews    map[uuid.UUID]domain.Review
+	flags      map[uuid.UUID]map[uuid.UUID]string
+	businesses *BusinessRepository
+}
+
+func NewReviewRepository(businesses *BusinessRepository) *ReviewRepository {
+	return &ReviewRepository{
+		reviews:    make(map[uuid.UUID]domain.Review),
+		flags:      make(map[uuid.UUID]map[uuid.UUID]string),
+		businesses: businesses,
+	}
+}
+
+func (r *ReviewRepository) Create(_ context.Context, rv *domain.Review) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.reviews {
+		if existing.OrderID == rv.OrderID {
+			return domain.ErrReviewExists
+		}
+	}
+	r.reviews[rv.ID] = *rv
+	if rv.Status == domain.ReviewPublished {
+		r.businesses.addRating(rv.BusinessID, rv.Rating, 1)
+	}
+	return nil
+}
+
+func (r *ReviewRepository) GetByID(_ context.Context, id uuid.UUID) (*domain.Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rv, ok := r.reviews[id]
+	if !ok {
+		return nil, domain.ErrReviewNotFound
+	}
+	return &rv, nil
+}
+
+func (r *ReviewRepository) ListByBusiness(_ context.Context, businessID uuid.UUID, after *domain.ReviewCursor, limit int) ([]*domain.Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*domain.Review
+	for _, rv := range r.reviews {
+		if rv.BusinessID != businessID || rv.Status != domain.ReviewPublished {
+			continue
+		}
+		if after != nil && !sortsAfter(after, &rv) {
+			continue
+		}
+		out = append(out, &rv)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return sortsAfter(&domain.ReviewCursor{CreatedAt: out[i].CreatedAt, ID: out[i].ID}, out[j])
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// sortsAfter reports whether rv comes after c in newest-first order.
+func sortsAfter(c *domain.ReviewCursor, rv *domain.Review) bool {
+	if !rv.CreatedAt.Equal(c.CreatedAt) {
+		return rv.CreatedAt.Before(c.CreatedAt)
+	}
+	return rv.ID.String() < c.ID.String()
+}
+
+func (r *ReviewRepository) SetReply(_ context.Context, id uuid.UUID, text string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rv, ok := r.reviews[id]
+	if !ok {
+		return domain.ErrReviewNotFound
+	}
+	rv.Reply = &domain.ReviewReply{Text: text, CreatedAt: at}
+	rv.UpdatedAt = at
+	r.reviews[id] = rv
+	return nil
+}
+
+func (r *ReviewRepository) Flag(_ context.Context, id, userID uuid.UUID, reason string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rv, ok := r.reviews[id]
+	if !ok {
+		return false, domain.ErrReviewNotFound
+	}
+	if _, dup := r.flags[id][userID]; dup {
+		return false, nil
+	}
+	if r.flags[id] == nil {
+		r.flags[id] = make(map[uuid.UUID]string)
+	}
+	r.flags[id][userID] = reason
+	rv.FlagCount++
+	r.reviews[id] = rv
+	return true, nil
+}
+
+func (r *ReviewRepository) SetStatus(_ context.Context, id uuid.UUID, status domain.ReviewStatus) (*domain.Review, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rv, ok := r.reviews[id]
+	if !ok {
+		return nil, domain.ErrReviewNotFound
+	}
+	switch {
+	case rv.Status == domain.ReviewPublished && status == domain.ReviewHidden:
+		r.businesses.addRating(rv.BusinessID, -rv.Rating, -1)
+	case rv.Status == domain.ReviewHidden && status == domain.ReviewPublished:
+		r.businesses.addRating(rv.BusinessID, rv.Rating, 1)
+	}
+	rv.Status, rv.FlagCount, rv.UpdatedAt = status, 0, time.Now()
+	r.reviews[id] = rv
+	delete(r.flags, id)
+	return &rv, nil
+}
+
+func (r *ReviewRepository) ListFlagged(_ context.Context, limit int) ([]*domain.Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*domain.Review
+	for _, rv := range r.reviews {
+		if rv.Status == domain.ReviewPublished && rv.FlagCount > 0 {
+			out = append(out, &rv)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].FlagCount != out[j].FlagCount {
+			return out[i].FlagCount > out[j].FlagCount
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
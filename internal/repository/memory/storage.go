@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Storage is an in-memory stand-in for the file storages in package storage.
+type Storage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func NewStorage() *Storage {
+	return &Storage{objects: make(map[string][]byte)}
+}
+
+func (s *Storage) Put(_ context.Context, key string, data []byte, _ string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *Storage) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *Storage) URL(key string) string {
+	return "https://media.test/" + key
+}
+
+// Keys returns the stored keys in order.
+func (s *Storage) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,10 +2,12 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/heptapegon/localpickup/internal/domain"
@@ -33,18 +35,24 @@ func (r *BusinessRepository) Create(ctx context.Context, b *domain.Business) err
 
 func (r *BusinessRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Business, error) {
 	b := &domain.Business{}
+	var ratingSum int
 	err := r.db.QueryRow(ctx, `
-		SELECT id, owner_id, name, description, address, latitude, longitude,
-		       category, fcm_token, is_active, created_at, updated_at
+		SELECT id, owner_id, name, COALESCE(description, ''), address, latitude, longitude,
+		       category, COALESCE(fcm_token, ''), is_active, image_key, rating_sum, rating_count,
+		       created_at, updated_at
 		FROM businesses WHERE id = $1`, id,
 	).Scan(
 		&b.ID, &b.OwnerID, &b.Name, &b.Description, &b.Address,
 		&b.Latitude, &b.Longitude, &b.Category, &b.FCMToken,
-		&b.IsActive, &b.CreatedAt, &b.UpdatedAt,
+		&b.IsActive, &b.ImageKey, &ratingSum, &b.RatingCount, &b.CreatedAt, &b.UpdatedAt,
 	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrBusinessNotFound
+	}
 	if err != nil {
-		return nil, fmt.Errorf("business %s not found: %w", id, err)
+		return nil, fmt.Errorf("get business %s: %w", id, err)
 	}
+	b.Rating = domain.AverageRating(ratingSum, b.RatingCount)
 	return b, nil
 }
 
@@ -65,8 +73,9 @@ func (r *BusinessRepository) GetByIDs(ctx context.Context, ids []string) ([]*dom
 	}
 
 	rows, err := r.db.Query(ctx, `
-		SELECT id, owner_id, name, description, address, latitude, longitude,
-		       category, is_active, created_at, updated_at
+		SELECT id, owner_id, name, COALESCE(description, ''), address, latitude, longitude,
+		       category, COALESCE(fcm_token, ''), is_active, image_key, rating_sum, rating_count,
+		       created_at, updated_at
 		FROM businesses
 		WHERE id = ANY($1) AND is_active = true`, uuids,
 	)
@@ -79,15 +88,20 @@ func (r *BusinessRepository) GetByIDs(ctx context.Context, ids []string) ([]*dom
 	byID := make(map[string]*domain.Business, len(uuids))
 	for rows.Next() {
 		b := &domain.Business{}
+		var ratingSum int
 		if err := rows.Scan(
 			&b.ID, &b.OwnerID, &b.Name, &b.Description, &b.Address,
-			&b.Latitude, &b.Longitude, &b.Category, &b.IsActive,
-			&b.CreatedAt, &b.UpdatedAt,
+			&b.Latitude, &b.Longitude, &b.Category, &b.FCMToken,
+			&b.IsActive, &b.ImageKey, &ratingSum, &b.RatingCount, &b.CreatedAt, &b.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
+		b.Rating = domain.AverageRating(ratingSum, b.RatingCount)
 		byID[b.ID.String()] = b
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	ordered := make([]*domain.Business, 0, len(ids))
 	for _, id := range ids {
@@ -105,3 +119,48 @@ func (r *BusinessRepository) UpdateFCMToken(ctx context.Context, id uuid.UUID, t
 	)
 	return err
 }
+
+// SetImage records the storage prefix of the business picture; "" clears it.
+func (r *BusinessRepository) SetImage(ctx context.Context, id uuid.UUID, key string) error {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE businesses SET image_key = $1, updated_at = $2 WHERE id = $3`,
+		key, time.Now(), id,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrBusinessNotFound
+	}
+	return nil
+}
+
+// CountActive returns the number of active businesses, i.e. those that should
+// be present in the geo index.
+func (r *BusinessRepository) CountActive(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM businesses WHERE is_active = true`).Scan(&n)
+	return n, err
+}
+
+// ActiveLocations returns the position of every active business; it is the
+// source of truth the geo index is reconciled against.
+func (r *BusinessRepository) ActiveLocations(ctx context.Context) ([]domain.GeoLocation, error) {
+	rows, err := r.db.Query(ctx, `SELECT id, category, latitude, longitude FROM businesses WHERE is_active = true`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []domain.GeoLocation
+	for rows.Next() {
+		var id uuid.UUID
+		var loc domain.GeoLocation
+		if err := rows.Scan(&id, &loc.Category, &loc.Latitude, &loc.Longitude); err != nil {
+			return nil, err
+		}
+		loc.ID = id.String()
+		out = append(out, loc)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,150 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
+	"github.com/heptapegon/localpickup/internal/testinfra"
+)
+
+func newBusiness(ownerID uuid.UUID, name string, active bool) *domain.Business {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	return &domain.Business{
+		ID:          uuid.New(),
+		OwnerID:     ownerID,
+		Name:        name,
+		Description: "desc " + name,
+		Address:     "Av. Reforma 1",
+		Latitude:    19.4326,
+		Longitude:   -99.1332,
+		Category:    "bakery",
+		FCMToken:    "token-" + name,
+		IsActive:    active,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+func seedBusiness(t *testing.T, db *pgxpool.Pool, ownerID uuid.UUID, name string, active bool) *domain.Business {
+	t.Helper()
+	b := newBusiness(ownerID, name, active)
+	if err := postgresrepo.NewBusinessRepository(db).Create(context.Background(), b); err != nil {
+		t.Fatalf("seed business %s: %v", name, err)
+	}
+	return b
+}
+
+func TestBusinessRepositoryRoundTrip(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewBusinessRepository(db)
+	ctx := context.Background()
+	owner := testinfra.InsertUser(t, db, "business_owner")
+
+	want := seedBusiness(t, db, owner, "rosa", true)
+
+	got, err := repo.GetByID(ctx, want.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error: %v", err)
+	}
+	if got.Name != want.Name || got.FCMToken != want.FCMToken || got.Description != want.Description ||
+		got.Latitude != want.Latitude || got.Longitude != want.Longitude || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("GetByID() = %+v, want %+v", got, want)
+	}
+
+	if _, err := repo.GetByID(ctx, uuid.New()); !errors.Is(err, domain.ErrBusinessNotFound) {
+		t.Errorf("GetByID(unknown) error = %v, want ErrBusinessNotFound", err)
+	}
+
+	if err := repo.UpdateFCMToken(ctx, want.ID, "rotated"); err != nil {
+		t.Fatalf("UpdateFCMToken() error: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, want.ID); got.FCMToken != "rotated" {
+		t.Errorf("FCMToken = %q, want %q", got.FCMToken, "rotated")
+	}
+	if got.ImageKey != "" {
+		t.Errorf("new business ImageKey = %q, want none", got.ImageKey)
+	}
+
+	key := "businesses/" + want.ID.String() + "/upload"
+	if err := repo.SetImage(ctx, want.ID, key); err != nil {
+		t.Fatalf("SetImage() error: %v", err)
+	}
+	if got, _ := repo.GetByIDs(ctx, []string{want.ID.String()}); len(got) != 1 || got[0].ImageKey != key {
+		t.Errorf("GetByIDs() = %+v, want ImageKey %q", got, key)
+	}
+	if err := repo.SetImage(ctx, uuid.New(), key); !errors.Is(err, domain.ErrBusinessNotFound) {
+		t.Errorf("SetImage(unknown) error = %v, want ErrBusinessNotFound", err)
+	}
+}
+
+func TestBusinessRepositoryGetByIDs(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewBusinessRepository(db)
+	owner := testinfra.InsertUser(t, db, "business_owner")
+
+	a := seedBusiness(t, db, owner, "a", true)
+	b := seedBusiness(t, db, owner, "b", true)
+	inactive := seedBusiness(t, db, owner, "inactive", false)
+
+	// Geo order (b, a) must be preserved; inactive, unknown and malformed IDs dropped.
+	ids := []string{b.ID.String(), "not-a-uuid", inactive.ID.String(), uuid.NewString(), a.ID.String()}
+	got, err := repo.GetByIDs(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("GetByIDs() error: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != b.ID || got[1].ID != a.ID {
+		t.Fatalf("GetByIDs() = %v, want [b a]", got)
+	}
+	if got[0].FCMToken != b.FCMToken {
+		t.Errorf("GetByIDs() FCMToken = %q, want %q", got[0].FCMToken, b.FCMToken)
+	}
+
+	if n, err := repo.CountActive(context.Background()); err != nil || n != 2 {
+		t.Errorf("CountActive() = %d, %v, want 2", n, err)
+	}
+
+	locs, err := repo.ActiveLocations(context.Background())
+	if err != nil || len(locs) != 2 {
+		t.Fatalf("ActiveLocations() = %v, %v, want 2 locations", locs, err)
+	}
+	for _, l := range locs {
+		if l.ID == inactive.ID.String() {
+			t.Errorf("ActiveLocations() includes inactive business")
+		}
+	}
+}
+
+func TestBusinessRepositoryConstraints(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewBusinessRepository(db)
+	ctx := context.Background()
+	owner := testinfra.InsertUser(t, db, "business_owner")
+
+	tests := []struct {
+		name   string
+		mutate func(b *domain.Business)
+	}{
+		{name: "unknown owner", mutate: func(b *domain.Business) { b.OwnerID = uuid.New() }},
+		{name: "name too long", mutate: func(b *domain.Business) { b.Name = string(make([]byte, 101)) }},
+		{name: "duplicate id", mutate: func(b *domain.Business) {
+			dup := seedBusiness(t, db, owner, "dup", true)
+			b.ID = dup.ID
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBusiness(owner, "x", true)
+			tt.mutate(b)
+			if err := repo.Create(ctx, b); err == nil {
+				t.Fatal("Create() succeeded, want constraint violation")
+			}
+		})
+	}
+}
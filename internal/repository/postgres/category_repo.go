@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+type CategoryRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCategoryRepository(db *pgxpool.Pool) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+// List returns the whole taxonomy. It is small and changes only through
+// migrations, so callers cache it.
+func (r *CategoryRepository) List(ctx context.Context) ([]domain.Category, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT slug, COALESCE(parent_slug, ''), names, icon, sort_order
+		FROM categories
+		ORDER BY sort_order, slug`)
+	if err != nil {
+		return nil, fmt.Errorf("list categories: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.Category
+	for rows.Next() {
+		var c domain.Category
+		if err := rows.Scan(&c.Slug, &c.ParentSlug, &c.Names, &c.Icon, &c.SortOrder); err != nil {
+			return nil, fmt.Errorf("scan category: %w", err)
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,36 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
+	"github.com/heptapegon/localpickup/internal/testinfra"
+)
+
+func TestCategoryRepositoryList(t *testing.T) {
+	db := testinfra.Postgres(t)
+	ctx := context.Background()
+
+	categories, err := postgresrepo.NewCategoryRepository(db).List(ctx)
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	tree := domain.NewCategoryTree(categories)
+	if got := tree.Subtree("food"); len(got) < 2 || got[0] != "food" {
+		t.Errorf("Subtree(food) = %v, want food and its subcategories", got)
+	}
+	for _, c := range categories {
+		if c.Names["es"] == "" || c.Icon == "" {
+			t.Errorf("category %q has no Spanish name or icon: %+v", c.Slug, c)
+		}
+	}
+
+	// businesses.category references the taxonomy.
+	b := newBusiness(testinfra.InsertUser(t, db, "business_owner"), "Ferretería", true)
+	b.Category = "hardware"
+	if err := postgresrepo.NewBusinessRepository(db).Create(ctx, b); err == nil {
+		t.Errorf("Create() with an unknown category succeeded")
+	}
+}
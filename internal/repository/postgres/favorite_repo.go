@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type FavoriteRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFavoriteRepository(db *pgxpool.Pool) *FavoriteRepository {
+	return &FavoriteRepository{db: db}
+}
+
+func (r *FavoriteRepository) Add(ctx context.Context, customerID, businessID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO favorites (customer_id, business_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`,
+		customerID, businessID,
+	)
+	return err
+}
+
+func (r *FavoriteRepository) Remove(ctx context.Context, customerID, businessID uuid.UUID) error {
+	_, err := r.db.Exec(ctx,
+		`DELETE FROM favorites WHERE customer_id = $1 AND business_id = $2`,
+		customerID, businessID,
+	)
+	return err
+}
+
+// ListBusinessIDs returns the customer's favorites, most recently added first.
+func (r *FavoriteRepository) ListBusinessIDs(ctx context.Context, customerID uuid.UUID) ([]string, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT business_id FROM favorites
+		WHERE customer_id = $1
+		ORDER BY created_at DESC, business_id`, customerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list favorites: %w", err)
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id.String())
+	}
+	return out, rows.Err()
+}
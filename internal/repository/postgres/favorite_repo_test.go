@@ -0,0 +1,45 @@
+package postgres_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
+	"github.com/heptapegon/localpickup/internal/testinfra"
+)
+
+func TestFavoriteRepository(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewFavoriteRepository(db)
+	ctx := context.Background()
+	customer := testinfra.InsertUser(t, db, "customer")
+	owner := testinfra.InsertUser(t, db, "business_owner")
+	rosa := seedBusiness(t, db, owner, "rosa", true)
+	lupe := seedBusiness(t, db, owner, "lupe", true)
+
+	// Adding rosa again keeps her original position.
+	for _, b := range []*domain.Business{rosa, lupe, rosa} {
+		if err := repo.Add(ctx, customer, b.ID); err != nil {
+			t.Fatalf("Add() error: %v", err)
+		}
+	}
+	got, err := repo.ListBusinessIDs(ctx, customer)
+	if err != nil {
+		t.Fatalf("ListBusinessIDs() error: %v", err)
+	}
+	if want := []string{lupe.ID.String(), rosa.ID.String()}; !slices.Equal(got, want) {
+		t.Errorf("ListBusinessIDs() = %v, want %v", got, want)
+	}
+
+	if err := repo.Remove(ctx, customer, lupe.ID); err != nil {
+		t.Fatalf("Remove() error: %v", err)
+	}
+	if err := repo.Remove(ctx, customer, lupe.ID); err != nil {
+		t.Errorf("Remove() of a non-favorite error: %v", err)
+	}
+	if got, _ := repo.ListBusinessIDs(ctx, customer); !slices.Equal(got, []string{rosa.ID.String()}) {
+		t.Errorf("ListBusinessIDs() after Remove() = %v, want only rosa", got)
+	}
+}
@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+// GeoRepository answers nearby queries straight from the businesses.location
+// geography column (GIST indexed). It is the fallback for — or, with
+// GEO_PRIMARY=postgis, the replacement of — the Redis geo index.
+type GeoRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewGeoRepository(db *pgxpool.Pool) *GeoRepository {
+	return &GeoRepository{db: db}
+}
+
+// IndexBusiness is a no-op: location is generated from latitude/longitude when
+// the business row is written.
+func (r *GeoRepository) IndexBusiness(context.Context, *domain.Business) error { return nil }
+
+// RemoveBusiness is a no-op: inactive businesses are filtered out by the query.
+func (r *GeoRepository) RemoveBusiness(context.Context, string) error { return nil }
+
+// FindNearby returns active business IDs within q.RadiusKm of the query point,
+// nearest first. Distances are geodesic (WGS 84 spheroid). Ties are broken by
+// ID, which orders uuids the same way as their string form.
+func (r *GeoRepository) FindNearby(ctx context.Context, q domain.GeoQuery) ([]domain.GeoResult, error) {
+	var afterDist *float64
+	var afterID *string
+	if q.After != nil {
+		afterDist, afterID = &q.After.DistanceKm, &q.After.ID
+	}
+
+	rows, err := r.db.Query(ctx, `
+		WITH origin AS (SELECT ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography AS p),
+		nearby AS (
+			SELECT b.id, ST_Distance(b.location, origin.p) / 1000 AS distance_km
+			FROM businesses b, origin
+			WHERE b.is_active = TRUE
+			  AND ST_DWithin(b.location, origin.p, $3 * 1000)
+			  AND (COALESCE(cardinality($4::text[]), 0) = 0 OR b.category = ANY($4))
+		)
+		SELECT id::text, distance_km FROM nearby
+		WHERE $5::float8 IS NULL OR (distance_km, id) > ($5, $6::uuid)
+		ORDER BY distance_km, id
+		LIMIT $7`,
+		q.Latitude, q.Longitude, q.RadiusKm, q.Categories, afterDist, afterID, q.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("postgis nearby: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.GeoResult
+	for rows.Next() {
+		var res domain.GeoResult
+		if err := rows.Scan(&res.ID, &res.DistanceKm); err != nil {
+			return nil, fmt.Errorf("scan nearby: %w", err)
+		}
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
+
+// FindInBounds returns the active businesses inside b, which must not cross
+// the antimeridian.
+func (r *GeoRepository) FindInBounds(ctx context.Context, b domain.Bounds, categories []string) ([]domain.GeoLocation, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id::text, category, latitude, longitude
+		FROM businesses
+		WHERE is_active = TRUE
+		  AND location::geometry && ST_MakeEnvelope($1, $2, $3, $4, 4326)
+		  AND (COALESCE(cardinality($5::text[]), 0) = 0 OR category = ANY($5))`,
+		b.West, b.South, b.East, b.North, categories,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("postgis in bounds: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.GeoLocation
+	for rows.Next() {
+		var loc domain.GeoLocation
+		if err := rows.Scan(&loc.ID, &loc.Category, &loc.Latitude, &loc.Longitude); err != nil {
+			return nil, fmt.Errorf("scan in bounds: %w", err)
+		}
+		out = append(out, loc)
+	}
+	return out, rows.Err()
+}
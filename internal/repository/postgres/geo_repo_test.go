@@ -0,0 +1,219 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
+	redisrepo "github.com/heptapegon/localpickup/internal/repository/redis"
+	"github.com/heptapegon/localpickup/internal/testinfra"
+)
+
+// Zócalo, Mexico City.
+const originLat, originLng = 19.4326, -99.1332
+
+// seedRing creates active businesses at increasing distances from the origin,
+// alternating compass directions so ordering can't fall out of insertion order.
+func seedRing(t *testing.T, repo *postgresrepo.BusinessRepository, owner uuid.UUID, n int) []*domain.Business {
+	t.Helper()
+	offsets := [][2]float64{{1, 0}, {0, 1}, {-1, 0}, {0, -1}, {0.7, 0.7}, {-0.7, 0.7}, {-0.7, -0.7}, {0.7, -0.7}}
+	var out []*domain.Business
+	for i := 0; i < n; i++ {
+		b := newBusiness(owner, fmt.Sprintf("ring-%02d", i), true)
+		step := 0.003 * float64(i+1) // ~330 m per ring
+		o := offsets[(i*3)%len(offsets)]
+		b.Latitude, b.Longitude = originLat+o[0]*step, originLng+o[1]*step
+		if err := repo.Create(context.Background(), b); err != nil {
+			t.Fatalf("seed %s: %v", b.Name, err)
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+func TestGeoRepositoryFindNearby(t *testing.T) {
+	db := testinfra.Postgres(t)
+	businesses := postgresrepo.NewBusinessRepository(db)
+	repo := postgresrepo.NewGeoRepository(db)
+	ctx := context.Background()
+	owner := testinfra.InsertUser(t, db, "business_owner")
+
+	ring := seedRing(t, businesses, owner, 6)
+	inactive := newBusiness(owner, "closed", false)
+	inactive.Latitude, inactive.Longitude = originLat, originLng
+	if err := businesses.Create(ctx, inactive); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		radiusKm float64
+		want     int
+	}{
+		{"everything", 10, 6},
+		{"inner rings only", 1.1, 3},
+		{"nothing", 0.1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.FindNearby(ctx, domain.GeoQuery{Latitude: originLat, Longitude: originLng, RadiusKm: tt.radiusKm, Limit: 50})
+			if err != nil {
+				t.Fatalf("FindNearby() error: %v", err)
+			}
+			if len(got) != tt.want {
+				t.Fatalf("FindNearby() returned %d results, want %d", len(got), tt.want)
+			}
+			for i, r := range got {
+				if r.ID != ring[i].ID.String() {
+					t.Errorf("result[%d] = %s, want %s", i, r.ID, ring[i].Name)
+				}
+				if r.DistanceKm > tt.radiusKm {
+					t.Errorf("result[%d] distance %.3f km exceeds radius", i, r.DistanceKm)
+				}
+			}
+		})
+	}
+}
+
+// TestGeoBackendsAgree checks that the PostGIS fallback ranks results exactly
+// like the Redis geo index, so failing over doesn't reshuffle the list.
+func TestGeoBackendsAgree(t *testing.T) {
+	db := testinfra.Postgres(t)
+	businesses := postgresrepo.NewBusinessRepository(db)
+	postgis := postgresrepo.NewGeoRepository(db)
+	redisGeo := redisrepo.NewGeoRepository(testinfra.Redis(t))
+	ctx := context.Background()
+	owner := testinfra.InsertUser(t, db, "business_owner")
+
+	for _, b := range seedRing(t, businesses, owner, 12) {
+		if err := redisGeo.IndexBusiness(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, radius := range []float64{0.5, 2, 5} {
+		fromRedis, err := redisGeo.FindNearby(ctx, domain.GeoQuery{Latitude: originLat, Longitude: originLng, RadiusKm: radius, Limit: 50})
+		if err != nil {
+			t.Fatalf("redis FindNearby(%v) error: %v", radius, err)
+		}
+		fromPostGIS, err := postgis.FindNearby(ctx, domain.GeoQuery{Latitude: originLat, Longitude: originLng, RadiusKm: radius, Limit: 50})
+		if err != nil {
+			t.Fatalf("postgis FindNearby(%v) error: %v", radius, err)
+		}
+		if len(fromRedis) != len(fromPostGIS) {
+			t.Fatalf("radius %v: redis returned %d, postgis %d", radius, len(fromRedis), len(fromPostGIS))
+		}
+		for i := range fromRedis {
+			if fromRedis[i].ID != fromPostGIS[i].ID {
+				t.Errorf("radius %v: result[%d] redis=%s postgis=%s", radius, i, fromRedis[i].ID, fromPostGIS[i].ID)
+			}
+			// Redis uses a sphere, PostGIS the WGS 84 spheroid.
+			if d := fromRedis[i].DistanceKm - fromPostGIS[i].DistanceKm; d > 0.01 || d < -0.01 {
+				t.Errorf("radius %v: result[%d] distance redis=%.4f postgis=%.4f", radius, i, fromRedis[i].DistanceKm, fromPostGIS[i].DistanceKm)
+			}
+		}
+	}
+}
+
+func TestGeoRepositoryFindNearbyPagesAndFilters(t *testing.T) {
+	db := testinfra.Postgres(t)
+	businesses := postgresrepo.NewBusinessRepository(db)
+	repo := postgresrepo.NewGeoRepository(db)
+	ctx := context.Background()
+	owner := testinfra.InsertUser(t, db, "business_owner")
+
+	ring := seedRing(t, businesses, owner, 7) // all "bakery"
+	cafe := newBusiness(owner, "cafe", true)
+	cafe.Category = "coffee"
+	cafe.Latitude = originLat + 0.05
+	if err := businesses.Create(ctx, cafe); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.FindNearby(ctx, domain.GeoQuery{Latitude: originLat, Longitude: originLng, RadiusKm: 10, Categories: []string{"coffee"}, Limit: 3})
+	if err != nil || len(got) != 1 || got[0].ID != cafe.ID.String() {
+		t.Errorf("coffee search = %v, %v, want only the cafe", got, err)
+	}
+
+	q := domain.GeoQuery{Latitude: originLat, Longitude: originLng, RadiusKm: 10, Categories: []string{"bakery"}, Limit: 3}
+	var ids []string
+	for {
+		page, err := repo.FindNearby(ctx, q)
+		if err != nil {
+			t.Fatalf("FindNearby() error: %v", err)
+		}
+		for _, r := range page {
+			ids = append(ids, r.ID)
+		}
+		if len(page) < q.Limit {
+			break
+		}
+		last := page[len(page)-1]
+		q.After = &domain.GeoCursor{DistanceKm: last.DistanceKm, ID: last.ID, Seen: len(ids)}
+	}
+	if len(ids) != len(ring) {
+		t.Fatalf("paged through %d results, want %d", len(ids), len(ring))
+	}
+	for i, b := range ring {
+		if ids[i] != b.ID.String() {
+			t.Errorf("result[%d] = %s, want %s", i, ids[i], b.Name)
+		}
+	}
+}
+
+func TestGeoRepositoryFindInBounds(t *testing.T) {
+	db := testinfra.Postgres(t)
+	businesses := postgresrepo.NewBusinessRepository(db)
+	postgis := postgresrepo.NewGeoRepository(db)
+	redisGeo := redisrepo.NewGeoRepository(testinfra.Redis(t))
+	ctx := context.Background()
+	owner := testinfra.InsertUser(t, db, "business_owner")
+
+	ring := seedRing(t, businesses, owner, 12)
+	for _, b := range ring {
+		if err := redisGeo.IndexBusiness(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inactive := newBusiness(owner, "closed", false)
+	if err := businesses.Create(ctx, inactive); err != nil {
+		t.Fatal(err)
+	}
+
+	// Roughly the inner 1 km square around the origin.
+	box := domain.Bounds{North: originLat + 0.01, South: originLat - 0.01, East: originLng + 0.01, West: originLng - 0.01}
+	want := map[string]bool{}
+	for _, b := range ring {
+		if b.Latitude <= box.North && b.Latitude >= box.South && b.Longitude <= box.East && b.Longitude >= box.West {
+			want[b.ID.String()] = true
+		}
+	}
+	if len(want) == 0 || len(want) == len(ring) {
+		t.Fatalf("box should contain some but not all of the ring, contains %d", len(want))
+	}
+
+	for name, index := range map[string]interface {
+		FindInBounds(context.Context, domain.Bounds, []string) ([]domain.GeoLocation, error)
+	}{"postgis": postgis, "redis": redisGeo} {
+		got, err := index.FindInBounds(ctx, box, nil)
+		if err != nil {
+			t.Fatalf("%s FindInBounds() error: %v", name, err)
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s FindInBounds() returned %d, want %d", name, len(got), len(want))
+		}
+		for _, l := range got {
+			if !want[l.ID] || l.Category != "bakery" {
+				t.Errorf("%s FindInBounds() returned unexpected %+v", name, l)
+			}
+		}
+
+		if got, _ := index.FindInBounds(ctx, box, []string{"coffee"}); len(got) != 0 {
+			t.Errorf("%s FindInBounds(coffee) = %v, want none", name, got)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+// IdempotencyRepository keeps idempotency records until they expire. Expired
+// rows are ignored, taken over by a new request with the same key, and
+// deleted by DeleteExpired.
+type IdempotencyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewIdempotencyRepository(db *pgxpool.Pool) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// reserveAttempts bounds Reserve when the record it conflicts with keeps
+// being released before it can be read.
+const reserveAttempts = 3
+
+func (r *IdempotencyRepository) Reserve(ctx context.Context, key string, rec *domain.IdempotencyRecord, ttl time.Duration) (*domain.IdempotencyRecord, error) {
+	for range reserveAttempts {
+		tag, err := r.db.Exec(ctx, `
+			INSERT INTO idempotency_keys (key, fingerprint, status, content_type, body, expires_at)
+			VALUES ($1, $2, $3, $4, $5, NOW() + make_interval(secs => $6))
+			ON CONFLICT (key) DO UPDATE
+			    SET fingerprint = EXCLUDED.fingerprint, status = EXCLUDED.status,
+			        content_type = EXCLUDED.content_type, body = EXCLUDED.body, expires_at = EXCLUDED.expires_at
+			    WHERE idempotency_keys.expires_at <= NOW()`,
+			key, rec.Fingerprint, rec.Status, rec.ContentType, rec.Body, ttl.Seconds(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("reserve idempotency key: %w", err)
+		}
+		if tag.RowsAffected() == 1 {
+			return nil, nil
+		}
+
+		existing := &domain.IdempotencyRecord{}
+		err = r.db.QueryRow(ctx, `
+			SELECT fingerprint, status, content_type, COALESCE(body, '')
+			FROM idempotency_keys WHERE key = $1 AND expires_at > NOW()`, key,
+		).Scan(&existing.Fingerprint, &existing.Status, &existing.ContentType, &existing.Body)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue // released or expired since the insert; try again
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get idempotency record: %w", err)
+		}
+		return existing, nil
+	}
+	return nil, fmt.Errorf("reserve idempotency key: record kept changing")
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, rec *domain.IdempotencyRecord, ttl time.Duration) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE idempotency_keys
+		SET fingerprint = $2, status = $3, content_type = $4, body = $5, expires_at = NOW() + make_interval(secs => $6)
+		WHERE key = $1`,
+		key, rec.Fingerprint, rec.Status, rec.ContentType, rec.Body, ttl.Seconds(),
+	)
+	return err
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE key = $1`, key)
+	return err
+}
+
+// DeleteExpired removes expired records and returns how many there were.
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,58 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
+	"github.com/heptapegon/localpickup/internal/testinfra"
+)
+
+func TestIdempotencyRepository(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewIdempotencyRepository(db)
+	ctx := context.Background()
+
+	pending := &domain.IdempotencyRecord{Fingerprint: "f1"}
+	if existing, err := repo.Reserve(ctx, "u1:k1", pending, time.Minute); err != nil || existing != nil {
+		t.Fatalf("Reserve() = %+v, %v, want the key reserved", existing, err)
+	}
+	existing, err := repo.Reserve(ctx, "u1:k1", &domain.IdempotencyRecord{Fingerprint: "f2"}, time.Minute)
+	if err != nil || existing == nil || existing.Fingerprint != "f1" || existing.Status != 0 {
+		t.Fatalf("second Reserve() = %+v, %v, want the pending record", existing, err)
+	}
+
+	done := &domain.IdempotencyRecord{Fingerprint: "f1", Status: 201, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+	if err := repo.Complete(ctx, "u1:k1", done, time.Hour); err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+	existing, err = repo.Reserve(ctx, "u1:k1", pending, time.Minute)
+	if err != nil || existing == nil || existing.Status != 201 || string(existing.Body) != `{"id":1}` || existing.ContentType != "application/json" {
+		t.Errorf("Reserve() after Complete() = %+v, %v, want the stored response", existing, err)
+	}
+
+	if err := repo.Release(ctx, "u1:k1"); err != nil {
+		t.Fatalf("Release() error: %v", err)
+	}
+	if existing, err := repo.Reserve(ctx, "u1:k1", pending, time.Minute); err != nil || existing != nil {
+		t.Errorf("Reserve() after Release() = %+v, %v, want the key reserved again", existing, err)
+	}
+
+	// An expired record is taken over, and purged.
+	if _, err := repo.Reserve(ctx, "u1:old", pending, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n, err := repo.DeleteExpired(ctx); err != nil || n != 1 {
+		t.Errorf("DeleteExpired() = %d, %v, want 1", n, err)
+	}
+	if _, err := repo.Reserve(ctx, "u1:stale", pending, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if existing, err := repo.Reserve(ctx, "u1:stale", &domain.IdempotencyRecord{Fingerprint: "f2"}, time.Minute); err != nil || existing != nil {
+		t.Errorf("Reserve() over an expired record = %+v, %v, want it taken over", existing, err)
+	}
+}
@@ -0,0 +1,10 @@
+package postgres_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/heptapegon/localpickup/internal/testinfra"
+)
+
+func TestMain(m *testing.M) { os.Exit(testinfra.Run(m)) }
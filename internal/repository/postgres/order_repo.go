@@ -2,9 +2,11 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/heptapegon/localpickup/internal/domain"
@@ -27,9 +29,10 @@ func (r *OrderRepository) Create(ctx context.Context, o *domain.Order) error {
 
 	_, err = tx.Exec(ctx, `
 		INSERT INTO orders
-		    (id, customer_id, business_id, total_amount, status, pin, stripe_payment_id, created_at, updated_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`,
-		o.ID, o.CustomerID, o.BusinessID, o.TotalAmount,
+		    (id, customer_id, business_id, subtotal, discount_amount, tax_amount, fee_amount, total_amount,
+		     status, pin, stripe_payment_id, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)`,
+		o.ID, o.CustomerID, o.BusinessID, o.Subtotal, o.Discount, o.Tax, o.Fees, o.TotalAmount,
 		o.Status, o.PIN, o.StripePaymentID, o.CreatedAt, o.UpdatedAt,
 	)
 	if err != nil {
@@ -38,9 +41,30 @@ func (r *OrderRepository) Create(ctx context.Context, o *domain.Order) error {
 
 	for _, item := range o.Items {
 		_, err = tx.Exec(ctx, `
-			INSERT INTO order_items (id, order_id, product_name, quantity, unit_price)
-			VALUES ($1,$2,$3,$4,$5)`,
-			item.ID, o.ID, item.ProductName, item.Quantity, item.UnitPrice,
+			INSERT INTO order_items (id, order_id, product_id, product_name, quantity, unit_price)
+			VALUES ($1,$2,$3,$4,$5,$6)`,
+			item.ID, o.ID, item.ProductID, item.ProductName, item.Quantity, item.UnitPrice,
+		)
+		if err != nil {
+			return err
+		}
+		for pos, m := range item.Modifiers {
+			_, err = tx.Exec(ctx, `
+				INSERT INTO order_item_modifiers (order_item_id, option_id, group_name, name, price_delta, position)
+				VALUES ($1,$2,$3,$4,$5,$6)`,
+				item.ID, m.OptionID, m.GroupName, m.Name, m.PriceDelta, pos,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for pos, d := range o.Discounts {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO order_discounts (order_id, position, promotion_id, code, name, amount, funded_by)
+			VALUES ($1,$2,$3,$4,$5,$6,$7)`,
+			o.ID, pos, d.PromotionID, d.Code, d.Name, d.Amount, d.FundedBy,
 		)
 		if err != nil {
 			return err
@@ -53,35 +77,73 @@ func (r *OrderRepository) Create(ctx context.Context, o *domain.Order) error {
 func (r *OrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Order, error) {
 	o := &domain.Order{}
 	err := r.db.QueryRow(ctx, `
-		SELECT id, customer_id, business_id, total_amount, status, pin, stripe_payment_id, created_at, updated_at
+		SELECT id, customer_id, business_id, subtotal, discount_amount, tax_amount, fee_amount, total_amount, status,
+		       COALESCE(pin, ''), COALESCE(stripe_payment_id, ''), created_at, updated_at
 		FROM orders WHERE id = $1`, id,
 	).Scan(
-		&o.ID, &o.CustomerID, &o.BusinessID, &o.TotalAmount,
+		&o.ID, &o.CustomerID, &o.BusinessID, &o.Subtotal, &o.Discount, &o.Tax, &o.Fees, &o.TotalAmount,
 		&o.Status, &o.PIN, &o.StripePaymentID, &o.CreatedAt, &o.UpdatedAt,
 	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrOrderNotFound
+	}
 	if err != nil {
-		return nil, fmt.Errorf("order %s not found: %w", id, err)
+		return nil, fmt.Errorf("get order %s: %w", id, err)
 	}
 
-	items, err := r.getItems(ctx, id)
-	if err != nil {
+	if err := r.loadItems(ctx, []*domain.Order{o}); err != nil {
+		return nil, err
+	}
+	if err := r.loadDiscounts(ctx, []*domain.Order{o}); err != nil {
 		return nil, err
 	}
-	o.Items = items
 	return o, nil
 }
 
 func (r *OrderRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status domain.OrderStatus) error {
-	_, err := r.db.Exec(ctx,
+	tag, err := r.db.Exec(ctx,
 		`UPDATE orders SET status = $1, updated_at = NOW() WHERE id = $2`,
 		status, id,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrOrderNotFound
+	}
+	return nil
+}
+
+// TransitionStatus moves an order from one status to another and reports
+// whether it was in the from status. Concurrent transitions out of the same
+// status cannot both succeed.
+func (r *OrderRepository) TransitionStatus(ctx context.Context, id uuid.UUID, from, to domain.OrderStatus) (bool, error) {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE orders SET status = $3, updated_at = NOW() WHERE id = $1 AND status = $2`,
+		id, from, to,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *OrderRepository) MarkPaid(ctx context.Context, id uuid.UUID, paymentID string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE orders SET status = 'paid', stripe_payment_id = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'`,
+		id, paymentID,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
 }
 
 func (r *OrderRepository) ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]*domain.Order, error) {
 	rows, err := r.db.Query(ctx, `
-		SELECT id, customer_id, business_id, total_amount, status, stripe_payment_id, created_at, updated_at
+		SELECT id, customer_id, business_id, subtotal, discount_amount, tax_amount, fee_amount, total_amount, status,
+		       COALESCE(stripe_payment_id, ''), created_at, updated_at
 		FROM orders
 		WHERE customer_id = $1
 		ORDER BY created_at DESC`, customerID,
@@ -95,33 +157,131 @@ func (r *OrderRepository) ListByCustomer(ctx context.Context, customerID uuid.UU
 	for rows.Next() {
 		o := &domain.Order{}
 		if err := rows.Scan(
-			&o.ID, &o.CustomerID, &o.BusinessID, &o.TotalAmount,
+			&o.ID, &o.CustomerID, &o.BusinessID, &o.Subtotal, &o.Discount, &o.Tax, &o.Fees, &o.TotalAmount,
 			&o.Status, &o.StripePaymentID, &o.CreatedAt, &o.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
 		orders = append(orders, o)
 	}
-	return orders, nil
+	return orders, rows.Err()
 }
 
-func (r *OrderRepository) getItems(ctx context.Context, orderID uuid.UUID) ([]domain.OrderItem, error) {
+// ListByBusiness returns the business's orders in the given statuses, oldest
+// first, with their items but without the PIN.
+func (r *OrderRepository) ListByBusiness(ctx context.Context, businessID uuid.UUID, statuses []domain.OrderStatus) ([]*domain.Order, error) {
 	rows, err := r.db.Query(ctx, `
-		SELECT id, order_id, product_name, quantity, unit_price
-		FROM order_items WHERE order_id = $1`, orderID,
+		SELECT id, customer_id, business_id, subtotal, discount_amount, tax_amount, fee_amount, total_amount, status,
+		       COALESCE(stripe_payment_id, ''), created_at, updated_at
+		FROM orders
+		WHERE business_id = $1 AND status = ANY($2)
+		ORDER BY created_at, id`, businessID, statuses,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var items []domain.OrderItem
+	orders := []*domain.Order{}
 	for rows.Next() {
-		var item domain.OrderItem
-		if err := rows.Scan(&item.ID, &item.OrderID, &item.ProductName, &item.Quantity, &item.UnitPrice); err != nil {
+		o := &domain.Order{}
+		if err := rows.Scan(
+			&o.ID, &o.CustomerID, &o.BusinessID, &o.Subtotal, &o.Discount, &o.Tax, &o.Fees, &o.TotalAmount,
+			&o.Status, &o.StripePaymentID, &o.CreatedAt, &o.UpdatedAt,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, item)
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if err := r.loadItems(ctx, orders); err != nil {
+		return nil, err
+	}
+	return orders, r.loadDiscounts(ctx, orders)
+}
+
+// loadItems fills in the items of orders, and the modifiers of each item.
+func (r *OrderRepository) loadItems(ctx context.Context, orders []*domain.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	byID := make(map[uuid.UUID]*domain.Order, len(orders))
+	ids := make([]uuid.UUID, 0, len(orders))
+	for _, o := range orders {
+		byID[o.ID] = o
+		ids = append(ids, o.ID)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT i.id, i.order_id, i.product_id, i.product_name, i.quantity, i.unit_price,
+		       m.option_id, m.group_name, m.name, m.price_delta
+		FROM order_items i
+		LEFT JOIN order_item_modifiers m ON m.order_item_id = i.id
+		WHERE i.order_id = ANY($1)
+		ORDER BY i.order_id, i.id, m.position`, ids,
+	)
+	if err != nil {
+		return fmt.Errorf("load order items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item domain.OrderItem
+		var optionID *uuid.UUID
+		var groupName, name *string
+		var delta *float64
+		if err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.ProductName, &item.Quantity, &item.UnitPrice,
+			&optionID, &groupName, &name, &delta); err != nil {
+			return err
+		}
+		o := byID[item.OrderID]
+		if n := len(o.Items); n == 0 || o.Items[n-1].ID != item.ID {
+			o.Items = append(o.Items, item)
+		}
+		if name != nil {
+			last := &o.Items[len(o.Items)-1]
+			last.Modifiers = append(last.Modifiers, domain.OrderItemModifier{
+				OptionID: optionID, GroupName: *groupName, Name: *name, PriceDelta: *delta,
+			})
+		}
+	}
+	return rows.Err()
+}
+
+// loadDiscounts fills in the discount lines of orders.
+func (r *OrderRepository) loadDiscounts(ctx context.Context, orders []*domain.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	byID := make(map[uuid.UUID]*domain.Order, len(orders))
+	ids := make([]uuid.UUID, 0, len(orders))
+	for _, o := range orders {
+		byID[o.ID] = o
+		ids = append(ids, o.ID)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT order_id, promotion_id, code, name, amount, funded_by
+		FROM order_discounts
+		WHERE order_id = ANY($1)
+		ORDER BY order_id, position`, ids,
+	)
+	if err != nil {
+		return fmt.Errorf("load order discounts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID uuid.UUID
+		var d domain.OrderDiscount
+		if err := rows.Scan(&orderID, &d.PromotionID, &d.Code, &d.Name, &d.Amount, &d.FundedBy); err != nil {
+			return err
+		}
+		o := byID[orderID]
+		o.Discounts = append(o.Discounts, d)
 	}
-	return items, nil
+	return rows.Err()
 }
@@ -0,0 +1,257 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
+	"github.com/heptapegon/localpickup/internal/testinfra"
+)
+
+func newOrder(customerID, businessID uuid.UUID, createdAt time.Time, items ...domain.OrderItem) *domain.Order {
+	return &domain.Order{
+		ID:              uuid.New(),
+		CustomerID:      customerID,
+		BusinessID:      businessID,
+		Items:           items,
+		Subtotal:        4,
+		Tax:             0.25,
+		Fees:            0.25,
+		TotalAmount:     4.5,
+		Status:          domain.OrderStatusPaid,
+		PIN:             "123456",
+		StripePaymentID: "pi_test",
+		CreatedAt:       createdAt,
+		UpdatedAt:       createdAt,
+	}
+}
+
+func item(name string, qty int, price float64) domain.OrderItem {
+	return domain.OrderItem{ID: uuid.New(), ProductName: name, Quantity: qty, UnitPrice: price}
+}
+
+func TestOrderRepositoryCreateAndGet(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewOrderRepository(db)
+	ctx := context.Background()
+	customer := testinfra.InsertUser(t, db, "customer")
+	business := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+
+	o := newOrder(customer, business.ID, time.Now().UTC(), item("Concha", 2, 1.5), item("Bolillo", 2, 0.75))
+	if err := repo.Create(ctx, o); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, o.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error: %v", err)
+	}
+	if got.PIN != o.PIN || got.TotalAmount != o.TotalAmount || got.Subtotal != o.Subtotal || got.Tax != o.Tax || got.Fees != o.Fees || got.Status != o.Status || len(got.Items) != 2 {
+		t.Errorf("GetByID() = %+v, want %+v", got, o)
+	}
+	for _, it := range got.Items {
+		if it.OrderID != o.ID {
+			t.Errorf("item %s has order_id %s, want %s", it.ProductName, it.OrderID, o.ID)
+		}
+	}
+
+	if _, err := repo.GetByID(ctx, uuid.New()); !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Errorf("GetByID(unknown) error = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestOrderRepositoryCreateIsTransactional(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewOrderRepository(db)
+	ctx := context.Background()
+	customer := testinfra.InsertUser(t, db, "customer")
+	business := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+
+	tests := []struct {
+		name  string
+		order *domain.Order
+	}{
+		{
+			name:  "item violates quantity check",
+			order: newOrder(customer, business.ID, time.Now().UTC(), item("Concha", 1, 1.5), item("Bolillo", 0, 0.75)),
+		},
+		{
+			name:  "item violates price check",
+			order: newOrder(customer, business.ID, time.Now().UTC(), item("Concha", 1, -1)),
+		},
+		{
+			name:  "unknown business",
+			order: newOrder(customer, uuid.New(), time.Now().UTC(), item("Concha", 1, 1.5)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := repo.Create(ctx, tt.order); err == nil {
+				t.Fatal("Create() succeeded, want constraint violation")
+			}
+			// Neither the order row nor any earlier item may survive.
+			if _, err := repo.GetByID(ctx, tt.order.ID); !errors.Is(err, domain.ErrOrderNotFound) {
+				t.Errorf("order persisted after failed Create (err=%v)", err)
+			}
+			var items int
+			if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM order_items WHERE order_id = $1`, tt.order.ID).Scan(&items); err != nil {
+				t.Fatal(err)
+			}
+			if items != 0 {
+				t.Errorf("%d order_items persisted after failed Create", items)
+			}
+		})
+	}
+}
+
+func TestOrderRepositoryUpdateStatus(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewOrderRepository(db)
+	ctx := context.Background()
+	customer := testinfra.InsertUser(t, db, "customer")
+	business := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+
+	o := newOrder(customer, business.ID, time.Now().UTC(), item("Concha", 1, 1.5))
+	if err := repo.Create(ctx, o); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.UpdateStatus(ctx, o.ID, domain.OrderStatusCompleted); err != nil {
+		t.Fatalf("UpdateStatus() error: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, o.ID); got.Status != domain.OrderStatusCompleted {
+		t.Errorf("status = %q, want completed", got.Status)
+	}
+	if err := repo.UpdateStatus(ctx, o.ID, domain.OrderStatus("shipped")); err == nil {
+		t.Error("UpdateStatus(shipped) succeeded, want check violation")
+	}
+	if err := repo.UpdateStatus(ctx, uuid.New(), domain.OrderStatusCompleted); !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Errorf("UpdateStatus(unknown) error = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestOrderRepositoryMarkPaid(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewOrderRepository(db)
+	ctx := context.Background()
+	customer := testinfra.InsertUser(t, db, "customer")
+	business := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+
+	o := newOrder(customer, business.ID, time.Now().UTC(), item("Concha", 1, 1.5))
+	o.Status, o.StripePaymentID = domain.OrderStatusPending, ""
+	if err := repo.Create(ctx, o); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := repo.MarkPaid(ctx, o.ID, "pi_paid"); err != nil || !ok {
+		t.Fatalf("MarkPaid() = %v, %v, want true", ok, err)
+	}
+	if got, _ := repo.GetByID(ctx, o.ID); got.Status != domain.OrderStatusPaid || got.StripePaymentID != "pi_paid" {
+		t.Errorf("order = %s with %q, want paid with pi_paid", got.Status, got.StripePaymentID)
+	}
+	if ok, err := repo.MarkPaid(ctx, o.ID, "pi_again"); err != nil || ok {
+		t.Errorf("MarkPaid() on a paid order = %v, %v, want false", ok, err)
+	}
+	if got, _ := repo.GetByID(ctx, o.ID); got.StripePaymentID != "pi_paid" {
+		t.Errorf("payment ID = %q after a refused MarkPaid(), want pi_paid", got.StripePaymentID)
+	}
+}
+
+func TestOrderRepositoryListByCustomer(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewOrderRepository(db)
+	ctx := context.Background()
+	customer := testinfra.InsertUser(t, db, "customer")
+	other := testinfra.InsertUser(t, db, "customer")
+	business := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+
+	base := time.Now().UTC()
+	older := newOrder(customer, business.ID, base.Add(-time.Hour), item("Concha", 1, 1.5))
+	newer := newOrder(customer, business.ID, base, item("Concha", 1, 1.5))
+	foreign := newOrder(other, business.ID, base, item("Concha", 1, 1.5))
+	for _, o := range []*domain.Order{older, newer, foreign} {
+		if err := repo.Create(ctx, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := repo.ListByCustomer(ctx, customer)
+	if err != nil {
+		t.Fatalf("ListByCustomer() error: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != newer.ID || got[1].ID != older.ID {
+		t.Errorf("ListByCustomer() = %v, want [newer older]", got)
+	}
+}
+
+func TestOrderRepositoryItemModifiers(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewOrderRepository(db)
+	ctx := context.Background()
+	customer := testinfra.InsertUser(t, db, "customer")
+	business := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+
+	latte := item("Latte", 1, 3.5)
+	latte.Modifiers = []domain.OrderItemModifier{
+		{GroupName: "Leche", Name: "Avena", PriceDelta: 0.5},
+		{GroupName: "Extras", Name: "Shot extra", PriceDelta: 0.75},
+	}
+	o := newOrder(customer, business.ID, time.Now().UTC(), latte, item("Concha", 2, 1.5))
+	if err := repo.Create(ctx, o); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, o.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, it := range got.Items {
+		want := 0
+		if it.ID == latte.ID {
+			want = 2
+		}
+		if len(it.Modifiers) != want {
+			t.Errorf("%s has %d modifiers, want %d", it.ProductName, len(it.Modifiers), want)
+		}
+		if want == 2 && (it.Modifiers[0].Name != "Avena" || it.Modifiers[1].PriceDelta != 0.75) {
+			t.Errorf("modifiers = %+v, want Avena then Shot extra", it.Modifiers)
+		}
+	}
+}
+
+func TestOrderRepositoryListByBusiness(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewOrderRepository(db)
+	ctx := context.Background()
+	customer := testinfra.InsertUser(t, db, "customer")
+	owner := testinfra.InsertUser(t, db, "business_owner")
+	business := seedBusiness(t, db, owner, "rosa", true)
+	other := seedBusiness(t, db, owner, "otra", true)
+
+	base := time.Now().UTC()
+	newer := newOrder(customer, business.ID, base, item("Concha", 1, 1.5))
+	older := newOrder(customer, business.ID, base.Add(-time.Hour), item("Bolillo", 2, 0.75), item("Concha", 1, 1.5))
+	done := newOrder(customer, business.ID, base, item("Concha", 1, 1.5))
+	done.Status = domain.OrderStatusCompleted
+	foreign := newOrder(customer, other.ID, base, item("Concha", 1, 1.5))
+	for _, o := range []*domain.Order{newer, older, done, foreign} {
+		if err := repo.Create(ctx, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := repo.ListByBusiness(ctx, business.ID, []domain.OrderStatus{domain.OrderStatusPaid, domain.OrderStatusReady})
+	if err != nil {
+		t.Fatalf("ListByBusiness() error: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != older.ID || got[1].ID != newer.ID {
+		t.Fatalf("ListByBusiness() = %v, want [older newer]", got)
+	}
+	if len(got[0].Items) != 2 || got[0].PIN != "" {
+		t.Errorf("queued order = %+v, want 2 items and no PIN", got[0])
+	}
+}
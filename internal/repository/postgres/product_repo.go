@@ -0,0 +1,395 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+type ProductRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewProductRepository(db *pgxpool.Pool) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+const productColumns = `id, business_id, name, description, price, is_available, stock, daily_stock, image_key, created_at, updated_at`
+
+func scanProduct(row pgx.Row) (*domain.Product, error) {
+	p := &domain.Product{}
+	err := row.Scan(&p.ID, &p.BusinessID, &p.Name, &p.Description, &p.Price, &p.IsAvailable,
+		&p.Stock, &p.DailyStock, &p.ImageKey, &p.CreatedAt, &p.UpdatedAt)
+	return p, err
+}
+
+// Create inserts p with its modifier groups. A product with a daily stock
+// counts as reset today.
+func (r *ProductRepository) Create(ctx context.Context, p *domain.Product) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertProduct(ctx, tx, p); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func insertProduct(ctx context.Context, tx pgx.Tx, p *domain.Product) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO products (`+productColumns+`, stock_reset_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11, CASE WHEN $8::int IS NULL THEN NULL ELSE NOW() END)`,
+		p.ID, p.BusinessID, p.Name, p.Description, p.Price, p.IsAvailable,
+		p.Stock, p.DailyStock, p.ImageKey, p.CreatedAt, p.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return saveModifierGroups(ctx, tx, p)
+}
+
+func (r *ProductRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	p, err := scanProduct(r.db.QueryRow(ctx, `SELECT `+productColumns+` FROM products WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrProductNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get product %s: %w", id, err)
+	}
+	if err := r.loadModifierGroups(ctx, []*domain.Product{p}); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Update replaces the editable fields of p, modifier groups included. Stock
+// is only written when it differs from readStock (see service.ProductStore).
+func (r *ProductRepository) Update(ctx context.Context, p *domain.Product, readStock *int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := updateProduct(ctx, tx, p, readStock); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func updateProduct(ctx context.Context, tx pgx.Tx, p *domain.Product, readStock *int) error {
+	tag, err := tx.Exec(ctx, `
+		UPDATE products SET name = $2, description = $3, price = $4, is_available = $5,
+		       daily_stock = $6, updated_at = $7,
+		       stock_reset_at = CASE WHEN $6::int IS NULL THEN NULL ELSE COALESCE(stock_reset_at, NOW()) END
+		WHERE id = $1`,
+		p.ID, p.Name, p.Description, p.Price, p.IsAvailable, p.DailyStock, p.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrProductNotFound
+	}
+	if err := updateStock(ctx, tx, p, readStock); err != nil {
+		return err
+	}
+	return saveModifierGroups(ctx, tx, p)
+}
+
+// updateStock writes an edited stock. Orders may have reserved or released
+// units since readStock was read, so a stock that stays limited moves by the
+// edit instead of being overwritten; the row lock of the UPDATE orders it
+// with ReserveStock. p.Stock is set to the result.
+func updateStock(ctx context.Context, tx pgx.Tx, p *domain.Product, readStock *int) error {
+	if (p.Stock == nil) == (readStock == nil) && (p.Stock == nil || *p.Stock == *readStock) {
+		return tx.QueryRow(ctx, `SELECT stock FROM products WHERE id = $1`, p.ID).Scan(&p.Stock)
+	}
+	var delta *int
+	if p.Stock != nil && readStock != nil {
+		d := *p.Stock - *readStock
+		delta = &d
+	}
+	return tx.QueryRow(ctx, `
+		UPDATE products
+		SET stock = CASE WHEN $3::int IS NULL OR stock IS NULL THEN $2::int ELSE GREATEST(stock + $3, 0) END
+		WHERE id = $1
+		RETURNING stock`,
+		p.ID, p.Stock, delta,
+	).Scan(&p.Stock)
+}
+
+// SetImage records the storage prefix of the product picture; "" clears it.
+// Update leaves the picture alone.
+func (r *ProductRepository) SetImage(ctx context.Context, id uuid.UUID, key string) error {
+	tag, err := r.db.Exec(ctx, `UPDATE products SET image_key = $1, updated_at = NOW() WHERE id = $2`, key, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrProductNotFound
+	}
+	return nil
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM products WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrProductNotFound
+	}
+	return nil
+}
+
+// ApplyMenu deletes, updates and creates products in one transaction; if
+// any step fails the catalog is left as it was.
+func (r *ProductRepository) ApplyMenu(ctx context.Context, create []*domain.Product, update []domain.ProductUpdate, remove []uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if len(remove) > 0 {
+		if _, err := tx.Exec(ctx, `DELETE FROM products WHERE id = ANY($1)`, remove); err != nil {
+			return fmt.Errorf("delete products: %w", err)
+		}
+	}
+	for _, u := range update {
+		if err := updateProduct(ctx, tx, u.Product, u.ReadStock); err != nil {
+			return fmt.Errorf("update product %q: %w", u.Product.Name, err)
+		}
+	}
+	for _, p := range create {
+		if err := insertProduct(ctx, tx, p); err != nil {
+			return fmt.Errorf("create product %q: %w", p.Name, err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// ListByBusiness returns a business's products ordered by name.
+func (r *ProductRepository) ListByBusiness(ctx context.Context, businessID uuid.UUID) ([]*domain.Product, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+productColumns+` FROM products WHERE business_id = $1 ORDER BY name, id`, businessID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*domain.Product{}
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return out, r.loadModifierGroups(ctx, out)
+}
+
+// GetByIDs returns the products among ids, in no particular order.
+func (r *ProductRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Product, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+productColumns+` FROM products WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("get products: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*domain.Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return out, r.loadModifierGroups(ctx, out)
+}
+
+// saveModifierGroups makes p.ModifierGroups the stored groups of p. Groups and
+// options no longer listed are deleted and the rest are upserted, so options
+// that keep their ID stay linked to past order lines.
+func saveModifierGroups(ctx context.Context, tx pgx.Tx, p *domain.Product) error {
+	groupIDs := make([]uuid.UUID, 0, len(p.ModifierGroups))
+	var optionIDs []uuid.UUID
+	for _, g := range p.ModifierGroups {
+		groupIDs = append(groupIDs, g.ID)
+		for _, o := range g.Options {
+			optionIDs = append(optionIDs, o.ID)
+		}
+	}
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM modifier_groups WHERE product_id = $1 AND id <> ALL($2)`, p.ID, groupIDs,
+	); err != nil {
+		return fmt.Errorf("delete modifier groups: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM modifier_options o USING modifier_groups g
+		WHERE o.group_id = g.id AND g.product_id = $1 AND o.id <> ALL($2)`, p.ID, optionIDs,
+	); err != nil {
+		return fmt.Errorf("delete modifier options: %w", err)
+	}
+
+	for gi, g := range p.ModifierGroups {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO modifier_groups (id, product_id, name, min_select, max_select, position)
+			VALUES ($1,$2,$3,$4,$5,$6)
+			ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, min_select = EXCLUDED.min_select,
+			       max_select = EXCLUDED.max_select, position = EXCLUDED.position
+			WHERE modifier_groups.product_id = EXCLUDED.product_id`,
+			g.ID, p.ID, g.Name, g.MinSelect, g.MaxSelect, gi,
+		); err != nil {
+			return fmt.Errorf("save modifier group %q: %w", g.Name, err)
+		}
+		for oi, o := range g.Options {
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO modifier_options (id, group_id, name, price_delta, is_available, position)
+				VALUES ($1,$2,$3,$4,$5,$6)
+				ON CONFLICT (id) DO UPDATE SET group_id = EXCLUDED.group_id, name = EXCLUDED.name,
+				       price_delta = EXCLUDED.price_delta, is_available = EXCLUDED.is_available,
+				       position = EXCLUDED.position`,
+				o.ID, g.ID, o.Name, o.PriceDelta, o.IsAvailable, oi,
+			); err != nil {
+				return fmt.Errorf("save modifier option %q: %w", o.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// loadModifierGroups fills in the modifier groups of products with one query.
+func (r *ProductRepository) loadModifierGroups(ctx context.Context, products []*domain.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	byID := make(map[uuid.UUID]*domain.Product, len(products))
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, p := range products {
+		p.ModifierGroups = []domain.ModifierGroup{}
+		byID[p.ID] = p
+		ids = append(ids, p.ID)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT g.product_id, g.id, g.name, g.min_select, g.max_select,
+		       o.id, o.name, o.price_delta, o.is_available
+		FROM modifier_groups g
+		JOIN modifier_options o ON o.group_id = g.id
+		WHERE g.product_id = ANY($1)
+		ORDER BY g.product_id, g.position, g.id, o.position, o.id`, ids,
+	)
+	if err != nil {
+		return fmt.Errorf("load modifier groups: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID uuid.UUID
+		var g domain.ModifierGroup
+		var o domain.ModifierOption
+		if err := rows.Scan(&productID, &g.ID, &g.Name, &g.MinSelect, &g.MaxSelect,
+			&o.ID, &o.Name, &o.PriceDelta, &o.IsAvailable); err != nil {
+			return err
+		}
+		p := byID[productID]
+		if n := len(p.ModifierGroups); n == 0 || p.ModifierGroups[n-1].ID != g.ID {
+			p.ModifierGroups = append(p.ModifierGroups, g)
+		}
+		last := &p.ModifierGroups[len(p.ModifierGroups)-1]
+		last.Options = append(last.Options, o)
+	}
+	return rows.Err()
+}
+
+// ReserveStock takes every line out of stock, or none of them: it fails with
+// ErrOutOfStock if any tracked product has fewer units than requested.
+// Unlimited products always succeed. Rows are locked in ID order so
+// concurrent orders cannot deadlock.
+func (r *ProductRepository) ReserveStock(ctx context.Context, lines []domain.StockLine) error {
+	lines = sortedLines(lines)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, l := range lines {
+		tag, err := tx.Exec(ctx, `
+			UPDATE products SET stock = stock - $2
+			WHERE id = $1 AND (stock IS NULL OR stock >= $2)`,
+			l.ProductID, l.Quantity,
+		)
+		if err != nil {
+			return fmt.Errorf("reserve stock of %s: %w", l.ProductID, err)
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrOutOfStock
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// ReleaseStock puts lines reserved at reservedAt back in stock. Units reserved
+// before the product's last daily reset are not returned: the reset already
+// replenished it. Unlimited and deleted products are skipped.
+func (r *ProductRepository) ReleaseStock(ctx context.Context, lines []domain.StockLine, reservedAt time.Time) error {
+	lines = sortedLines(lines)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, l := range lines {
+		_, err := tx.Exec(ctx, `
+			UPDATE products SET stock = stock + $2
+			WHERE id = $1 AND stock IS NOT NULL AND (stock_reset_at IS NULL OR stock_reset_at <= $3)`,
+			l.ProductID, l.Quantity, reservedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("release stock of %s: %w", l.ProductID, err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// ResetDailyStock refills every product with a daily stock that was last reset
+// before the given time, and returns how many were refilled.
+func (r *ProductRepository) ResetDailyStock(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE products SET stock = daily_stock, stock_reset_at = NOW()
+		WHERE daily_stock IS NOT NULL AND (stock_reset_at IS NULL OR stock_reset_at < $1)`,
+		before,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("reset daily stock: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func sortedLines(lines []domain.StockLine) []domain.StockLine {
+	out := append([]domain.StockLine(nil), lines...)
+	sort.Slice(out, func(i, j int) bool { return out[i].ProductID.String() < out[j].ProductID.String() })
+	return out
+}
@@ -0,0 +1,275 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
+	"github.com/heptapegon/localpickup/internal/testinfra"
+)
+
+func seedProduct(t *testing.T, db *pgxpool.Pool, businessID uuid.UUID, name string, price float64) *domain.Product {
+	t.Helper()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	p := &domain.Product{
+		ID: uuid.New(), BusinessID: businessID, Name: name, Description: "desc " + name,
+		Price: price, IsAvailable: true, CreatedAt: now, UpdatedAt: now,
+	}
+	if err := postgresrepo.NewProductRepository(db).Create(context.Background(), p); err != nil {
+		t.Fatalf("seed product %s: %v", name, err)
+	}
+	return p
+}
+
+func TestProductRepositoryCRUD(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewProductRepository(db)
+	ctx := context.Background()
+	owner := testinfra.InsertUser(t, db, "business_owner")
+	shop := seedBusiness(t, db, owner, "rosa", true)
+
+	concha := seedProduct(t, db, shop.ID, "Concha", 18.50)
+	bolillo := seedProduct(t, db, shop.ID, "Bolillo", 4)
+
+	got, err := repo.GetByID(ctx, concha.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error: %v", err)
+	}
+	if got.Name != concha.Name || got.Price != concha.Price || !got.CreatedAt.Equal(concha.CreatedAt) {
+		t.Errorf("GetByID() = %+v, want %+v", got, concha)
+	}
+
+	concha.Price, concha.IsAvailable = 20, false
+	if err := repo.Update(ctx, concha, nil); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	list, err := repo.ListByBusiness(ctx, shop.ID)
+	if err != nil {
+		t.Fatalf("ListByBusiness() error: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != bolillo.ID || list[1].Price != 20 || list[1].IsAvailable {
+		t.Errorf("ListByBusiness() = %+v, want bolillo then the updated concha", list)
+	}
+
+	if err := repo.Delete(ctx, bolillo.ID); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	for name, err := range map[string]error{
+		"get deleted":    func() error { _, err := repo.GetByID(ctx, bolillo.ID); return err }(),
+		"delete twice":   repo.Delete(ctx, bolillo.ID),
+		"update unknown": repo.Update(ctx, &domain.Product{ID: uuid.New()}, nil),
+	} {
+		if !errors.Is(err, domain.ErrProductNotFound) {
+			t.Errorf("%s: err = %v, want ErrProductNotFound", name, err)
+		}
+	}
+}
+
+func TestProductRepositorySetImage(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewProductRepository(db)
+	ctx := context.Background()
+	shop := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+	concha := seedProduct(t, db, shop.ID, "Concha", 18.50)
+
+	key := "businesses/" + shop.ID.String() + "/products/" + concha.ID.String() + "/upload"
+	if err := repo.SetImage(ctx, concha.ID, key); err != nil {
+		t.Fatalf("SetImage() error: %v", err)
+	}
+	concha.Price = 20 // an edit made with a copy loaded before the upload
+	if err := repo.Update(ctx, concha, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repo.GetByID(ctx, concha.ID); got.ImageKey != key || got.Price != 20 {
+		t.Errorf("GetByID() = %+v, want ImageKey %q kept through Update", got, key)
+	}
+	if err := repo.SetImage(ctx, uuid.New(), key); !errors.Is(err, domain.ErrProductNotFound) {
+		t.Errorf("SetImage(unknown) error = %v, want ErrProductNotFound", err)
+	}
+}
+
+func TestProductRepositoryStock(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewProductRepository(db)
+	ctx := context.Background()
+	shop := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+
+	croissant := seedProduct(t, db, shop.ID, "Croissant", 35)
+	twelve := 12
+	croissant.Stock, croissant.DailyStock = &twelve, &twelve
+	if err := repo.Update(ctx, croissant, nil); err != nil {
+		t.Fatal(err)
+	}
+	coffee := seedProduct(t, db, shop.ID, "Café", 30) // unlimited
+
+	stock := func() int {
+		t.Helper()
+		p, err := repo.GetByID(ctx, croissant.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *p.Stock
+	}
+
+	reservedAt := time.Now()
+	if err := repo.ReserveStock(ctx, []domain.StockLine{{ProductID: croissant.ID, Quantity: 10}, {ProductID: coffee.ID, Quantity: 50}}); err != nil {
+		t.Fatalf("ReserveStock() error: %v", err)
+	}
+	if got := stock(); got != 2 {
+		t.Errorf("stock after reserving 10 = %d, want 2", got)
+	}
+
+	// All or nothing: the unlimited line is not applied when another fails.
+	err := repo.ReserveStock(ctx, []domain.StockLine{{ProductID: coffee.ID, Quantity: 1}, {ProductID: croissant.ID, Quantity: 3}})
+	if !errors.Is(err, domain.ErrOutOfStock) {
+		t.Errorf("ReserveStock(3 of 2) error = %v, want ErrOutOfStock", err)
+	}
+	if got := stock(); got != 2 {
+		t.Errorf("stock after failed reservation = %d, want 2", got)
+	}
+
+	if err := repo.ReleaseStock(ctx, []domain.StockLine{{ProductID: croissant.ID, Quantity: 4}}, reservedAt); err != nil {
+		t.Fatalf("ReleaseStock() error: %v", err)
+	}
+	if got := stock(); got != 6 {
+		t.Errorf("stock after releasing 4 = %d, want 6", got)
+	}
+
+	n, err := repo.ResetDailyStock(ctx, time.Now().Add(time.Hour))
+	if err != nil || n != 1 || stock() != 12 {
+		t.Errorf("ResetDailyStock() = %d, %v; stock %d, want 1 product reset to 12", n, err, stock())
+	}
+	// Units reserved before the reset are not returned on top of it.
+	if err := repo.ReleaseStock(ctx, []domain.StockLine{{ProductID: croissant.ID, Quantity: 6}}, reservedAt); err != nil {
+		t.Fatal(err)
+	}
+	if got := stock(); got != 12 {
+		t.Errorf("stock after releasing a pre-reset reservation = %d, want 12", got)
+	}
+	// Edits made with a copy read before a reservation keep it.
+	read, err := repo.GetByID(ctx, croissant.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ReserveStock(ctx, []domain.StockLine{{ProductID: croissant.ID, Quantity: 5}}); err != nil {
+		t.Fatal(err)
+	}
+	read.Price = 38
+	if err := repo.Update(ctx, read, read.Stock); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	if got := stock(); got != 7 || *read.Stock != 7 {
+		t.Errorf("stock after an edit leaving it alone = %d (returned %d), want 7", got, *read.Stock)
+	}
+	readStock, twenty := read.Stock, 20
+	read.Stock = &twenty
+	if err := repo.ReserveStock(ctx, []domain.StockLine{{ProductID: croissant.ID, Quantity: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(ctx, read, readStock); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	if got := stock(); got != 18 || *read.Stock != 18 {
+		t.Errorf("stock after restocking 7 to 20 with 2 sold meanwhile = %d (returned %d), want 18", got, *read.Stock)
+	}
+}
+
+func TestProductRepositoryModifierGroups(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewProductRepository(db)
+	ctx := context.Background()
+	shop := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+
+	cafe := seedProduct(t, db, shop.ID, "Café de olla", 30)
+	size := domain.ModifierGroup{ID: uuid.New(), Name: "Tamaño", MinSelect: 1, MaxSelect: 1, Options: []domain.ModifierOption{
+		{ID: uuid.New(), Name: "Chico", IsAvailable: true},
+		{ID: uuid.New(), Name: "Grande", PriceDelta: 8, IsAvailable: true},
+	}}
+	extras := domain.ModifierGroup{ID: uuid.New(), Name: "Extras", MaxSelect: 2, Options: []domain.ModifierOption{
+		{ID: uuid.New(), Name: "Canela", PriceDelta: 2.5, IsAvailable: true},
+		{ID: uuid.New(), Name: "Piloncillo", PriceDelta: 3, IsAvailable: false},
+	}}
+	cafe.ModifierGroups = []domain.ModifierGroup{size, extras}
+	if err := repo.Update(ctx, cafe, nil); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, cafe.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.ModifierGroups) != 2 || got.ModifierGroups[0].ID != size.ID || got.ModifierGroups[1].Name != "Extras" {
+		t.Fatalf("groups = %+v, want Tamaño then Extras", got.ModifierGroups)
+	}
+	if o := got.ModifierGroups[1].Options[1]; o.Name != "Piloncillo" || o.PriceDelta != 3 || o.IsAvailable {
+		t.Errorf("option = %+v, want the unavailable piloncillo", o)
+	}
+
+	// Drop the extras, rename an option in place and add another.
+	size.Options[1].Name = "Grande (16 oz)"
+	size.Options = append(size.Options, domain.ModifierOption{ID: uuid.New(), Name: "Mediano", PriceDelta: 4, IsAvailable: true})
+	cafe.ModifierGroups = []domain.ModifierGroup{size}
+	if err := repo.Update(ctx, cafe, nil); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	list, err := repo.GetByIDs(ctx, []uuid.UUID{cafe.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := list[0].ModifierGroups
+	if len(groups) != 1 || len(groups[0].Options) != 3 || groups[0].Options[1].ID != size.Options[1].ID || groups[0].Options[1].Name != "Grande (16 oz)" {
+		t.Errorf("groups after update = %+v", groups)
+	}
+	var orphans int
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM modifier_options WHERE id = ANY($1)`,
+		[]uuid.UUID{extras.Options[0].ID, extras.Options[1].ID}).Scan(&orphans); err != nil {
+		t.Fatal(err)
+	}
+	if orphans != 0 {
+		t.Errorf("%d options of the removed group survived", orphans)
+	}
+
+	if plain, _ := repo.GetByID(ctx, seedProduct(t, db, shop.ID, "Bolillo", 4).ID); plain.ModifierGroups == nil || len(plain.ModifierGroups) != 0 {
+		t.Errorf("product without modifiers has groups %#v, want empty", plain.ModifierGroups)
+	}
+}
+
+func TestProductRepositoryApplyMenu(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewProductRepository(db)
+	ctx := context.Background()
+	shop := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+
+	concha := seedProduct(t, db, shop.ID, "Concha", 18.5)
+	bolillo := seedProduct(t, db, shop.ID, "Bolillo", 4)
+	now := time.Now().UTC()
+	dona := &domain.Product{ID: uuid.New(), BusinessID: shop.ID, Name: "Dona", Price: 12, IsAvailable: true, CreatedAt: now, UpdatedAt: now}
+
+	// A failing step rolls back the earlier ones.
+	ghost := &domain.Product{ID: uuid.New(), BusinessID: shop.ID, Name: "Fantasma", Price: 1}
+	if err := repo.ApplyMenu(ctx, []*domain.Product{dona}, []domain.ProductUpdate{{Product: ghost}}, []uuid.UUID{bolillo.ID}); !errors.Is(err, domain.ErrProductNotFound) {
+		t.Fatalf("ApplyMenu() error = %v, want ErrProductNotFound", err)
+	}
+	if list, _ := repo.ListByBusiness(ctx, shop.ID); len(list) != 2 {
+		t.Fatalf("catalog after failed ApplyMenu = %+v, want it untouched", list)
+	}
+
+	concha.Price = 20
+	if err := repo.ApplyMenu(ctx, []*domain.Product{dona}, []domain.ProductUpdate{{Product: concha}}, []uuid.UUID{bolillo.ID}); err != nil {
+		t.Fatalf("ApplyMenu() error: %v", err)
+	}
+	list, err := repo.ListByBusiness(ctx, shop.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ID != concha.ID || list[0].Price != 20 || list[1].ID != dona.ID {
+		t.Errorf("catalog = %+v, want the updated concha and the new dona", list)
+	}
+}
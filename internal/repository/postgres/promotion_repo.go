@@ -0,0 +1,212 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+type PromotionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPromotionRepository(db *pgxpool.Pool) *PromotionRepository {
+	return &PromotionRepository{db: db}
+}
+
+const promotionColumns = `id, code, name, description, business_id, kind, value, max_discount, buy_quantity, get_quantity,
+	first_order, min_subtotal, category, starts_at, ends_at, weekdays,
+	max_redemptions, max_per_customer, redemption_count, is_active, created_at, updated_at`
+
+func scanPromotion(row pgx.Row) (*domain.Promotion, error) {
+	p := &domain.Promotion{}
+	var weekdays []int16
+	if err := row.Scan(
+		&p.ID, &p.Code, &p.Name, &p.Description, &p.BusinessID, &p.Kind, &p.Value, &p.MaxDiscount,
+		&p.BuyQuantity, &p.GetQuantity,
+		&p.Rules.FirstOrder, &p.Rules.MinSubtotal, &p.Rules.Category, &p.Rules.StartsAt, &p.Rules.EndsAt, &weekdays,
+		&p.MaxRedemptions, &p.MaxPerCustomer, &p.RedemptionCount, &p.IsActive, &p.CreatedAt, &p.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	for _, d := range weekdays {
+		p.Rules.Weekdays = append(p.Rules.Weekdays, time.Weekday(d))
+	}
+	return p, nil
+}
+
+func collectPromotions(rows pgx.Rows) ([]*domain.Promotion, error) {
+	defer rows.Close()
+	var out []*domain.Promotion
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan promotion: %w", err)
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// Create stores a promotion. A code already in use is ErrPromoCodeTaken.
+func (r *PromotionRepository) Create(ctx context.Context, p *domain.Promotion) error {
+	weekdays := make([]int16, 0, len(p.Rules.Weekdays))
+	for _, d := range p.Rules.Weekdays {
+		weekdays = append(weekdays, int16(d))
+	}
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO promotions
+		    (id, code, name, description, business_id, kind, value, max_discount, buy_quantity, get_quantity,
+		     first_order, min_subtotal, category, starts_at, ends_at, weekdays,
+		     max_redemptions, max_per_customer, is_active, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21)
+		ON CONFLICT (code) DO NOTHING`,
+		p.ID, p.Code, p.Name, p.Description, p.BusinessID, p.Kind, p.Value, p.MaxDiscount, p.BuyQuantity, p.GetQuantity,
+		p.Rules.FirstOrder, p.Rules.MinSubtotal, p.Rules.Category, p.Rules.StartsAt, p.Rules.EndsAt, weekdays,
+		p.MaxRedemptions, p.MaxPerCustomer, p.IsActive, p.CreatedAt, p.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create promotion: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrPromoCodeTaken
+	}
+	return nil
+}
+
+func (r *PromotionRepository) get(ctx context.Context, where string, arg any) (*domain.Promotion, error) {
+	p, err := scanPromotion(r.db.QueryRow(ctx, `SELECT `+promotionColumns+` FROM promotions WHERE `+where, arg))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrPromotionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get promotion: %w", err)
+	}
+	return p, nil
+}
+
+func (r *PromotionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Promotion, error) {
+	return r.get(ctx, `id = $1`, id)
+}
+
+func (r *PromotionRepository) GetByCode(ctx context.Context, code string) (*domain.Promotion, error) {
+	return r.get(ctx, `code = $1`, code)
+}
+
+func (r *PromotionRepository) ListAutomatic(ctx context.Context, businessID uuid.UUID) ([]*domain.Promotion, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+promotionColumns+` FROM promotions
+		WHERE code IS NULL AND is_active AND (business_id IS NULL OR business_id = $1)
+		ORDER BY created_at, id`, businessID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return collectPromotions(rows)
+}
+
+func (r *PromotionRepository) List(ctx context.Context, businessID *uuid.UUID) ([]*domain.Promotion, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+promotionColumns+` FROM promotions
+		WHERE $1::uuid IS NULL OR business_id = $1
+		ORDER BY created_at DESC, id`, businessID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return collectPromotions(rows)
+}
+
+func (r *PromotionRepository) SetActive(ctx context.Context, id uuid.UUID, active bool) (*domain.Promotion, error) {
+	p, err := scanPromotion(r.db.QueryRow(ctx, `
+		UPDATE promotions SET is_active = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+promotionColumns, id, active,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrPromotionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("set promotion %s active: %w", id, err)
+	}
+	return p, nil
+}
+
+func (r *PromotionRepository) CustomerRedemptions(ctx context.Context, promotionID, customerID uuid.UUID) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND customer_id = $2`,
+		promotionID, customerID,
+	).Scan(&n)
+	return n, err
+}
+
+// Redeem takes one use of each promotion in a single transaction. The
+// conditional increment of redemption_count enforces the global limit and
+// locks the promotion row until commit, so concurrent redemptions of the
+// same promotion are serialized and the per-customer count read after it is
+// current.
+func (r *PromotionRepository) Redeem(ctx context.Context, orderID, customerID uuid.UUID, discounts []domain.OrderDiscount) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, d := range discounts {
+		if d.PromotionID == nil {
+			continue
+		}
+		exhausted := domain.ErrPromotionExhausted.WithMessage(fmt.Sprintf("%q is no longer available", d.Name))
+		var maxPerCustomer *int
+		err := tx.QueryRow(ctx, `
+			UPDATE promotions SET redemption_count = redemption_count + 1
+			WHERE id = $1 AND is_active AND (max_redemptions IS NULL OR redemption_count < max_redemptions)
+			RETURNING max_per_customer`, *d.PromotionID,
+		).Scan(&maxPerCustomer)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return exhausted
+		}
+		if err != nil {
+			return fmt.Errorf("redeem promotion %s: %w", *d.PromotionID, err)
+		}
+		if maxPerCustomer != nil {
+			var used int
+			if err := tx.QueryRow(ctx, `
+				SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND customer_id = $2`,
+				*d.PromotionID, customerID,
+			).Scan(&used); err != nil {
+				return err
+			}
+			if used >= *maxPerCustomer {
+				return exhausted
+			}
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO promotion_redemptions (promotion_id, order_id, customer_id, amount)
+			VALUES ($1,$2,$3,$4)`,
+			*d.PromotionID, orderID, customerID, d.Amount,
+		); err != nil {
+			return fmt.Errorf("record redemption: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PromotionRepository) Release(ctx context.Context, orderID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+		WITH released AS (
+			DELETE FROM promotion_redemptions WHERE order_id = $1 RETURNING promotion_id
+		)
+		UPDATE promotions p SET redemption_count = redemption_count - 1
+		FROM released WHERE p.id = released.promotion_id`, orderID,
+	)
+	return err
+}
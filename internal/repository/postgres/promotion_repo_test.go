@@ -0,0 +1,179 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
+	"github.com/heptapegon/localpickup/internal/testinfra"
+)
+
+func newPromotion(name string, code *string, businessID *uuid.UUID, createdAt time.Time) *domain.Promotion {
+	return &domain.Promotion{
+		ID: uuid.New(), Code: code, Name: name, BusinessID: businessID,
+		Kind: domain.PromotionPercent, Value: 10, IsActive: true,
+		CreatedAt: createdAt, UpdatedAt: createdAt,
+	}
+}
+
+func TestPromotionRepositoryRoundTrip(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewPromotionRepository(db)
+	ctx := context.Background()
+	shop := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+	other := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "otra", true)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	code := "ROSA10"
+	coupon := newPromotion("coupon", &code, &shop.ID, now)
+	coupon.Kind, coupon.Value, coupon.BuyQuantity, coupon.GetQuantity = domain.PromotionBuyXGetY, 0, 1, 1
+	coupon.Rules = domain.PromotionRules{
+		FirstOrder: true, MinSubtotal: 5, Category: "bakery", StartsAt: &now,
+		Weekdays: []time.Weekday{time.Tuesday, time.Saturday},
+	}
+	limit := 3
+	coupon.MaxPerCustomer = &limit
+	platform := newPromotion("platform", nil, nil, now.Add(time.Second))
+	mine := newPromotion("mine", nil, &shop.ID, now.Add(2*time.Second))
+	theirs := newPromotion("theirs", nil, &other.ID, now.Add(3*time.Second))
+	paused := newPromotion("paused", nil, nil, now.Add(4*time.Second))
+	paused.IsActive = false
+	for _, p := range []*domain.Promotion{coupon, platform, mine, theirs, paused} {
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatalf("Create(%s) error: %v", p.Name, err)
+		}
+	}
+	dup := newPromotion("dup", &code, nil, now)
+	if err := repo.Create(ctx, dup); !errors.Is(err, domain.ErrPromoCodeTaken) {
+		t.Errorf("Create(same code) error = %v, want ErrPromoCodeTaken", err)
+	}
+
+	got, err := repo.GetByCode(ctx, "ROSA10")
+	if err != nil {
+		t.Fatalf("GetByCode() error: %v", err)
+	}
+	if got.ID != coupon.ID || got.Kind != domain.PromotionBuyXGetY || got.GetQuantity != 1 || !got.Rules.FirstOrder ||
+		got.Rules.Category != "bakery" || len(got.Rules.Weekdays) != 2 || got.Rules.Weekdays[1] != time.Saturday ||
+		got.MaxPerCustomer == nil || *got.MaxPerCustomer != 3 || got.MaxRedemptions != nil {
+		t.Errorf("GetByCode() = %+v, want the stored coupon", got)
+	}
+	if _, err := repo.GetByCode(ctx, "NOPE"); !errors.Is(err, domain.ErrPromotionNotFound) {
+		t.Errorf("GetByCode(unknown) error = %v, want ErrPromotionNotFound", err)
+	}
+
+	automatic, err := repo.ListAutomatic(ctx, shop.ID)
+	if err != nil {
+		t.Fatalf("ListAutomatic() error: %v", err)
+	}
+	if len(automatic) != 2 || automatic[0].ID != platform.ID || automatic[1].ID != mine.ID {
+		t.Errorf("ListAutomatic() = %v, want the platform and the shop's active promotions", automatic)
+	}
+	listed, err := repo.List(ctx, &shop.ID)
+	if err != nil || len(listed) != 2 || listed[0].ID != mine.ID || listed[1].ID != coupon.ID {
+		t.Errorf("List(shop) = %v, %v, want mine then the coupon", listed, err)
+	}
+	if all, err := repo.List(ctx, nil); err != nil || len(all) != 5 {
+		t.Errorf("List(nil) = %d promotions, %v, want 5", len(all), err)
+	}
+
+	resumed, err := repo.SetActive(ctx, paused.ID, true)
+	if err != nil || !resumed.IsActive {
+		t.Errorf("SetActive() = %+v, %v, want it active", resumed, err)
+	}
+	if _, err := repo.SetActive(ctx, uuid.New(), true); !errors.Is(err, domain.ErrPromotionNotFound) {
+		t.Errorf("SetActive(unknown) error = %v, want ErrPromotionNotFound", err)
+	}
+}
+
+func TestPromotionRepositoryRedeem(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewPromotionRepository(db)
+	orders := postgresrepo.NewOrderRepository(db)
+	ctx := context.Background()
+	customer := testinfra.InsertUser(t, db, "customer")
+	shop := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	once := newPromotion("once each", nil, nil, now)
+	one := 1
+	once.MaxPerCustomer = &one
+	scarce := newPromotion("first three", nil, nil, now)
+	three := 3
+	scarce.MaxRedemptions = &three
+	for _, p := range []*domain.Promotion{once, scarce} {
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	line := func(p *domain.Promotion) domain.OrderDiscount {
+		return domain.OrderDiscount{PromotionID: &p.ID, Name: p.Name, Amount: 0.5, FundedBy: domain.FundedByPlatform}
+	}
+
+	// The order is stored with its discount lines.
+	o := newOrder(customer, shop.ID, now, item("Concha", 2, 1.5))
+	o.Discount, o.Discounts = 0.5, []domain.OrderDiscount{line(once)}
+	if err := repo.Redeem(ctx, o.ID, customer, o.Discounts); err != nil {
+		t.Fatalf("Redeem() error: %v", err)
+	}
+	if err := orders.Create(ctx, o); err != nil {
+		t.Fatalf("order Create() error: %v", err)
+	}
+	stored, err := orders.GetByID(ctx, o.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Discount != 0.5 || len(stored.Discounts) != 1 || *stored.Discounts[0].PromotionID != once.ID || stored.Discounts[0].Name != "once each" {
+		t.Errorf("stored order discounts = %v (%+v), want the once-each line", stored.Discount, stored.Discounts)
+	}
+
+	// All or none: the second line fails, so the first is not taken either.
+	if err := repo.Redeem(ctx, uuid.New(), customer, []domain.OrderDiscount{line(scarce), line(once)}); !errors.Is(err, domain.ErrPromotionExhausted) {
+		t.Fatalf("Redeem() past the per-customer limit error = %v, want ErrPromotionExhausted", err)
+	}
+	if got, _ := repo.GetByID(ctx, scarce.ID); got.RedemptionCount != 0 {
+		t.Errorf("redemption count after a failed Redeem() = %d, want 0", got.RedemptionCount)
+	}
+	if n, err := repo.CustomerRedemptions(ctx, once.ID, customer); err != nil || n != 1 {
+		t.Errorf("CustomerRedemptions() = %d, %v, want 1", n, err)
+	}
+
+	if err := repo.Release(ctx, o.ID); err != nil {
+		t.Fatalf("Release() error: %v", err)
+	}
+	if err := repo.Release(ctx, o.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repo.GetByID(ctx, once.ID); got.RedemptionCount != 0 {
+		t.Errorf("redemption count after Release() = %d, want 0", got.RedemptionCount)
+	}
+
+	// Concurrent redemptions by different customers never exceed the limit.
+	customers := make([]uuid.UUID, 8)
+	for i := range customers {
+		customers[i] = testinfra.InsertUser(t, db, "customer")
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	redeemed := 0
+	for _, c := range customers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := repo.Redeem(ctx, uuid.New(), c, []domain.OrderDiscount{line(scarce)}); err == nil {
+				mu.Lock()
+				redeemed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if got, _ := repo.GetByID(ctx, scarce.ID); redeemed != 3 || got.RedemptionCount != 3 {
+		t.Errorf("%d redemptions succeeded and %d were counted, want 3", redeemed, got.RedemptionCount)
+	}
+}
@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+type ReviewRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReviewRepository(db *pgxpool.Pool) *ReviewRepository {
+	return &ReviewRepository{db: db}
+}
+
+const reviewColumns = `id, order_id, business_id, customer_id, rating, comment, reply, replied_at, status, flag_count, created_at, updated_at`
+
+func scanReview(row pgx.Row) (*domain.Review, error) {
+	rv := &domain.Review{}
+	var reply *string
+	var repliedAt *time.Time
+	if err := row.Scan(
+		&rv.ID, &rv.OrderID, &rv.BusinessID, &rv.CustomerID, &rv.Rating, &rv.Comment,
+		&reply, &repliedAt, &rv.Status, &rv.FlagCount, &rv.CreatedAt, &rv.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if reply != nil && repliedAt != nil {
+		rv.Reply = &domain.ReviewReply{Text: *reply, CreatedAt: *repliedAt}
+	}
+	return rv, nil
+}
+
+func collectReviews(rows pgx.Rows) ([]*domain.Review, error) {
+	defer rows.Close()
+	var out []*domain.Review
+	for rows.Next() {
+		rv, err := scanReview(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan review: %w", err)
+		}
+		out = append(out, rv)
+	}
+	return out, rows.Err()
+}
+
+// Create stores a published review and adds its rating to the business in the
+// same transaction. A second review of the same order is ErrReviewExists.
+func (r *ReviewRepository) Create(ctx context.Context, rv *domain.Review) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO reviews (id, order_id, business_id, customer_id, rating, comment, status, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+		ON CONFLICT (order_id) DO NOTHING`,
+		rv.ID, rv.OrderID, rv.BusinessID, rv.CustomerID, rv.Rating, rv.Comment,
+		rv.Status, rv.CreatedAt, rv.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrReviewExists
+	}
+	if rv.Status == domain.ReviewPublished {
+		if err := addRating(ctx, tx, rv.BusinessID, rv.Rating, 1); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func addRating(ctx context.Context, tx pgx.Tx, businessID uuid.UUID, sum, count int) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE businesses SET rating_sum = rating_sum + $1, rating_count = rating_count + $2
+		WHERE id = $3`,
+		sum, count, businessID,
+	)
+	return err
+}
+
+func (r *ReviewRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Review, error) {
+	rv, err := scanReview(r.db.QueryRow(ctx, `SELECT `+reviewColumns+` FROM reviews WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get review %s: %w", id, err)
+	}
+	return rv, nil
+}
+
+// ListByBusiness returns up to limit published reviews newest first, starting
+// after the given position when it is not nil.
+func (r *ReviewRepository) ListByBusiness(ctx context.Context, businessID uuid.UUID, after *domain.ReviewCursor, limit int) ([]*domain.Review, error) {
+	var afterAt *time.Time
+	var afterID *uuid.UUID
+	if after != nil {
+		afterAt, afterID = &after.CreatedAt, &after.ID
+	}
+	rows, err := r.db.Query(ctx, `
+		SELECT `+reviewColumns+` FROM reviews
+		WHERE business_id = $1 AND status = 'published'
+		  AND ($2::timestamptz IS NULL OR (created_at, id) < ($2, $3::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4`,
+		businessID, afterAt, afterID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list reviews: %w", err)
+	}
+	return collectReviews(rows)
+}
+
+// SetReply stores (or replaces) the business's reply.
+func (r *ReviewRepository) SetReply(ctx context.Context, id uuid.UUID, text string, at time.Time) error {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE reviews SET reply = $1, replied_at = $2, updated_at = $2 WHERE id = $3`,
+		text, at, id,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrReviewNotFound
+	}
+	return nil
+}
+
+// Flag records userID's report of the review. It reports false when the user
+// had already flagged it since the last moderation.
+func (r *ReviewRepository) Flag(ctx context.Context, id, userID uuid.UUID, reason string) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO review_flags (review_id, user_id, reason) VALUES ($1,$2,$3)
+		ON CONFLICT DO NOTHING`,
+		id, userID, reason,
+	)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	if _, err := tx.Exec(ctx, `UPDATE reviews SET flag_count = flag_count + 1 WHERE id = $1`, id); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// SetStatus publishes or hides a review, moving its rating in or out of the
+// business aggregate, and clears its flags.
+func (r *ReviewRepository) SetStatus(ctx context.Context, id uuid.UUID, status domain.ReviewStatus) (*domain.Review, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rv, err := scanReview(tx.QueryRow(ctx, `SELECT `+reviewColumns+` FROM reviews WHERE id = $1 FOR UPDATE`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get review %s: %w", id, err)
+	}
+
+	switch {
+	case rv.Status == domain.ReviewPublished && status == domain.ReviewHidden:
+		err = addRating(ctx, tx, rv.BusinessID, -rv.Rating, -1)
+	case rv.Status == domain.ReviewHidden && status == domain.ReviewPublished:
+		err = addRating(ctx, tx, rv.BusinessID, rv.Rating, 1)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(ctx,
+		`UPDATE reviews SET status = $1, flag_count = 0, updated_at = $2 WHERE id = $3`,
+		status, now, id,
+	); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM review_flags WHERE review_id = $1`, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	rv.Status, rv.FlagCount, rv.UpdatedAt = status, 0, now
+	return rv, nil
+}
+
+// ListFlagged returns published reviews with pending flags, most flagged first.
+func (r *ReviewRepository) ListFlagged(ctx context.Context, limit int) ([]*domain.Review, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+reviewColumns+` FROM reviews
+		WHERE status = 'published' AND flag_count > 0
+		ORDER BY flag_count DESC, created_at, id
+		LIMIT $1`, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list flagged reviews: %w", err)
+	}
+	return collectReviews(rows)
+}
@@ -0,0 +1,190 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
+	"github.com/heptapegon/localpickup/internal/testinfra"
+)
+
+// seedReview stores a published review of a new completed order.
+func seedReview(t *testing.T, db *pgxpool.Pool, customerID, businessID uuid.UUID, rating int, at time.Time) *domain.Review {
+	t.Helper()
+	ctx := context.Background()
+	o := newOrder(customerID, businessID, at)
+	o.Status = domain.OrderStatusCompleted
+	if err := postgresrepo.NewOrderRepository(db).Create(ctx, o); err != nil {
+		t.Fatal(err)
+	}
+	r := &domain.Review{
+		ID: uuid.New(), OrderID: o.ID, BusinessID: businessID, CustomerID: customerID,
+		Rating: rating, Status: domain.ReviewPublished, CreatedAt: at, UpdatedAt: at,
+	}
+	if err := postgresrepo.NewReviewRepository(db).Create(ctx, r); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	return r
+}
+
+func TestReviewRepositoryCreateMaintainsRating(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewReviewRepository(db)
+	businesses := postgresrepo.NewBusinessRepository(db)
+	ctx := context.Background()
+	customer := testinfra.InsertUser(t, db, "customer")
+	shop := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+
+	if got, _ := businesses.GetByID(ctx, shop.ID); got.Rating != nil || got.RatingCount != 0 {
+		t.Fatalf("unrated business rating = %v over %d", got.Rating, got.RatingCount)
+	}
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	first := seedReview(t, db, customer, shop.ID, 5, now)
+	seedReview(t, db, customer, shop.ID, 4, now)
+	seedReview(t, db, customer, shop.ID, 4, now)
+
+	dup := *first
+	dup.ID = uuid.New()
+	if err := repo.Create(ctx, &dup); !errors.Is(err, domain.ErrReviewExists) {
+		t.Errorf("Create(same order) error = %v, want ErrReviewExists", err)
+	}
+
+	got, err := businesses.GetByID(ctx, shop.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Rating == nil || *got.Rating != 4.33 || got.RatingCount != 3 {
+		t.Errorf("rating = %v over %d, want 4.33 over 3", got.Rating, got.RatingCount)
+	}
+	listed, _ := businesses.GetByIDs(ctx, []string{shop.ID.String()})
+	if len(listed) != 1 || listed[0].Rating == nil || *listed[0].Rating != 4.33 {
+		t.Errorf("GetByIDs() rating = %+v, want 4.33", listed)
+	}
+
+	stored, err := repo.GetByID(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error: %v", err)
+	}
+	if stored.Rating != 5 || stored.Reply != nil || stored.Status != domain.ReviewPublished {
+		t.Errorf("GetByID() = %+v, want the stored review", stored)
+	}
+	if _, err := repo.GetByID(ctx, uuid.New()); !errors.Is(err, domain.ErrReviewNotFound) {
+		t.Errorf("GetByID(unknown) error = %v, want ErrReviewNotFound", err)
+	}
+}
+
+func TestReviewRepositoryListByBusiness(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewReviewRepository(db)
+	ctx := context.Background()
+	customer := testinfra.InsertUser(t, db, "customer")
+	shop := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+	other := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "otra", true)
+
+	base := time.Now().UTC().Truncate(time.Microsecond)
+	var want []uuid.UUID
+	for i := 0; i < 4; i++ {
+		r := seedReview(t, db, customer, shop.ID, 5, base.Add(-time.Duration(i)*time.Minute))
+		want = append(want, r.ID)
+	}
+	seedReview(t, db, customer, other.ID, 1, base)
+	hidden := seedReview(t, db, customer, shop.ID, 1, base.Add(time.Minute))
+	if _, err := repo.SetStatus(ctx, hidden.ID, domain.ReviewHidden); err != nil {
+		t.Fatal(err)
+	}
+
+	firstPage, err := repo.ListByBusiness(ctx, shop.ID, nil, 3)
+	if err != nil {
+		t.Fatalf("ListByBusiness() error: %v", err)
+	}
+	last := firstPage[len(firstPage)-1]
+	rest, err := repo.ListByBusiness(ctx, shop.ID, &domain.ReviewCursor{CreatedAt: last.CreatedAt, ID: last.ID}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := append(firstPage, rest...)
+	if len(got) != len(want) {
+		t.Fatalf("listed %d reviews, want %d published ones", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ID != want[i] {
+			t.Errorf("review[%d] = %s, want %s (newest first)", i, got[i].ID, want[i])
+		}
+	}
+}
+
+func TestReviewRepositoryReplyFlagsAndModeration(t *testing.T) {
+	db := testinfra.Postgres(t)
+	repo := postgresrepo.NewReviewRepository(db)
+	businesses := postgresrepo.NewBusinessRepository(db)
+	ctx := context.Background()
+	customer := testinfra.InsertUser(t, db, "customer")
+	shop := seedBusiness(t, db, testinfra.InsertUser(t, db, "business_owner"), "rosa", true)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	seedReview(t, db, customer, shop.ID, 5, now)
+	review := seedReview(t, db, customer, shop.ID, 1, now)
+
+	if err := repo.SetReply(ctx, review.ID, "Lo sentimos", now); err != nil {
+		t.Fatalf("SetReply() error: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, review.ID); got.Reply == nil || got.Reply.Text != "Lo sentimos" || !got.Reply.CreatedAt.Equal(now) {
+		t.Errorf("reply = %+v, want the stored reply", got.Reply)
+	}
+	if err := repo.SetReply(ctx, uuid.New(), "?", now); !errors.Is(err, domain.ErrReviewNotFound) {
+		t.Errorf("SetReply(unknown) error = %v, want ErrReviewNotFound", err)
+	}
+
+	reporter := testinfra.InsertUser(t, db, "customer")
+	for i, want := range []bool{true, false} {
+		added, err := repo.Flag(ctx, review.ID, reporter, "spam")
+		if err != nil || added != want {
+			t.Errorf("Flag() #%d = %v, %v, want %v", i+1, added, err, want)
+		}
+	}
+	if _, err := repo.Flag(ctx, review.ID, customer, "offensive"); err != nil {
+		t.Fatal(err)
+	}
+	flagged, err := repo.ListFlagged(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListFlagged() error: %v", err)
+	}
+	if len(flagged) != 1 || flagged[0].ID != review.ID || flagged[0].FlagCount != 2 {
+		t.Fatalf("ListFlagged() = %+v, want the review with 2 flags", flagged)
+	}
+
+	hidden, err := repo.SetStatus(ctx, review.ID, domain.ReviewHidden)
+	if err != nil {
+		t.Fatalf("SetStatus() error: %v", err)
+	}
+	if hidden.Status != domain.ReviewHidden || hidden.FlagCount != 0 {
+		t.Errorf("SetStatus() = %+v, want hidden without flags", hidden)
+	}
+	if got, _ := businesses.GetByID(ctx, shop.ID); got.Rating == nil || *got.Rating != 5 || got.RatingCount != 1 {
+		t.Errorf("rating after hiding = %v over %d, want 5 over 1", got.Rating, got.RatingCount)
+	}
+	if flagged, _ := repo.ListFlagged(ctx, 10); len(flagged) != 0 {
+		t.Errorf("ListFlagged() after moderation = %+v, want none", flagged)
+	}
+	// The flags were cleared, so the same user can report it again.
+	if added, err := repo.Flag(ctx, review.ID, reporter, "spam"); err != nil || !added {
+		t.Errorf("Flag() after moderation = %v, %v, want a new flag", added, err)
+	}
+
+	for range 2 {
+		if _, err := repo.SetStatus(ctx, review.ID, domain.ReviewPublished); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, _ := businesses.GetByID(ctx, shop.ID); got.Rating == nil || *got.Rating != 3 || got.RatingCount != 2 {
+		t.Errorf("rating after restoring = %v over %d, want 3 over 2", got.Rating, got.RatingCount)
+	}
+	if _, err := repo.SetStatus(ctx, uuid.New(), domain.ReviewHidden); !errors.Is(err, domain.ErrReviewNotFound) {
+		t.Errorf("SetStatus(unknown) error = %v, want ErrReviewNotFound", err)
+	}
+}
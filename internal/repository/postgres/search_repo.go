@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+// Ranking knobs. Relevance is the better of the full-text rank (normalised to
+// [0, 1)) and the trigram word similarity of the name; a product hit counts a
+// little less than a hit on the business itself, and a hit on the name of its
+// category less still. With an origin, the final
+// score blends relevance with a proximity term 1/(1 + d/searchProximityKm),
+// which is 1 at the origin and ½ at searchProximityKm.
+const (
+	searchProductWeight   = 0.9
+	searchCategoryWeight  = 0.4
+	searchRelevanceWeight = 0.7
+	searchProximityKm     = 2.0
+)
+
+type SearchRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSearchRepository(db *pgxpool.Pool) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// Search returns active businesses whose name, description, category names or
+// available, in-stock products match q, best first (or best rated first when
+// q.Sort is rating, ties broken by score). Limit and RadiusKm must be set; the
+// radius only applies when q has an origin.
+func (r *SearchRepository) Search(ctx context.Context, q domain.SearchQuery) ([]*domain.SearchResult, error) {
+	var lat, lng *float64
+	if q.HasOrigin() {
+		lat, lng = &q.Latitude, &q.Longitude
+	}
+
+	rows, err := r.db.Query(ctx, `
+		WITH q AS (
+			SELECT websearch_to_tsquery('es_unaccent', $1) AS tsq, search_fold($1) AS term
+		),
+		origin AS (
+			SELECT CASE WHEN $2::float8 IS NULL THEN NULL
+			            ELSE ST_SetSRID(ST_MakePoint($3::float8, $2::float8), 4326)::geography END AS p
+		),
+		hits AS (
+			SELECT b.id AS business_id,
+			       GREATEST(ts_rank_cd(b.search_vector, q.tsq, 32), word_similarity(q.term, search_fold(b.name))) AS relevance,
+			       NULL::text AS product
+			FROM businesses b, q
+			WHERE b.search_vector @@ q.tsq OR q.term <% search_fold(b.name)
+			UNION ALL
+			SELECT p.business_id,
+			       $5 * GREATEST(ts_rank_cd(p.search_vector, q.tsq, 32), word_similarity(q.term, search_fold(p.name))),
+			       p.name
+			FROM products p, q
+			WHERE p.is_available AND (p.stock IS NULL OR p.stock > 0) AND (p.search_vector @@ q.tsq OR q.term <% search_fold(p.name))
+			UNION ALL
+			SELECT b.id, $9 * ts_rank_cd(c.search_vector, q.tsq, 32), NULL
+			FROM categories c
+			JOIN businesses b ON b.category = c.slug
+			CROSS JOIN q
+			WHERE c.search_vector @@ q.tsq
+		),
+		matched AS (
+			SELECT business_id, max(relevance) AS relevance,
+			       (array_remove(array_agg(product ORDER BY relevance DESC, product), NULL))[1:3] AS products
+			FROM hits
+			GROUP BY business_id
+		),
+		scored AS (
+			SELECT b.id, ST_Distance(b.location, origin.p) / 1000 AS distance_km, m.relevance, m.products
+			FROM matched m
+			JOIN businesses b ON b.id = m.business_id AND b.is_active = TRUE
+			CROSS JOIN origin
+			WHERE origin.p IS NULL OR ST_DWithin(b.location, origin.p, $4 * 1000)
+		)
+		SELECT b.id, b.owner_id, b.name, COALESCE(b.description, ''), b.address, b.latitude, b.longitude,
+		       b.category, COALESCE(b.fcm_token, ''), b.is_active, b.image_key, b.rating_sum, b.rating_count,
+		       b.created_at, b.updated_at,
+		       s.distance_km,
+		       CASE WHEN s.distance_km IS NULL THEN s.relevance
+		            ELSE $6 * s.relevance + (1 - $6) / (1 + s.distance_km / $7) END AS score,
+		       COALESCE(s.products, '{}')
+		FROM scored s
+		JOIN businesses b ON b.id = s.id
+		ORDER BY CASE WHEN $10 THEN b.rating_sum::float8 / NULLIF(b.rating_count, 0) END DESC NULLS LAST,
+		         CASE WHEN $10 THEN b.rating_count END DESC NULLS LAST,
+		         score DESC, b.id
+		LIMIT $8`,
+		q.Query, lat, lng, q.RadiusKm,
+		searchProductWeight, searchRelevanceWeight, searchProximityKm, q.Limit,
+		searchCategoryWeight, q.Sort == domain.SortRating,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*domain.SearchResult
+	for rows.Next() {
+		res := &domain.SearchResult{}
+		b := &res.Business
+		var ratingSum int
+		if err := rows.Scan(
+			&b.ID, &b.OwnerID, &b.Name, &b.Description, &b.Address,
+			&b.Latitude, &b.Longitude, &b.Category, &b.FCMToken,
+			&b.IsActive, &b.ImageKey, &ratingSum, &b.RatingCount, &b.CreatedAt, &b.UpdatedAt,
+			&res.DistanceKm, &res.Score, &res.MatchedProducts,
+		); err != nil {
+			return nil, fmt.Errorf("scan search result: %w", err)
+		}
+		b.Rating = domain.AverageRating(ratingSum, b.RatingCount)
+		out = append(out, res)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,121 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
+	"github.com/heptapegon/localpickup/internal/testinfra"
+)
+
+func TestSearchRepository(t *testing.T) {
+	db := testinfra.Postgres(t)
+	businesses := postgresrepo.NewBusinessRepository(db)
+	repo := postgresrepo.NewSearchRepository(db)
+	ctx := context.Background()
+	owner := testinfra.InsertUser(t, db, "business_owner")
+
+	create := func(name, category, description string, lat float64, active bool) *domain.Business {
+		b := newBusiness(owner, name, active)
+		b.Category, b.Description, b.Latitude = category, description, lat
+		if err := businesses.Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	// Latitudes are ~1 km apart going north from the origin.
+	pharmacyFar := create("Farmacia del Ahorro", "pharmacy", "Medicamentos y perfumería", originLat+0.09, true)
+	pharmacyNear := create("Farmacia San Pablo", "pharmacy", "Medicamentos genéricos", originLat+0.01, true)
+	taqueria := create("El Güero", "tacos", "Antojitos", originLat+0.02, true)
+	seedProduct(t, db, taqueria.ID, "Tacos al pastor", 15)
+	seedProduct(t, db, taqueria.ID, "Taco de suadero", 15)
+	closed := create("Farmacia Cerrada", "pharmacy", "", originLat, false)
+	seedReview(t, db, testinfra.InsertUser(t, db, "customer"), pharmacyFar.ID, 5, time.Now().UTC())
+
+	tests := []struct {
+		name         string
+		q            domain.SearchQuery
+		want         []string // business names, best first
+		wantProducts []string // matched products of the first result
+	}{
+		{
+			name: "stemming and accents, nearest first",
+			q:    domain.SearchQuery{Query: "fármacias", Latitude: originLat, Longitude: originLng},
+			want: []string{pharmacyNear.Name, pharmacyFar.Name},
+		},
+		{
+			name: "typo tolerated",
+			q:    domain.SearchQuery{Query: "farmasia", Latitude: originLat, Longitude: originLng},
+			want: []string{pharmacyNear.Name, pharmacyFar.Name},
+		},
+		{
+			name:         "product names",
+			q:            domain.SearchQuery{Query: "tacos"},
+			want:         []string{taqueria.Name},
+			wantProducts: []string{"Tacos al pastor", "Taco de suadero"},
+		},
+		{
+			name: "category display names",
+			q:    domain.SearchQuery{Query: "taquería"},
+			want: []string{taqueria.Name},
+		},
+		{
+			name: "radius applies with an origin",
+			q:    domain.SearchQuery{Query: "farmacia", Latitude: originLat, Longitude: originLng, RadiusKm: 5},
+			want: []string{pharmacyNear.Name},
+		},
+		{
+			name: "best rated first",
+			q:    domain.SearchQuery{Query: "farmacia", Latitude: originLat, Longitude: originLng, Sort: domain.SortRating},
+			want: []string{pharmacyFar.Name, pharmacyNear.Name},
+		},
+		{
+			name: "no match",
+			q:    domain.SearchQuery{Query: "ferretería"},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.q
+			q.Limit = 10
+			if q.RadiusKm == 0 {
+				q.RadiusKm = 50
+			}
+			got, err := repo.Search(ctx, q)
+			if err != nil {
+				t.Fatalf("Search() error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				names := make([]string, len(got))
+				for i, r := range got {
+					names[i] = r.Name
+				}
+				t.Fatalf("Search() = %v, want %v", names, tt.want)
+			}
+			for i, r := range got {
+				if r.Name != tt.want[i] {
+					t.Errorf("result[%d] = %q, want %q", i, r.Name, tt.want[i])
+				}
+				if r.ID == closed.ID {
+					t.Errorf("inactive business returned")
+				}
+				if q.HasOrigin() != (r.DistanceKm != nil) {
+					t.Errorf("result[%d] distance = %v with origin=%v", i, r.DistanceKm, q.HasOrigin())
+				}
+				if r.Score <= 0 || r.Score > 1 {
+					t.Errorf("result[%d] score = %v, want in (0, 1]", i, r.Score)
+				}
+			}
+			if tt.wantProducts != nil {
+				products := got[0].MatchedProducts
+				if len(products) != len(tt.wantProducts) {
+					t.Fatalf("matched products = %v, want %v", products, tt.wantProducts)
+				}
+			}
+		})
+	}
+}
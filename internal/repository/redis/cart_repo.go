@@ -0,0 +1,178 @@
+package redisrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+// cartPrefix keys each cart's JSON; cartCustomerPrefix keys a hash per
+// customer from business ID to cart ID.
+const (
+	cartPrefix         = "cart:"
+	cartCustomerPrefix = "cart:customer:"
+)
+
+func cartKey(id uuid.UUID) string                  { return cartPrefix + id.String() }
+func customerCartsKey(customerID uuid.UUID) string { return cartCustomerPrefix + customerID.String() }
+
+// CartRepository keeps carts as JSON strings that expire on their own. The
+// per-customer index expires with the customer's newest cart; entries left
+// behind by older expired carts are pruned when read.
+type CartRepository struct {
+	client *redis.Client
+}
+
+func NewCartRepository(client *redis.Client) *CartRepository {
+	return &CartRepository{client: client}
+}
+
+func (r *CartRepository) Save(ctx context.Context, c *domain.Cart, ttl time.Duration) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("encode cart: %w", err)
+	}
+	index := customerCartsKey(c.CustomerID)
+	_, err = r.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, cartKey(c.ID), data, ttl)
+		p.HSet(ctx, index, c.BusinessID.String(), c.ID.String())
+		p.Expire(ctx, index, ttl)
+		return nil
+	})
+	return err
+}
+
+// restoreCart puts a cart back unless the customer's index already points
+// at another cart for the business, in one step so a concurrent Find cannot
+// see the index entry before the cart exists.
+var restoreCart = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], ARGV[1])
+if current and current ~= ARGV[2] then
+	return 0
+end
+redis.call('SET', KEYS[2], ARGV[3], 'PX', ARGV[4])
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return 1
+`)
+
+func (r *CartRepository) Restore(ctx context.Context, c *domain.Cart, ttl time.Duration) (bool, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return false, fmt.Errorf("encode cart: %w", err)
+	}
+	keys := []string{customerCartsKey(c.CustomerID), cartKey(c.ID)}
+	n, err := restoreCart.Run(ctx, r.client, keys, c.BusinessID.String(), c.ID.String(), data, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *CartRepository) Get(ctx context.Context, id uuid.UUID) (*domain.Cart, error) {
+	data, err := r.client.Get(ctx, cartKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrCartNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeCart(data)
+}
+
+func (r *CartRepository) Find(ctx context.Context, customerID, businessID uuid.UUID) (*domain.Cart, error) {
+	index := customerCartsKey(customerID)
+	raw, err := r.client.HGet(ctx, index, businessID.String()).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrCartNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("cart index %s: %w", index, err)
+	}
+	c, err := r.Get(ctx, id)
+	if errors.Is(err, domain.ErrCartNotFound) {
+		r.client.HDel(ctx, index, businessID.String())
+	}
+	return c, err
+}
+
+// ListByCustomer returns the customer's live carts in no particular order.
+func (r *CartRepository) ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]*domain.Cart, error) {
+	index := customerCartsKey(customerID)
+	entries, err := r.client.HGetAll(ctx, index).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	businesses := make([]string, 0, len(entries))
+	keys := make([]string, 0, len(entries))
+	for business, id := range entries {
+		businesses = append(businesses, business)
+		keys = append(keys, cartPrefix+id)
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*domain.Cart
+	var expired []string
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			expired = append(expired, businesses[i])
+			continue
+		}
+		c, err := decodeCart([]byte(s))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	if len(expired) > 0 {
+		r.client.HDel(ctx, index, expired...)
+	}
+	return out, nil
+}
+
+// Take uses GETDEL (Redis ≥ 6.2) so a cart cannot be taken twice.
+func (r *CartRepository) Take(ctx context.Context, id uuid.UUID) (*domain.Cart, error) {
+	data, err := r.client.GetDel(ctx, cartKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrCartNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, err := decodeCart(data)
+	if err != nil {
+		return nil, err
+	}
+	// Only unlink the index entry if it still points at this cart.
+	index := customerCartsKey(c.CustomerID)
+	if current, err := r.client.HGet(ctx, index, c.BusinessID.String()).Result(); err == nil && current == id.String() {
+		r.client.HDel(ctx, index, c.BusinessID.String())
+	}
+	return c, nil
+}
+
+func decodeCart(data []byte) (*domain.Cart, error) {
+	c := &domain.Cart{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("decode cart: %w", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,102 @@
+package redisrepo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	redisrepo "github.com/heptapegon/localpickup/internal/repository/redis"
+	"github.com/heptapegon/localpickup/internal/testinfra"
+)
+
+func TestCartRepository(t *testing.T) {
+	client := testinfra.Redis(t)
+	repo := redisrepo.NewCartRepository(client)
+	ctx := context.Background()
+	customer, rosa, otra := uuid.New(), uuid.New(), uuid.New()
+
+	cart := &domain.Cart{
+		ID: uuid.New(), CustomerID: customer, BusinessID: rosa,
+		Items: []domain.CreateOrderItemReq{{ProductID: uuid.New(), Quantity: 2, Modifiers: []uuid.UUID{uuid.New()}}},
+		Quote: &domain.Quote{Subtotal: 3, Total: 3.48},
+	}
+	if err := repo.Save(ctx, cart, time.Hour); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	got, err := repo.Get(ctx, cart.ID)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if got.CustomerID != customer || len(got.Items) != 1 || got.Items[0].Quantity != 2 || got.Quote == nil || got.Quote.Total != 3.48 {
+		t.Errorf("Get() = %+v, want the saved cart", got)
+	}
+	if found, err := repo.Find(ctx, customer, rosa); err != nil || found.ID != cart.ID {
+		t.Errorf("Find() = %v, %v, want the saved cart", found, err)
+	}
+	if _, err := repo.Find(ctx, customer, otra); !errors.Is(err, domain.ErrCartNotFound) {
+		t.Errorf("Find(other business) error = %v, want ErrCartNotFound", err)
+	}
+
+	// Once the cart for another business expires it drops out of the list,
+	// and its index entry with it.
+	expired := &domain.Cart{ID: uuid.New(), CustomerID: customer, BusinessID: otra}
+	if err := repo.Save(ctx, expired, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if carts, err := repo.ListByCustomer(ctx, customer); err != nil || len(carts) != 2 {
+		t.Fatalf("ListByCustomer() = %d carts, %v, want 2", len(carts), err)
+	}
+	if err := client.Del(ctx, "cart:"+expired.ID.String()).Err(); err != nil { // expire it
+		t.Fatal(err)
+	}
+	carts, err := repo.ListByCustomer(ctx, customer)
+	if err != nil || len(carts) != 1 || carts[0].ID != cart.ID {
+		t.Fatalf("ListByCustomer() after expiry = %v, %v, want only the live cart", carts, err)
+	}
+	if n, _ := client.HLen(ctx, "cart:customer:"+customer.String()).Result(); n != 1 {
+		t.Errorf("index has %d entries after pruning, want 1", n)
+	}
+
+	taken, err := repo.Take(ctx, cart.ID)
+	if err != nil || taken.ID != cart.ID {
+		t.Fatalf("Take() = %v, %v, want the cart", taken, err)
+	}
+	if _, err := repo.Take(ctx, cart.ID); !errors.Is(err, domain.ErrCartNotFound) {
+		t.Errorf("second Take() error = %v, want ErrCartNotFound", err)
+	}
+	if _, err := repo.Get(ctx, cart.ID); !errors.Is(err, domain.ErrCartNotFound) {
+		t.Errorf("Get() after Take() error = %v, want ErrCartNotFound", err)
+	}
+	if carts, err := repo.ListByCustomer(ctx, customer); err != nil || len(carts) != 0 {
+		t.Errorf("ListByCustomer() after Take() = %v, %v, want none", carts, err)
+	}
+
+	// A taken cart goes back while nothing replaced it, but not over a cart
+	// saved for the same business in the meantime.
+	if ok, err := repo.Restore(ctx, taken, time.Hour); err != nil || !ok {
+		t.Fatalf("Restore() = %v, %v, want it restored", ok, err)
+	}
+	if found, err := repo.Find(ctx, customer, rosa); err != nil || found.ID != cart.ID {
+		t.Errorf("Find() after Restore() = %v, %v, want the restored cart", found, err)
+	}
+	if _, err := repo.Take(ctx, cart.ID); err != nil {
+		t.Fatal(err)
+	}
+	newer := &domain.Cart{ID: uuid.New(), CustomerID: customer, BusinessID: rosa}
+	if err := repo.Save(ctx, newer, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := repo.Restore(ctx, taken, time.Hour); err != nil || ok {
+		t.Fatalf("Restore() over a newer cart = %v, %v, want it dropped", ok, err)
+	}
+	if found, err := repo.Find(ctx, customer, rosa); err != nil || found.ID != newer.ID {
+		t.Errorf("Find() after a dropped Restore() = %v, %v, want the newer cart", found, err)
+	}
+	if _, err := repo.Get(ctx, cart.ID); !errors.Is(err, domain.ErrCartNotFound) {
+		t.Errorf("Get(dropped cart) error = %v, want ErrCartNotFound", err)
+	}
+}
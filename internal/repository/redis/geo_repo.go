@@ -3,13 +3,28 @@ package redisrepo
 import (
 	"context"
 	"fmt"
+	"math"
+	"sort"
 
 	"github.com/redis/go-redis/v9"
 
 	"github.com/heptapegon/localpickup/internal/domain"
 )
 
-const businessGeoKey = "businesses:geo"
+const (
+	// businessGeoKey holds every active business; each category also has its
+	// own key so category searches are capped after filtering, not before.
+	businessGeoKey = "businesses:geo"
+	// categoryGeoKeys is the set of categories that have a geo key.
+	categoryGeoKeys = "businesses:geo:categories"
+	// redisEarthRadiusKm is the radius Redis uses for geo distances.
+	redisEarthRadiusKm = 6372.797560856
+	// geoBatchSize bounds the arguments sent per GEOPOS/GEOADD/ZREM call
+	// during bulk reconciliation.
+	geoBatchSize = 500
+)
+
+func categoryGeoKey(category string) string { return businessGeoKey + ":cat:" + category }
 
 type GeoRepository struct {
 	client *redis.Client
@@ -21,46 +36,271 @@ func NewGeoRepository(client *redis.Client) *GeoRepository {
 
 // IndexBusiness adds (or updates) a business location in the Redis Geo index.
 func (r *GeoRepository) IndexBusiness(ctx context.Context, b *domain.Business) error {
-	return r.client.GeoAdd(ctx, businessGeoKey, &redis.GeoLocation{
-		Name:      b.ID.String(),
-		Longitude: b.Longitude,
+	return r.AddLocations(ctx, []domain.GeoLocation{{
+		ID:        b.ID.String(),
+		Category:  b.Category,
 		Latitude:  b.Latitude,
-	}).Err()
+		Longitude: b.Longitude,
+	}})
 }
 
-// GeoResult combines an ID with the distance returned by Redis.
-type GeoResult struct {
-	ID         string
-	DistanceKm float64
+// FindNearby returns business IDs within q.RadiusKm of the query point, sorted
+// by ascending distance. Uses GEOSEARCH (Redis ≥ 6.2), which supersedes GEORADIUS.
+// With several categories each category key is searched and the results are
+// merged; a business is in exactly one category key, so there are no duplicates.
+//
+// GEOSEARCH cannot start from a cursor, so the scan covers every earlier page
+// (After.Seen results) and drops them; if entries were added in the meantime
+// and the page comes up short, the scan is widened.
+func (r *GeoRepository) FindNearby(ctx context.Context, q domain.GeoQuery) ([]domain.GeoResult, error) {
+	keys := []string{businessGeoKey}
+	if len(q.Categories) > 0 {
+		keys = make([]string, len(q.Categories))
+		for i, cat := range q.Categories {
+			keys[i] = categoryGeoKey(cat)
+		}
+	}
+	count := q.Limit
+	if q.After != nil {
+		count += q.After.Seen
+	}
+
+	for {
+		query := &redis.GeoSearchLocationQuery{
+			GeoSearchQuery: redis.GeoSearchQuery{
+				Latitude:   q.Latitude,
+				Longitude:  q.Longitude,
+				Radius:     q.RadiusKm,
+				RadiusUnit: "km",
+				Sort:       "ASC",
+				Count:      count,
+			},
+			WithDist: true,
+		}
+		pipe := r.client.Pipeline()
+		cmds := make([]*redis.GeoSearchLocationCmd, len(keys))
+		for i, key := range keys {
+			cmds[i] = pipe.GeoSearchLocation(ctx, key, query)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("redis geo search: %w", err)
+		}
+
+		var results []domain.GeoResult
+		truncated := false
+		for _, cmd := range cmds {
+			locations := cmd.Val()
+			truncated = truncated || len(locations) == count
+			for _, loc := range locations {
+				results = append(results, domain.GeoResult{ID: loc.Name, DistanceKm: loc.Dist})
+			}
+		}
+		// Redis leaves ties in arbitrary order; cursors need a total order.
+		sort.SliceStable(results, func(i, j int) bool {
+			if results[i].DistanceKm != results[j].DistanceKm {
+				return results[i].DistanceKm < results[j].DistanceKm
+			}
+			return results[i].ID < results[j].ID
+		})
+		// Beyond the first count results, a key that was cut short may be
+		// missing entries nearer than the ones other keys returned.
+		results = results[:min(len(results), count)]
+
+		page := results
+		if q.After != nil {
+			page = results[:0]
+			for _, res := range results {
+				if !q.After.Before(res) {
+					page = append(page, res)
+				}
+			}
+		}
+		if len(page) >= q.Limit || !truncated {
+			return page[:min(len(page), q.Limit)], nil
+		}
+		count *= 2
+	}
 }
 
-// FindNearby returns business IDs within radiusKm of (lat, lng), sorted by
-// ascending distance. Uses GEOSEARCH (Redis ≥ 6.2), which supersedes GEORADIUS.
-func (r *GeoRepository) FindNearby(ctx context.Context, lat, lng, radiusKm float64) ([]GeoResult, error) {
-	locations, err := r.client.GeoSearchLocation(ctx, businessGeoKey, &redis.GeoSearchLocationQuery{
+// FindInBounds returns the businesses inside b, which must not cross the
+// antimeridian. Without categories every category key is searched, so each
+// result carries its category.
+//
+// BYBOX measures width along each member's own parallel, so the box is sized
+// for the parallel nearest the equator (the widest one) and the results are
+// then trimmed to the exact rectangle.
+func (r *GeoRepository) FindInBounds(ctx context.Context, b domain.Bounds, categories []string) ([]domain.GeoLocation, error) {
+	if len(categories) == 0 {
+		var err error
+		if categories, err = r.client.SMembers(ctx, categoryGeoKeys).Result(); err != nil {
+			return nil, fmt.Errorf("redis geo categories: %w", err)
+		}
+	}
+
+	widest := 0.0
+	if b.South > 0 {
+		widest = b.South
+	} else if b.North < 0 {
+		widest = b.North
+	}
+	const pad = 1.001
+	query := &redis.GeoSearchLocationQuery{
 		GeoSearchQuery: redis.GeoSearchQuery{
-			Latitude:   lat,
-			Longitude:  lng,
-			Radius:     radiusKm,
-			RadiusUnit: "km",
-			Sort:       "ASC",
-			Count:      50,
+			Latitude:  (b.North + b.South) / 2,
+			Longitude: (b.East + b.West) / 2,
+			BoxWidth:  pad * degToRad(b.East-b.West) * redisEarthRadiusKm * math.Cos(degToRad(widest)),
+			BoxHeight: pad * degToRad(b.North-b.South) * redisEarthRadiusKm,
+			BoxUnit:   "km",
 		},
-		WithDist:  true,
-		WithCoord: false,
-	}).Result()
-	if err != nil {
-		return nil, fmt.Errorf("redis geo search: %w", err)
+		WithCoord: true,
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.GeoSearchLocationCmd, len(categories))
+	for i, cat := range categories {
+		cmds[i] = pipe.GeoSearchLocation(ctx, categoryGeoKey(cat), query)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("redis geo box search: %w", err)
 	}
 
-	results := make([]GeoResult, 0, len(locations))
-	for _, loc := range locations {
-		results = append(results, GeoResult{ID: loc.Name, DistanceKm: loc.Dist})
+	var out []domain.GeoLocation
+	for i, cmd := range cmds {
+		for _, loc := range cmd.Val() {
+			if loc.Latitude < b.South || loc.Latitude > b.North || loc.Longitude < b.West || loc.Longitude > b.East {
+				continue
+			}
+			out = append(out, domain.GeoLocation{ID: loc.Name, Category: categories[i], Latitude: loc.Latitude, Longitude: loc.Longitude})
+		}
 	}
-	return results, nil
+	return out, nil
 }
 
+func degToRad(d float64) float64 { return d * math.Pi / 180 }
+
 // RemoveBusiness removes a business from the geo index (e.g. when deactivated).
 func (r *GeoRepository) RemoveBusiness(ctx context.Context, businessID string) error {
-	return r.client.ZRem(ctx, businessGeoKey, businessID).Err()
+	return r.RemoveLocations(ctx, []string{businessID})
+}
+
+// Count returns the number of businesses in the geo index.
+func (r *GeoRepository) Count(ctx context.Context) (int64, error) {
+	return r.client.ZCard(ctx, businessGeoKey).Result()
+}
+
+// Locations returns every member of the geo index with its stored position
+// and category. A business that is in no category key (or in several) is
+// returned with an empty category; one that is only in a category key is
+// returned with a zero position. Either way it no longer matches the database
+// and the reconciler rewrites it.
+func (r *GeoRepository) Locations(ctx context.Context) ([]domain.GeoLocation, error) {
+	ids, err := r.client.ZRange(ctx, businessGeoKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis geo members: %w", err)
+	}
+	categories, err := r.memberCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]domain.GeoLocation, 0, len(ids))
+	for start := 0; start < len(ids); start += geoBatchSize {
+		batch := ids[start:min(start+geoBatchSize, len(ids))]
+		positions, err := r.client.GeoPos(ctx, businessGeoKey, batch...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("redis geo positions: %w", err)
+		}
+		for i, pos := range positions {
+			if pos == nil {
+				continue // removed between ZRANGE and GEOPOS
+			}
+			loc := domain.GeoLocation{ID: batch[i], Latitude: pos.Latitude, Longitude: pos.Longitude}
+			if cats := categories[loc.ID]; len(cats) == 1 {
+				loc.Category = cats[0]
+			}
+			delete(categories, loc.ID)
+			out = append(out, loc)
+		}
+	}
+	for id, cats := range categories {
+		out = append(out, domain.GeoLocation{ID: id, Category: cats[0]})
+	}
+	return out, nil
+}
+
+// memberCategories maps each member of a category key to its categories.
+func (r *GeoRepository) memberCategories(ctx context.Context) (map[string][]string, error) {
+	categories, err := r.client.SMembers(ctx, categoryGeoKeys).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis geo categories: %w", err)
+	}
+	out := make(map[string][]string)
+	for _, cat := range categories {
+		ids, err := r.client.ZRange(ctx, categoryGeoKey(cat), 0, -1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("redis geo members of %q: %w", cat, err)
+		}
+		for _, id := range ids {
+			out[id] = append(out[id], cat)
+		}
+	}
+	return out, nil
+}
+
+// AddLocations adds or moves many businesses in the geo index. It does not
+// remove a business from its previous category; callers changing a category
+// remove the business first.
+func (r *GeoRepository) AddLocations(ctx context.Context, locs []domain.GeoLocation) error {
+	for start := 0; start < len(locs); start += geoBatchSize {
+		batch := locs[start:min(start+geoBatchSize, len(locs))]
+		members := make([]*redis.GeoLocation, 0, len(batch))
+		byCategory := make(map[string][]*redis.GeoLocation)
+		for _, l := range batch {
+			m := &redis.GeoLocation{Name: l.ID, Latitude: l.Latitude, Longitude: l.Longitude}
+			members = append(members, m)
+			if l.Category != "" {
+				byCategory[l.Category] = append(byCategory[l.Category], m)
+			}
+		}
+
+		pipe := r.client.TxPipeline()
+		pipe.GeoAdd(ctx, businessGeoKey, members...)
+		for cat, ms := range byCategory {
+			pipe.SAdd(ctx, categoryGeoKeys, cat)
+			pipe.GeoAdd(ctx, categoryGeoKey(cat), ms...)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("redis geo add: %w", err)
+		}
+	}
+	return nil
+}
+
+// RemoveLocations removes many businesses from the geo index and every
+// category key.
+func (r *GeoRepository) RemoveLocations(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	categories, err := r.client.SMembers(ctx, categoryGeoKeys).Result()
+	if err != nil {
+		return fmt.Errorf("redis geo categories: %w", err)
+	}
+
+	for start := 0; start < len(ids); start += geoBatchSize {
+		batch := ids[start:min(start+geoBatchSize, len(ids))]
+		members := make([]any, len(batch))
+		for i, id := range batch {
+			members[i] = id
+		}
+		pipe := r.client.TxPipeline()
+		pipe.ZRem(ctx, businessGeoKey, members...)
+		for _, cat := range categories {
+			pipe.ZRem(ctx, categoryGeoKey(cat), members...)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("redis geo remove: %w", err)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,217 @@
+package redisrepo_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	redisrepo "github.com/heptapegon/localpickup/internal/repository/redis"
+	"github.com/heptapegon/localpickup/internal/testinfra"
+)
+
+func TestGeoRepositoryFindNearby(t *testing.T) {
+	repo := redisrepo.NewGeoRepository(testinfra.Redis(t))
+	ctx := context.Background()
+
+	// Zócalo, CDMX, and points roughly 1, 3 and 30 km north of it.
+	const lat, lng = 19.4326, -99.1332
+	near := &domain.Business{ID: uuid.New(), Latitude: 19.4416, Longitude: lng}
+	mid := &domain.Business{ID: uuid.New(), Latitude: 19.4596, Longitude: lng}
+	far := &domain.Business{ID: uuid.New(), Latitude: 19.7026, Longitude: lng}
+	for _, b := range []*domain.Business{far, near, mid} {
+		if err := repo.IndexBusiness(ctx, b); err != nil {
+			t.Fatalf("IndexBusiness() error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		radiusKm float64
+		want     []uuid.UUID
+	}{
+		{name: "small radius", radiusKm: 2, want: []uuid.UUID{near.ID}},
+		{name: "medium radius sorted", radiusKm: 5, want: []uuid.UUID{near.ID, mid.ID}},
+		{name: "large radius sorted", radiusKm: 50, want: []uuid.UUID{near.ID, mid.ID, far.ID}},
+		{name: "nothing in range", radiusKm: 0.5, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.FindNearby(ctx, domain.GeoQuery{Latitude: lat, Longitude: lng, RadiusKm: tt.radiusKm, Limit: 50})
+			if err != nil {
+				t.Fatalf("FindNearby() error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d results, want %d", len(got), len(tt.want))
+			}
+			for i, r := range got {
+				if r.ID != tt.want[i].String() {
+					t.Errorf("result[%d] = %s, want %s", i, r.ID, tt.want[i])
+				}
+				if i > 0 && r.DistanceKm < got[i-1].DistanceKm {
+					t.Errorf("results not sorted by distance: %v", got)
+				}
+			}
+		})
+	}
+
+	if n, err := repo.Count(ctx); err != nil || n != 3 {
+		t.Errorf("Count() = %d, %v, want 3", n, err)
+	}
+
+	t.Run("removed businesses are not returned", func(t *testing.T) {
+		if err := repo.RemoveBusiness(ctx, near.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := repo.FindNearby(ctx, domain.GeoQuery{Latitude: lat, Longitude: lng, RadiusKm: 2, Limit: 50})
+		if len(got) != 0 {
+			t.Errorf("got %v after RemoveBusiness, want none", got)
+		}
+	})
+}
+
+func TestGeoRepositoryPaginates(t *testing.T) {
+	repo := redisrepo.NewGeoRepository(testinfra.Redis(t))
+	ctx := context.Background()
+
+	const lat, lng = 19.4326, -99.1332
+	var want []string
+	for i := 0; i < 60; i++ {
+		b := &domain.Business{ID: uuid.New(), Latitude: lat + float64(i)*0.0001, Longitude: lng, Category: "coffee"}
+		if err := repo.IndexBusiness(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, b.ID.String())
+	}
+
+	var got []string
+	q := domain.GeoQuery{Latitude: lat, Longitude: lng, RadiusKm: 10, Limit: 25}
+	for page := 0; page < 5; page++ {
+		res, err := repo.FindNearby(ctx, q)
+		if err != nil {
+			t.Fatalf("FindNearby() page %d error: %v", page, err)
+		}
+		for _, r := range res {
+			got = append(got, r.ID)
+		}
+		if len(res) < q.Limit {
+			break
+		}
+		last := res[len(res)-1]
+		q.After = &domain.GeoCursor{DistanceKm: last.DistanceKm, ID: last.ID, Seen: len(got)}
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("paged through %d results, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("result[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGeoRepositoryFiltersCategoryBeforeCap(t *testing.T) {
+	repo := redisrepo.NewGeoRepository(testinfra.Redis(t))
+	ctx := context.Background()
+
+	const lat, lng = 19.4326, -99.1332
+	for i := 0; i < 60; i++ {
+		b := &domain.Business{ID: uuid.New(), Latitude: lat + float64(i)*0.0001, Longitude: lng, Category: "coffee"}
+		if err := repo.IndexBusiness(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bakery := &domain.Business{ID: uuid.New(), Latitude: lat + 0.02, Longitude: lng, Category: "bakery"}
+	if err := repo.IndexBusiness(ctx, bakery); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.FindNearby(ctx, domain.GeoQuery{Latitude: lat, Longitude: lng, RadiusKm: 10, Categories: []string{"bakery"}, Limit: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != bakery.ID.String() {
+		t.Errorf("bakery search = %v, want only %s", got, bakery.ID)
+	}
+
+	// Several categories (a parent and its children) merge into one ordering.
+	q := domain.GeoQuery{Latitude: lat, Longitude: lng, RadiusKm: 10, Categories: []string{"food", "coffee", "bakery"}, Limit: 25}
+	var all []domain.GeoResult
+	for {
+		page, err := repo.FindNearby(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, page...)
+		if len(page) < q.Limit {
+			break
+		}
+		last := page[len(page)-1]
+		q.After = &domain.GeoCursor{DistanceKm: last.DistanceKm, ID: last.ID, Seen: len(all)}
+	}
+	if len(all) != 61 || all[60].ID != bakery.ID.String() {
+		t.Fatalf("merged search returned %d results ending in %v, want 61 ending in the bakery", len(all), all[len(all)-1])
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].DistanceKm < all[i-1].DistanceKm {
+			t.Fatalf("merged results out of order at %d: %v after %v", i, all[i], all[i-1])
+		}
+	}
+
+	if err := repo.RemoveBusiness(ctx, bakery.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = repo.FindNearby(ctx, domain.GeoQuery{Latitude: lat, Longitude: lng, RadiusKm: 10, Categories: []string{"bakery"}, Limit: 50})
+	if len(got) != 0 {
+		t.Errorf("bakery search after RemoveBusiness = %v, want none", got)
+	}
+}
+
+func TestGeoRepositoryBulk(t *testing.T) {
+	repo := redisrepo.NewGeoRepository(testinfra.Redis(t))
+	ctx := context.Background()
+
+	// More than one batch, to exercise the chunking.
+	var locs []domain.GeoLocation
+	for i := 0; i < 1200; i++ {
+		locs = append(locs, domain.GeoLocation{ID: uuid.NewString(), Category: []string{"coffee", "bakery"}[i%2], Latitude: 19 + float64(i)*0.0001, Longitude: -99.1})
+	}
+	if err := repo.AddLocations(ctx, locs); err != nil {
+		t.Fatalf("AddLocations() error: %v", err)
+	}
+
+	got, err := repo.Locations(ctx)
+	if err != nil {
+		t.Fatalf("Locations() error: %v", err)
+	}
+	if len(got) != len(locs) {
+		t.Fatalf("Locations() returned %d, want %d", len(got), len(locs))
+	}
+	byID := make(map[string]domain.GeoLocation, len(got))
+	for _, l := range got {
+		byID[l.ID] = l
+	}
+	for _, want := range locs[:10] {
+		l := byID[want.ID]
+		if l.Category != want.Category {
+			t.Errorf("category of %s = %q, want %q", want.ID, l.Category, want.Category)
+		}
+		if math.Abs(l.Latitude-want.Latitude) > 1e-5 || math.Abs(l.Longitude-want.Longitude) > 1e-5 {
+			t.Errorf("position of %s = (%f, %f), want (%f, %f)", want.ID, l.Latitude, l.Longitude, want.Latitude, want.Longitude)
+		}
+	}
+
+	ids := make([]string, 0, 700)
+	for _, l := range locs[:700] {
+		ids = append(ids, l.ID)
+	}
+	if err := repo.RemoveLocations(ctx, ids); err != nil {
+		t.Fatalf("RemoveLocations() error: %v", err)
+	}
+	if n, _ := repo.Count(ctx); n != 500 {
+		t.Errorf("Count() after remove = %d, want 500", n)
+	}
+}
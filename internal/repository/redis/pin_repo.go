@@ -0,0 +1,41 @@
+package redisrepo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const pinPrefix = "order:pin:"
+
+// PINRepository caches pickup PINs so validation doesn't need a Postgres read.
+type PINRepository struct {
+	client *redis.Client
+}
+
+func NewPINRepository(client *redis.Client) *PINRepository {
+	return &PINRepository{client: client}
+}
+
+func (r *PINRepository) Save(ctx context.Context, orderID uuid.UUID, pin string, ttl time.Duration) error {
+	return r.client.Set(ctx, pinPrefix+orderID.String(), pin, ttl).Err()
+}
+
+// Get returns the cached PIN; found is false when the entry expired or was evicted.
+func (r *PINRepository) Get(ctx context.Context, orderID uuid.UUID) (pin string, found bool, err error) {
+	pin, err = r.client.Get(ctx, pinPrefix+orderID.String()).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return pin, true, nil
+}
+
+func (r *PINRepository) Delete(ctx context.Context, orderID uuid.UUID) error {
+	return r.client.Del(ctx, pinPrefix+orderID.String()).Err()
+}
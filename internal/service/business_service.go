@@ -2,28 +2,44 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/heptapegon/localpickup/internal/domain"
-	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
-	redisrepo "github.com/heptapegon/localpickup/internal/repository/redis"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/metrics"
 )
 
 type BusinessService struct {
-	repo    *postgresrepo.BusinessRepository
-	geoRepo *redisrepo.GeoRepository
+	repo       BusinessStore
+	geoRepo    GeoIndex
+	categories *CategoryService
+	images     ImageStorage
+	logger     *slog.Logger
 }
 
 func NewBusinessService(
-	repo *postgresrepo.BusinessRepository,
-	geoRepo *redisrepo.GeoRepository,
+	repo BusinessStore,
+	geoRepo GeoIndex,
+	categories *CategoryService,
+	images ImageStorage,
+	logger *slog.Logger,
 ) *BusinessService {
-	return &BusinessService{repo: repo, geoRepo: geoRepo}
+	return &BusinessService{repo: repo, geoRepo: geoRepo, categories: categories, images: images, logger: logger}
 }
 
 func (s *BusinessService) Create(ctx context.Context, ownerID uuid.UUID, req *domain.CreateBusinessRequest) (*domain.Business, error) {
+	if err := s.categories.Validate(ctx, req.Category); err != nil {
+		return nil, err
+	}
+
 	b := &domain.Business{
 		ID:          uuid.New(),
 		OwnerID:     ownerID,
@@ -43,31 +59,184 @@ func (s *BusinessService) Create(ctx context.Context, ownerID uuid.UUID, req *do
 		return nil, err
 	}
 
-	// Index in Redis for geo queries. Non-fatal: data can be re-indexed via
-	// a background job or admin endpoint if this call fails.
+	ctx = logging.With(ctx, logging.BusinessID, b.ID.String())
+	s.logger.InfoContext(ctx, "business created", "category", b.Category)
+
+	// Index in Redis for geo queries. Non-fatal: GeoReconciler adds missing
+	// businesses on its next pass (or via POST /api/v1/admin/geo/reindex).
 	if err := s.geoRepo.IndexBusiness(ctx, b); err != nil {
-		// log in production; omit import here to keep the layer clean
-		_ = err
+		s.logger.ErrorContext(ctx, "failed to index business location; it won't appear in nearby results until reindexed", logging.Error, err)
 	}
 
 	return b, nil
 }
 
-// GetNearby queries Redis for IDs within radiusKm and hydrates them from Postgres.
-func (s *BusinessService) GetNearby(ctx context.Context, q *domain.NearbyQuery) ([]*domain.Business, error) {
-	radius := q.RadiusKm
-	if radius <= 0 {
-		radius = 5.0
+// Page sizes for nearby searches.
+const (
+	defaultNearbyLimit  = 20
+	maxNearbyLimit      = 50
+	defaultNearbyRadius = 5.0
+)
+
+// nearbyCursor is the opaque next_cursor handed to clients. It records the
+// search it belongs to so it can't be replayed against a different one.
+type nearbyCursor struct {
+	domain.GeoCursor
+	Query string `json:"q"`
+}
+
+// GetNearby queries the geo index for one page of businesses within the radius
+// (filtered by category inside the index) and hydrates them from Postgres. A
+// parent category matches businesses in any of its subcategories. Results are
+// nearest first unless q.Sort asks for the best rated.
+func (s *BusinessService) GetNearby(ctx context.Context, q *domain.NearbyQuery) (*domain.NearbyPage, error) {
+	categories, err := s.categories.Subtree(ctx, q.Category)
+	if err != nil {
+		return nil, err
+	}
+	geoQuery := domain.GeoQuery{
+		Latitude:   q.Latitude,
+		Longitude:  q.Longitude,
+		RadiusKm:   q.RadiusKm,
+		Categories: categories,
+		Limit:      q.Limit,
+	}
+	if geoQuery.RadiusKm <= 0 {
+		geoQuery.RadiusKm = defaultNearbyRadius
+	}
+	if geoQuery.Limit <= 0 {
+		geoQuery.Limit = defaultNearbyLimit
+	}
+	geoQuery.Limit = min(geoQuery.Limit, maxNearbyLimit)
+
+	fingerprint := nearbyFingerprint(geoQuery, q.Category)
+	if q.Sort == domain.SortRating {
+		return s.nearbyByRating(ctx, q, geoQuery, categories, fingerprint+","+q.Sort)
 	}
+	if q.Cursor != "" {
+		after, err := decodeNearbyCursor(q.Cursor, fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		geoQuery.After = after
+	}
+
+	// Ask for one extra result to learn whether there is a next page.
+	pageSize := geoQuery.Limit
+	geoQuery.Limit++
 
-	results, err := s.geoRepo.FindNearby(ctx, q.Latitude, q.Longitude, radius)
+	start := time.Now()
+	results, err := s.geoRepo.FindNearby(ctx, geoQuery)
 	if err != nil {
 		return nil, err
 	}
+	metrics.GeoSearch(time.Since(start), len(results))
+
+	page := &domain.NearbyPage{Data: []*domain.NearbyBusiness{}}
+	if len(results) > pageSize {
+		results = results[:pageSize]
+		last := results[pageSize-1]
+		seen := pageSize
+		if geoQuery.After != nil {
+			seen += geoQuery.After.Seen
+		}
+		page.NextCursor = encodeNearbyCursor(nearbyCursor{
+			GeoCursor: domain.GeoCursor{DistanceKm: last.DistanceKm, ID: last.ID, Seen: seen, Index: last.Index},
+			Query:     fingerprint,
+		})
+	}
+
+	if page.Data, err = s.hydrateNearby(ctx, results, categories); err != nil {
+		return nil, err
+	}
+	page.Count = len(page.Data)
+	return page, nil
+}
+
+// maxRatedCandidates caps how many of the nearest businesses a rating-sorted
+// nearby search ranks; the index only knows distances, so they are all
+// hydrated and sorted in memory.
+const maxRatedCandidates = 500
+
+// nearbyByRating serves one page of a rating-sorted nearby search: best
+// average first, unrated businesses last, ties broken by rating count then
+// distance. The cursor records how many results were already served.
+func (s *BusinessService) nearbyByRating(ctx context.Context, q *domain.NearbyQuery, geoQuery domain.GeoQuery, categories []string, fingerprint string) (*domain.NearbyPage, error) {
+	var after *domain.GeoCursor
+	offset := 0
+	if q.Cursor != "" {
+		var err error
+		if after, err = decodeNearbyCursor(q.Cursor, fingerprint); err != nil {
+			return nil, err
+		}
+		offset = after.Seen
+	}
+
+	pageSize := geoQuery.Limit
+	geoQuery.Limit = maxRatedCandidates
+	start := time.Now()
+	results, err := s.geoRepo.FindNearby(ctx, geoQuery)
+	if err != nil {
+		return nil, err
+	}
+	metrics.GeoSearch(time.Since(start), len(results))
+	// Ties in rating are broken by distance, which differs between indexes.
+	if after != nil && len(results) > 0 && geoIndexOf(results) != after.Index {
+		return nil, domain.ErrStaleCursor
+	}
+
+	ranked, err := s.hydrateNearby(ctx, results, categories)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ratedBefore(ranked[i], ranked[j]) })
+
+	page := &domain.NearbyPage{Data: []*domain.NearbyBusiness{}}
+	if offset < len(ranked) {
+		page.Data = ranked[offset:min(offset+pageSize, len(ranked))]
+	}
+	if seen := offset + len(page.Data); seen < len(ranked) {
+		last := page.Data[len(page.Data)-1]
+		page.NextCursor = encodeNearbyCursor(nearbyCursor{
+			GeoCursor: domain.GeoCursor{DistanceKm: last.DistanceKm, ID: last.ID.String(), Seen: seen, Index: geoIndexOf(results)},
+			Query:     fingerprint,
+		})
+	}
+	page.Count = len(page.Data)
+	return page, nil
+}
+
+// geoIndexOf returns the index that served results.
+func geoIndexOf(results []domain.GeoResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+	return results[0].Index
+}
+
+// ratedBefore orders nearby results for SortRating.
+func ratedBefore(a, b *domain.NearbyBusiness) bool {
+	switch {
+	case (a.Rating == nil) != (b.Rating == nil):
+		return a.Rating != nil
+	case a.Rating != nil && *a.Rating != *b.Rating:
+		return *a.Rating > *b.Rating
+	case a.RatingCount != b.RatingCount:
+		return a.RatingCount > b.RatingCount
+	case a.DistanceKm != b.DistanceKm:
+		return a.DistanceKm < b.DistanceKm
+	}
+	return a.ID.String() < b.ID.String()
+}
 
+// hydrateNearby loads the businesses behind geo index results, keeping their
+// order.
+func (s *BusinessService) hydrateNearby(ctx context.Context, results []domain.GeoResult, categories []string) ([]*domain.NearbyBusiness, error) {
 	ids := make([]string, 0, len(results))
+	distances := make(map[string]float64, len(results))
 	for _, r := range results {
 		ids = append(ids, r.ID)
+		distances[r.ID] = r.DistanceKm
 	}
 
 	businesses, err := s.repo.GetByIDs(ctx, ids)
@@ -75,19 +244,132 @@ func (s *BusinessService) GetNearby(ctx context.Context, q *domain.NearbyQuery)
 		return nil, err
 	}
 
-	if q.Category == "" {
-		return businesses, nil
+	out := make([]*domain.NearbyBusiness, 0, len(businesses))
+	for _, b := range businesses {
+		// The index may briefly disagree with the database until the next
+		// reconcile; the database wins.
+		if categories != nil && !slices.Contains(categories, b.Category) {
+			continue
+		}
+		attachBusinessImages(s.images, b)
+		out = append(out, &domain.NearbyBusiness{Business: *b, DistanceKm: distances[b.ID.String()]})
 	}
+	return out, nil
+}
 
-	filtered := businesses[:0]
-	for _, b := range businesses {
-		if b.Category == q.Category {
-			filtered = append(filtered, b)
+const (
+	// clusterMaxZoom is the zoom level from which the map shows individual
+	// businesses instead of clusters.
+	clusterMaxZoom = 15
+	// maxBoundsPins caps the businesses returned individually; denser
+	// viewports are clustered whatever the zoom.
+	maxBoundsPins = 500
+)
+
+// InBounds returns what the map should draw for a viewport: the businesses
+// themselves when zoomed in to clusterMaxZoom and no more than maxBoundsPins
+// are visible, grid clusters otherwise.
+func (s *BusinessService) InBounds(ctx context.Context, q *domain.BoundsQuery) (*domain.BoundsResult, error) {
+	categories, err := s.categories.Subtree(ctx, q.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	boxes := []domain.Bounds{{North: q.North, South: q.South, East: q.East, West: q.West}}
+	if q.West > q.East {
+		// The viewport crosses the antimeridian; search each side separately.
+		boxes = []domain.Bounds{
+			{North: q.North, South: q.South, West: q.West, East: 180},
+			{North: q.North, South: q.South, West: -180, East: q.East},
+		}
+	}
+
+	var locs []domain.GeoLocation
+	for _, b := range boxes {
+		found, err := s.geoRepo.FindInBounds(ctx, b, categories)
+		if err != nil {
+			return nil, err
 		}
+		locs = append(locs, found...)
+	}
+
+	result := &domain.BoundsResult{Zoom: q.Zoom, Count: len(locs)}
+	if q.Zoom < clusterMaxZoom || len(locs) > maxBoundsPins {
+		result.Clustered = true
+		result.Clusters = clusterLocations(locs, q.Zoom)
+		return result, nil
+	}
+
+	ids := make([]string, 0, len(locs))
+	for _, l := range locs {
+		ids = append(ids, l.ID)
 	}
-	return filtered, nil
+	sort.Strings(ids)
+	businesses, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	attachBusinessImages(s.images, businesses...)
+	result.Businesses = businesses
+	result.Count = len(businesses)
+	return result, nil
+}
+
+// nearbyFingerprint identifies the search a cursor was issued for.
+func nearbyFingerprint(q domain.GeoQuery, category string) string {
+	return strconv.FormatFloat(q.Latitude, 'f', 6, 64) + "," +
+		strconv.FormatFloat(q.Longitude, 'f', 6, 64) + "," +
+		strconv.FormatFloat(q.RadiusKm, 'f', 3, 64) + "," + category
+}
+
+func encodeNearbyCursor(c nearbyCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeNearbyCursor(s, fingerprint string) (*domain.GeoCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor.WithCause(err)
+	}
+	var c nearbyCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, domain.ErrInvalidCursor.WithCause(err)
+	}
+	if c.Query != fingerprint || c.ID == "" || c.Seen <= 0 {
+		return nil, domain.ErrInvalidCursor
+	}
+	return &c.GeoCursor, nil
 }
 
 func (s *BusinessService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Business, error) {
-	return s.repo.GetByID(ctx, id)
+	b, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	attachBusinessImages(s.images, b)
+	return b, nil
+}
+
+// SetImage replaces the picture of an owner's business with an upload, which
+// must be a JPEG, PNG or WebP file. Nil data removes the picture.
+func (s *BusinessService) SetImage(ctx context.Context, ownerID, businessID uuid.UUID, data []byte) (*domain.Business, error) {
+	ctx = logging.With(ctx, logging.BusinessID, businessID.String())
+	b, err := s.repo.GetByID(ctx, businessID)
+	if err != nil {
+		return nil, err
+	}
+	if b.OwnerID != ownerID {
+		return nil, domain.ErrBusinessForbidden
+	}
+
+	key, err := replaceImage(ctx, s.images, s.logger, "businesses/"+businessID.String(), b.ImageKey, data,
+		func(key string) error { return s.repo.SetImage(ctx, businessID, key) })
+	if err != nil {
+		return nil, err
+	}
+	b.ImageKey = key
+	attachBusinessImages(s.images, b)
+	s.logger.InfoContext(ctx, "business image updated", "removed", key == "")
+	return b, nil
 }
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/repository/memory"
+)
+
+// newTestCategories serves a small taxonomy: food > {coffee, bakery}, pharmacy.
+func newTestCategories() *CategoryService {
+	return NewCategoryService(memory.NewCategoryRepository(
+		domain.Category{Slug: "food", Names: map[string]string{"es": "Comida", "en": "Food"}},
+		domain.Category{Slug: "coffee", ParentSlug: "food", Names: map[string]string{"es": "Cafetería"}, SortOrder: 2},
+		domain.Category{Slug: "bakery", ParentSlug: "food", Names: map[string]string{"es": "Panadería"}, SortOrder: 1},
+		domain.Category{Slug: "pharmacy", Names: map[string]string{"es": "Farmacia"}, SortOrder: 1},
+	), logging.Nop())
+}
+
+func TestBusinessServiceGetNearby(t *testing.T) {
+	ctx := context.Background()
+	svc := NewBusinessService(memory.NewBusinessRepository(), memory.NewGeoRepository(), newTestCategories(), memory.NewStorage(), logging.Nop())
+	owner := uuid.New()
+
+	// Roughly 0.5 km, 1 km, 1.5 km and 20 km north of the search point.
+	seed := []domain.CreateBusinessRequest{
+		{Name: "Cafe Near", Latitude: 19.4371, Longitude: -99.1332, Category: "coffee"},
+		{Name: "Pharmacy", Latitude: 19.4416, Longitude: -99.1332, Category: "pharmacy"},
+		{Name: "Bakery Mid", Latitude: 19.4461, Longitude: -99.1332, Category: "bakery"},
+		{Name: "Cafe Far", Latitude: 19.6126, Longitude: -99.1332, Category: "coffee"},
+	}
+	for i := range seed {
+		if _, err := svc.Create(ctx, owner, &seed[i]); err != nil {
+			t.Fatalf("Create(%s) error: %v", seed[i].Name, err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query domain.NearbyQuery
+		want  []string
+	}{
+		{name: "default radius sorted by distance", query: domain.NearbyQuery{}, want: []string{"Cafe Near", "Pharmacy", "Bakery Mid"}},
+		{name: "larger radius", query: domain.NearbyQuery{RadiusKm: 25}, want: []string{"Cafe Near", "Pharmacy", "Bakery Mid", "Cafe Far"}},
+		{name: "category filter", query: domain.NearbyQuery{RadiusKm: 25, Category: "coffee"}, want: []string{"Cafe Near", "Cafe Far"}},
+		{name: "parent category filter", query: domain.NearbyQuery{RadiusKm: 25, Category: "food"}, want: []string{"Cafe Near", "Bakery Mid", "Cafe Far"}},
+		{name: "nothing in range", query: domain.NearbyQuery{RadiusKm: 0.1}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.query
+			q.Latitude, q.Longitude = 19.4326, -99.1332
+
+			got, err := svc.GetNearby(ctx, &q)
+			if err != nil {
+				t.Fatalf("GetNearby() error: %v", err)
+			}
+			if len(got.Data) != len(tt.want) || got.Count != len(tt.want) {
+				t.Fatalf("got %d businesses (count %d), want %d", len(got.Data), got.Count, len(tt.want))
+			}
+			for i, b := range got.Data {
+				if b.Name != tt.want[i] {
+					t.Errorf("result[%d] = %q, want %q", i, b.Name, tt.want[i])
+				}
+				if b.DistanceKm <= 0 || (i > 0 && b.DistanceKm < got.Data[i-1].DistanceKm) {
+					t.Errorf("result[%d] distance = %v, want positive and ascending", i, b.DistanceKm)
+				}
+			}
+			if got.NextCursor != "" {
+				t.Errorf("NextCursor = %q on a single page", got.NextCursor)
+			}
+		})
+	}
+}
+
+func TestBusinessServiceCategoryValidation(t *testing.T) {
+	ctx := context.Background()
+	svc := NewBusinessService(memory.NewBusinessRepository(), memory.NewGeoRepository(), newTestCategories(), memory.NewStorage(), logging.Nop())
+
+	for _, category := range []string{"Coffee", "cafetería", "florist"} {
+		req := domain.CreateBusinessRequest{Name: "Shop", Latitude: 19.4, Longitude: -99.1, Category: category}
+		if _, err := svc.Create(ctx, uuid.New(), &req); !errors.Is(err, domain.ErrUnknownCategory) {
+			t.Errorf("Create(category %q) error = %v, want ErrUnknownCategory", category, err)
+		}
+	}
+
+	if _, err := svc.GetNearby(ctx, &domain.NearbyQuery{Latitude: 19.4, Longitude: -99.1, Category: "florist"}); !errors.Is(err, domain.ErrUnknownCategory) {
+		t.Errorf("GetNearby(unknown category) error = %v, want ErrUnknownCategory", err)
+	}
+	if _, err := svc.InBounds(ctx, &domain.BoundsQuery{North: 20, South: 19, East: -99, West: -100, Category: "florist"}); !errors.Is(err, domain.ErrUnknownCategory) {
+		t.Errorf("InBounds(unknown category) error = %v, want ErrUnknownCategory", err)
+	}
+}
+
+func TestBusinessServiceGetNearbyPagination(t *testing.T) {
+	ctx := context.Background()
+	svc := NewBusinessService(memory.NewBusinessRepository(), memory.NewGeoRepository(), newTestCategories(), memory.NewStorage(), logging.Nop())
+	owner := uuid.New()
+
+	// 60 coffee shops packed within ~700 m, and one bakery 2 km out: the
+	// bakery must still be found when filtering, even though it is 61st overall.
+	var coffee []string
+	for i := 0; i < 60; i++ {
+		req := domain.CreateBusinessRequest{Name: fmt.Sprintf("Cafe %02d", i), Latitude: 19.4326 + float64(i)*0.0001, Longitude: -99.1332, Category: "coffee"}
+		if _, err := svc.Create(ctx, owner, &req); err != nil {
+			t.Fatal(err)
+		}
+		coffee = append(coffee, req.Name)
+	}
+	bakery := domain.CreateBusinessRequest{Name: "Bakery", Latitude: 19.4506, Longitude: -99.1332, Category: "bakery"}
+	if _, err := svc.Create(ctx, owner, &bakery); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("category filtered before the cap", func(t *testing.T) {
+		q := domain.NearbyQuery{Latitude: 19.4326, Longitude: -99.1332, Category: "bakery", Limit: 50}
+		page, err := svc.GetNearby(ctx, &q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Count != 1 || page.Data[0].Name != "Bakery" {
+			t.Errorf("bakery search returned %d results, want the bakery", page.Count)
+		}
+	})
+
+	t.Run("cursor walks every result once", func(t *testing.T) {
+		q := domain.NearbyQuery{Latitude: 19.4326, Longitude: -99.1332, Category: "coffee", Limit: 25}
+		var got []string
+		for pages := 0; ; pages++ {
+			if pages > 3 {
+				t.Fatal("pagination did not terminate")
+			}
+			page, err := svc.GetNearby(ctx, &q)
+			if err != nil {
+				t.Fatalf("GetNearby() error: %v", err)
+			}
+			for _, b := range page.Data {
+				got = append(got, b.Name)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+		if len(got) != len(coffee) {
+			t.Fatalf("paged through %d businesses, want %d", len(got), len(coffee))
+		}
+		for i := range coffee {
+			if got[i] != coffee[i] {
+				t.Fatalf("result[%d] = %q, want %q", i, got[i], coffee[i])
+			}
+		}
+	})
+
+	t.Run("invalid cursors", func(t *testing.T) {
+		first, err := svc.GetNearby(ctx, &domain.NearbyQuery{Latitude: 19.4326, Longitude: -99.1332, Category: "coffee", Limit: 10})
+		if err != nil || first.NextCursor == "" {
+			t.Fatalf("first page: %v, cursor %q", err, first.NextCursor)
+		}
+		for name, q := range map[string]domain.NearbyQuery{
+			"garbage":          {Latitude: 19.4326, Longitude: -99.1332, Category: "coffee", Cursor: "not a cursor"},
+			"different search": {Latitude: 19.4326, Longitude: -99.1332, Category: "bakery", Cursor: first.NextCursor},
+		} {
+			if _, err := svc.GetNearby(ctx, &q); !errors.Is(err, domain.ErrInvalidCursor) {
+				t.Errorf("%s: err = %v, want ErrInvalidCursor", name, err)
+			}
+		}
+	})
+}
+
+func TestBusinessServiceGetNearbyByRating(t *testing.T) {
+	ctx := context.Background()
+	businesses := memory.NewBusinessRepository()
+	reviews := memory.NewReviewRepository(businesses)
+	svc := NewBusinessService(businesses, memory.NewGeoRepository(), newTestCategories(), memory.NewStorage(), logging.Nop())
+	owner := uuid.New()
+
+	// Nearest first: an unrated cafe, a 4-star cafe with two ratings, a 4-star
+	// cafe with one and a 5-star cafe.
+	ratings := map[string][]int{"Unrated": nil, "Four twice": {5, 3}, "Four once": {4}, "Five": {5}}
+	for i, name := range []string{"Unrated", "Four twice", "Four once", "Five"} {
+		req := domain.CreateBusinessRequest{Name: name, Latitude: 19.4326 + float64(i+1)*0.001, Longitude: -99.1332, Category: "coffee"}
+		b, err := svc.Create(ctx, owner, &req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range ratings[name] {
+			review := &domain.Review{ID: uuid.New(), OrderID: uuid.New(), BusinessID: b.ID, Rating: r, Status: domain.ReviewPublished}
+			if err := reviews.Create(ctx, review); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	q := domain.NearbyQuery{Latitude: 19.4326, Longitude: -99.1332, Sort: domain.SortRating, Limit: 3}
+	var got []string
+	for pages := 0; ; pages++ {
+		if pages > 2 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, err := svc.GetNearby(ctx, &q)
+		if err != nil {
+			t.Fatalf("GetNearby() error: %v", err)
+		}
+		for _, b := range page.Data {
+			got = append(got, b.Name)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	if want := []string{"Five", "Four twice", "Four once", "Unrated"}; !slices.Equal(got, want) {
+		t.Errorf("rating order = %v, want %v", got, want)
+	}
+
+	distanceFirst, err := svc.GetNearby(ctx, &domain.NearbyQuery{Latitude: 19.4326, Longitude: -99.1332, Limit: 3})
+	if err != nil || distanceFirst.NextCursor == "" {
+		t.Fatalf("distance page: %v, cursor %q", err, distanceFirst.NextCursor)
+	}
+	if _, err := svc.GetNearby(ctx, &domain.NearbyQuery{Latitude: 19.4326, Longitude: -99.1332, Sort: domain.SortRating, Cursor: distanceFirst.NextCursor, Limit: 3}); !errors.Is(err, domain.ErrInvalidCursor) {
+		t.Errorf("distance cursor on a rating search: err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestBusinessServiceInBounds(t *testing.T) {
+	ctx := context.Background()
+	svc := NewBusinessService(memory.NewBusinessRepository(), memory.NewGeoRepository(), newTestCategories(), memory.NewStorage(), logging.Nop())
+	owner := uuid.New()
+
+	seed := []domain.CreateBusinessRequest{
+		// Three shops in the Centro block, one in Roma, one just outside the box.
+		{Name: "Cafe A", Latitude: 19.4330, Longitude: -99.1330, Category: "coffee"},
+		{Name: "Cafe B", Latitude: 19.4332, Longitude: -99.1334, Category: "coffee"},
+		{Name: "Bakery A", Latitude: 19.4334, Longitude: -99.1338, Category: "bakery"},
+		{Name: "Bakery Roma", Latitude: 19.4150, Longitude: -99.1620, Category: "bakery"},
+		{Name: "Outside", Latitude: 19.5000, Longitude: -99.1330, Category: "coffee"},
+		// Either side of the antimeridian, in Fiji.
+		{Name: "Suva", Latitude: -17.80, Longitude: 178.50, Category: "coffee"},
+		{Name: "Taveuni", Latitude: -16.80, Longitude: -179.90, Category: "coffee"},
+	}
+	for i := range seed {
+		if _, err := svc.Create(ctx, owner, &seed[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cdmx := domain.BoundsQuery{North: 19.45, South: 19.40, East: -99.10, West: -99.18}
+
+	t.Run("pins when zoomed in", func(t *testing.T) {
+		q := cdmx
+		q.Zoom = 16
+		got, err := svc.InBounds(ctx, &q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Clustered || got.Count != 4 || len(got.Businesses) != 4 {
+			t.Fatalf("got clustered=%v count=%d businesses=%d, want 4 pins", got.Clustered, got.Count, len(got.Businesses))
+		}
+		for _, b := range got.Businesses {
+			if b.Name == "Outside" {
+				t.Errorf("business outside the viewport returned")
+			}
+		}
+	})
+
+	t.Run("pins filtered by category", func(t *testing.T) {
+		q := cdmx
+		q.Zoom, q.Category = 16, "bakery"
+		got, err := svc.InBounds(ctx, &q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Count != 2 {
+			t.Errorf("count = %d, want the 2 bakeries", got.Count)
+		}
+	})
+
+	t.Run("clusters when zoomed out", func(t *testing.T) {
+		q := cdmx
+		q.Zoom = 12
+		got, err := svc.InBounds(ctx, &q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Clustered || got.Count != 4 || len(got.Clusters) != 2 {
+			t.Fatalf("got clustered=%v count=%d clusters=%+v, want 2 clusters of 4 businesses", got.Clustered, got.Count, got.Clusters)
+		}
+		centro := got.Clusters[0]
+		if centro.Count != 3 || math.Abs(centro.Latitude-19.4332) > 1e-6 || math.Abs(centro.Longitude+99.1334) > 1e-6 {
+			t.Errorf("largest cluster = %+v, want 3 businesses around (19.4332, -99.1334)", centro)
+		}
+		wantTop := []domain.CategoryCount{{Category: "coffee", Count: 2}, {Category: "bakery", Count: 1}}
+		if len(centro.TopCategories) != 2 || centro.TopCategories[0] != wantTop[0] || centro.TopCategories[1] != wantTop[1] {
+			t.Errorf("top categories = %+v, want %+v", centro.TopCategories, wantTop)
+		}
+	})
+
+	t.Run("viewport across the antimeridian", func(t *testing.T) {
+		q := domain.BoundsQuery{North: -16, South: -18, East: -179, West: 178, Zoom: 16}
+		got, err := svc.InBounds(ctx, &q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Count != 2 {
+			t.Errorf("count = %d, want Suva and Taveuni", got.Count)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+)
+
+// CartService keeps the carts customers build before ordering, quotes them
+// with the same pricing as OrderService and turns quoted carts into orders.
+type CartService struct {
+	carts      CartStore
+	orders     *OrderService
+	businesses BusinessStore
+	ttl        time.Duration
+	logger     *slog.Logger
+}
+
+func NewCartService(carts CartStore, orders *OrderService, businesses BusinessStore, ttl time.Duration, logger *slog.Logger) *CartService {
+	return &CartService{carts: carts, orders: orders, businesses: businesses, ttl: ttl, logger: logger}
+}
+
+// Save replaces the items of the customer's cart for the business, creating
+// the cart if needed. Any previous quote is dropped and the TTL restarts.
+func (s *CartService) Save(ctx context.Context, customerID uuid.UUID, req *domain.CartRequest) (*domain.Cart, error) {
+	if _, err := s.businesses.GetByID(ctx, req.BusinessID); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	cart, err := s.carts.Find(ctx, customerID, req.BusinessID)
+	switch {
+	case errors.Is(err, domain.ErrCartNotFound):
+		cart = &domain.Cart{ID: uuid.New(), CustomerID: customerID, BusinessID: req.BusinessID}
+	case err != nil:
+		return nil, err
+	}
+	cart.Items = req.Items
+	cart.PromoCode = req.PromoCode
+	cart.Quote = nil
+	if err := s.save(ctx, cart, now); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func (s *CartService) save(ctx context.Context, cart *domain.Cart, now time.Time) error {
+	cart.UpdatedAt = now
+	cart.ExpiresAt = now.Add(s.ttl)
+	return s.carts.Save(ctx, cart, s.ttl)
+}
+
+// Get returns one of the customer's carts.
+func (s *CartService) Get(ctx context.Context, customerID, cartID uuid.UUID) (*domain.Cart, error) {
+	cart, err := s.carts.Get(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	if cart.CustomerID != customerID {
+		return nil, domain.ErrCartForbidden
+	}
+	return cart, nil
+}
+
+// List returns the customer's carts that have not expired.
+func (s *CartService) List(ctx context.Context, customerID uuid.UUID) ([]*domain.Cart, error) {
+	carts, err := s.carts.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if carts == nil {
+		carts = []*domain.Cart{}
+	}
+	return carts, nil
+}
+
+// Delete empties and removes a cart.
+func (s *CartService) Delete(ctx context.Context, customerID, cartID uuid.UUID) error {
+	if _, err := s.Get(ctx, customerID, cartID); err != nil {
+		return err
+	}
+	_, err := s.carts.Take(ctx, cartID)
+	return err
+}
+
+// Quote prices the cart as an order placed now would be and keeps the quote
+// in the cart, so that Checkout charges exactly its total.
+func (s *CartService) Quote(ctx context.Context, customerID, cartID uuid.UUID) (*domain.Cart, error) {
+	cart, err := s.Get(ctx, customerID, cartID)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := s.orders.Quote(ctx, customerID, cart.BusinessID, cart.Items, cart.PromoCode)
+	if err != nil {
+		return nil, err
+	}
+	cart.Quote = quote
+	if err := s.save(ctx, cart, quote.QuotedAt); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// Checkout places the order for a quoted cart. The cart is taken first, so
+// two concurrent checkouts cannot both order it; if the order fails the cart
+// is put back, without its quote when prices or promotions changed since.
+func (s *CartService) Checkout(ctx context.Context, customerID, cartID uuid.UUID) (*domain.OrderResponse, error) {
+	if _, err := s.Get(ctx, customerID, cartID); err != nil {
+		return nil, err
+	}
+	cart, err := s.carts.Take(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	if cart.Quote == nil {
+		s.restore(ctx, cart)
+		return nil, domain.ErrCartNotQuoted
+	}
+
+	total := cart.Quote.Total
+	resp, err := s.orders.Create(ctx, customerID, &domain.CreateOrderRequest{
+		BusinessID:    cart.BusinessID,
+		Items:         cart.Items,
+		PromoCode:     cart.PromoCode,
+		ExpectedTotal: &total,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrPriceChanged) || errors.Is(err, domain.ErrPromotionExhausted) {
+			cart.Quote = nil
+		}
+		s.restore(ctx, cart)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// restore puts back a cart taken by a checkout that did not go through. If
+// the customer saved a new cart for the business meanwhile, that one holds
+// their latest edits and the taken cart is dropped.
+func (s *CartService) restore(ctx context.Context, cart *domain.Cart) {
+	now := time.Now().UTC()
+	cart.UpdatedAt = now
+	cart.ExpiresAt = now.Add(s.ttl)
+	ctx = logging.With(ctx, logging.BusinessID, cart.BusinessID.String())
+	restored, err := s.carts.Restore(ctx, cart, s.ttl)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to restore cart after checkout", "cart_id", cart.ID, logging.Error, err)
+		return
+	}
+	if !restored {
+		s.logger.InfoContext(ctx, "dropped cart replaced during checkout", "cart_id", cart.ID)
+	}
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/repository/memory"
+)
+
+// newCartFixture builds a cart service over an order fixture that charges
+// 10 % tax, a $0.50 fee and needs 15 minutes plus 5 per queued order.
+func newCartFixture(t *testing.T) (*orderFixture, *CartService, *memory.CartRepository) {
+	t.Helper()
+	f := newOrderFixture(t)
+	f.svc = NewOrderService(f.orders, f.businesses, f.products, f.payments, f.notifier, f.pins, f.promotions, Pricing{
+		TaxRate:          0.1,
+		ServiceFee:       0.5,
+		PrepTime:         15 * time.Minute,
+		PrepTimePerOrder: 5 * time.Minute,
+	}, logging.Nop())
+	carts := memory.NewCartRepository()
+	return f, NewCartService(carts, f.svc, f.businesses, time.Hour, logging.Nop()), carts
+}
+
+func TestCartServiceSave(t *testing.T) {
+	f, svc, _ := newCartFixture(t)
+	ctx := context.Background()
+
+	first, err := svc.Save(ctx, f.customerID, &domain.CartRequest{
+		BusinessID: f.business.ID,
+		Items:      []domain.CreateOrderItemReq{{ProductID: f.concha.ID, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if _, err := svc.Quote(ctx, f.customerID, first.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := svc.Save(ctx, f.customerID, &domain.CartRequest{
+		BusinessID: f.business.ID,
+		Items:      []domain.CreateOrderItemReq{{ProductID: f.bolillo.ID, Quantity: 3}},
+	})
+	if err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if second.ID != first.ID || second.Quote != nil || len(second.Items) != 1 || second.Items[0].ProductID != f.bolillo.ID {
+		t.Errorf("Save() again = %+v, want the same cart with the new items and no quote", second)
+	}
+	if !second.ExpiresAt.Equal(second.UpdatedAt.Add(time.Hour)) {
+		t.Errorf("ExpiresAt = %v, want an hour after %v", second.ExpiresAt, second.UpdatedAt)
+	}
+
+	carts, err := svc.List(ctx, f.customerID)
+	if err != nil || len(carts) != 1 {
+		t.Errorf("List() = %d carts, %v, want 1", len(carts), err)
+	}
+	if _, err := svc.Get(ctx, uuid.New(), first.ID); !errors.Is(err, domain.ErrCartForbidden) {
+		t.Errorf("Get() by another customer error = %v, want ErrCartForbidden", err)
+	}
+	if _, err := svc.Save(ctx, f.customerID, &domain.CartRequest{BusinessID: uuid.New(), Items: second.Items}); !errors.Is(err, domain.ErrBusinessNotFound) {
+		t.Errorf("Save(unknown business) error = %v, want ErrBusinessNotFound", err)
+	}
+
+	if err := svc.Delete(ctx, f.customerID, first.ID); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if _, err := svc.Get(ctx, f.customerID, first.ID); !errors.Is(err, domain.ErrCartNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want ErrCartNotFound", err)
+	}
+}
+
+func TestCartServiceQuote(t *testing.T) {
+	f, svc, _ := newCartFixture(t)
+	ctx := context.Background()
+	f.placeOrder(t)
+	f.placeOrder(t)
+
+	cart, err := svc.Save(ctx, f.customerID, &domain.CartRequest{
+		BusinessID: f.business.ID,
+		Items: []domain.CreateOrderItemReq{
+			{ProductID: f.concha.ID, Quantity: 2},
+			{ProductID: f.bolillo.ID, Quantity: 3},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now()
+	quoted, err := svc.Quote(ctx, f.customerID, cart.ID)
+	if err != nil {
+		t.Fatalf("Quote() error: %v", err)
+	}
+
+	q := quoted.Quote
+	if q == nil || len(q.Lines) != 2 || q.Lines[0].LineTotal != 3 || q.Lines[1].LineTotal != 2.25 {
+		t.Fatalf("quote lines = %+v, want 2 × $1.50 and 3 × $0.75", q)
+	}
+	// $5.25 of items, $0.53 tax (rounded to the cent) and the $0.50 fee.
+	if q.Subtotal != 5.25 || q.Tax != 0.53 || q.Fees != 0.5 || q.Discount != 0 || q.Total != 6.28 {
+		t.Errorf("quote = %+v, want 5.25 + 0.53 + 0.50 = 6.28", q)
+	}
+	// Two paid orders ahead: 15 + 2 × 5 minutes, rounded up to the minute.
+	earliest := before.Add(25 * time.Minute).Truncate(time.Minute)
+	if q.EarliestPickup.Before(earliest) || q.EarliestPickup.After(earliest.Add(2*time.Minute)) || q.EarliestPickup.Second() != 0 {
+		t.Errorf("EarliestPickup = %v, want about 25 minutes from now on a whole minute", q.EarliestPickup)
+	}
+	if got := f.stockOf(t, f.bolillo); got != 10 {
+		t.Errorf("bolillo stock after Quote() = %d, want 10 (nothing reserved)", got)
+	}
+	stored, _ := svc.Get(ctx, f.customerID, cart.ID)
+	if stored.Quote == nil || stored.Quote.Total != q.Total {
+		t.Errorf("stored cart quote = %+v, want the quote kept", stored.Quote)
+	}
+
+	inactive := &domain.Business{ID: uuid.New(), OwnerID: f.ownerID, IsActive: false}
+	if err := f.businesses.Create(ctx, inactive); err != nil {
+		t.Fatal(err)
+	}
+	closed, err := svc.Save(ctx, f.customerID, &domain.CartRequest{BusinessID: inactive.ID, Items: cart.Items})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Quote(ctx, f.customerID, closed.ID); !errors.Is(err, domain.ErrBusinessInactive) {
+		t.Errorf("Quote() for an inactive business error = %v, want ErrBusinessInactive", err)
+	}
+}
+
+func TestCartServiceCheckout(t *testing.T) {
+	f, svc, carts := newCartFixture(t)
+	ctx := context.Background()
+	items := []domain.CreateOrderItemReq{{ProductID: f.concha.ID, Quantity: 2}}
+
+	cart, err := svc.Save(ctx, f.customerID, &domain.CartRequest{BusinessID: f.business.ID, Items: items})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Checkout(ctx, f.customerID, cart.ID); !errors.Is(err, domain.ErrCartNotQuoted) {
+		t.Fatalf("Checkout() before Quote() error = %v, want ErrCartNotQuoted", err)
+	}
+	if _, err := svc.Quote(ctx, f.customerID, cart.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Checkout(ctx, uuid.New(), cart.ID); !errors.Is(err, domain.ErrCartForbidden) {
+		t.Errorf("Checkout() by another customer error = %v, want ErrCartForbidden", err)
+	}
+
+	// The concha got dearer after the quote: nothing is charged and the cart
+	// stays, unquoted.
+	concha := *f.concha
+	concha.Price = 2
+	if err := f.products.Update(ctx, &concha, concha.Stock); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Checkout(ctx, f.customerID, cart.ID); !errors.Is(err, domain.ErrPriceChanged) {
+		t.Fatalf("Checkout() after a price change error = %v, want ErrPriceChanged", err)
+	}
+	if len(f.payments.charges) != 0 {
+		t.Errorf("charged %v after a price change, want nothing", f.payments.charges)
+	}
+	kept, err := carts.Get(ctx, cart.ID)
+	if err != nil || kept.Quote != nil {
+		t.Fatalf("cart after a price change = %+v, %v, want it kept without its quote", kept, err)
+	}
+
+	quoted, err := svc.Quote(ctx, f.customerID, cart.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := svc.Checkout(ctx, f.customerID, cart.ID)
+	if err != nil {
+		t.Fatalf("Checkout() error: %v", err)
+	}
+	if resp.TotalAmount != quoted.Quote.Total || resp.Subtotal != 4 || resp.Tax != 0.4 || resp.Fees != 0.5 || resp.PIN == "" {
+		t.Errorf("order = %+v, want the quoted total %v", resp.Order, quoted.Quote.Total)
+	}
+	if len(f.payments.charges) != 1 || f.payments.charges[0] != quoted.Quote.Total {
+		t.Errorf("charges = %v, want one of %v", f.payments.charges, quoted.Quote.Total)
+	}
+	if _, err := svc.Checkout(ctx, f.customerID, cart.ID); !errors.Is(err, domain.ErrCartNotFound) {
+		t.Errorf("second Checkout() error = %v, want ErrCartNotFound", err)
+	}
+}
+
+// saveDuringCheckout runs save right after a cart is taken, standing in for
+// a Save request that lands while the checkout is in flight.
+type saveDuringCheckout struct {
+	*memory.CartRepository
+	save func()
+}
+
+func (r *saveDuringCheckout) Take(ctx context.Context, id uuid.UUID) (*domain.Cart, error) {
+	c, err := r.CartRepository.Take(ctx, id)
+	if err == nil && r.save != nil {
+		r.save()
+	}
+	return c, err
+}
+
+func TestCartServiceCheckoutKeepsNewerCart(t *testing.T) {
+	f, _, _ := newCartFixture(t)
+	ctx := context.Background()
+	carts := &saveDuringCheckout{CartRepository: memory.NewCartRepository()}
+	svc := NewCartService(carts, f.svc, f.businesses, time.Hour, logging.Nop())
+
+	cart, err := svc.Save(ctx, f.customerID, &domain.CartRequest{
+		BusinessID: f.business.ID,
+		Items:      []domain.CreateOrderItemReq{{ProductID: f.concha.ID, Quantity: 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Checkout(ctx, f.customerID, cart.ID); !errors.Is(err, domain.ErrCartNotQuoted) {
+		t.Fatalf("Checkout() before Quote() error = %v, want ErrCartNotQuoted", err)
+	}
+
+	var newer *domain.Cart
+	carts.save = func() {
+		newer, err = svc.Save(ctx, f.customerID, &domain.CartRequest{
+			BusinessID: f.business.ID,
+			Items:      []domain.CreateOrderItemReq{{ProductID: f.bolillo.ID, Quantity: 3}},
+		})
+		if err != nil {
+			t.Errorf("Save() during checkout error: %v", err)
+		}
+	}
+	if _, err := svc.Checkout(ctx, f.customerID, cart.ID); !errors.Is(err, domain.ErrCartNotQuoted) {
+		t.Fatalf("Checkout() error = %v, want ErrCartNotQuoted", err)
+	}
+	if newer == nil || newer.ID == cart.ID {
+		t.Fatalf("Save() during checkout = %+v, want a new cart", newer)
+	}
+	found, err := carts.Find(ctx, f.customerID, f.business.ID)
+	if err != nil || found.ID != newer.ID || found.Items[0].ProductID != f.bolillo.ID {
+		t.Errorf("Find() after the failed checkout = %+v, %v, want the cart saved during it", found, err)
+	}
+	if _, err := carts.Get(ctx, cart.ID); !errors.Is(err, domain.ErrCartNotFound) {
+		t.Errorf("Get(taken cart) error = %v, want ErrCartNotFound", err)
+	}
+}
+
+func TestCartServiceQuotePromotion(t *testing.T) {
+	f, svc, _ := newCartFixture(t)
+	ctx := context.Background()
+	f.addPromotion(t, domain.PromotionRequest{Code: ptr("PAN20"), Name: "20% off", Kind: domain.PromotionPercent, Value: 20, MaxRedemptions: ptr(1)})
+
+	cart, err := svc.Save(ctx, f.customerID, &domain.CartRequest{
+		BusinessID: f.business.ID,
+		Items:      []domain.CreateOrderItemReq{{ProductID: f.concha.ID, Quantity: 4}},
+		PromoCode:  "pan20",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	quoted, err := svc.Quote(ctx, f.customerID, cart.ID)
+	if err != nil {
+		t.Fatalf("Quote() error: %v", err)
+	}
+	// $6.00 less $1.20, $0.48 tax on the discounted $4.80 and the $0.50 fee.
+	q := quoted.Quote
+	if q.Subtotal != 6 || q.Discount != 1.2 || q.Tax != 0.48 || q.Total != 5.78 || len(q.Discounts) != 1 || q.Discounts[0].Code != "PAN20" {
+		t.Errorf("quote = %+v, want 6.00 - 1.20 + 0.48 + 0.50 = 5.78 with the PAN20 line", q)
+	}
+
+	resp, err := svc.Checkout(ctx, f.customerID, cart.ID)
+	if err != nil {
+		t.Fatalf("Checkout() error: %v", err)
+	}
+	if resp.TotalAmount != q.Total || resp.Discount != q.Discount || f.payments.charges[0] != q.Total {
+		t.Errorf("order = %+v, charges = %v, want the quoted %v", resp.Order, f.payments.charges, q.Total)
+	}
+
+	// The code is used up: quoting another cart with it explains why.
+	other, _ := svc.Save(ctx, uuid.New(), &domain.CartRequest{BusinessID: f.business.ID, Items: cart.Items, PromoCode: "PAN20"})
+	if _, err := svc.Quote(ctx, other.CustomerID, other.ID); !errors.Is(err, domain.ErrPromotionNotApplicable) {
+		t.Errorf("Quote() with a used-up code error = %v, want ErrPromotionNotApplicable", err)
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+)
+
+// categoryCacheTTL bounds how long a taxonomy change (a migration) takes to
+// reach every replica.
+const categoryCacheTTL = 5 * time.Minute
+
+// CategoryService serves the category taxonomy from a cache refreshed every
+// categoryCacheTTL.
+type CategoryService struct {
+	store  CategoryStore
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	tree     *domain.CategoryTree
+	loadedAt time.Time
+}
+
+func NewCategoryService(store CategoryStore, logger *slog.Logger) *CategoryService {
+	return &CategoryService{store: store, logger: logger}
+}
+
+// Tree returns the taxonomy. If a refresh fails the stale copy is served.
+func (s *CategoryService) Tree(ctx context.Context) (*domain.CategoryTree, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tree != nil && time.Since(s.loadedAt) < categoryCacheTTL {
+		return s.tree, nil
+	}
+
+	categories, err := s.store.List(ctx)
+	if err != nil {
+		if s.tree != nil {
+			s.logger.WarnContext(ctx, "failed to refresh categories; serving cached copy", logging.Error, err)
+			return s.tree, nil
+		}
+		return nil, err
+	}
+	s.tree = domain.NewCategoryTree(categories)
+	s.loadedAt = time.Now()
+	return s.tree, nil
+}
+
+// List returns the taxonomy as nested nodes named in lang.
+func (s *CategoryService) List(ctx context.Context, lang string) ([]*domain.CategoryNode, error) {
+	tree, err := s.Tree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Nodes(lang), nil
+}
+
+// Subtree resolves a category filter to the category and its descendants. An
+// empty slug means no filter and resolves to nil.
+func (s *CategoryService) Subtree(ctx context.Context, slug string) ([]string, error) {
+	if slug == "" {
+		return nil, nil
+	}
+	tree, err := s.Tree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	categories := tree.Subtree(slug)
+	if categories == nil {
+		return nil, domain.ErrUnknownCategory
+	}
+	return categories, nil
+}
+
+// Validate returns ErrUnknownCategory unless slug is in the taxonomy.
+func (s *CategoryService) Validate(ctx context.Context, slug string) error {
+	tree, err := s.Tree(ctx)
+	if err != nil {
+		return err
+	}
+	if !tree.Has(slug) {
+		return domain.ErrUnknownCategory
+	}
+	return nil
+}
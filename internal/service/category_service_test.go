@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestCategoryServiceList(t *testing.T) {
+	svc := newTestCategories()
+
+	nodes, err := svc.List(context.Background(), "en")
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	// Roots and children are ordered by sort order then slug; names fall back
+	// to Spanish when there is no translation.
+	var got []string
+	for _, n := range nodes {
+		got = append(got, n.Slug+":"+n.Name)
+		for _, child := range n.Children {
+			got = append(got, "  "+child.Slug+":"+child.Name)
+		}
+	}
+	want := []string{"food:Food", "  bakery:Panadería", "  coffee:Cafetería", "pharmacy:Farmacia"}
+	if !slices.Equal(got, want) {
+		t.Errorf("List(en) = %q, want %q", got, want)
+	}
+}
+
+func TestCategoryServiceSubtree(t *testing.T) {
+	svc := newTestCategories()
+	ctx := context.Background()
+
+	tests := []struct {
+		slug    string
+		want    []string
+		wantErr bool
+	}{
+		{slug: "", want: nil},
+		{slug: "coffee", want: []string{"coffee"}},
+		{slug: "food", want: []string{"food", "bakery", "coffee"}},
+		{slug: "Food", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := svc.Subtree(ctx, tt.slug)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Subtree(%q) error = %v, wantErr %v", tt.slug, err, tt.wantErr)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Subtree(%q) = %v, want %v", tt.slug, got, tt.want)
+		}
+	}
+}
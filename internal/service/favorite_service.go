@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+)
+
+// FavoriteService keeps each customer's favorite businesses.
+type FavoriteService struct {
+	favorites  FavoriteStore
+	businesses BusinessStore
+	images     ImageStorage
+	logger     *slog.Logger
+}
+
+func NewFavoriteService(favorites FavoriteStore, businesses BusinessStore, images ImageStorage, logger *slog.Logger) *FavoriteService {
+	return &FavoriteService{favorites: favorites, businesses: businesses, images: images, logger: logger}
+}
+
+// Add stars a business. Starring it again does nothing.
+func (s *FavoriteService) Add(ctx context.Context, customerID, businessID uuid.UUID) error {
+	if _, err := s.businesses.GetByID(ctx, businessID); err != nil {
+		return err
+	}
+	if err := s.favorites.Add(ctx, customerID, businessID); err != nil {
+		return err
+	}
+	s.logger.InfoContext(logging.With(ctx, logging.BusinessID, businessID.String()), "favorite added")
+	return nil
+}
+
+// Remove unstars a business; removing one that is not a favorite is fine.
+func (s *FavoriteService) Remove(ctx context.Context, customerID, businessID uuid.UUID) error {
+	return s.favorites.Remove(ctx, customerID, businessID)
+}
+
+// List returns the customer's favorite businesses, most recently starred
+// first. Businesses that are currently inactive are left out.
+func (s *FavoriteService) List(ctx context.Context, customerID uuid.UUID) ([]*domain.Business, error) {
+	ids, err := s.favorites.ListBusinessIDs(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	businesses, err := s.businesses.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if businesses == nil {
+		businesses = []*domain.Business{}
+	}
+	attachBusinessImages(s.images, businesses...)
+	return businesses, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/repository/memory"
+)
+
+func TestFavoriteService(t *testing.T) {
+	ctx := context.Background()
+	businesses := memory.NewBusinessRepository()
+	svc := NewFavoriteService(memory.NewFavoriteRepository(), businesses, memory.NewStorage(), logging.Nop())
+	customer := uuid.New()
+
+	var shops []*domain.Business
+	for _, active := range []bool{true, true, false} {
+		b := &domain.Business{ID: uuid.New(), OwnerID: uuid.New(), IsActive: active}
+		if err := businesses.Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+		shops = append(shops, b)
+	}
+	for _, b := range []*domain.Business{shops[0], shops[1], shops[0], shops[2]} {
+		if err := svc.Add(ctx, customer, b.ID); err != nil {
+			t.Fatalf("Add() error: %v", err)
+		}
+	}
+	if err := svc.Add(ctx, customer, uuid.New()); !errors.Is(err, domain.ErrBusinessNotFound) {
+		t.Errorf("Add(unknown) error = %v, want ErrBusinessNotFound", err)
+	}
+
+	list := func() []uuid.UUID {
+		t.Helper()
+		got, err := svc.List(ctx, customer)
+		if err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+		ids := make([]uuid.UUID, 0, len(got))
+		for _, b := range got {
+			ids = append(ids, b.ID)
+		}
+		return ids
+	}
+	// Newest first, once each, without the inactive one.
+	if got := list(); len(got) != 2 || got[0] != shops[1].ID || got[1] != shops[0].ID {
+		t.Errorf("List() = %v, want [%s %s]", got, shops[1].ID, shops[0].ID)
+	}
+
+	if err := svc.Remove(ctx, customer, shops[1].ID); err != nil {
+		t.Fatalf("Remove() error: %v", err)
+	}
+	if got := list(); len(got) != 1 || got[0] != shops[0].ID {
+		t.Errorf("List() after Remove() = %v, want [%s]", got, shops[0].ID)
+	}
+	if got, _ := svc.List(ctx, uuid.New()); got == nil || len(got) != 0 {
+		t.Errorf("List() for a customer without favorites = %v, want empty", got)
+	}
+}
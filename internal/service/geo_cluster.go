@@ -0,0 +1,86 @@
+package service
+
+import (
+	"math"
+	"sort"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+const (
+	// clusterCellsPerTile splits each 256px map tile into a 4×4 grid, i.e.
+	// roughly one cluster per 64px square on screen.
+	clusterCellsPerTile = 4
+	// topCategoriesPerCluster is how many categories a cluster reports.
+	topCategoriesPerCluster = 3
+)
+
+type cellKey struct{ x, y int64 }
+
+type cell struct {
+	sumLat, sumLng float64
+	count          int
+	categories     map[string]int
+}
+
+// clusterLocations groups locs into a square grid whose cell size halves with
+// every zoom level, matching how web map tiles subdivide.
+func clusterLocations(locs []domain.GeoLocation, zoom int) []domain.Cluster {
+	size := 360 / (math.Exp2(float64(zoom)) * clusterCellsPerTile)
+
+	cells := make(map[cellKey]*cell)
+	for _, l := range locs {
+		k := cellKey{
+			x: int64(math.Floor((l.Longitude + 180) / size)),
+			y: int64(math.Floor((l.Latitude + 90) / size)),
+		}
+		c := cells[k]
+		if c == nil {
+			c = &cell{categories: make(map[string]int)}
+			cells[k] = c
+		}
+		c.sumLat += l.Latitude
+		c.sumLng += l.Longitude
+		c.count++
+		c.categories[l.Category]++
+	}
+
+	clusters := make([]domain.Cluster, 0, len(cells))
+	for _, c := range cells {
+		clusters = append(clusters, domain.Cluster{
+			Latitude:      c.sumLat / float64(c.count),
+			Longitude:     c.sumLng / float64(c.count),
+			Count:         c.count,
+			TopCategories: topCategories(c.categories, topCategoriesPerCluster),
+		})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		a, b := clusters[i], clusters[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Latitude != b.Latitude {
+			return a.Latitude > b.Latitude
+		}
+		return a.Longitude < b.Longitude
+	})
+	return clusters
+}
+
+// topCategories returns the n most frequent categories, ties broken by name.
+func topCategories(counts map[string]int, n int) []domain.CategoryCount {
+	out := make([]domain.CategoryCount, 0, len(counts))
+	for cat, count := range counts {
+		out = append(out, domain.CategoryCount{Category: cat, Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Category < out[j].Category
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/heptapegon/localpickup/internal/breaker"
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/metrics"
+)
+
+// geoCounter is implemented by indexes that can be cold (empty after a flush
+// or a fresh deploy) while the source of truth is not.
+type geoCounter interface {
+	Count(ctx context.Context) (int64, error)
+}
+
+// FailoverGeoIndex serves geo searches from a primary GeoIndex and falls back
+// to a secondary one when the primary fails, its circuit breaker is open, or
+// it returns nothing because it is empty. Writes go to both.
+//
+// Nearby results are stamped with the index that served them. A cursor from
+// one index is refused by the other with ErrStaleCursor: their distances
+// differ slightly, so resuming there could skip or repeat results.
+type FailoverGeoIndex struct {
+	primary  GeoIndex
+	fallback GeoIndex
+	breaker  *breaker.Breaker
+	logger   *slog.Logger
+}
+
+func NewFailoverGeoIndex(primary, fallback GeoIndex, br *breaker.Breaker, logger *slog.Logger) *FailoverGeoIndex {
+	return &FailoverGeoIndex{primary: primary, fallback: fallback, breaker: br, logger: logger}
+}
+
+func (f *FailoverGeoIndex) IndexBusiness(ctx context.Context, b *domain.Business) error {
+	return errors.Join(f.primary.IndexBusiness(ctx, b), f.fallback.IndexBusiness(ctx, b))
+}
+
+func (f *FailoverGeoIndex) RemoveBusiness(ctx context.Context, businessID string) error {
+	return errors.Join(f.primary.RemoveBusiness(ctx, businessID), f.fallback.RemoveBusiness(ctx, businessID))
+}
+
+// Names stamped on nearby results by FailoverGeoIndex.
+const (
+	geoIndexPrimary  = "primary"
+	geoIndexFallback = "fallback"
+)
+
+func (f *FailoverGeoIndex) FindNearby(ctx context.Context, q domain.GeoQuery) ([]domain.GeoResult, error) {
+	return failover(ctx, f, func(idx GeoIndex, name string) ([]domain.GeoResult, error) {
+		if q.After != nil && q.After.Index != name {
+			return nil, domain.ErrStaleCursor
+		}
+		results, err := idx.FindNearby(ctx, q)
+		for i := range results {
+			results[i].Index = name
+		}
+		return results, err
+	})
+}
+
+func (f *FailoverGeoIndex) FindInBounds(ctx context.Context, b domain.Bounds, categories []string) ([]domain.GeoLocation, error) {
+	return failover(ctx, f, func(idx GeoIndex, _ string) ([]domain.GeoLocation, error) {
+		return idx.FindInBounds(ctx, b, categories)
+	})
+}
+
+// failover runs search against the primary unless its breaker is open, and
+// against the fallback if the primary errors or comes back empty while cold.
+func failover[T any](ctx context.Context, f *FailoverGeoIndex, search func(idx GeoIndex, name string) ([]T, error)) ([]T, error) {
+	if !f.breaker.Allow() {
+		metrics.GeoFallback(metrics.GeoFallbackOpen)
+		return search(f.fallback, geoIndexFallback)
+	}
+
+	results, err := search(f.primary, geoIndexPrimary)
+	if err != nil {
+		// The caller gave up, or brought a cursor from the fallback; that
+		// says nothing about the primary's health, so a probe is handed on
+		// to the next search.
+		if ctx.Err() != nil || errors.Is(err, domain.ErrStaleCursor) {
+			f.breaker.Release()
+			return nil, err
+		}
+		if f.breaker.Failure() {
+			f.logger.WarnContext(ctx, "geo index circuit opened; serving geo searches from fallback", logging.Error, err)
+		} else {
+			f.logger.WarnContext(ctx, "geo index lookup failed; using fallback", logging.Error, err)
+		}
+		metrics.GeoFallback(metrics.GeoFallbackError)
+		return search(f.fallback, geoIndexFallback)
+	}
+	f.breaker.Success()
+
+	if len(results) == 0 && f.cold(ctx) {
+		metrics.GeoFallback(metrics.GeoFallbackCold)
+		return search(f.fallback, geoIndexFallback)
+	}
+	return results, nil
+}
+
+// cold reports whether the primary index is empty. Only consulted when a
+// search comes back empty, so the extra round trip is rare.
+func (f *FailoverGeoIndex) cold(ctx context.Context) bool {
+	c, ok := f.primary.(geoCounter)
+	if !ok {
+		return false
+	}
+	n, err := c.Count(ctx)
+	return err == nil && n == 0
+}
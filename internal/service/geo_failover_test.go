@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/breaker"
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/repository/memory"
+)
+
+// flakyGeoIndex wraps the memory index and fails FindNearby while down is set.
+// Like a network client it gives up on a cancelled context before making the
+// call.
+type flakyGeoIndex struct {
+	*memory.GeoRepository
+	down  bool
+	calls int
+}
+
+func (f *flakyGeoIndex) FindNearby(ctx context.Context, q domain.GeoQuery) ([]domain.GeoResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.calls++
+	if f.down {
+		return nil, errors.New("connection refused")
+	}
+	return f.GeoRepository.FindNearby(ctx, q)
+}
+
+func TestFailoverGeoIndex(t *testing.T) {
+	ctx := context.Background()
+	near := &domain.Business{ID: uuid.New(), Latitude: 19.4326, Longitude: -99.1332}
+	far := &domain.Business{ID: uuid.New(), Latitude: 19.4400, Longitude: -99.1400}
+
+	tests := []struct {
+		name        string
+		down        bool
+		coldPrimary bool
+		searches    int
+		wantPrimary int // FindNearby calls that reached the primary
+		wantIDs     []string
+	}{
+		{name: "healthy primary", searches: 3, wantPrimary: 3, wantIDs: []string{near.ID.String(), far.ID.String()}},
+		{name: "primary down trips breaker", down: true, searches: 5, wantPrimary: 2, wantIDs: []string{near.ID.String(), far.ID.String()}},
+		{name: "cold primary", coldPrimary: true, searches: 1, wantPrimary: 1, wantIDs: []string{near.ID.String(), far.ID.String()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primaryRepo := memory.NewGeoRepository()
+			fallbackRepo := memory.NewGeoRepository()
+			for _, b := range []*domain.Business{near, far} {
+				_ = fallbackRepo.IndexBusiness(ctx, b)
+				if !tt.coldPrimary {
+					_ = primaryRepo.IndexBusiness(ctx, b)
+				}
+			}
+			primary := &flakyGeoIndex{GeoRepository: primaryRepo, down: tt.down}
+			idx := NewFailoverGeoIndex(primary, fallbackRepo, breaker.New(2, time.Minute), logging.Nop())
+
+			for i := 0; i < tt.searches; i++ {
+				got, err := idx.FindNearby(ctx, domain.GeoQuery{Latitude: near.Latitude, Longitude: near.Longitude, RadiusKm: 5, Limit: 10})
+				if err != nil {
+					t.Fatalf("FindNearby() error: %v", err)
+				}
+				assertOrder(t, got, tt.wantIDs)
+			}
+			if primary.calls != tt.wantPrimary {
+				t.Errorf("primary calls = %d, want %d", primary.calls, tt.wantPrimary)
+			}
+		})
+	}
+}
+
+func TestFailoverGeoIndexCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	br := breaker.New(1, time.Minute)
+	idx := NewFailoverGeoIndex(&flakyGeoIndex{GeoRepository: memory.NewGeoRepository(), down: true}, memory.NewGeoRepository(), br, logging.Nop())
+
+	if _, err := idx.FindNearby(ctx, domain.GeoQuery{RadiusKm: 5, Limit: 10}); err == nil {
+		t.Fatal("FindNearby() with cancelled context: want error")
+	}
+	if br.State() != breaker.Closed {
+		t.Errorf("breaker state = %s, want closed", br.State())
+	}
+}
+
+func TestFailoverGeoIndexCursor(t *testing.T) {
+	ctx := context.Background()
+	primaryRepo, fallbackRepo := memory.NewGeoRepository(), memory.NewGeoRepository()
+	for i := 0; i < 3; i++ {
+		b := &domain.Business{ID: uuid.New(), Latitude: 19.4326 + float64(i)*0.001, Longitude: -99.1332}
+		_ = primaryRepo.IndexBusiness(ctx, b)
+		_ = fallbackRepo.IndexBusiness(ctx, b)
+	}
+	primary := &flakyGeoIndex{GeoRepository: primaryRepo}
+	const cooldown = 50 * time.Millisecond
+	br := breaker.New(1, cooldown)
+	idx := NewFailoverGeoIndex(primary, fallbackRepo, br, logging.Nop())
+	q := domain.GeoQuery{Latitude: 19.4326, Longitude: -99.1332, RadiusKm: 5, Limit: 1}
+	next := func(q domain.GeoQuery, r domain.GeoResult) domain.GeoQuery {
+		q.After = &domain.GeoCursor{DistanceKm: r.DistanceKm, ID: r.ID, Seen: 1, Index: r.Index}
+		return q
+	}
+
+	first, err := idx.FindNearby(ctx, q)
+	if err != nil || len(first) != 1 || first[0].Index != geoIndexPrimary {
+		t.Fatalf("first page = %v, %v, want one result from the primary", first, err)
+	}
+	if _, err := idx.FindNearby(ctx, next(q, first[0])); err != nil {
+		t.Errorf("second page from the same index: %v", err)
+	}
+
+	primary.down = true
+	if _, err := idx.FindNearby(ctx, next(q, first[0])); !errors.Is(err, domain.ErrStaleCursor) {
+		t.Errorf("primary cursor on the fallback: err = %v, want ErrStaleCursor", err)
+	}
+	fromFallback, err := idx.FindNearby(ctx, q)
+	if err != nil || len(fromFallback) != 1 || fromFallback[0].Index != geoIndexFallback {
+		t.Fatalf("first page while down = %v, %v, want one result from the fallback", fromFallback, err)
+	}
+
+	// Once the cooldown is over, the probe goes to the first search. A stale
+	// cursor or a caller that gave up never reaches the primary, and hands
+	// the probe on to the next search.
+	primary.down = false
+	time.Sleep(cooldown)
+	calls := primary.calls
+	if _, err := idx.FindNearby(ctx, next(q, fromFallback[0])); !errors.Is(err, domain.ErrStaleCursor) {
+		t.Errorf("fallback cursor on the primary: err = %v, want ErrStaleCursor", err)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := idx.FindNearby(cancelled, q); err == nil {
+		t.Error("FindNearby() with cancelled context: want error")
+	}
+	if primary.calls != calls || br.State() != breaker.Open {
+		t.Errorf("stale or abandoned probes reached the primary (%d calls) or left the breaker %s, want it open", primary.calls-calls, br.State())
+	}
+	recovered, err := idx.FindNearby(ctx, q)
+	if err != nil || len(recovered) != 1 || recovered[0].Index != geoIndexPrimary {
+		t.Fatalf("first page after the cooldown = %v, %v, want one result from the primary", recovered, err)
+	}
+	if primary.calls != calls+1 || br.State() != breaker.Closed {
+		t.Errorf("probe made %d primary calls and left the breaker %s, want 1 and closed", primary.calls-calls, br.State())
+	}
+}
+
+func assertOrder(t *testing.T, got []domain.GeoResult, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ID != want[i] {
+			t.Errorf("result[%d] = %s, want %s", i, got[i].ID, want[i])
+		}
+	}
+}
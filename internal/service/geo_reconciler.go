@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/metrics"
+)
+
+// geoTolerance is how far (in degrees, ≈1 m) an indexed position may be from
+// the database before it counts as moved. Redis stores 52-bit geohashes, so
+// positions read back are never exact.
+const geoTolerance = 1e-5
+
+// ReconcileReport describes the drift found between Postgres and the geo index.
+type ReconcileReport struct {
+	DryRun  bool `json:"dry_run"`
+	Active  int  `json:"active"`
+	Indexed int  `json:"indexed"`
+	// Added are active businesses that were missing from the index.
+	Added []string `json:"added"`
+	// Moved are indexed businesses whose position or category differed from
+	// the database.
+	Moved []string `json:"moved"`
+	// Removed are indexed IDs that are inactive, deleted or unknown.
+	Removed    []string `json:"removed"`
+	DurationMs float64  `json:"duration_ms"`
+}
+
+// Drift is the number of index entries that were (or, in a dry run, would be) fixed.
+func (r *ReconcileReport) Drift() int {
+	return len(r.Added) + len(r.Moved) + len(r.Removed)
+}
+
+// GeoReconciler repairs the Redis geo index from Postgres. The index can drift
+// when IndexBusiness fails after the row is written, or be evicted outright
+// since Redis runs with allkeys-lru.
+type GeoReconciler struct {
+	businesses ActiveLocationLister
+	index      GeoIndexSyncer
+	logger     *slog.Logger
+}
+
+func NewGeoReconciler(businesses ActiveLocationLister, index GeoIndexSyncer, logger *slog.Logger) *GeoReconciler {
+	return &GeoReconciler{businesses: businesses, index: index, logger: logger}
+}
+
+// Reconcile compares both sides and, unless dryRun, fixes the index.
+//
+// The index is read before the database: a business created in between then
+// shows up as missing (and is re-added, harmlessly) rather than as unknown,
+// which would remove it.
+func (r *GeoReconciler) Reconcile(ctx context.Context, dryRun bool) (*ReconcileReport, error) {
+	start := time.Now()
+
+	indexed, err := r.index.Locations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	active, err := r.businesses.ActiveLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReconcileReport{
+		DryRun:  dryRun,
+		Active:  len(active),
+		Indexed: len(indexed),
+		Added:   []string{},
+		Moved:   []string{},
+		Removed: []string{},
+	}
+
+	inIndex := make(map[string]domain.GeoLocation, len(indexed))
+	for _, loc := range indexed {
+		inIndex[loc.ID] = loc
+	}
+
+	var upserts []domain.GeoLocation
+	for _, want := range active {
+		got, ok := inIndex[want.ID]
+		delete(inIndex, want.ID)
+		switch {
+		case !ok:
+			report.Added = append(report.Added, want.ID)
+		case got.Category != want.Category ||
+			math.Abs(got.Latitude-want.Latitude) > geoTolerance || math.Abs(got.Longitude-want.Longitude) > geoTolerance:
+			report.Moved = append(report.Moved, want.ID)
+		default:
+			continue
+		}
+		upserts = append(upserts, want)
+	}
+	// Whatever is left in the index has no active business behind it.
+	for id := range inIndex {
+		report.Removed = append(report.Removed, id)
+	}
+
+	if !dryRun {
+		// Moved entries are removed first so they also leave the key of a
+		// category they no longer belong to.
+		stale := append(append([]string{}, report.Removed...), report.Moved...)
+		if err := r.index.RemoveLocations(ctx, stale); err != nil {
+			return nil, err
+		}
+		if err := r.index.AddLocations(ctx, upserts); err != nil {
+			return nil, err
+		}
+		metrics.GeoReconciled(len(report.Added), len(report.Moved), len(report.Removed))
+	}
+
+	report.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	return report, nil
+}
+
+// Run reconciles immediately and then every interval until ctx is cancelled.
+// Every replica may run it; all operations are idempotent.
+func (r *GeoReconciler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		report, err := r.Reconcile(ctx, false)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			r.logger.ErrorContext(ctx, "geo reconcile failed", logging.Error, err)
+		case err == nil && report.Drift() > 0:
+			r.logger.WarnContext(ctx, "geo index drift repaired",
+				"added", len(report.Added), "moved", len(report.Moved), "removed", len(report.Removed),
+				"active", report.Active, "indexed", report.Indexed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
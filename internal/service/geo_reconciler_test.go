@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/repository/memory"
+)
+
+func TestGeoReconcilerReconcile(t *testing.T) {
+	ctx := context.Background()
+	businesses := memory.NewBusinessRepository()
+	index := memory.NewGeoRepository()
+
+	seed := func(active bool, lat, lng float64) *domain.Business {
+		b := &domain.Business{ID: uuid.New(), Category: "coffee", Latitude: lat, Longitude: lng, IsActive: active}
+		if err := businesses.Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	inSync := seed(true, 19.43, -99.13)
+	missing := seed(true, 19.44, -99.14)
+	moved := seed(true, 19.45, -99.15)
+	inactive := seed(false, 19.46, -99.16)
+	recategorised := seed(true, 19.48, -99.18)
+	stale := uuid.NewString()
+
+	_ = index.AddLocations(ctx, []domain.GeoLocation{
+		{ID: inSync.ID.String(), Category: "coffee", Latitude: inSync.Latitude + geoTolerance/2, Longitude: inSync.Longitude},
+		{ID: moved.ID.String(), Category: "coffee", Latitude: 20.0, Longitude: -100.0},
+		{ID: recategorised.ID.String(), Category: "bakery", Latitude: recategorised.Latitude, Longitude: recategorised.Longitude},
+		{ID: inactive.ID.String(), Latitude: inactive.Latitude, Longitude: inactive.Longitude},
+		{ID: stale, Latitude: 19.47, Longitude: -99.17},
+	})
+
+	r := NewGeoReconciler(businesses, index, logging.Nop())
+
+	tests := []struct {
+		name        string
+		dryRun      bool
+		wantAdded   []string
+		wantMoved   []string
+		wantRemoved []string
+	}{
+		{
+			name:        "dry run reports drift",
+			dryRun:      true,
+			wantAdded:   []string{missing.ID.String()},
+			wantMoved:   []string{moved.ID.String(), recategorised.ID.String()},
+			wantRemoved: []string{inactive.ID.String(), stale},
+		},
+		{
+			name:        "dry run changed nothing",
+			dryRun:      false,
+			wantAdded:   []string{missing.ID.String()},
+			wantMoved:   []string{moved.ID.String(), recategorised.ID.String()},
+			wantRemoved: []string{inactive.ID.String(), stale},
+		},
+		{
+			name:   "index is now in sync",
+			dryRun: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := r.Reconcile(ctx, tt.dryRun)
+			if err != nil {
+				t.Fatalf("Reconcile() error: %v", err)
+			}
+			assertIDs(t, "added", report.Added, tt.wantAdded)
+			assertIDs(t, "moved", report.Moved, tt.wantMoved)
+			assertIDs(t, "removed", report.Removed, tt.wantRemoved)
+			if report.Active != 4 {
+				t.Errorf("active = %d, want 4", report.Active)
+			}
+		})
+	}
+
+	// The repaired index answers nearby queries with only active businesses.
+	got, _ := index.FindNearby(ctx, domain.GeoQuery{Latitude: moved.Latitude, Longitude: moved.Longitude, RadiusKm: 1, Limit: 10})
+	if len(got) != 1 || got[0].ID != moved.ID.String() {
+		t.Errorf("FindNearby after reconcile = %v, want only the moved business", got)
+	}
+	got, _ = index.FindNearby(ctx, domain.GeoQuery{Latitude: recategorised.Latitude, Longitude: recategorised.Longitude, RadiusKm: 1, Categories: []string{"coffee"}, Limit: 10})
+	if len(got) != 1 || got[0].ID != recategorised.ID.String() {
+		t.Errorf("coffee search after reconcile = %v, want the recategorised business", got)
+	}
+}
+
+func assertIDs(t *testing.T, label string, got, want []string) {
+	t.Helper()
+	got = append([]string(nil), got...)
+	want = append([]string(nil), want...)
+	sort.Strings(got)
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Errorf("%s = %v, want %v", label, got, want)
+		return
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("%s = %v, want %v", label, got, want)
+			return
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"path"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/imaging"
+	"github.com/heptapegon/localpickup/internal/logging"
+)
+
+// Pictures are stored as businesses/<id>[/products/<id>]/<upload>/<variant>.jpg.
+// Every upload gets a fresh prefix, so a URL never changes content and can be
+// cached forever; the previous upload's files are removed once the new prefix
+// is recorded.
+
+// replaceImage renders an upload into its variants, saves them under a new
+// prefix below dir, records the prefix with set and then removes the picture
+// stored under old. Nil data just clears the picture. It returns the recorded
+// prefix.
+func replaceImage(ctx context.Context, storage ImageStorage, logger *slog.Logger, dir, old string, data []byte, set func(prefix string) error) (string, error) {
+	var prefix string
+	if data != nil {
+		variants, err := imaging.Render(data)
+		if err != nil {
+			return "", err
+		}
+		prefix = path.Join(dir, uuid.NewString())
+		for _, v := range imaging.Variants {
+			if err := storage.Put(ctx, imageVariantKey(prefix, v.Name), variants[v.Name], imaging.ContentType); err != nil {
+				removeImage(ctx, storage, logger, prefix)
+				return "", err
+			}
+		}
+	}
+	if err := set(prefix); err != nil {
+		removeImage(ctx, storage, logger, prefix)
+		return "", err
+	}
+	removeImage(ctx, storage, logger, old)
+	return prefix, nil
+}
+
+// removeImage deletes the variants under prefix. Failures only leave
+// unreferenced files behind, so they are logged rather than returned.
+func removeImage(ctx context.Context, storage ImageStorage, logger *slog.Logger, prefix string) {
+	if prefix == "" {
+		return
+	}
+	for _, v := range imaging.Variants {
+		key := imageVariantKey(prefix, v.Name)
+		if err := storage.Delete(ctx, key); err != nil {
+			logger.WarnContext(ctx, "failed to delete image file", "key", key, logging.Error, err)
+		}
+	}
+}
+
+// imageURLs resolves a stored picture to the URLs of its variants; nil when
+// there is no picture.
+func imageURLs(storage ImageStorage, prefix string) *domain.Image {
+	if prefix == "" {
+		return nil
+	}
+	return &domain.Image{
+		Thumbnail: storage.URL(imageVariantKey(prefix, imaging.Thumbnail)),
+		Medium:    storage.URL(imageVariantKey(prefix, imaging.Medium)),
+		Large:     storage.URL(imageVariantKey(prefix, imaging.Large)),
+	}
+}
+
+func imageVariantKey(prefix, variant string) string {
+	return prefix + "/" + variant + imaging.Ext
+}
+
+func attachBusinessImages(storage ImageStorage, businesses ...*domain.Business) {
+	for _, b := range businesses {
+		b.Image = imageURLs(storage, b.ImageKey)
+	}
+}
+
+func attachProductImages(storage ImageStorage, products ...*domain.Product) {
+	for _, p := range products {
+		p.Image = imageURLs(storage, p.ImageKey)
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/repository/memory"
+)
+
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 300, 150))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBusinessServiceSetImage(t *testing.T) {
+	ctx := context.Background()
+	files := memory.NewStorage()
+	svc := NewBusinessService(memory.NewBusinessRepository(), memory.NewGeoRepository(), newTestCategories(), files, logging.Nop())
+	owner := uuid.New()
+	shop, err := svc.Create(ctx, owner, &domain.CreateBusinessRequest{Name: "Panadería", Latitude: 19.43, Longitude: -99.13, Category: "bakery"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shop.Image != nil {
+		t.Fatalf("new business image = %+v, want none", shop.Image)
+	}
+
+	first, err := svc.SetImage(ctx, owner, shop.ID, testPNG(t))
+	if err != nil {
+		t.Fatalf("SetImage() error: %v", err)
+	}
+	prefix := "businesses/" + shop.ID.String() + "/"
+	if first.Image == nil || !strings.HasPrefix(first.Image.Thumbnail, "https://media.test/"+prefix) ||
+		!strings.HasSuffix(first.Image.Large, "/large.jpg") {
+		t.Fatalf("image = %+v, want variant URLs under %s", first.Image, prefix)
+	}
+	if got := files.Keys(); len(got) != 3 {
+		t.Fatalf("stored files = %v, want 3 variants", got)
+	}
+	if got, _ := svc.GetByID(ctx, shop.ID); got.Image == nil || *got.Image != *first.Image {
+		t.Errorf("GetByID() image = %+v, want %+v", got.Image, first.Image)
+	}
+
+	second, err := svc.SetImage(ctx, owner, shop.ID, testPNG(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Image.Medium == first.Image.Medium {
+		t.Error("a new upload should get new URLs")
+	}
+	upload := strings.TrimSuffix(strings.TrimPrefix(second.Image.Large, "https://media.test/"), "large.jpg")
+	for _, key := range files.Keys() {
+		if !strings.HasPrefix(key, upload) {
+			t.Errorf("stored file %s, want only the new upload's variants", key)
+		}
+	}
+
+	if _, err := svc.SetImage(ctx, uuid.New(), shop.ID, testPNG(t)); !errors.Is(err, domain.ErrBusinessForbidden) {
+		t.Errorf("SetImage() by a stranger error = %v, want ErrBusinessForbidden", err)
+	}
+	if _, err := svc.SetImage(ctx, owner, shop.ID, []byte("GIF89a not welcome")); !errors.Is(err, domain.ErrUnsupportedImage) {
+		t.Errorf("SetImage(gif) error = %v, want ErrUnsupportedImage", err)
+	}
+
+	cleared, err := svc.SetImage(ctx, owner, shop.ID, nil)
+	if err != nil {
+		t.Fatalf("SetImage(nil) error: %v", err)
+	}
+	if cleared.Image != nil || len(files.Keys()) != 0 {
+		t.Errorf("after removal image = %+v, files = %v, want neither", cleared.Image, files.Keys())
+	}
+}
+
+func TestProductServiceSetImage(t *testing.T) {
+	ctx := context.Background()
+	businesses := memory.NewBusinessRepository()
+	files := memory.NewStorage()
+	svc := NewProductService(memory.NewProductRepository(), businesses, files, logging.Nop())
+	owner := uuid.New()
+	shop := &domain.Business{ID: uuid.New(), OwnerID: owner, IsActive: true}
+	if err := businesses.Create(ctx, shop); err != nil {
+		t.Fatal(err)
+	}
+	concha, err := svc.Create(ctx, owner, shop.ID, &domain.ProductRequest{Name: "Concha", Price: 18})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withImage, err := svc.SetImage(ctx, owner, shop.ID, concha.ID, testPNG(t))
+	if err != nil {
+		t.Fatalf("SetImage() error: %v", err)
+	}
+	if withImage.Image == nil || !strings.Contains(withImage.Image.Thumbnail, "/products/"+concha.ID.String()+"/") {
+		t.Fatalf("image = %+v, want URLs under the product", withImage.Image)
+	}
+
+	// Editing the product keeps its picture.
+	updated, err := svc.Update(ctx, owner, shop.ID, concha.ID, &domain.ProductRequest{Name: "Concha de vainilla", Price: 19})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, _ := svc.ListByBusiness(ctx, shop.ID)
+	for _, img := range []*domain.Image{updated.Image, list[0].Image} {
+		if img == nil || *img != *withImage.Image {
+			t.Errorf("image after update = %+v, want %+v", img, withImage.Image)
+		}
+	}
+
+	if _, err := svc.SetImage(ctx, owner, uuid.New(), concha.ID, testPNG(t)); !errors.Is(err, domain.ErrBusinessNotFound) {
+		t.Errorf("SetImage() through another business error = %v, want ErrBusinessNotFound", err)
+	}
+	if err := svc.Delete(ctx, owner, shop.ID, concha.ID); err != nil {
+		t.Fatal(err)
+	}
+	if keys := files.Keys(); len(keys) != 0 {
+		t.Errorf("files left after deleting the product: %v", keys)
+	}
+}
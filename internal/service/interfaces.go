@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+// The interfaces below are what the services need from the outside world.
+// Production wiring uses the Postgres/Redis repositories, PaymentService and
+// NotificationService; tests use the in-memory versions in repository/memory.
+
+// BusinessStore persists businesses.
+type BusinessStore interface {
+	Create(ctx context.Context, b *domain.Business) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Business, error)
+	// GetByIDs returns the active businesses among ids, in the order given.
+	GetByIDs(ctx context.Context, ids []string) ([]*domain.Business, error)
+	// SetImage records the storage prefix of the business picture.
+	SetImage(ctx context.Context, id uuid.UUID, key string) error
+}
+
+// CategoryStore lists the category taxonomy.
+type CategoryStore interface {
+	List(ctx context.Context) ([]domain.Category, error)
+}
+
+// ProductStore persists business catalogs.
+type ProductStore interface {
+	Create(ctx context.Context, p *domain.Product) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Product, error)
+	// Update replaces the editable fields of p. Stock is only written when
+	// p.Stock differs from readStock, what it was when p was read; a limited
+	// stock then moves by the difference, so that units reserved or released
+	// meanwhile are kept. p.Stock is set to the stored result.
+	Update(ctx context.Context, p *domain.Product, readStock *int) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ListByBusiness(ctx context.Context, businessID uuid.UUID) ([]*domain.Product, error)
+	// SetImage records the storage prefix of the product picture; Update
+	// leaves it alone.
+	SetImage(ctx context.Context, id uuid.UUID, key string) error
+}
+
+// ImageStorage keeps the files of uploaded pictures (storage.Local or
+// storage.S3).
+type ImageStorage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// MenuStore applies an imported menu to a catalog.
+type MenuStore interface {
+	ListByBusiness(ctx context.Context, businessID uuid.UUID) ([]*domain.Product, error)
+	// ApplyMenu creates, updates and deletes products in one transaction.
+	// Updates write stock as ProductStore.Update does.
+	ApplyMenu(ctx context.Context, create []*domain.Product, update []domain.ProductUpdate, remove []uuid.UUID) error
+}
+
+// StockStore prices order lines from the catalog and holds stock for them.
+type StockStore interface {
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Product, error)
+	// ReserveStock takes every line out of stock or, with ErrOutOfStock,
+	// none of them.
+	ReserveStock(ctx context.Context, lines []domain.StockLine) error
+	// ReleaseStock returns lines reserved at reservedAt, unless the daily
+	// reset has replenished the product since.
+	ReleaseStock(ctx context.Context, lines []domain.StockLine, reservedAt time.Time) error
+	// ResetDailyStock refills products whose last reset predates before.
+	ResetDailyStock(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Searcher ranks businesses and products against free text.
+type Searcher interface {
+	Search(ctx context.Context, q domain.SearchQuery) ([]*domain.SearchResult, error)
+}
+
+// OrderStore persists orders and their items.
+type OrderStore interface {
+	Create(ctx context.Context, o *domain.Order) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Order, error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, status domain.OrderStatus) error
+	// TransitionStatus changes the status only if it is currently from.
+	TransitionStatus(ctx context.Context, id uuid.UUID, from, to domain.OrderStatus) (bool, error)
+	// MarkPaid records the payment of a pending order and makes it paid. It
+	// reports false if the order is not pending.
+	MarkPaid(ctx context.Context, id uuid.UUID, paymentID string) (bool, error)
+	ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]*domain.Order, error)
+	// ListByBusiness returns orders in the given statuses oldest first, with
+	// their items.
+	ListByBusiness(ctx context.Context, businessID uuid.UUID, statuses []domain.OrderStatus) ([]*domain.Order, error)
+}
+
+// ReviewStore persists reviews and keeps the rating aggregate of their
+// business in step with the published ones.
+type ReviewStore interface {
+	// Create fails with ErrReviewExists if the order was already reviewed.
+	Create(ctx context.Context, r *domain.Review) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Review, error)
+	// ListByBusiness returns published reviews newest first, after the given
+	// position when it is not nil.
+	ListByBusiness(ctx context.Context, businessID uuid.UUID, after *domain.ReviewCursor, limit int) ([]*domain.Review, error)
+	SetReply(ctx context.Context, id uuid.UUID, text string, at time.Time) error
+	// Flag reports false when the user had already flagged the review.
+	Flag(ctx context.Context, id, userID uuid.UUID, reason string) (bool, error)
+	// SetStatus publishes or hides a review and clears its flags.
+	SetStatus(ctx context.Context, id uuid.UUID, status domain.ReviewStatus) (*domain.Review, error)
+	// ListFlagged returns published reviews with flags, most flagged first.
+	ListFlagged(ctx context.Context, limit int) ([]*domain.Review, error)
+}
+
+// FavoriteStore persists the businesses each customer starred.
+type FavoriteStore interface {
+	// Add is a no-op when the business is already a favorite.
+	Add(ctx context.Context, customerID, businessID uuid.UUID) error
+	Remove(ctx context.Context, customerID, businessID uuid.UUID) error
+	// ListBusinessIDs returns the customer's favorites, most recent first.
+	ListBusinessIDs(ctx context.Context, customerID uuid.UUID) ([]string, error)
+}
+
+// CartStore keeps carts until they expire. A customer has at most one cart
+// per business.
+type CartStore interface {
+	// Save stores the cart and restarts its expiry.
+	Save(ctx context.Context, c *domain.Cart, ttl time.Duration) error
+	// Get and Find fail with ErrCartNotFound once the cart expired.
+	Get(ctx context.Context, id uuid.UUID) (*domain.Cart, error)
+	Find(ctx context.Context, customerID, businessID uuid.UUID) (*domain.Cart, error)
+	ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]*domain.Cart, error)
+	// Take removes the cart and returns it; of two concurrent calls only
+	// one gets it.
+	Take(ctx context.Context, id uuid.UUID) (*domain.Cart, error)
+	// Restore puts back a taken cart like Save, unless the customer has
+	// saved another cart for the business since; it reports whether it did.
+	Restore(ctx context.Context, c *domain.Cart, ttl time.Duration) (bool, error)
+}
+
+// PromotionStore persists promotions and counts their redemptions.
+type PromotionStore interface {
+	// Create fails with ErrPromoCodeTaken if the code is already in use.
+	Create(ctx context.Context, p *domain.Promotion) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Promotion, error)
+	// GetByCode takes an upper-case code.
+	GetByCode(ctx context.Context, code string) (*domain.Promotion, error)
+	// ListAutomatic returns the active promotions without a code that can
+	// apply at the business: its own and the platform's, oldest first.
+	ListAutomatic(ctx context.Context, businessID uuid.UUID) ([]*domain.Promotion, error)
+	// List returns the promotions of a business, or every promotion when
+	// businessID is nil, newest first.
+	List(ctx context.Context, businessID *uuid.UUID) ([]*domain.Promotion, error)
+	SetActive(ctx context.Context, id uuid.UUID, active bool) (*domain.Promotion, error)
+	// CustomerRedemptions counts the customer's uses of a promotion.
+	CustomerRedemptions(ctx context.Context, promotionID, customerID uuid.UUID) (int, error)
+	// Redeem records the order's use of the promotion of each discount, all
+	// or none. It fails with ErrPromotionExhausted when a use would exceed a
+	// global or per-customer limit, even under concurrent redemptions.
+	Redeem(ctx context.Context, orderID, customerID uuid.UUID, discounts []domain.OrderDiscount) error
+	// Release gives back the order's redemptions; releasing twice is harmless.
+	Release(ctx context.Context, orderID uuid.UUID) error
+}
+
+// GeoIndex answers "which businesses are near this point".
+type GeoIndex interface {
+	IndexBusiness(ctx context.Context, b *domain.Business) error
+	// FindNearby returns up to q.Limit business IDs within q.RadiusKm, ordered
+	// by distance then ID.
+	FindNearby(ctx context.Context, q domain.GeoQuery) ([]domain.GeoResult, error)
+	// FindInBounds returns every business inside b (which does not cross the
+	// antimeridian), optionally restricted to the given categories.
+	FindInBounds(ctx context.Context, b domain.Bounds, categories []string) ([]domain.GeoLocation, error)
+	RemoveBusiness(ctx context.Context, businessID string) error
+}
+
+// ActiveLocationLister is the source of truth for the geo index.
+type ActiveLocationLister interface {
+	ActiveLocations(ctx context.Context) ([]domain.GeoLocation, error)
+}
+
+// GeoIndexSyncer is the bulk access the geo reconciler needs.
+type GeoIndexSyncer interface {
+	Locations(ctx context.Context) ([]domain.GeoLocation, error)
+	AddLocations(ctx context.Context, locs []domain.GeoLocation) error
+	RemoveLocations(ctx context.Context, ids []string) error
+}
+
+// PINStore is a short-lived cache of pickup PINs keyed by order.
+type PINStore interface {
+	Save(ctx context.Context, orderID uuid.UUID, pin string, ttl time.Duration) error
+	// Get reports found=false when the PIN is no longer cached.
+	Get(ctx context.Context, orderID uuid.UUID) (pin string, found bool, err error)
+	Delete(ctx context.Context, orderID uuid.UUID) error
+}
+
+// Notifier delivers push notifications. Failures are logged, not returned.
+type Notifier interface {
+	SendNewOrderNotification(ctx context.Context, fcmToken string, o *domain.Order)
+	SendOrderReadyNotification(ctx context.Context, customerFCMToken string, o *domain.Order)
+}
+
+// PaymentProcessor charges customers and returns the provider's payment ID.
+type PaymentProcessor interface {
+	ChargeCustomer(ctx context.Context, amountUSD float64, idempotencyKey string) (string, error)
+	// RefundCharge refunds a payment in full. Repeating it is harmless.
+	RefundCharge(ctx context.Context, paymentID string) error
+}
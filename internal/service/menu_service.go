@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+)
+
+// MenuService imports and exports whole catalogs. An imported menu replaces
+// the catalog: it is compared with the current products, and the resulting
+// creates, updates and deletes are applied together or not at all.
+type MenuService struct {
+	products   MenuStore
+	businesses BusinessStore
+	images     ImageStorage
+	logger     *slog.Logger
+}
+
+func NewMenuService(products MenuStore, businesses BusinessStore, images ImageStorage, logger *slog.Logger) *MenuService {
+	return &MenuService{products: products, businesses: businesses, images: images, logger: logger}
+}
+
+// Export returns the catalog of an owner's business, ordered by name.
+func (s *MenuService) Export(ctx context.Context, ownerID, businessID uuid.UUID) ([]*domain.Product, error) {
+	if err := authorizeOwner(ctx, s.businesses, ownerID, businessID); err != nil {
+		return nil, err
+	}
+	return s.products.ListByBusiness(ctx, businessID)
+}
+
+// Import compares items, which have passed request validation, with the
+// catalog and reports the difference. Unless dryRun is set or the report
+// has errors, the difference is then applied. Items are matched to products
+// by ID, or else by name (ignoring case); products no item matches are
+// deleted.
+func (s *MenuService) Import(ctx context.Context, ownerID, businessID uuid.UUID, items []domain.MenuItem, dryRun bool) (*domain.MenuReport, error) {
+	ctx = logging.With(ctx, logging.BusinessID, businessID.String())
+	if err := authorizeOwner(ctx, s.businesses, ownerID, businessID); err != nil {
+		return nil, err
+	}
+	current, err := s.products.ListByBusiness(ctx, businessID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*domain.Product, len(current))
+	byName := make(map[string][]*domain.Product, len(current))
+	for _, p := range current {
+		byID[p.ID] = p
+		byName[menuKey(p.Name)] = append(byName[menuKey(p.Name)], p)
+	}
+
+	report := &domain.MenuReport{
+		DryRun:  dryRun,
+		Created: []domain.MenuChange{},
+		Updated: []domain.MenuChange{},
+		Deleted: []domain.MenuChange{},
+	}
+	matched := make(map[uuid.UUID]int, len(items)) // product → row
+	newNames := make(map[string]int)               // name of a new product → row
+	var creates []*domain.Product
+	var updates []domain.ProductUpdate
+	now := time.Now().UTC()
+
+	for _, item := range items {
+		fail := func(field, msg string) {
+			report.Errors = append(report.Errors, domain.MenuRowError{Row: item.Row, Field: field, Message: msg})
+		}
+		if err := checkModifierGroups(item.ModifierGroups); err != nil {
+			fail("modifier_groups", errorMessage(err))
+			continue
+		}
+
+		var existing *domain.Product
+		if item.ID != nil {
+			if existing = byID[*item.ID]; existing == nil {
+				fail("id", "no product of this business has this id")
+				continue
+			}
+		} else if same := byName[menuKey(item.Name)]; len(same) > 1 {
+			fail("name", fmt.Sprintf("several products are called %q; give the id", item.Name))
+			continue
+		} else if len(same) == 1 {
+			existing = same[0]
+		}
+
+		req := item.ProductRequest
+		if existing == nil {
+			if row, dup := newNames[menuKey(item.Name)]; dup {
+				fail("name", fmt.Sprintf("%q is also on row %d", item.Name, row))
+				continue
+			}
+			newNames[menuKey(item.Name)] = item.Row
+			p := &domain.Product{ID: uuid.New(), BusinessID: businessID, CreatedAt: now}
+			applyProductRequest(p, &req, now)
+			creates = append(creates, p)
+			report.Created = append(report.Created, domain.MenuChange{Row: item.Row, Name: p.Name})
+			continue
+		}
+
+		if row, dup := matched[existing.ID]; dup {
+			fail("", fmt.Sprintf("row %d already sets %q", row, existing.Name))
+			continue
+		}
+		matched[existing.ID] = item.Row
+		p := *existing
+		applyProductRequest(&p, &req, now)
+		if req.ModifierGroups == nil {
+			p.ModifierGroups = existing.ModifierGroups
+		}
+		fields := changedFields(existing, &p)
+		if len(fields) == 0 {
+			report.Unchanged++
+			continue
+		}
+		id := p.ID
+		updates = append(updates, domain.ProductUpdate{Product: &p, ReadStock: existing.Stock})
+		report.Updated = append(report.Updated, domain.MenuChange{Row: item.Row, ID: &id, Name: p.Name, Fields: fields})
+	}
+
+	var remove []uuid.UUID
+	var removedImages []string
+	for _, p := range current {
+		if _, ok := matched[p.ID]; !ok {
+			id := p.ID
+			remove = append(remove, id)
+			removedImages = append(removedImages, p.ImageKey)
+			report.Deleted = append(report.Deleted, domain.MenuChange{ID: &id, Name: p.Name})
+		}
+	}
+
+	if len(report.Errors) > 0 || dryRun {
+		return report, nil
+	}
+	if len(creates)+len(updates)+len(remove) > 0 {
+		if err := s.products.ApplyMenu(ctx, creates, updates, remove); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range removedImages {
+		removeImage(ctx, s.images, s.logger, key)
+	}
+	for i, p := range creates {
+		id := p.ID
+		report.Created[i].ID = &id
+	}
+	s.logger.InfoContext(ctx, "menu imported",
+		"created", len(creates), "updated", len(updates), "deleted", len(remove), "unchanged", report.Unchanged)
+	return report, nil
+}
+
+// menuKey is how names are compared when matching items to products.
+func menuKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// changedFields lists, by their JSON names, the fields an update changes.
+func changedFields(old, updated *domain.Product) []string {
+	var fields []string
+	check := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+	check("name", old.Name != updated.Name)
+	check("description", old.Description != updated.Description)
+	check("price", old.Price != updated.Price)
+	check("is_available", old.IsAvailable != updated.IsAvailable)
+	check("stock", !equalIntPtr(old.Stock, updated.Stock))
+	check("daily_stock", !equalIntPtr(old.DailyStock, updated.DailyStock))
+	check("modifier_groups", len(old.ModifierGroups)+len(updated.ModifierGroups) > 0 &&
+		!reflect.DeepEqual(old.ModifierGroups, updated.ModifierGroups))
+	return fields
+}
+
+func equalIntPtr(a, b *int) bool {
+	return (a == nil) == (b == nil) && (a == nil || *a == *b)
+}
+
+// errorMessage is the user-facing message of a domain error.
+func errorMessage(err error) string {
+	var derr *domain.Error
+	if errors.As(err, &derr) {
+		return derr.Message
+	}
+	return err.Error()
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/repository/memory"
+)
+
+type menuFixture struct {
+	svc      *MenuService
+	products *memory.ProductRepository
+	shop     *domain.Business
+	concha   *domain.Product
+	cafe     *domain.Product // has a modifier group
+}
+
+func newMenuFixture(t *testing.T) *menuFixture {
+	t.Helper()
+	ctx := context.Background()
+	businesses := memory.NewBusinessRepository()
+	f := &menuFixture{
+		products: memory.NewProductRepository(),
+		shop:     &domain.Business{ID: uuid.New(), OwnerID: uuid.New(), IsActive: true},
+	}
+	if err := businesses.Create(ctx, f.shop); err != nil {
+		t.Fatal(err)
+	}
+	catalog := NewProductService(f.products, businesses, memory.NewStorage(), logging.Nop())
+	add := func(req *domain.ProductRequest) *domain.Product {
+		p, err := catalog.Create(ctx, f.shop.OwnerID, f.shop.ID, req)
+		if err != nil {
+			t.Fatalf("seed product: %v", err)
+		}
+		return p
+	}
+	f.concha = add(&domain.ProductRequest{Name: "Concha", Price: 18.5})
+	add(&domain.ProductRequest{Name: "Bolillo", Price: 4})
+	f.cafe = add(&domain.ProductRequest{Name: "Café", Price: 30, ModifierGroups: []domain.ModifierGroupRequest{
+		{Name: "Tamaño", MinSelect: 1, Options: []domain.ModifierOptionRequest{{Name: "Chico"}, {Name: "Grande", PriceDelta: 8}}},
+	}})
+	f.svc = NewMenuService(f.products, businesses, memory.NewStorage(), logging.Nop())
+	return f
+}
+
+func (f *menuFixture) names(t *testing.T) []string {
+	t.Helper()
+	list, err := f.products.ListByBusiness(context.Background(), f.shop.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range list {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func changeNames(changes []domain.MenuChange) []string {
+	var names []string
+	for _, c := range changes {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestMenuServiceImport(t *testing.T) {
+	f := newMenuFixture(t)
+	ctx := context.Background()
+	daily := 24
+	items := []domain.MenuItem{
+		{Row: 2, ProductRequest: domain.ProductRequest{Name: "Concha", Price: 18.5}},                     // unchanged, matched by name
+		{Row: 3, ID: &f.cafe.ID, ProductRequest: domain.ProductRequest{Name: "Café de olla", Price: 32}}, // renamed, groups kept
+		{Row: 4, ProductRequest: domain.ProductRequest{Name: "Dona", Price: 12, DailyStock: &daily}},     // new
+	}
+
+	dry, err := f.svc.Import(ctx, f.shop.OwnerID, f.shop.ID, items, true)
+	if err != nil {
+		t.Fatalf("Import(dry run) error: %v", err)
+	}
+	if got := f.names(t); !slices.Equal(got, []string{"Bolillo", "Café", "Concha"}) {
+		t.Fatalf("dry run changed the catalog: %v", got)
+	}
+	if !dry.DryRun || dry.Unchanged != 1 || dry.Created[0].ID != nil ||
+		!slices.Equal(changeNames(dry.Created), []string{"Dona"}) ||
+		!slices.Equal(changeNames(dry.Updated), []string{"Café de olla"}) ||
+		!slices.Equal(changeNames(dry.Deleted), []string{"Bolillo"}) {
+		t.Fatalf("dry run report = %+v", dry)
+	}
+	if fields := dry.Updated[0].Fields; !slices.Equal(fields, []string{"name", "price"}) {
+		t.Errorf("updated fields = %v, want [name price]", fields)
+	}
+
+	report, err := f.svc.Import(ctx, f.shop.OwnerID, f.shop.ID, items, false)
+	if err != nil {
+		t.Fatalf("Import() error: %v", err)
+	}
+	if report.Created[0].ID == nil {
+		t.Error("applied import should report the new product's ID")
+	}
+	if got := f.names(t); !slices.Equal(got, []string{"Café de olla", "Concha", "Dona"}) {
+		t.Errorf("catalog = %v, want [Café de olla Concha Dona]", got)
+	}
+	cafe, _ := f.products.GetByID(ctx, f.cafe.ID)
+	if len(cafe.ModifierGroups) != 1 || cafe.ModifierGroups[0].ID != f.cafe.ModifierGroups[0].ID {
+		t.Errorf("modifier groups = %+v, want the original group kept", cafe.ModifierGroups)
+	}
+	dona, _ := f.products.GetByID(ctx, *report.Created[0].ID)
+	if dona.Stock == nil || *dona.Stock != 24 {
+		t.Errorf("new product stock = %v, want its daily stock", dona.Stock)
+	}
+
+	again, err := f.svc.Import(ctx, f.shop.OwnerID, f.shop.ID, items, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Unchanged != 3 || len(again.Created)+len(again.Updated)+len(again.Deleted) != 0 {
+		t.Errorf("re-import report = %+v, want everything unchanged", again)
+	}
+}
+
+func TestMenuServiceImportErrors(t *testing.T) {
+	f := newMenuFixture(t)
+	ctx := context.Background()
+	foreign := uuid.New()
+
+	tests := []struct {
+		name    string
+		items   []domain.MenuItem
+		ownerID uuid.UUID
+		wantErr error
+		want    []domain.MenuRowError
+	}{
+		{
+			name:  "unknown id",
+			items: []domain.MenuItem{{Row: 2, ID: &foreign, ProductRequest: domain.ProductRequest{Name: "Concha", Price: 1}}},
+			want:  []domain.MenuRowError{{Row: 2, Field: "id", Message: "no product of this business has this id"}},
+		},
+		{
+			name: "two rows for one product",
+			items: []domain.MenuItem{
+				{Row: 2, ID: &f.concha.ID, ProductRequest: domain.ProductRequest{Name: "Concha", Price: 1}},
+				{Row: 3, ProductRequest: domain.ProductRequest{Name: "CONCHA", Price: 2}},
+			},
+			want: []domain.MenuRowError{{Row: 3, Message: `row 2 already sets "Concha"`}},
+		},
+		{
+			name: "two new products with one name",
+			items: []domain.MenuItem{
+				{Row: 2, ProductRequest: domain.ProductRequest{Name: "Dona", Price: 1}},
+				{Row: 5, ProductRequest: domain.ProductRequest{Name: "dona", Price: 2}},
+			},
+			want: []domain.MenuRowError{{Row: 5, Field: "name", Message: `"dona" is also on row 2`}},
+		},
+		{
+			name: "unsatisfiable modifier group",
+			items: []domain.MenuItem{{Row: 2, ProductRequest: domain.ProductRequest{Name: "Taco", Price: 1, ModifierGroups: []domain.ModifierGroupRequest{
+				{Name: "Salsa", MinSelect: 2, MaxSelect: 2, Options: []domain.ModifierOptionRequest{{Name: "Verde"}}},
+			}}}},
+			want: []domain.MenuRowError{{Row: 2, Field: "modifier_groups", Message: `"Salsa": min_select is above the number of options`}},
+		},
+		{
+			name:    "not the owner",
+			items:   []domain.MenuItem{},
+			ownerID: uuid.New(),
+			wantErr: domain.ErrBusinessForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner := f.shop.OwnerID
+			if tt.ownerID != uuid.Nil {
+				owner = tt.ownerID
+			}
+			report, err := f.svc.Import(ctx, owner, f.shop.ID, tt.items, false)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Import() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !slices.Equal(report.Errors, tt.want) {
+				t.Errorf("errors = %+v, want %+v", report.Errors, tt.want)
+			}
+			if got := f.names(t); !slices.Equal(got, []string{"Bolillo", "Café", "Concha"}) {
+				t.Errorf("rejected import changed the catalog: %v", got)
+			}
+		})
+	}
+}
+
+func TestMenuServiceExport(t *testing.T) {
+	f := newMenuFixture(t)
+	products, err := f.svc.Export(context.Background(), f.shop.OwnerID, f.shop.ID)
+	if err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	if len(products) != 3 || len(products[1].ModifierGroups) != 1 {
+		t.Errorf("Export() = %+v, want 3 products with the café's group", products)
+	}
+	if _, err := f.svc.Export(context.Background(), uuid.New(), f.shop.ID); !errors.Is(err, domain.ErrBusinessForbidden) {
+		t.Errorf("Export() by a stranger error = %v, want ErrBusinessForbidden", err)
+	}
+}
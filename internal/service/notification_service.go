@@ -3,48 +3,83 @@ package service
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
 
 	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/metrics"
 	"github.com/heptapegon/localpickup/pkg/fcm"
 )
 
 type NotificationService struct {
-	fcm *fcm.Client
+	fcm    *fcm.Client
+	logger *slog.Logger
 }
 
-func NewNotificationService(fcmClient *fcm.Client) *NotificationService {
-	return &NotificationService{fcm: fcmClient}
+func NewNotificationService(fcmClient *fcm.Client, logger *slog.Logger) *NotificationService {
+	return &NotificationService{fcm: fcmClient, logger: logger}
 }
 
 // SendNewOrderNotification pushes an FCM alert to the business when a payment
 // is confirmed. Called asynchronously from OrderService.Create.
 func (s *NotificationService) SendNewOrderNotification(ctx context.Context, fcmToken string, o *domain.Order) {
 	if fcmToken == "" {
+		metrics.Notification("new_order", metrics.NotificationSkipped)
 		return
 	}
 
 	shortID := o.ID.String()[:8]
+	body := fmt.Sprintf("Pedido #%s por $%.2f — ¡prepáralo!", shortID, o.TotalAmount)
+	if lines := orderLines(o.Items, notificationMaxLines); len(lines) > 0 {
+		body += "\n" + strings.Join(lines, "\n")
+	}
 	msg := &fcm.Message{
 		Token: fcmToken,
 		Notification: &fcm.Notification{
 			Title: "Nuevo Pedido Recibido",
-			Body:  fmt.Sprintf("Pedido #%s por $%.2f — ¡prepáralo!", shortID, o.TotalAmount),
+			Body:  body,
 		},
 		Data: map[string]string{
 			"type":     "new_order",
 			"order_id": o.ID.String(),
+			"items":    strings.Join(orderLines(o.Items, len(o.Items)), "\n"),
 		},
 	}
 
-	if err := s.fcm.Send(ctx, msg); err != nil {
-		log.Printf("notification: FCM send failed for order %s: %v", o.ID, err)
+	s.send(ctx, msg)
+}
+
+// notificationMaxLines bounds the items listed in a notification body; push
+// alerts are truncated on screen well before FCM's size limit.
+const notificationMaxLines = 4
+
+// orderLines describes items as "2× Latte (Leche: Avena, Extra shot)", one
+// per line, listing at most limit items and summarising the rest.
+func orderLines(items []domain.OrderItem, limit int) []string {
+	lines := make([]string, 0, min(len(items), limit)+1)
+	for i, item := range items {
+		if i == limit {
+			lines = append(lines, fmt.Sprintf("+%d más", len(items)-limit))
+			break
+		}
+		line := fmt.Sprintf("%d× %s", item.Quantity, item.ProductName)
+		if len(item.Modifiers) > 0 {
+			opts := make([]string, len(item.Modifiers))
+			for j, m := range item.Modifiers {
+				opts[j] = m.GroupName + ": " + m.Name
+			}
+			line += " (" + strings.Join(opts, ", ") + ")"
+		}
+		lines = append(lines, line)
 	}
+	return lines
 }
 
 // SendOrderReadyNotification notifies the customer that the order is ready for pickup.
 func (s *NotificationService) SendOrderReadyNotification(ctx context.Context, customerFCMToken string, o *domain.Order) {
 	if customerFCMToken == "" {
+		metrics.Notification("order_ready", metrics.NotificationSkipped)
 		return
 	}
 
@@ -60,7 +95,20 @@ func (s *NotificationService) SendOrderReadyNotification(ctx context.Context, cu
 		},
 	}
 
+	s.send(ctx, msg)
+}
+
+// send delivers msg and records the outcome under its "type" data field.
+func (s *NotificationService) send(ctx context.Context, msg *fcm.Message) {
+	kind := msg.Data["type"]
+	if !s.fcm.Enabled() {
+		metrics.Notification(kind, metrics.NotificationDisabled)
+		return
+	}
 	if err := s.fcm.Send(ctx, msg); err != nil {
-		log.Printf("notification: FCM send failed: %v", err)
+		metrics.Notification(kind, metrics.NotificationFailed)
+		s.logger.WarnContext(ctx, "push notification failed", "type", kind, logging.Error, err)
+		return
 	}
+	metrics.Notification(kind, metrics.NotificationSent)
 }
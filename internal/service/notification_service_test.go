@@ -0,0 +1,44 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+func TestOrderLines(t *testing.T) {
+	latte := domain.OrderItem{ProductName: "Latte", Quantity: 2, Modifiers: []domain.OrderItemModifier{
+		{GroupName: "Leche", Name: "Avena"},
+		{GroupName: "Extras", Name: "Shot extra"},
+	}}
+	concha := domain.OrderItem{ProductName: "Concha", Quantity: 1}
+
+	tests := []struct {
+		name  string
+		items []domain.OrderItem
+		limit int
+		want  []string
+	}{
+		{
+			name:  "options in parentheses",
+			items: []domain.OrderItem{latte, concha},
+			limit: 4,
+			want:  []string{"2× Latte (Leche: Avena, Extras: Shot extra)", "1× Concha"},
+		},
+		{
+			name:  "rest summarised",
+			items: []domain.OrderItem{concha, latte, concha},
+			limit: 1,
+			want:  []string{"1× Concha", "+2 más"},
+		},
+		{name: "no items", limit: 4, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orderLines(tt.items, tt.limit); !slices.Equal(got, tt.want) {
+				t.Errorf("orderLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,68 +4,95 @@ import (
 	"context"
 	"crypto/rand"
 	"fmt"
+	"log/slog"
 	"math/big"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
 
 	"github.com/heptapegon/localpickup/internal/domain"
-	postgresrepo "github.com/heptapegon/localpickup/internal/repository/postgres"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/metrics"
 )
 
-const (
-	pinTTL    = 24 * time.Hour
-	pinPrefix = "order:pin:"
-)
+const pinTTL = 24 * time.Hour
 
 type OrderService struct {
-	orderRepo    *postgresrepo.OrderRepository
-	businessRepo *postgresrepo.BusinessRepository
-	paymentSvc   *PaymentService
-	notifSvc     *NotificationService
-	redis        *redis.Client
+	orderRepo    OrderStore
+	businessRepo BusinessStore
+	stock        StockStore
+	paymentSvc   PaymentProcessor
+	notifSvc     Notifier
+	pins         PINStore
+	promotions   *PromotionService
+	pricing      Pricing
+	logger       *slog.Logger
 }
 
 func NewOrderService(
-	orderRepo *postgresrepo.OrderRepository,
-	businessRepo *postgresrepo.BusinessRepository,
-	paymentSvc *PaymentService,
-	notifSvc *NotificationService,
-	rdb *redis.Client,
+	orderRepo OrderStore,
+	businessRepo BusinessStore,
+	stock StockStore,
+	paymentSvc PaymentProcessor,
+	notifSvc Notifier,
+	pins PINStore,
+	promotions *PromotionService,
+	pricing Pricing,
+	logger *slog.Logger,
 ) *OrderService {
 	return &OrderService{
 		orderRepo:    orderRepo,
 		businessRepo: businessRepo,
+		stock:        stock,
 		paymentSvc:   paymentSvc,
 		notifSvc:     notifSvc,
-		redis:        rdb,
+		pins:         pins,
+		promotions:   promotions,
+		pricing:      pricing,
+		logger:       logger,
 	}
 }
 
 // Create executes the full order flow:
-//  1. Build order + calculate total
-//  2. Charge via Stripe (simulated)
-//  3. Generate a cryptographically-random 6-digit PIN
-//  4. Persist order in Postgres
-//  5. Cache PIN in Redis with a 24 h TTL
-//  6. Fire FCM notification to the business (async)
+//  1. Check the business exists and is accepting orders
+//  2. Price the items from the catalog, apply promotions, add tax and fees,
+//     and reserve the stock; a req.ExpectedTotal that no longer matches fails
+//     the order
+//  3. Redeem the promotions and persist the order as pending in Postgres
+//  4. Charge the discounted total via Stripe (simulated), keyed on the order
+//     ID, and mark the order paid; discounts are trimmed so that total is
+//     nothing or at least Stripe's minimum charge
+//  5. If any step up to here fails, the stock and redemptions are released,
+//     the pending order cancelled and the charge refunded. A retry places a
+//     new order with a Stripe key of its own, so it never replays the
+//     refunded charge
+//  6. Cache PIN in Redis with a 24 h TTL; from here on failures are only
+//     logged, since the order stands
+//  7. Fire FCM notification to the business (async)
 func (s *OrderService) Create(ctx context.Context, customerID uuid.UUID, req *domain.CreateOrderRequest) (*domain.OrderResponse, error) {
-	var total float64
-	items := make([]domain.OrderItem, 0, len(req.Items))
-	for _, i := range req.Items {
-		total += float64(i.Quantity) * i.UnitPrice
-		items = append(items, domain.OrderItem{
-			ID:          uuid.New(),
-			ProductName: i.ProductName,
-			Quantity:    i.Quantity,
-			UnitPrice:   i.UnitPrice,
-		})
+	business, err := s.businessRepo.GetByID(ctx, req.BusinessID)
+	if err != nil {
+		return nil, err
+	}
+	ctx = logging.With(ctx, logging.BusinessID, business.ID.String())
+	if !business.IsActive {
+		return nil, domain.ErrBusinessInactive
 	}
 
-	paymentID, err := s.paymentSvc.ChargeCustomer(ctx, total)
+	items, lines, subtotal, err := s.priceItems(ctx, business.ID, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	discounts, err := s.promotions.apply(ctx, customerID, business, items, subtotal, req.PromoCode, time.Now())
 	if err != nil {
-		return nil, fmt.Errorf("payment failed: %w", err)
+		return nil, err
+	}
+	charges, discounts := s.pricing.discountedCharges(subtotal, discounts)
+	total := charges.total
+	if req.ExpectedTotal != nil && !sameAmount(*req.ExpectedTotal, total) {
+		return nil, domain.ErrPriceChanged.WithMessage(fmt.Sprintf(
+			"the total is now $%.2f instead of the quoted $%.2f; quote the cart again", total, *req.ExpectedTotal))
 	}
 
 	pin, err := generatePIN()
@@ -73,76 +100,346 @@ func (s *OrderService) Create(ctx context.Context, customerID uuid.UUID, req *do
 		return nil, fmt.Errorf("pin generation failed: %w", err)
 	}
 
+	// Taken before reserving so that a daily reset racing with this order is
+	// seen as later than the reservation (see StockStore.ReleaseStock).
 	now := time.Now().UTC()
-	order := &domain.Order{
-		ID:              uuid.New(),
-		CustomerID:      customerID,
-		BusinessID:      req.BusinessID,
-		Items:           items,
-		TotalAmount:     total,
-		Status:          domain.OrderStatusPaid,
-		PIN:             pin,
-		StripePaymentID: paymentID,
-		CreatedAt:       now,
-		UpdatedAt:       now,
+	if err := s.stock.ReserveStock(ctx, lines); err != nil {
+		return nil, err
 	}
+	orderID := uuid.New()
+	ctx = logging.With(ctx, logging.OrderID, orderID.String())
+	placed, redeemed, saved := false, false, false
+	var paymentID string
+	defer func() {
+		if !placed {
+			s.releaseStock(ctx, lines, now)
+			if redeemed {
+				s.promotions.release(ctx, orderID)
+			}
+			if saved {
+				s.abandon(ctx, orderID)
+			}
+			if paymentID != "" {
+				s.refund(ctx, paymentID, "refund of an order that could not be placed failed; refund it manually")
+			}
+		}
+	}()
 
-	if err := s.orderRepo.Create(ctx, order); err != nil {
+	if err := s.promotions.redeem(ctx, orderID, customerID, discounts); err != nil {
 		return nil, err
 	}
+	redeemed = len(discounts) > 0
 
-	// Cache PIN in Redis (short-circuit lookup at validation time).
-	if err := s.redis.Set(ctx, pinPrefix+order.ID.String(), pin, pinTTL).Err(); err != nil {
-		return nil, fmt.Errorf("failed to cache PIN: %w", err)
+	order := &domain.Order{
+		ID:          orderID,
+		CustomerID:  customerID,
+		BusinessID:  req.BusinessID,
+		Items:       items,
+		Subtotal:    charges.subtotal,
+		Discount:    charges.discount,
+		Tax:         charges.tax,
+		Fees:        charges.fees,
+		TotalAmount: total,
+		Status:      domain.OrderStatusPending,
+		PIN:         pin,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Discounts:   discounts,
 	}
+	// Nothing is charged when promotions cover the whole order.
+	if total == 0 {
+		order.Status = domain.OrderStatusPaid
+	}
+	if err := s.orderRepo.Create(ctx, order); err != nil {
+		return nil, err
+	}
+	saved = true
 
-	// Notify business asynchronously — failure is non-fatal.
-	go func() {
-		bgCtx := context.Background()
-		business, err := s.businessRepo.GetByID(bgCtx, req.BusinessID)
+	if total > 0 {
+		paymentID, err = s.paymentSvc.ChargeCustomer(ctx, total, stripeIdempotencyKey(orderID))
 		if err != nil {
-			return
+			return nil, err
 		}
-		s.notifSvc.SendNewOrderNotification(bgCtx, business.FCMToken, order)
-	}()
+		ok, err := s.orderRepo.MarkPaid(ctx, orderID, paymentID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("order %s stopped being pending while it was charged", orderID)
+		}
+		order.Status, order.StripePaymentID = domain.OrderStatusPaid, paymentID
+	}
+	placed = true
+
+	// Cache PIN in Redis (short-circuit lookup at validation time). The order
+	// is placed by now, so failing here would only make the client retry it
+	// as a new order; ValidatePIN falls back to the stored PIN.
+	if err := s.pins.Save(ctx, order.ID, pin, pinTTL); err != nil {
+		s.logger.WarnContext(ctx, "failed to cache pin", logging.Error, err)
+	}
+
+	metrics.OrderCreated(business.Category)
+	s.logger.InfoContext(ctx, "order created", "total", total, "discount", charges.discount, "items", len(items), "payment_id", paymentID)
+
+	// Notify business asynchronously — failure is non-fatal. WithoutCancel
+	// keeps the trace and request values but outlives the HTTP request.
+	go s.notifSvc.SendNewOrderNotification(context.WithoutCancel(ctx), business.FCMToken, order)
 
 	return &domain.OrderResponse{Order: *order, PIN: pin}, nil
 }
 
-// ValidatePIN is called by the business to confirm pickup and complete the order.
-func (s *OrderService) ValidatePIN(ctx context.Context, orderID uuid.UUID, pin string, claimerID uuid.UUID) error {
+// Quote prices items as Create would right now for the customer, promotions
+// included, without reserving or charging anything, and estimates when the
+// order could be picked up.
+func (s *OrderService) Quote(ctx context.Context, customerID, businessID uuid.UUID, req []domain.CreateOrderItemReq, promoCode string) (*domain.Quote, error) {
+	business, err := s.businessRepo.GetByID(ctx, businessID)
+	if err != nil {
+		return nil, err
+	}
+	if !business.IsActive {
+		return nil, domain.ErrBusinessInactive
+	}
+	items, _, subtotal, err := s.priceItems(ctx, businessID, req)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := s.orderRepo.ListByBusiness(ctx, businessID, []domain.OrderStatus{domain.OrderStatusPaid})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	discounts, err := s.promotions.apply(ctx, customerID, business, items, subtotal, promoCode, now)
+	if err != nil {
+		return nil, err
+	}
+	c, discounts := s.pricing.discountedCharges(subtotal, discounts)
+	if discounts == nil {
+		discounts = []domain.OrderDiscount{}
+	}
+	return &domain.Quote{
+		Lines:          quoteLines(items),
+		Subtotal:       c.subtotal,
+		Discount:       c.discount,
+		Discounts:      discounts,
+		Tax:            c.tax,
+		Fees:           c.fees,
+		Total:          c.total,
+		EarliestPickup: s.pricing.earliestPickup(now, len(queue)),
+		QuotedAt:       now,
+	}, nil
+}
+
+// priceItems resolves the requested items against the business's catalog,
+// taking names, prices and modifier deltas from it, and totals the stock each
+// product needs.
+func (s *OrderService) priceItems(ctx context.Context, businessID uuid.UUID, req []domain.CreateOrderItemReq) ([]domain.OrderItem, []domain.StockLine, float64, error) {
+	ids := make([]uuid.UUID, 0, len(req))
+	for _, i := range req {
+		ids = append(ids, i.ProductID)
+	}
+	products, err := s.stock.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	byID := make(map[uuid.UUID]*domain.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+
+	var total float64
+	items := make([]domain.OrderItem, 0, len(req))
+	var lines []domain.StockLine
+	needed := make(map[uuid.UUID]int, len(req))
+	for _, i := range req {
+		p, ok := byID[i.ProductID]
+		if !ok || p.BusinessID != businessID {
+			return nil, nil, 0, domain.ErrProductNotFound.WithMessage(fmt.Sprintf("product %s not found in this business", i.ProductID))
+		}
+		if !p.IsAvailable {
+			return nil, nil, 0, domain.ErrProductUnavailable.WithMessage(fmt.Sprintf("%q is not available", p.Name))
+		}
+		modifiers, delta, err := p.SelectModifiers(i.Modifiers)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		unitPrice := p.Price + delta
+		if unitPrice < 0 {
+			return nil, nil, 0, domain.ErrInvalidModifiers.WithMessage(fmt.Sprintf("%q would cost less than nothing", p.Name))
+		}
+		if _, seen := needed[p.ID]; !seen {
+			lines = append(lines, domain.StockLine{ProductID: p.ID})
+		}
+		needed[p.ID] += i.Quantity
+
+		productID := p.ID
+		total += float64(i.Quantity) * unitPrice
+		items = append(items, domain.OrderItem{
+			ID:          uuid.New(),
+			ProductID:   &productID,
+			ProductName: p.Name,
+			Quantity:    i.Quantity,
+			UnitPrice:   unitPrice,
+			Modifiers:   modifiers,
+		})
+	}
+
+	for k := range lines {
+		l := &lines[k]
+		l.Quantity = needed[l.ProductID]
+		// Fail early with a useful message; ReserveStock has the final say.
+		if p := byID[l.ProductID]; !p.InStock(l.Quantity) {
+			msg := fmt.Sprintf("%q is sold out", p.Name)
+			if *p.Stock > 0 {
+				msg = fmt.Sprintf("only %d of %q left", *p.Stock, p.Name)
+			}
+			return nil, nil, 0, domain.ErrOutOfStock.WithMessage(msg)
+		}
+	}
+	return items, lines, total, nil
+}
+
+// releaseStock returns reserved stock. It runs after the request may have
+// been cancelled, and failures are only logged: at worst the product shows
+// fewer units than it has until the owner corrects it or the daily reset.
+func (s *OrderService) releaseStock(ctx context.Context, lines []domain.StockLine, reservedAt time.Time) {
+	if len(lines) == 0 {
+		return
+	}
+	if err := s.stock.ReleaseStock(context.WithoutCancel(ctx), lines, reservedAt); err != nil {
+		s.logger.ErrorContext(ctx, "failed to release stock", logging.Error, err)
+	}
+}
+
+// refund returns a charge, even after the request was cancelled. Failures are
+// logged with msg for someone to refund by hand.
+func (s *OrderService) refund(ctx context.Context, paymentID, msg string) {
+	if err := s.paymentSvc.RefundCharge(context.WithoutCancel(ctx), paymentID); err != nil {
+		s.logger.ErrorContext(ctx, msg, "payment_id", paymentID, logging.Error, err)
+	}
+}
+
+// abandon cancels a pending order that could not be paid for. Failures are
+// only logged: a pending order is never prepared, and holds no stock once
+// Create released it.
+func (s *OrderService) abandon(ctx context.Context, orderID uuid.UUID) {
+	ok, err := s.orderRepo.TransitionStatus(context.WithoutCancel(ctx), orderID, domain.OrderStatusPending, domain.OrderStatusCancelled)
+	if err != nil || !ok {
+		s.logger.ErrorContext(ctx, "failed to cancel an order that could not be paid for", "cancelled", ok, logging.Error, err)
+	}
+}
+
+// Cancel lets a customer cancel a paid order the business has not prepared
+// yet. The charge is refunded, and the stock and promotion uses returned.
+func (s *OrderService) Cancel(ctx context.Context, orderID, customerID uuid.UUID) (*domain.Order, error) {
+	ctx = logging.With(ctx, logging.OrderID, orderID.String())
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	ctx = logging.With(ctx, logging.BusinessID, order.BusinessID.String())
+	if order.CustomerID != customerID {
+		return nil, domain.ErrOrderForbidden.WithMessage("order does not belong to you")
+	}
+	notCancellable := domain.ErrOrderNotCancellable.WithMessage(fmt.Sprintf("order cannot be cancelled in status %q", order.Status))
+	if order.Status != domain.OrderStatusPaid {
+		return nil, notCancellable
+	}
+	// Guards against a concurrent cancel, completion or "ready" update.
+	ok, err := s.orderRepo.TransitionStatus(ctx, orderID, domain.OrderStatusPaid, domain.OrderStatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, notCancellable
+	}
+	order.Status = domain.OrderStatusCancelled
+
+	var lines []domain.StockLine
+	for _, item := range order.Items {
+		if item.ProductID != nil {
+			lines = append(lines, domain.StockLine{ProductID: *item.ProductID, Quantity: item.Quantity})
+		}
+	}
+	s.releaseStock(ctx, lines, order.CreatedAt)
+	if len(order.Discounts) > 0 {
+		s.promotions.release(ctx, orderID)
+	}
+
+	if order.StripePaymentID != "" {
+		s.refund(ctx, order.StripePaymentID, "refund failed; the order is cancelled and must be refunded manually")
+	}
+	if err := s.pins.Delete(ctx, orderID); err != nil {
+		s.logger.WarnContext(ctx, "failed to delete cached pin", logging.Error, err)
+	}
+
+	category := ""
+	if business, err := s.businessRepo.GetByID(ctx, order.BusinessID); err == nil {
+		category = business.Category
+	}
+	metrics.OrderCancelled(category)
+	s.logger.InfoContext(ctx, "order cancelled", "total", order.TotalAmount)
+	return order, nil
+}
+
+// ValidatePIN is called by the business owner to confirm pickup and complete the order.
+func (s *OrderService) ValidatePIN(ctx context.Context, orderID uuid.UUID, pin string, ownerID uuid.UUID) error {
+	ctx = logging.With(ctx, logging.OrderID, orderID.String())
 	order, err := s.orderRepo.GetByID(ctx, orderID)
 	if err != nil {
-		return fmt.Errorf("order not found")
+		return err
 	}
 
-	if order.BusinessID != claimerID {
-		return fmt.Errorf("order does not belong to your business")
+	ctx = logging.With(ctx, logging.BusinessID, order.BusinessID.String())
+	business, err := s.businessRepo.GetByID(ctx, order.BusinessID)
+	if err != nil {
+		return err
+	}
+	if business.OwnerID != ownerID {
+		metrics.PINValidationFailed(metrics.PINForbidden)
+		s.logger.WarnContext(ctx, "pin validation by non-owner")
+		return domain.ErrOrderForbidden
 	}
 
 	if order.Status != domain.OrderStatusPaid && order.Status != domain.OrderStatusReady {
-		return fmt.Errorf("order cannot be completed in status %q", order.Status)
+		metrics.PINValidationFailed(metrics.PINNotCompletable)
+		return domain.ErrOrderNotCompletable.WithMessage(fmt.Sprintf("order cannot be completed in status %q", order.Status))
 	}
 
 	// Verify against Redis cache (fast path) and fall back to DB value.
-	cachedPIN, err := s.redis.Get(ctx, pinPrefix+orderID.String()).Result()
-	if err == redis.Nil {
+	cachedPIN, found, err := s.pins.Get(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to verify PIN: %w", err)
+	}
+	if !found {
 		// PIN expired in cache — fall back to DB (already hashed in prod).
+		s.logger.DebugContext(ctx, "pin not cached, using stored value")
 		cachedPIN = order.PIN
-	} else if err != nil {
-		return fmt.Errorf("failed to verify PIN: %w", err)
 	}
 
 	if cachedPIN != pin {
-		return fmt.Errorf("invalid PIN")
+		metrics.PINValidationFailed(metrics.PINWrong)
+		s.logger.InfoContext(ctx, "wrong pin")
+		return domain.ErrInvalidPIN
 	}
 
-	if err := s.orderRepo.UpdateStatus(ctx, orderID, domain.OrderStatusCompleted); err != nil {
+	// Guards against a concurrent cancellation: a refunded order must never
+	// end up completed.
+	ok, err := s.orderRepo.TransitionStatus(ctx, orderID, order.Status, domain.OrderStatusCompleted)
+	if err != nil {
 		return err
 	}
+	if !ok {
+		metrics.PINValidationFailed(metrics.PINNotCompletable)
+		return domain.ErrOrderNotCompletable.WithMessage("order changed status while being completed; reload it")
+	}
+	metrics.OrderCompleted(business.Category)
+	s.logger.InfoContext(ctx, "order completed")
 
-	// Clean up PIN from cache.
-	s.redis.Del(ctx, pinPrefix+orderID.String())
+	// Clean up PIN from cache. It expires on its own, so failure is harmless.
+	if err := s.pins.Delete(ctx, orderID); err != nil {
+		s.logger.WarnContext(ctx, "failed to delete cached pin", logging.Error, err)
+	}
 	return nil
 }
 
@@ -154,6 +451,137 @@ func (s *OrderService) ListByCustomer(ctx context.Context, customerID uuid.UUID)
 	return s.orderRepo.ListByCustomer(ctx, customerID)
 }
 
+// Reorder rebuilds one of the customer's past orders from the current
+// catalog: every line is re-priced, and lines whose product or options are
+// gone, unavailable or sold out are reported and left out of the items to
+// order. Nothing is reserved or charged.
+func (s *OrderService) Reorder(ctx context.Context, customerID, orderID uuid.UUID) (*domain.Reorder, error) {
+	ctx = logging.With(ctx, logging.OrderID, orderID.String())
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.CustomerID != customerID {
+		return nil, domain.ErrOrderForbidden.WithMessage("order does not belong to you")
+	}
+	business, err := s.businessRepo.GetByID(ctx, order.BusinessID)
+	if err != nil {
+		return nil, err
+	}
+	if !business.IsActive {
+		return nil, domain.ErrBusinessInactive
+	}
+
+	ids := make([]uuid.UUID, 0, len(order.Items))
+	for _, item := range order.Items {
+		if item.ProductID != nil {
+			ids = append(ids, *item.ProductID)
+		}
+	}
+	products, err := s.stock.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*domain.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+
+	out := &domain.Reorder{
+		BusinessID:    order.BusinessID,
+		Items:         []domain.CreateOrderItemReq{},
+		Lines:         make([]domain.ReorderLine, 0, len(order.Items)),
+		PreviousTotal: order.TotalAmount,
+	}
+	needed := make(map[uuid.UUID]int, len(products))
+	for _, item := range order.Items {
+		line := domain.ReorderLine{
+			ProductID:         item.ProductID,
+			ProductName:       item.ProductName,
+			Quantity:          item.Quantity,
+			PreviousUnitPrice: item.UnitPrice,
+			Status:            domain.ReorderUnavailable,
+		}
+		var p *domain.Product
+		if item.ProductID != nil {
+			p = byID[*item.ProductID]
+		}
+		if p == nil || p.BusinessID != order.BusinessID || !p.IsAvailable {
+			out.Lines = append(out.Lines, line)
+			continue
+		}
+		line.ProductName = p.Name
+
+		options, removed := make([]uuid.UUID, 0, len(item.Modifiers)), false
+		for _, m := range item.Modifiers {
+			if m.OptionID == nil {
+				removed = true
+				continue
+			}
+			options = append(options, *m.OptionID)
+		}
+		modifiers, delta, err := p.SelectModifiers(options)
+		switch {
+		case removed || err != nil:
+			line.Status = domain.ReorderOptionsChanged
+		case !p.InStock(needed[p.ID] + item.Quantity):
+			line.Status = domain.ReorderSoldOut
+		default:
+			needed[p.ID] += item.Quantity
+			line.UnitPrice = p.Price + delta
+			line.Modifiers = modifiers
+			line.Status = domain.ReorderUnchanged
+			if !sameAmount(line.UnitPrice, item.UnitPrice) {
+				line.Status = domain.ReorderPriceChanged
+			}
+			out.TotalAmount += float64(item.Quantity) * line.UnitPrice
+			out.Items = append(out.Items, domain.CreateOrderItemReq{ProductID: p.ID, Quantity: item.Quantity, Modifiers: options})
+		}
+		out.Lines = append(out.Lines, line)
+	}
+	for _, line := range out.Lines {
+		out.Changed = out.Changed || line.Status != domain.ReorderUnchanged
+	}
+	if len(out.Items) > 0 {
+		out.TotalAmount = s.pricing.charges(out.TotalAmount, 0).total
+	}
+
+	s.logger.InfoContext(ctx, "order rebuilt for reorder", "items", len(out.Items), "lines", len(out.Lines), "changed", out.Changed)
+	return out, nil
+}
+
+// queueStatuses are the orders a business still has to hand over.
+var queueStatuses = []domain.OrderStatus{domain.OrderStatusPaid, domain.OrderStatusReady}
+
+// Queue returns the business's open orders, oldest first, with their items
+// and chosen modifiers. statuses narrows the queue; empty means every open
+// status. Only the owner may see it.
+func (s *OrderService) Queue(ctx context.Context, ownerID, businessID uuid.UUID, statuses []domain.OrderStatus) ([]*domain.Order, error) {
+	business, err := s.businessRepo.GetByID(ctx, businessID)
+	if err != nil {
+		return nil, err
+	}
+	if business.OwnerID != ownerID {
+		return nil, domain.ErrBusinessForbidden
+	}
+	for _, st := range statuses {
+		if !slices.Contains(queueStatuses, st) {
+			return nil, domain.ErrInvalidOrderStatus.WithMessage(fmt.Sprintf("status %q is not part of the queue", st))
+		}
+	}
+	if len(statuses) == 0 {
+		statuses = queueStatuses
+	}
+	return s.orderRepo.ListByBusiness(ctx, businessID, statuses)
+}
+
+// stripeIdempotencyKey keys the charge of an order on its ID: retries of the
+// call to Stripe cannot charge twice, and a charge refunded because the order
+// failed is never replayed for another one.
+func stripeIdempotencyKey(orderID uuid.UUID) string {
+	return "order-create:" + orderID.String()
+}
+
 // generatePIN produces a cryptographically-random zero-padded 6-digit string.
 func generatePIN() (string, error) {
 	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
@@ -1,7 +1,21 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/repository/memory"
+	"github.com/heptapegon/localpickup/internal/telemetry"
 )
 
 func TestGeneratePIN(t *testing.T) {
@@ -28,3 +42,902 @@ func TestGeneratePIN(t *testing.T) {
 		t.Errorf("PIN entropy too low: only %d unique values in 1000 samples", len(seen))
 	}
 }
+
+// ─── Order lifecycle ────────────────────────────────────────────────────────
+
+type fakePayments struct {
+	mu      sync.Mutex
+	err     error
+	charges []float64
+	keys    []string
+	refunds []string
+}
+
+// ChargeCustomer replays the payment of an idempotency key it has already
+// seen, as Stripe does, even if that payment was refunded.
+func (p *fakePayments) ChargeCustomer(_ context.Context, amountUSD float64, idempotencyKey string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return "", p.err
+	}
+	if toCents(amountUSD) < toCents(minCharge) {
+		return "", domain.ErrAmountBelowMinimum
+	}
+	for i, k := range p.keys {
+		if idempotencyKey != "" && k == idempotencyKey {
+			return fmt.Sprintf("pi_test_%d", i+1), nil
+		}
+	}
+	p.charges = append(p.charges, amountUSD)
+	p.keys = append(p.keys, idempotencyKey)
+	return fmt.Sprintf("pi_test_%d", len(p.charges)), nil
+}
+
+func (p *fakePayments) RefundCharge(_ context.Context, paymentID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refunds = append(p.refunds, paymentID)
+	return nil
+}
+
+type orderFixture struct {
+	svc        *OrderService
+	orders     *memory.OrderRepository
+	businesses *memory.BusinessRepository
+	products   *memory.ProductRepository
+	pins       *memory.PINStore
+	promotions *PromotionService
+	notifier   *memory.Notifier
+	payments   *fakePayments
+	business   *domain.Business
+	concha     *domain.Product // unlimited, $1.50
+	bolillo    *domain.Product // 10 in stock, $0.75
+	ownerID    uuid.UUID
+	customerID uuid.UUID
+}
+
+func newOrderFixture(t *testing.T) *orderFixture {
+	t.Helper()
+	f := &orderFixture{
+		orders:     memory.NewOrderRepository(),
+		businesses: memory.NewBusinessRepository(),
+		products:   memory.NewProductRepository(),
+		pins:       memory.NewPINStore(),
+		notifier:   memory.NewNotifier(),
+		payments:   &fakePayments{},
+		ownerID:    uuid.New(),
+		customerID: uuid.New(),
+	}
+	f.business = &domain.Business{
+		ID:       uuid.New(),
+		OwnerID:  f.ownerID,
+		Name:     "Panadería Rosa",
+		Category: "bakery",
+		FCMToken: "business-device-token",
+		IsActive: true,
+	}
+	if err := f.businesses.Create(context.Background(), f.business); err != nil {
+		t.Fatalf("seed business: %v", err)
+	}
+	f.concha = f.addProduct(t, "Concha", 1.5, nil)
+	stock := 10
+	f.bolillo = f.addProduct(t, "Bolillo", 0.75, &stock)
+	f.promotions = NewPromotionService(memory.NewPromotionRepository(), f.orders, f.businesses, newTestCategories(), time.UTC, logging.Nop())
+	f.svc = NewOrderService(f.orders, f.businesses, f.products, f.payments, f.notifier, f.pins, f.promotions, Pricing{}, logging.Nop())
+	return f
+}
+
+func (f *orderFixture) addProduct(t *testing.T, name string, price float64, stock *int) *domain.Product {
+	t.Helper()
+	p := &domain.Product{ID: uuid.New(), BusinessID: f.business.ID, Name: name, Price: price, IsAvailable: true, Stock: stock}
+	if err := f.products.Create(context.Background(), p); err != nil {
+		t.Fatalf("seed product: %v", err)
+	}
+	return p
+}
+
+// stockOf returns the units left of p; -1 means unlimited.
+func (f *orderFixture) stockOf(t *testing.T, p *domain.Product) int {
+	t.Helper()
+	got, err := f.products.GetByID(context.Background(), p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Stock == nil {
+		return -1
+	}
+	return *got.Stock
+}
+
+func (f *orderFixture) placeOrder(t *testing.T) *domain.OrderResponse {
+	t.Helper()
+	resp, err := f.svc.Create(context.Background(), f.customerID, &domain.CreateOrderRequest{
+		BusinessID: f.business.ID,
+		Items:      []domain.CreateOrderItemReq{{ProductID: f.concha.ID, Quantity: 2}},
+	})
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	return resp
+}
+
+// waitFor polls cond until it holds; used for the async FCM notification.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestOrderServiceCreate(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(f *orderFixture, req *domain.CreateOrderRequest)
+		wantErr   error
+		wantTotal float64
+	}{
+		{
+			name:      "charges, persists and caches PIN",
+			setup:     func(f *orderFixture, req *domain.CreateOrderRequest) {},
+			wantTotal: 2*1.5 + 3*0.75,
+		},
+		{
+			name: "unknown business",
+			setup: func(f *orderFixture, req *domain.CreateOrderRequest) {
+				req.BusinessID = uuid.New()
+			},
+			wantErr: domain.ErrBusinessNotFound,
+		},
+		{
+			name: "inactive business",
+			setup: func(f *orderFixture, req *domain.CreateOrderRequest) {
+				inactive := &domain.Business{ID: uuid.New(), OwnerID: f.ownerID, IsActive: false}
+				_ = f.businesses.Create(context.Background(), inactive)
+				req.BusinessID = inactive.ID
+			},
+			wantErr: domain.ErrBusinessInactive,
+		},
+		{
+			name: "payment declined",
+			setup: func(f *orderFixture, req *domain.CreateOrderRequest) {
+				f.payments.err = domain.ErrPaymentFailed
+			},
+			wantErr: domain.ErrPaymentDeclined,
+		},
+		{
+			name: "not enough stock",
+			setup: func(f *orderFixture, req *domain.CreateOrderRequest) {
+				req.Items[1].Quantity = 11
+			},
+			wantErr: domain.ErrOutOfStock,
+		},
+		{
+			name: "repeated lines share the stock",
+			setup: func(f *orderFixture, req *domain.CreateOrderRequest) {
+				req.Items = append(req.Items, domain.CreateOrderItemReq{ProductID: f.bolillo.ID, Quantity: 8})
+			},
+			wantErr: domain.ErrOutOfStock,
+		},
+		{
+			name: "unavailable product",
+			setup: func(f *orderFixture, req *domain.CreateOrderRequest) {
+				f.bolillo.IsAvailable = false
+				_ = f.products.Update(context.Background(), f.bolillo, f.bolillo.Stock)
+			},
+			wantErr: domain.ErrProductUnavailable,
+		},
+		{
+			name: "product of another business",
+			setup: func(f *orderFixture, req *domain.CreateOrderRequest) {
+				other := &domain.Product{ID: uuid.New(), BusinessID: uuid.New(), Name: "Ajeno", Price: 1, IsAvailable: true}
+				_ = f.products.Create(context.Background(), other)
+				req.Items[0].ProductID = other.ID
+			},
+			wantErr: domain.ErrProductNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOrderFixture(t)
+			ctx := context.Background()
+			req := &domain.CreateOrderRequest{
+				BusinessID: f.business.ID,
+				Items: []domain.CreateOrderItemReq{
+					{ProductID: f.concha.ID, Quantity: 2},
+					{ProductID: f.bolillo.ID, Quantity: 3},
+				},
+			}
+			tt.setup(f, req)
+
+			resp, err := f.svc.Create(ctx, f.customerID, req)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+				}
+				orders, _ := f.orders.ListByCustomer(ctx, f.customerID)
+				for _, o := range orders {
+					if o.Status != domain.OrderStatusCancelled {
+						t.Errorf("order left %s, want none or only cancelled ones", o.Status)
+					}
+				}
+				if left := f.stockOf(t, f.bolillo); left != 10 {
+					t.Errorf("bolillos left = %d, want all 10 back", left)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Create() error: %v", err)
+			}
+
+			if resp.TotalAmount != tt.wantTotal {
+				t.Errorf("total = %.2f, want %.2f", resp.TotalAmount, tt.wantTotal)
+			}
+			if resp.Status != domain.OrderStatusPaid {
+				t.Errorf("status = %q, want %q", resp.Status, domain.OrderStatusPaid)
+			}
+			if len(resp.PIN) != 6 {
+				t.Errorf("expected 6-digit PIN in response, got %q", resp.PIN)
+			}
+
+			stored, err := f.orders.GetByID(ctx, resp.ID)
+			if err != nil {
+				t.Fatalf("order not persisted: %v", err)
+			}
+			if len(stored.Items) != 2 || stored.StripePaymentID == "" {
+				t.Errorf("stored order = %+v, want 2 items and a payment ID", stored)
+			}
+			if item := stored.Items[1]; item.ProductName != "Bolillo" || item.UnitPrice != 0.75 || *item.ProductID != f.bolillo.ID {
+				t.Errorf("stored item = %+v, want name and price from the catalog", item)
+			}
+			if left := f.stockOf(t, f.bolillo); left != 7 {
+				t.Errorf("bolillos left = %d, want 7", left)
+			}
+
+			if pin, found, _ := f.pins.Get(ctx, resp.ID); !found || pin != resp.PIN {
+				t.Errorf("cached PIN = %q (found=%v), want %q", pin, found, resp.PIN)
+			}
+
+			if got, wantKey := f.payments.keys[0], "order-create:"+resp.ID.String(); got != wantKey {
+				t.Errorf("stripe idempotency key = %q, want %q", got, wantKey)
+			}
+
+			waitFor(t, func() bool { return len(f.notifier.Sent()) == 1 })
+			if n := f.notifier.Sent()[0]; n.Type != "new_order" || n.Token != f.business.FCMToken || n.OrderID != resp.ID.String() {
+				t.Errorf("notification = %+v", n)
+			}
+		})
+	}
+}
+
+// unpaidOrders fails to record payments while failing is set.
+type unpaidOrders struct {
+	*memory.OrderRepository
+	failing *bool
+}
+
+func (r unpaidOrders) MarkPaid(ctx context.Context, id uuid.UUID, paymentID string) (bool, error) {
+	if *r.failing {
+		return false, errors.New("connection reset")
+	}
+	return r.OrderRepository.MarkPaid(ctx, id, paymentID)
+}
+
+func TestOrderServiceCreateRefundsUnpaidOrder(t *testing.T) {
+	f := newOrderFixture(t)
+	ctx := context.Background()
+	failing := true
+	f.svc = NewOrderService(unpaidOrders{f.orders, &failing}, f.businesses, f.products, f.payments, f.notifier, f.pins, f.promotions, Pricing{}, logging.Nop())
+	req := &domain.CreateOrderRequest{
+		BusinessID: f.business.ID,
+		Items:      []domain.CreateOrderItemReq{{ProductID: f.bolillo.ID, Quantity: 4}},
+	}
+
+	if _, err := f.svc.Create(ctx, f.customerID, req); err == nil {
+		t.Fatal("Create() succeeded, want the store's error")
+	}
+	if len(f.payments.charges) != 1 || len(f.payments.refunds) != 1 || f.payments.refunds[0] != "pi_test_1" {
+		t.Errorf("charges = %v, refunds = %v, want the charge refunded", f.payments.charges, f.payments.refunds)
+	}
+	if left := f.stockOf(t, f.bolillo); left != 10 {
+		t.Errorf("bolillos left = %d, want all 10 back", left)
+	}
+	orders, _ := f.orders.ListByCustomer(ctx, f.customerID)
+	if len(orders) != 1 || orders[0].Status != domain.OrderStatusCancelled {
+		t.Fatalf("orders = %v, want the unpaid one cancelled", orders)
+	}
+
+	// The retry behind the same Idempotency-Key is a new order with a Stripe
+	// key of its own, so it is charged afresh instead of replaying the
+	// refunded payment.
+	failing = false
+	resp, err := f.svc.Create(ctx, f.customerID, req)
+	if err != nil {
+		t.Fatalf("retried Create() error: %v", err)
+	}
+	if resp.StripePaymentID != "pi_test_2" || len(f.payments.charges) != 2 || f.payments.keys[0] == f.payments.keys[1] {
+		t.Errorf("retry paid with %q (keys %v), want a new charge under a new key", resp.StripePaymentID, f.payments.keys)
+	}
+	if stored, _ := f.orders.GetByID(ctx, resp.ID); stored.Status != domain.OrderStatusPaid || stored.StripePaymentID != "pi_test_2" {
+		t.Errorf("stored retry = %s with %q, want paid with pi_test_2", stored.Status, stored.StripePaymentID)
+	}
+}
+
+// uncachedPINs fails to cache PINs.
+type uncachedPINs struct {
+	*memory.PINStore
+}
+
+func (uncachedPINs) Save(context.Context, uuid.UUID, string, time.Duration) error {
+	return errors.New("connection reset")
+}
+
+func TestOrderServiceCreateWithoutPINCache(t *testing.T) {
+	f := newOrderFixture(t)
+	ctx := context.Background()
+	f.svc = NewOrderService(f.orders, f.businesses, f.products, f.payments, f.notifier, uncachedPINs{f.pins}, f.promotions, Pricing{}, logging.Nop())
+
+	// The order is placed and paid for; failing now would make the client
+	// retry it as a second order.
+	resp, err := f.svc.Create(ctx, f.customerID, &domain.CreateOrderRequest{
+		BusinessID: f.business.ID,
+		Items:      []domain.CreateOrderItemReq{{ProductID: f.bolillo.ID, Quantity: 4}},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v, want the order placed", err)
+	}
+	if len(f.payments.refunds) != 0 {
+		t.Errorf("refunds = %v, want none", f.payments.refunds)
+	}
+	if err := f.svc.ValidatePIN(ctx, resp.ID, resp.PIN, f.ownerID); err != nil {
+		t.Errorf("ValidatePIN() with the stored PIN error: %v", err)
+	}
+}
+
+// addCafe adds a $2.00 coffee with a required single-select size and up to
+// two extras, one of them unavailable.
+func (f *orderFixture) addCafe(t *testing.T) *domain.Product {
+	t.Helper()
+	p := &domain.Product{ID: uuid.New(), BusinessID: f.business.ID, Name: "Café", Price: 2, IsAvailable: true,
+		ModifierGroups: []domain.ModifierGroup{
+			{ID: uuid.New(), Name: "Tamaño", MinSelect: 1, MaxSelect: 1, Options: []domain.ModifierOption{
+				{ID: uuid.New(), Name: "Chico", IsAvailable: true},
+				{ID: uuid.New(), Name: "Grande", PriceDelta: 0.5, IsAvailable: true},
+			}},
+			{ID: uuid.New(), Name: "Extras", MaxSelect: 2, Options: []domain.ModifierOption{
+				{ID: uuid.New(), Name: "Canela", PriceDelta: 0.25, IsAvailable: true},
+				{ID: uuid.New(), Name: "Piloncillo", PriceDelta: 0.25, IsAvailable: true},
+				{ID: uuid.New(), Name: "Leche", PriceDelta: 0.3},
+			}},
+		}}
+	if err := f.products.Create(context.Background(), p); err != nil {
+		t.Fatalf("seed product: %v", err)
+	}
+	return p
+}
+
+func TestOrderServiceCreateModifiers(t *testing.T) {
+	f := newOrderFixture(t)
+	cafe := f.addCafe(t)
+	chico, grande := cafe.ModifierGroups[0].Options[0].ID, cafe.ModifierGroups[0].Options[1].ID
+	canela, piloncillo, leche := cafe.ModifierGroups[1].Options[0].ID, cafe.ModifierGroups[1].Options[1].ID, cafe.ModifierGroups[1].Options[2].ID
+
+	tests := []struct {
+		name      string
+		modifiers []uuid.UUID
+		wantErr   error
+		wantUnit  float64
+		wantNames []string
+	}{
+		{name: "single required choice", modifiers: []uuid.UUID{chico}, wantUnit: 2, wantNames: []string{"Chico"}},
+		{
+			name:      "deltas add up in display order",
+			modifiers: []uuid.UUID{piloncillo, grande, canela},
+			wantUnit:  2 + 0.5 + 0.25 + 0.25,
+			wantNames: []string{"Grande", "Canela", "Piloncillo"},
+		},
+		{name: "required group left out", modifiers: []uuid.UUID{canela}, wantErr: domain.ErrInvalidModifiers},
+		{name: "two options of a single-select group", modifiers: []uuid.UUID{chico, grande}, wantErr: domain.ErrInvalidModifiers},
+		{name: "unavailable option", modifiers: []uuid.UUID{chico, leche}, wantErr: domain.ErrInvalidModifiers},
+		{name: "option chosen twice", modifiers: []uuid.UUID{chico, canela, canela}, wantErr: domain.ErrInvalidModifiers},
+		{name: "option of another product", modifiers: []uuid.UUID{chico, uuid.New()}, wantErr: domain.ErrInvalidModifiers},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			resp, err := f.svc.Create(ctx, f.customerID, &domain.CreateOrderRequest{
+				BusinessID: f.business.ID,
+				Items: []domain.CreateOrderItemReq{
+					{ProductID: cafe.ID, Quantity: 2, Modifiers: tt.modifiers},
+					{ProductID: f.concha.ID, Quantity: 1},
+				},
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Create() error: %v", err)
+			}
+
+			if want := 2*tt.wantUnit + 1.5; resp.TotalAmount != want {
+				t.Errorf("total = %.2f, want %.2f", resp.TotalAmount, want)
+			}
+			stored, err := f.orders.GetByID(ctx, resp.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			item := stored.Items[0]
+			if item.UnitPrice != tt.wantUnit {
+				t.Errorf("unit price = %.2f, want %.2f", item.UnitPrice, tt.wantUnit)
+			}
+			var names []string
+			for _, m := range item.Modifiers {
+				names = append(names, m.Name)
+			}
+			if !slices.Equal(names, tt.wantNames) {
+				t.Errorf("modifiers = %v, want %v", names, tt.wantNames)
+			}
+			if len(stored.Items[1].Modifiers) != 0 {
+				t.Errorf("plain item has modifiers %+v", stored.Items[1].Modifiers)
+			}
+		})
+	}
+}
+
+func TestOrderServiceQueue(t *testing.T) {
+	f := newOrderFixture(t)
+	ctx := context.Background()
+
+	first := f.placeOrder(t)
+	time.Sleep(time.Millisecond)
+	second := f.placeOrder(t)
+	time.Sleep(time.Millisecond)
+	done := f.placeOrder(t)
+	if err := f.orders.UpdateStatus(ctx, second.ID, domain.OrderStatusReady); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.orders.UpdateStatus(ctx, done.ID, domain.OrderStatusCompleted); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		ownerID  uuid.UUID
+		statuses []domain.OrderStatus
+		want     []uuid.UUID
+		wantErr  error
+	}{
+		{name: "open orders oldest first", ownerID: f.ownerID, want: []uuid.UUID{first.ID, second.ID}},
+		{name: "filtered by status", ownerID: f.ownerID, statuses: []domain.OrderStatus{domain.OrderStatusReady}, want: []uuid.UUID{second.ID}},
+		{name: "closed status", ownerID: f.ownerID, statuses: []domain.OrderStatus{domain.OrderStatusCompleted}, wantErr: domain.ErrInvalidOrderStatus},
+		{name: "not the owner", ownerID: f.customerID, wantErr: domain.ErrBusinessForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.svc.Queue(ctx, tt.ownerID, f.business.ID, tt.statuses)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Queue() error = %v, want %v", err, tt.wantErr)
+			}
+			ids := make([]uuid.UUID, 0, len(got))
+			for _, o := range got {
+				ids = append(ids, o.ID)
+				if len(o.Items) == 0 || o.PIN != "" {
+					t.Errorf("queued order = %+v, want items and no PIN", o)
+				}
+			}
+			if tt.wantErr == nil && !slices.Equal(ids, tt.want) {
+				t.Errorf("Queue() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+// cancelAfterRead cancels orders right after they are read, as a Cancel
+// racing with the caller would.
+type cancelAfterRead struct {
+	*memory.OrderRepository
+}
+
+func (r cancelAfterRead) GetByID(ctx context.Context, id uuid.UUID) (*domain.Order, error) {
+	o, err := r.OrderRepository.GetByID(ctx, id)
+	if err == nil {
+		_, _ = r.TransitionStatus(ctx, id, domain.OrderStatusPaid, domain.OrderStatusCancelled)
+	}
+	return o, err
+}
+
+func TestOrderServiceValidatePIN(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(f *orderFixture, order *domain.OrderResponse) (orderID uuid.UUID, pin string, ownerID uuid.UUID)
+		wantErr    error
+		wantStatus domain.OrderStatus
+	}{
+		{
+			name: "correct PIN completes order",
+			setup: func(f *orderFixture, o *domain.OrderResponse) (uuid.UUID, string, uuid.UUID) {
+				return o.ID, o.PIN, f.ownerID
+			},
+			wantStatus: domain.OrderStatusCompleted,
+		},
+		{
+			name: "falls back to stored PIN when cache evicted",
+			setup: func(f *orderFixture, o *domain.OrderResponse) (uuid.UUID, string, uuid.UUID) {
+				f.pins.Evict(o.ID)
+				return o.ID, o.PIN, f.ownerID
+			},
+			wantStatus: domain.OrderStatusCompleted,
+		},
+		{
+			name: "ready orders can be completed",
+			setup: func(f *orderFixture, o *domain.OrderResponse) (uuid.UUID, string, uuid.UUID) {
+				_ = f.orders.UpdateStatus(context.Background(), o.ID, domain.OrderStatusReady)
+				return o.ID, o.PIN, f.ownerID
+			},
+			wantStatus: domain.OrderStatusCompleted,
+		},
+		{
+			name: "wrong PIN",
+			setup: func(f *orderFixture, o *domain.OrderResponse) (uuid.UUID, string, uuid.UUID) {
+				return o.ID, wrongPIN(o.PIN), f.ownerID
+			},
+			wantErr:    domain.ErrInvalidPIN,
+			wantStatus: domain.OrderStatusPaid,
+		},
+		{
+			name: "another owner",
+			setup: func(f *orderFixture, o *domain.OrderResponse) (uuid.UUID, string, uuid.UUID) {
+				return o.ID, o.PIN, uuid.New()
+			},
+			wantErr:    domain.ErrForbidden,
+			wantStatus: domain.OrderStatusPaid,
+		},
+		{
+			name: "unknown order",
+			setup: func(f *orderFixture, o *domain.OrderResponse) (uuid.UUID, string, uuid.UUID) {
+				return uuid.New(), o.PIN, f.ownerID
+			},
+			wantErr:    domain.ErrOrderNotFound,
+			wantStatus: domain.OrderStatusPaid,
+		},
+		{
+			name: "already completed",
+			setup: func(f *orderFixture, o *domain.OrderResponse) (uuid.UUID, string, uuid.UUID) {
+				_ = f.orders.UpdateStatus(context.Background(), o.ID, domain.OrderStatusCompleted)
+				return o.ID, o.PIN, f.ownerID
+			},
+			wantErr:    domain.ErrInvalidState,
+			wantStatus: domain.OrderStatusCompleted,
+		},
+		{
+			name: "cancelled",
+			setup: func(f *orderFixture, o *domain.OrderResponse) (uuid.UUID, string, uuid.UUID) {
+				_ = f.orders.UpdateStatus(context.Background(), o.ID, domain.OrderStatusCancelled)
+				return o.ID, o.PIN, f.ownerID
+			},
+			wantErr:    domain.ErrOrderNotCompletable,
+			wantStatus: domain.OrderStatusCancelled,
+		},
+		{
+			name: "cancelled while validating",
+			setup: func(f *orderFixture, o *domain.OrderResponse) (uuid.UUID, string, uuid.UUID) {
+				f.svc = NewOrderService(cancelAfterRead{f.orders}, f.businesses, f.products, f.payments, f.notifier, f.pins, f.promotions, Pricing{}, logging.Nop())
+				return o.ID, o.PIN, f.ownerID
+			},
+			wantErr:    domain.ErrOrderNotCompletable,
+			wantStatus: domain.OrderStatusCancelled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOrderFixture(t)
+			ctx := context.Background()
+			placed := f.placeOrder(t)
+
+			orderID, pin, ownerID := tt.setup(f, placed)
+			err := f.svc.ValidatePIN(ctx, orderID, pin, ownerID)
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ValidatePIN() error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidatePIN() error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, _ := f.orders.GetByID(ctx, placed.ID)
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", stored.Status, tt.wantStatus)
+			}
+			if tt.wantStatus == domain.OrderStatusCompleted && tt.wantErr == nil {
+				if _, found, _ := f.pins.Get(ctx, placed.ID); found {
+					t.Error("expected PIN to be removed from cache after completion")
+				}
+			}
+		})
+	}
+}
+
+func TestOrderServiceCancel(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(f *orderFixture, o *domain.OrderResponse) (customerID uuid.UUID)
+		wantErr    error
+		wantStatus domain.OrderStatus
+		wantStock  int // bolillos left afterwards
+	}{
+		{
+			name:       "refunds and restocks",
+			setup:      func(f *orderFixture, o *domain.OrderResponse) uuid.UUID { return f.customerID },
+			wantStatus: domain.OrderStatusCancelled,
+			wantStock:  10,
+		},
+		{
+			name:       "another customer",
+			setup:      func(f *orderFixture, o *domain.OrderResponse) uuid.UUID { return uuid.New() },
+			wantErr:    domain.ErrForbidden,
+			wantStatus: domain.OrderStatusPaid,
+			wantStock:  6,
+		},
+		{
+			name: "already ready",
+			setup: func(f *orderFixture, o *domain.OrderResponse) uuid.UUID {
+				_ = f.orders.UpdateStatus(context.Background(), o.ID, domain.OrderStatusReady)
+				return f.customerID
+			},
+			wantErr:    domain.ErrOrderNotCancellable,
+			wantStatus: domain.OrderStatusReady,
+			wantStock:  6,
+		},
+		{
+			name: "stock reset since the order",
+			setup: func(f *orderFixture, o *domain.OrderResponse) uuid.UUID {
+				f.bolillo.DailyStock = f.bolillo.Stock
+				_ = f.products.Update(context.Background(), f.bolillo, f.bolillo.Stock)
+				_, _ = f.products.ResetDailyStock(context.Background(), time.Now().Add(time.Hour))
+				return f.customerID
+			},
+			wantStatus: domain.OrderStatusCancelled,
+			wantStock:  10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOrderFixture(t)
+			ctx := context.Background()
+			placed, err := f.svc.Create(ctx, f.customerID, &domain.CreateOrderRequest{
+				BusinessID: f.business.ID,
+				Items:      []domain.CreateOrderItemReq{{ProductID: f.bolillo.ID, Quantity: 4}},
+			})
+			if err != nil {
+				t.Fatalf("Create() error: %v", err)
+			}
+
+			_, err = f.svc.Cancel(ctx, placed.ID, tt.setup(f, placed))
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Cancel() error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Cancel() error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, _ := f.orders.GetByID(ctx, placed.ID)
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", stored.Status, tt.wantStatus)
+			}
+			if left := f.stockOf(t, f.bolillo); left != tt.wantStock {
+				t.Errorf("bolillos left = %d, want %d", left, tt.wantStock)
+			}
+			cancelled := tt.wantErr == nil
+			if refunded := len(f.payments.refunds) == 1 && f.payments.refunds[0] == placed.StripePaymentID; refunded != cancelled {
+				t.Errorf("refunds = %v, want refunded=%v", f.payments.refunds, cancelled)
+			}
+			if _, found, _ := f.pins.Get(ctx, placed.ID); found == cancelled {
+				t.Errorf("PIN cached = %v after cancel=%v", found, cancelled)
+			}
+		})
+	}
+
+	t.Run("only once", func(t *testing.T) {
+		f := newOrderFixture(t)
+		ctx := context.Background()
+		placed, err := f.svc.Create(ctx, f.customerID, &domain.CreateOrderRequest{
+			BusinessID: f.business.ID,
+			Items:      []domain.CreateOrderItemReq{{ProductID: f.bolillo.ID, Quantity: 4}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.svc.Cancel(ctx, placed.ID, f.customerID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.svc.Cancel(ctx, placed.ID, f.customerID); !errors.Is(err, domain.ErrOrderNotCancellable) {
+			t.Errorf("second Cancel() error = %v, want ErrOrderNotCancellable", err)
+		}
+		if left := f.stockOf(t, f.bolillo); left != 10 {
+			t.Errorf("bolillos left = %d, want 10", left)
+		}
+	})
+}
+
+func TestOrderServiceListByCustomer(t *testing.T) {
+	f := newOrderFixture(t)
+	ctx := context.Background()
+
+	first := f.placeOrder(t)
+	time.Sleep(time.Millisecond)
+	second := f.placeOrder(t)
+
+	orders, err := f.svc.ListByCustomer(ctx, f.customerID)
+	if err != nil {
+		t.Fatalf("ListByCustomer() error: %v", err)
+	}
+	if len(orders) != 2 {
+		t.Fatalf("got %d orders, want 2", len(orders))
+	}
+	if orders[0].ID != second.ID || orders[1].ID != first.ID {
+		t.Error("expected newest order first")
+	}
+
+	others, _ := f.svc.ListByCustomer(ctx, uuid.New())
+	if len(others) != 0 {
+		t.Errorf("other customer sees %d orders, want 0", len(others))
+	}
+}
+
+func TestOrderServiceReorder(t *testing.T) {
+	f := newOrderFixture(t)
+	ctx := context.Background()
+	cafe := f.addCafe(t)
+	dona := f.addProduct(t, "Dona", 1, nil)
+	grande, canela := cafe.ModifierGroups[0].Options[1].ID, cafe.ModifierGroups[1].Options[0].ID
+
+	past, err := f.svc.Create(ctx, f.customerID, &domain.CreateOrderRequest{
+		BusinessID: f.business.ID,
+		Items: []domain.CreateOrderItemReq{
+			{ProductID: f.concha.ID, Quantity: 2},
+			{ProductID: f.bolillo.ID, Quantity: 4},
+			{ProductID: cafe.ID, Quantity: 1, Modifiers: []uuid.UUID{grande}},
+			{ProductID: cafe.ID, Quantity: 1, Modifiers: []uuid.UUID{grande, canela}},
+			{ProductID: dona.ID, Quantity: 1},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unchanged, err := f.svc.Reorder(ctx, f.customerID, past.ID)
+	if err != nil {
+		t.Fatalf("Reorder() error: %v", err)
+	}
+	if unchanged.Changed || len(unchanged.Items) != 5 || unchanged.TotalAmount != past.TotalAmount {
+		t.Fatalf("Reorder() of an unchanged catalog = %+v, want the same 5 items and total", unchanged)
+	}
+	if got := f.stockOf(t, f.bolillo); got != 6 {
+		t.Errorf("bolillo stock after Reorder() = %d, want 6 (nothing reserved)", got)
+	}
+
+	// Conchas got dearer, only 3 bolillos are left, cinnamon ran out and the
+	// donut left the menu.
+	concha := *f.concha
+	concha.Price = 1.75
+	cafeNow := *cafe
+	cafeNow.ModifierGroups = slices.Clone(cafe.ModifierGroups)
+	cafeNow.ModifierGroups[1].Options = slices.Clone(cafe.ModifierGroups[1].Options)
+	cafeNow.ModifierGroups[1].Options[0].IsAvailable = false
+	bolillo := *f.bolillo
+	three := 3
+	bolillo.Stock = &three
+	for _, p := range []*domain.Product{&concha, &cafeNow, &bolillo} {
+		stored, _ := f.products.GetByID(ctx, p.ID)
+		if err := f.products.Update(ctx, p, stored.Stock); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.products.Delete(ctx, dona.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := f.svc.Reorder(ctx, f.customerID, past.ID)
+	if err != nil {
+		t.Fatalf("Reorder() error: %v", err)
+	}
+	want := []domain.ReorderStatus{
+		domain.ReorderPriceChanged, domain.ReorderSoldOut, domain.ReorderUnchanged,
+		domain.ReorderOptionsChanged, domain.ReorderUnavailable,
+	}
+	statuses := make([]domain.ReorderStatus, 0, len(got.Lines))
+	for _, l := range got.Lines {
+		statuses = append(statuses, l.Status)
+	}
+	if !slices.Equal(statuses, want) {
+		t.Errorf("line statuses = %v, want %v", statuses, want)
+	}
+	if !got.Changed || got.Lines[0].UnitPrice != 1.75 || got.Lines[0].PreviousUnitPrice != 1.5 {
+		t.Errorf("reorder = %+v, want the new concha price reported", got)
+	}
+	if len(got.Items) != 2 || got.Items[0].ProductID != f.concha.ID || got.Items[1].ProductID != cafe.ID {
+		t.Errorf("items = %+v, want the concha and the plain large coffee", got.Items)
+	}
+	if got.TotalAmount != 2*1.75+2.5 || got.PreviousTotal != past.TotalAmount {
+		t.Errorf("totals = %v (was %v), want %v (was %v)", got.TotalAmount, got.PreviousTotal, 2*1.75+2.5, past.TotalAmount)
+	}
+
+	// The rebuilt items can be ordered as they are.
+	if _, err := f.svc.Create(ctx, f.customerID, &domain.CreateOrderRequest{BusinessID: got.BusinessID, Items: got.Items}); err != nil {
+		t.Errorf("Create() from the reorder items error: %v", err)
+	}
+
+	if _, err := f.svc.Reorder(ctx, uuid.New(), past.ID); !errors.Is(err, domain.ErrOrderForbidden) {
+		t.Errorf("Reorder() by another customer error = %v, want ErrOrderForbidden", err)
+	}
+	if _, err := f.svc.Reorder(ctx, f.customerID, uuid.New()); !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Errorf("Reorder(unknown) error = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func wrongPIN(pin string) string {
+	if pin == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+// ctxNotifier records the context the async notification was sent with.
+type ctxNotifier struct {
+	got chan context.Context
+}
+
+func (n *ctxNotifier) SendNewOrderNotification(ctx context.Context, _ string, _ *domain.Order) {
+	n.got <- ctx
+}
+
+func (n *ctxNotifier) SendOrderReadyNotification(context.Context, string, *domain.Order) {}
+
+func TestOrderServiceCreateNotificationKeepsTrace(t *testing.T) {
+	spans, restore := telemetry.InMemory()
+	defer restore()
+
+	f := newOrderFixture(t)
+	notifier := &ctxNotifier{got: make(chan context.Context, 1)}
+	svc := NewOrderService(f.orders, f.businesses, f.products, f.payments, notifier, f.pins, f.promotions, Pricing{}, logging.Nop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, span := telemetry.Tracer().Start(ctx, "POST /api/v1/orders")
+	_, err := svc.Create(ctx, f.customerID, &domain.CreateOrderRequest{
+		BusinessID: f.business.ID,
+		Items:      []domain.CreateOrderItemReq{{ProductID: f.concha.ID, Quantity: 1}},
+	})
+	span.End()
+	// The HTTP request finishing must not cancel the notification.
+	cancel()
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	select {
+	case got := <-notifier.got:
+		if tid := trace.SpanContextFromContext(got).TraceID(); tid != span.SpanContext().TraceID() {
+			t.Errorf("notification trace = %s, want %s", tid, span.SpanContext().TraceID())
+		}
+		if got.Err() != nil {
+			t.Errorf("notification ctx cancelled with the request: %v", got.Err())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("notification not sent within 1s")
+	}
+
+	if n := len(spans.GetSpans()); n != 1 {
+		t.Errorf("recorded %d spans, want 1", n)
+	}
+}
@@ -2,18 +2,35 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
+	"strings"
 
 	"github.com/stripe/stripe-go/v76"
 	"github.com/stripe/stripe-go/v76/paymentintent"
+	"github.com/stripe/stripe-go/v76/refund"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/metrics"
+	"github.com/heptapegon/localpickup/internal/telemetry"
 )
 
-type PaymentService struct{}
+// minCharge is the smallest amount Stripe accepts in USD.
+const minCharge = 0.50
+
+type PaymentService struct {
+	logger *slog.Logger
+}
 
-func NewPaymentService(secretKey string) *PaymentService {
+func NewPaymentService(secretKey string, logger *slog.Logger) *PaymentService {
 	stripe.Key = secretKey
-	return &PaymentService{}
+	return &PaymentService{logger: logger}
 }
 
 // ChargeCustomer creates a Stripe PaymentIntent and returns its ID.
@@ -21,10 +38,29 @@ func NewPaymentService(secretKey string) *PaymentService {
 // In production the frontend receives the client_secret, the user confirms
 // the payment in-app, and a webhook fires payment_intent.succeeded.
 // Here we create the intent server-side and simulate success for development.
-func (s *PaymentService) ChargeCustomer(_ context.Context, amountUSD float64) (string, error) {
-	amountCents := int64(amountUSD * 100)
-	if amountCents < 50 {
-		return "", fmt.Errorf("minimum charge amount is $0.50")
+//
+// A non-empty idempotencyKey is sent to Stripe so that retries of the same
+// logical charge return the original PaymentIntent instead of creating a new one.
+func (s *PaymentService) ChargeCustomer(ctx context.Context, amountUSD float64, idempotencyKey string) (_ string, err error) {
+	amountCents := toCents(amountUSD)
+
+	ctx, span := telemetry.Tracer().Start(ctx, "payment.charge", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("payment.provider", "stripe"),
+			attribute.Int64("payment.amount_cents", amountCents),
+			attribute.Bool("payment.idempotent", idempotencyKey != ""),
+		))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "charge failed")
+		}
+		span.End()
+	}()
+
+	if amountCents < toCents(minCharge) {
+		metrics.PaymentFailed("below_minimum")
+		return "", domain.ErrAmountBelowMinimum
 	}
 
 	params := &stripe.PaymentIntentParams{
@@ -34,13 +70,77 @@ func (s *PaymentService) ChargeCustomer(_ context.Context, amountUSD float64) (s
 			Enabled: stripe.Bool(true),
 		},
 	}
+	params.Context = ctx
+	if idempotencyKey != "" {
+		params.SetIdempotencyKey(idempotencyKey)
+	}
 
 	pi, err := paymentintent.New(params)
+	var stripeErr *stripe.Error
+	if errors.As(err, &stripeErr) && stripeErr.Type == stripe.ErrorTypeCard {
+		reason := declineReason(stripeErr)
+		metrics.PaymentFailed(reason)
+		s.logger.InfoContext(ctx, "card declined", "reason", reason, "stripe_request_id", stripeErr.RequestID)
+		return "", domain.ErrPaymentFailed.WithMessage(stripeErr.Msg).WithCause(err)
+	}
 	if err != nil {
 		// In dev/test mode the Stripe key is a placeholder, so we simulate.
-		log.Printf("payment: stripe error (%v) — using simulated payment ID", err)
-		return fmt.Sprintf("pi_simulated_%d_cents", amountCents), nil
+		s.logger.WarnContext(ctx, "stripe unavailable, using simulated payment ID", logging.Error, err)
+		span.SetAttributes(attribute.Bool("payment.simulated", true))
+		return fmt.Sprintf("%s%d_cents", simulatedPaymentPrefix, amountCents), nil
 	}
 
+	span.SetAttributes(attribute.String("payment.intent_id", pi.ID))
 	return pi.ID, nil
 }
+
+// toCents converts a dollar amount to the nearest cent. Truncating would
+// charge $19.99 as 1998 cents, since 19.99*100 is just below 1999.
+func toCents(amountUSD float64) int64 {
+	return int64(math.Round(amountUSD * 100))
+}
+
+// simulatedPaymentPrefix marks payment IDs made up when Stripe is unavailable.
+const simulatedPaymentPrefix = "pi_simulated_"
+
+// RefundCharge refunds a PaymentIntent in full. The refund is keyed on the
+// payment ID, so retrying it never refunds twice.
+func (s *PaymentService) RefundCharge(ctx context.Context, paymentID string) (err error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "payment.refund", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("payment.provider", "stripe"),
+			attribute.String("payment.intent_id", paymentID),
+		))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "refund failed")
+		}
+		span.End()
+	}()
+
+	if strings.HasPrefix(paymentID, simulatedPaymentPrefix) {
+		span.SetAttributes(attribute.Bool("payment.simulated", true))
+		return nil
+	}
+
+	params := &stripe.RefundParams{PaymentIntent: stripe.String(paymentID)}
+	params.Context = ctx
+	params.SetIdempotencyKey("refund:" + paymentID)
+	if _, err := refund.New(params); err != nil {
+		return fmt.Errorf("stripe refund %s: %w", paymentID, err)
+	}
+	return nil
+}
+
+// declineReason prefers Stripe's decline code (insufficient_funds,
+// lost_card, …) over the coarser error code (card_declined).
+func declineReason(e *stripe.Error) string {
+	if e.DeclineCode != "" {
+		return string(e.DeclineCode)
+	}
+	if e.Code != "" {
+		return string(e.Code)
+	}
+	return "card_error"
+}
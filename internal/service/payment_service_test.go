@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/telemetry"
+)
+
+func TestPaymentServiceChargeSpan(t *testing.T) {
+	spans, restore := telemetry.InMemory()
+	defer restore()
+
+	// Below Stripe's minimum, so no network call is made. 0.29*100 is just
+	// below 29.
+	_, err := NewPaymentService("sk_test_placeholder", logging.Nop()).ChargeCustomer(context.Background(), 0.29, "key-1")
+	if !errors.Is(err, domain.ErrAmountBelowMinimum) {
+		t.Fatalf("ChargeCustomer() error = %v, want ErrAmountBelowMinimum", err)
+	}
+
+	got := spans.GetSpans()
+	if len(got) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(got))
+	}
+	s := got[0]
+	if s.Name != "payment.charge" {
+		t.Errorf("span name = %q, want payment.charge", s.Name)
+	}
+	if s.Status.Code != codes.Error {
+		t.Errorf("span status = %v, want Error", s.Status.Code)
+	}
+	attrs := make(map[string]any)
+	for _, kv := range s.Attributes {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	if attrs["payment.amount_cents"] != int64(29) || attrs["payment.idempotent"] != true {
+		t.Errorf("span attributes = %v", attrs)
+	}
+}
+
+func TestToCents(t *testing.T) {
+	for _, tt := range []struct {
+		usd  float64
+		want int64
+	}{
+		{19.99, 1999},
+		{0.29, 29},
+		{4.35, 435},
+		{10, 1000},
+	} {
+		if got := toCents(tt.usd); got != tt.want {
+			t.Errorf("toCents(%v) = %d, want %d", tt.usd, got, tt.want)
+		}
+	}
+}
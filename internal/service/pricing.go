@@ -0,0 +1,97 @@
+package service
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+// Pricing is what an order is charged on top of its items, and how long a
+// business needs to prepare it. The zero value charges the items alone and
+// promises nothing about pickup.
+type Pricing struct {
+	// TaxRate is applied to the subtotal, e.g. 0.16 for 16 %.
+	TaxRate float64
+	// ServiceFee is a flat amount added to every order.
+	ServiceFee float64
+	// PrepTime is how long an order takes when the business has nothing
+	// else to prepare; PrepTimePerOrder is added for each paid order ahead
+	// of it.
+	PrepTime         time.Duration
+	PrepTimePerOrder time.Duration
+}
+
+// charges breaks down the amount charged for items costing subtotal, less a
+// discount of at most the subtotal. Tax is levied on the discounted amount.
+type charges struct {
+	subtotal, discount, tax, fees, total float64
+}
+
+func (p Pricing) charges(subtotal, discount float64) charges {
+	c := charges{subtotal: roundCents(subtotal)}
+	c.discount = min(roundCents(discount), c.subtotal)
+	if c.subtotal > 0 {
+		c.tax = roundCents((c.subtotal - c.discount) * p.TaxRate)
+		c.fees = roundCents(p.ServiceFee)
+	}
+	c.total = roundCents(c.subtotal - c.discount + c.tax + c.fees)
+	return c
+}
+
+// discountedCharges prices items costing subtotal less the discount lines.
+// A discount that would leave something, but less than minCharge, to pay is
+// trimmed from the last line back until the customer pays minCharge, since
+// smaller charges are refused; lines trimmed to nothing are dropped.
+func (p Pricing) discountedCharges(subtotal float64, discounts []domain.OrderDiscount) (charges, []domain.OrderDiscount) {
+	discounts = slices.Clone(discounts)
+	c := p.charges(subtotal, totalDiscount(discounts))
+	for c.total > 0 && c.total < minCharge && len(discounts) > 0 {
+		// Each cent of discount taken back adds a cent plus its tax.
+		short := max(math.Floor(math.Round((minCharge-c.total)*100)/(1+p.TaxRate)), 1) / 100
+		if last := &discounts[len(discounts)-1]; short < last.Amount {
+			last.Amount = roundCents(last.Amount - short)
+		} else {
+			discounts = discounts[:len(discounts)-1]
+		}
+		c = p.charges(subtotal, totalDiscount(discounts))
+	}
+	return c, discounts
+}
+
+// earliestPickup is when an order placed at now could be ready, given the
+// number of orders the business is already preparing. It is rounded up to
+// the minute.
+func (p Pricing) earliestPickup(now time.Time, queued int) time.Time {
+	ready := now.Add(p.PrepTime + time.Duration(queued)*p.PrepTimePerOrder)
+	if t := ready.Truncate(time.Minute); t.Before(ready) {
+		return t.Add(time.Minute)
+	}
+	return ready
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// sameAmount compares two prices to the cent.
+func sameAmount(a, b float64) bool {
+	return math.Round(a*100) == math.Round(b*100)
+}
+
+// quoteLines lists priced order items for a quote.
+func quoteLines(items []domain.OrderItem) []domain.QuoteLine {
+	lines := make([]domain.QuoteLine, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, domain.QuoteLine{
+			ProductID:   *item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			LineTotal:   roundCents(float64(item.Quantity) * item.UnitPrice),
+			Modifiers:   item.Modifiers,
+		})
+	}
+	return lines
+}
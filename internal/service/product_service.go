@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+)
+
+// ProductService manages business catalogs. Only the business owner may
+// change a catalog; anyone may read it.
+type ProductService struct {
+	products   ProductStore
+	businesses BusinessStore
+	images     ImageStorage
+	logger     *slog.Logger
+}
+
+func NewProductService(products ProductStore, businesses BusinessStore, images ImageStorage, logger *slog.Logger) *ProductService {
+	return &ProductService{products: products, businesses: businesses, images: images, logger: logger}
+}
+
+func (s *ProductService) Create(ctx context.Context, ownerID, businessID uuid.UUID, req *domain.ProductRequest) (*domain.Product, error) {
+	if err := s.authorize(ctx, ownerID, businessID); err != nil {
+		return nil, err
+	}
+	if err := checkModifierGroups(req.ModifierGroups); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	p := &domain.Product{
+		ID:         uuid.New(),
+		BusinessID: businessID,
+		CreatedAt:  now,
+	}
+	applyProductRequest(p, req, now)
+	if err := s.products.Create(ctx, p); err != nil {
+		return nil, err
+	}
+	flagSoldOut(p)
+	attachProductImages(s.images, p)
+
+	ctx = logging.With(ctx, logging.BusinessID, businessID.String())
+	s.logger.InfoContext(ctx, "product created", "product_id", p.ID.String())
+	return p, nil
+}
+
+func (s *ProductService) Update(ctx context.Context, ownerID, businessID, productID uuid.UUID, req *domain.ProductRequest) (*domain.Product, error) {
+	p, err := s.owned(ctx, ownerID, businessID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkModifierGroups(req.ModifierGroups); err != nil {
+		return nil, err
+	}
+	readStock := p.Stock
+	applyProductRequest(p, req, time.Now().UTC())
+	if err := s.products.Update(ctx, p, readStock); err != nil {
+		return nil, err
+	}
+	flagSoldOut(p)
+	attachProductImages(s.images, p)
+	return p, nil
+}
+
+func (s *ProductService) Delete(ctx context.Context, ownerID, businessID, productID uuid.UUID) error {
+	p, err := s.owned(ctx, ownerID, businessID, productID)
+	if err != nil {
+		return err
+	}
+	if err := s.products.Delete(ctx, productID); err != nil {
+		return err
+	}
+	removeImage(ctx, s.images, s.logger, p.ImageKey)
+	return nil
+}
+
+// SetImage replaces the picture of a product with an upload, which must be a
+// JPEG, PNG or WebP file. Nil data removes the picture.
+func (s *ProductService) SetImage(ctx context.Context, ownerID, businessID, productID uuid.UUID, data []byte) (*domain.Product, error) {
+	p, err := s.owned(ctx, ownerID, businessID, productID)
+	if err != nil {
+		return nil, err
+	}
+	ctx = logging.With(ctx, logging.BusinessID, businessID.String())
+	dir := fmt.Sprintf("businesses/%s/products/%s", businessID, productID)
+	key, err := replaceImage(ctx, s.images, s.logger, dir, p.ImageKey, data,
+		func(key string) error { return s.products.SetImage(ctx, productID, key) })
+	if err != nil {
+		return nil, err
+	}
+	p.ImageKey = key
+	flagSoldOut(p)
+	attachProductImages(s.images, p)
+	s.logger.InfoContext(ctx, "product image updated", "product_id", productID.String(), "removed", key == "")
+	return p, nil
+}
+
+// ListByBusiness returns the whole catalog; products without stock left are
+// flagged sold_out rather than hidden so the owner can restock them.
+func (s *ProductService) ListByBusiness(ctx context.Context, businessID uuid.UUID) ([]*domain.Product, error) {
+	if _, err := s.businesses.GetByID(ctx, businessID); err != nil {
+		return nil, err
+	}
+	products, err := s.products.ListByBusiness(ctx, businessID)
+	if err != nil {
+		return nil, err
+	}
+	flagSoldOut(products...)
+	attachProductImages(s.images, products...)
+	return products, nil
+}
+
+// authorize checks that businessID exists and belongs to ownerID.
+func (s *ProductService) authorize(ctx context.Context, ownerID, businessID uuid.UUID) error {
+	return authorizeOwner(ctx, s.businesses, ownerID, businessID)
+}
+
+func authorizeOwner(ctx context.Context, businesses BusinessStore, ownerID, businessID uuid.UUID) error {
+	b, err := businesses.GetByID(ctx, businessID)
+	if err != nil {
+		return err
+	}
+	if b.OwnerID != ownerID {
+		return domain.ErrBusinessForbidden
+	}
+	return nil
+}
+
+// owned loads a product of businessID after checking ownership. A product of
+// another business is reported as not found.
+func (s *ProductService) owned(ctx context.Context, ownerID, businessID, productID uuid.UUID) (*domain.Product, error) {
+	if err := s.authorize(ctx, ownerID, businessID); err != nil {
+		return nil, err
+	}
+	p, err := s.products.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if p.BusinessID != businessID {
+		return nil, domain.ErrProductNotFound
+	}
+	return p, nil
+}
+
+func applyProductRequest(p *domain.Product, req *domain.ProductRequest, now time.Time) {
+	p.Name = req.Name
+	p.Description = req.Description
+	p.Price = req.Price
+	p.IsAvailable = req.IsAvailable == nil || *req.IsAvailable
+	p.Stock, p.DailyStock = req.Stock, req.DailyStock
+	if p.Stock == nil && p.DailyStock != nil {
+		stock := *p.DailyStock
+		p.Stock = &stock
+	}
+	p.ModifierGroups = modifierGroups(p.ModifierGroups, req.ModifierGroups)
+	p.UpdatedAt = now
+}
+
+// checkModifierGroups rejects groups that no selection could satisfy.
+func checkModifierGroups(groups []domain.ModifierGroupRequest) error {
+	for _, g := range groups {
+		if g.MinSelect > max(g.MaxSelect, 1) {
+			return domain.ErrInvalidModifiers.WithMessage(fmt.Sprintf("%q: min_select is above max_select", g.Name))
+		}
+		if g.MinSelect > len(g.Options) {
+			return domain.ErrInvalidModifiers.WithMessage(fmt.Sprintf("%q: min_select is above the number of options", g.Name))
+		}
+	}
+	return nil
+}
+
+// modifierGroups builds the groups described by req. A group or option whose
+// ID is already one of the product's keeps it, so that clients holding the ID
+// can still order it; an unknown ID is replaced rather than trusted, since it
+// could belong to another product.
+func modifierGroups(current []domain.ModifierGroup, req []domain.ModifierGroupRequest) []domain.ModifierGroup {
+	known := make(map[uuid.UUID]bool)
+	for _, g := range current {
+		known[g.ID] = true
+		for _, o := range g.Options {
+			known[o.ID] = true
+		}
+	}
+	reuse := func(id *uuid.UUID) uuid.UUID {
+		if id != nil && known[*id] {
+			delete(known, *id)
+			return *id
+		}
+		return uuid.New()
+	}
+
+	groups := make([]domain.ModifierGroup, 0, len(req))
+	for _, gr := range req {
+		g := domain.ModifierGroup{
+			ID:        reuse(gr.ID),
+			Name:      gr.Name,
+			MinSelect: gr.MinSelect,
+			MaxSelect: max(gr.MaxSelect, 1),
+			Options:   make([]domain.ModifierOption, 0, len(gr.Options)),
+		}
+		for _, or := range gr.Options {
+			g.Options = append(g.Options, domain.ModifierOption{
+				ID:          reuse(or.ID),
+				Name:        or.Name,
+				PriceDelta:  or.PriceDelta,
+				IsAvailable: or.IsAvailable == nil || *or.IsAvailable,
+			})
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+func flagSoldOut(products ...*domain.Product) {
+	for _, p := range products {
+		p.SoldOut = !p.InStock(1)
+	}
+}
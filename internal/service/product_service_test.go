@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/repository/memory"
+)
+
+func TestProductService(t *testing.T) {
+	ctx := context.Background()
+	businesses := memory.NewBusinessRepository()
+	svc := NewProductService(memory.NewProductRepository(), businesses, memory.NewStorage(), logging.Nop())
+
+	owner, stranger := uuid.New(), uuid.New()
+	shop := &domain.Business{ID: uuid.New(), OwnerID: owner, IsActive: true}
+	other := &domain.Business{ID: uuid.New(), OwnerID: stranger, IsActive: true}
+	for _, b := range []*domain.Business{shop, other} {
+		if err := businesses.Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	croissant, err := svc.Create(ctx, owner, shop.ID, &domain.ProductRequest{Name: "Croissant", Price: 35})
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if !croissant.IsAvailable {
+		t.Error("new product should default to available")
+	}
+	theirs, err := svc.Create(ctx, stranger, other.ID, &domain.ProductRequest{Name: "Concha", Price: 18})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unavailable := false
+	tests := []struct {
+		name    string
+		run     func() error
+		wantErr error
+	}{
+		{
+			name: "owner updates",
+			run: func() error {
+				_, err := svc.Update(ctx, owner, shop.ID, croissant.ID, &domain.ProductRequest{Name: "Croissant", Price: 38, IsAvailable: &unavailable})
+				return err
+			},
+		},
+		{
+			name: "stranger cannot create",
+			run: func() error {
+				_, err := svc.Create(ctx, stranger, shop.ID, &domain.ProductRequest{Name: "Spam", Price: 1})
+				return err
+			},
+			wantErr: domain.ErrBusinessForbidden,
+		},
+		{
+			name: "stranger cannot update",
+			run: func() error {
+				_, err := svc.Update(ctx, stranger, shop.ID, croissant.ID, &domain.ProductRequest{Name: "x", Price: 1})
+				return err
+			},
+			wantErr: domain.ErrBusinessForbidden,
+		},
+		{
+			name:    "product of another business is not found",
+			run:     func() error { return svc.Delete(ctx, owner, shop.ID, theirs.ID) },
+			wantErr: domain.ErrProductNotFound,
+		},
+		{
+			name: "unknown business",
+			run: func() error {
+				_, err := svc.ListByBusiness(ctx, uuid.New())
+				return err
+			},
+			wantErr: domain.ErrBusinessNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	list, err := svc.ListByBusiness(ctx, shop.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Price != 38 || list[0].IsAvailable {
+		t.Errorf("catalog = %+v, want the updated, unavailable croissant", list)
+	}
+
+	if err := svc.Delete(ctx, owner, shop.ID, croissant.ID); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if list, _ := svc.ListByBusiness(ctx, shop.ID); len(list) != 0 {
+		t.Errorf("catalog after delete = %+v, want empty", list)
+	}
+}
+
+func TestProductServiceModifierGroups(t *testing.T) {
+	ctx := context.Background()
+	businesses := memory.NewBusinessRepository()
+	svc := NewProductService(memory.NewProductRepository(), businesses, memory.NewStorage(), logging.Nop())
+	owner := uuid.New()
+	shop := &domain.Business{ID: uuid.New(), OwnerID: owner, IsActive: true}
+	if err := businesses.Create(ctx, shop); err != nil {
+		t.Fatal(err)
+	}
+
+	unavailable := false
+	req := &domain.ProductRequest{Name: "Café", Price: 30, ModifierGroups: []domain.ModifierGroupRequest{
+		{Name: "Tamaño", MinSelect: 1, Options: []domain.ModifierOptionRequest{{Name: "Chico"}, {Name: "Grande", PriceDelta: 8}}},
+		{Name: "Extras", MaxSelect: 2, Options: []domain.ModifierOptionRequest{{Name: "Canela", PriceDelta: 2, IsAvailable: &unavailable}}},
+	}}
+	cafe, err := svc.Create(ctx, owner, shop.ID, req)
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	size := cafe.ModifierGroups[0]
+	if !size.SingleSelect() || size.Options[0].ID == uuid.Nil || !size.Options[0].IsAvailable || cafe.ModifierGroups[1].Options[0].IsAvailable {
+		t.Errorf("groups = %+v, want single-select size and unavailable canela", cafe.ModifierGroups)
+	}
+
+	// Known IDs are kept; IDs the product does not have are replaced.
+	foreign := uuid.New()
+	req.ModifierGroups = []domain.ModifierGroupRequest{{
+		ID: &size.ID, Name: "Tamaño", MinSelect: 1,
+		Options: []domain.ModifierOptionRequest{{ID: &size.Options[1].ID, Name: "Grande", PriceDelta: 9}, {ID: &foreign, Name: "Mediano"}},
+	}}
+	updated, err := svc.Update(ctx, owner, shop.ID, cafe.ID, req)
+	if err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	opts := updated.ModifierGroups[0].Options
+	if len(updated.ModifierGroups) != 1 || updated.ModifierGroups[0].ID != size.ID || opts[0].ID != size.Options[1].ID || opts[1].ID == foreign {
+		t.Errorf("groups after update = %+v", updated.ModifierGroups)
+	}
+
+	req.ModifierGroups = []domain.ModifierGroupRequest{{Name: "Salsas", MinSelect: 2, MaxSelect: 3, Options: []domain.ModifierOptionRequest{{Name: "Verde"}}}}
+	if _, err := svc.Update(ctx, owner, shop.ID, cafe.ID, req); !errors.Is(err, domain.ErrInvalidModifiers) {
+		t.Errorf("Update() with unsatisfiable group error = %v, want ErrInvalidModifiers", err)
+	}
+}
+
+// reserveAfterRead reserves units of every product it loads, as orders
+// placed while the owner edits it would.
+type reserveAfterRead struct {
+	*memory.ProductRepository
+	units int
+}
+
+func (r *reserveAfterRead) GetByID(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	p, err := r.ProductRepository.GetByID(ctx, id)
+	if err == nil && r.units > 0 {
+		err = r.ReserveStock(ctx, []domain.StockLine{{ProductID: id, Quantity: r.units}})
+	}
+	return p, err
+}
+
+func TestProductServiceUpdateKeepsReservations(t *testing.T) {
+	ctx := context.Background()
+	businesses := memory.NewBusinessRepository()
+	products := &reserveAfterRead{ProductRepository: memory.NewProductRepository()}
+	svc := NewProductService(products, businesses, memory.NewStorage(), logging.Nop())
+	owner := uuid.New()
+	shop := &domain.Business{ID: uuid.New(), OwnerID: owner, IsActive: true}
+	if err := businesses.Create(ctx, shop); err != nil {
+		t.Fatal(err)
+	}
+	ten := 10
+	croissant, err := svc.Create(ctx, owner, shop.ID, &domain.ProductRequest{Name: "Croissant", Price: 35, Stock: &ten})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A rename sends back the stock it was shown; the 4 units sold meanwhile
+	// stay sold.
+	products.units = 4
+	renamed, err := svc.Update(ctx, owner, shop.ID, croissant.ID, &domain.ProductRequest{Name: "Croissant de mantequilla", Price: 35, Stock: &ten})
+	if err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	if *renamed.Stock != 6 {
+		t.Errorf("stock after a rename = %d, want 6", *renamed.Stock)
+	}
+
+	// Restocking from 6 to 16 adds 10 to what is left after 1 more sale.
+	products.units = 1
+	sixteen := 16
+	restocked, err := svc.Update(ctx, owner, shop.ID, croissant.ID, &domain.ProductRequest{Name: "Croissant de mantequilla", Price: 35, Stock: &sixteen})
+	if err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	products.units = 0
+	stored, _ := products.GetByID(ctx, croissant.ID)
+	if *restocked.Stock != 15 || *stored.Stock != 15 {
+		t.Errorf("stock after restocking = %d (stored %d), want 15", *restocked.Stock, *stored.Stock)
+	}
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+)
+
+// PromotionService manages promotions and decides which apply to an order.
+// An order gets the best automatic promotion it is eligible for plus the one
+// of the code the customer entered, if any; together they never take more
+// than the subtotal.
+type PromotionService struct {
+	promotions PromotionStore
+	orders     OrderStore
+	businesses BusinessStore
+	categories *CategoryService
+	// location is where weekdays and time windows are evaluated.
+	location *time.Location
+	logger   *slog.Logger
+}
+
+func NewPromotionService(
+	promotions PromotionStore,
+	orders OrderStore,
+	businesses BusinessStore,
+	categories *CategoryService,
+	location *time.Location,
+	logger *slog.Logger,
+) *PromotionService {
+	return &PromotionService{
+		promotions: promotions,
+		orders:     orders,
+		businesses: businesses,
+		categories: categories,
+		location:   location,
+		logger:     logger,
+	}
+}
+
+// Create adds a promotion as an admin. Promotions with a BusinessID are
+// funded by that business.
+func (s *PromotionService) Create(ctx context.Context, req *domain.PromotionRequest) (*domain.Promotion, error) {
+	if req.BusinessID != nil {
+		if _, err := s.businesses.GetByID(ctx, *req.BusinessID); err != nil {
+			return nil, err
+		}
+	}
+	return s.create(ctx, req.BusinessID, req)
+}
+
+// CreateForBusiness adds a coupon funded by the owner's business.
+func (s *PromotionService) CreateForBusiness(ctx context.Context, ownerID, businessID uuid.UUID, req *domain.PromotionRequest) (*domain.Promotion, error) {
+	if err := authorizeOwner(ctx, s.businesses, ownerID, businessID); err != nil {
+		return nil, err
+	}
+	return s.create(ctx, &businessID, req)
+}
+
+func (s *PromotionService) create(ctx context.Context, businessID *uuid.UUID, req *domain.PromotionRequest) (*domain.Promotion, error) {
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	p := &domain.Promotion{
+		ID:             uuid.New(),
+		Name:           req.Name,
+		Description:    req.Description,
+		BusinessID:     businessID,
+		Kind:           req.Kind,
+		Value:          req.Value,
+		MaxDiscount:    req.MaxDiscount,
+		Rules:          req.Rules,
+		MaxRedemptions: req.MaxRedemptions,
+		MaxPerCustomer: req.MaxPerCustomer,
+		IsActive:       true,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if req.Code != nil {
+		code := normalizeCode(*req.Code)
+		p.Code = &code
+	}
+	if p.Kind == domain.PromotionBuyXGetY {
+		p.BuyQuantity, p.GetQuantity, p.Value, p.MaxDiscount = req.BuyQuantity, req.GetQuantity, 0, nil
+	}
+	if err := s.promotions.Create(ctx, p); err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "promotion created", "promotion_id", p.ID, "kind", p.Kind, "funded_by", p.FundedBy())
+	return p, nil
+}
+
+// validate checks what the request tags cannot express.
+func (s *PromotionService) validate(ctx context.Context, req *domain.PromotionRequest) error {
+	switch req.Kind {
+	case domain.PromotionPercent:
+		if req.Value <= 0 || req.Value > 100 {
+			return domain.ErrInvalidPromotion.WithMessage("value of a percent promotion must be above 0 and at most 100")
+		}
+	case domain.PromotionFixed:
+		if req.Value <= 0 {
+			return domain.ErrInvalidPromotion.WithMessage("value of a fixed promotion must be above 0")
+		}
+		if req.MaxDiscount != nil {
+			return domain.ErrInvalidPromotion.WithMessage("max_discount only applies to percent promotions")
+		}
+	case domain.PromotionBuyXGetY:
+		if req.BuyQuantity < 1 || req.GetQuantity < 1 {
+			return domain.ErrInvalidPromotion.WithMessage("buy_quantity and get_quantity must be at least 1")
+		}
+	}
+	if r := req.Rules; r.StartsAt != nil && r.EndsAt != nil && !r.EndsAt.After(*r.StartsAt) {
+		return domain.ErrInvalidPromotion.WithMessage("ends_at must be after starts_at")
+	}
+	if req.Rules.Category != "" {
+		return s.categories.Validate(ctx, req.Rules.Category)
+	}
+	return nil
+}
+
+// normalizeCode makes codes case-insensitive.
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// List returns every promotion, newest first.
+func (s *PromotionService) List(ctx context.Context) ([]*domain.Promotion, error) {
+	return s.list(ctx, nil)
+}
+
+// ListForBusiness returns the promotions funded by the owner's business.
+func (s *PromotionService) ListForBusiness(ctx context.Context, ownerID, businessID uuid.UUID) ([]*domain.Promotion, error) {
+	if err := authorizeOwner(ctx, s.businesses, ownerID, businessID); err != nil {
+		return nil, err
+	}
+	return s.list(ctx, &businessID)
+}
+
+func (s *PromotionService) list(ctx context.Context, businessID *uuid.UUID) ([]*domain.Promotion, error) {
+	promotions, err := s.promotions.List(ctx, businessID)
+	if err != nil {
+		return nil, err
+	}
+	if promotions == nil {
+		promotions = []*domain.Promotion{}
+	}
+	return promotions, nil
+}
+
+// SetActive pauses or resumes any promotion as an admin.
+func (s *PromotionService) SetActive(ctx context.Context, id uuid.UUID, active bool) (*domain.Promotion, error) {
+	p, err := s.promotions.SetActive(ctx, id, active)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "promotion status changed", "promotion_id", id, "active", active)
+	return p, nil
+}
+
+// SetActiveForBusiness pauses or resumes a promotion of the owner's business.
+// A promotion of another business is reported as not found.
+func (s *PromotionService) SetActiveForBusiness(ctx context.Context, ownerID, businessID, id uuid.UUID, active bool) (*domain.Promotion, error) {
+	if err := authorizeOwner(ctx, s.businesses, ownerID, businessID); err != nil {
+		return nil, err
+	}
+	p, err := s.promotions.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.BusinessID == nil || *p.BusinessID != businessID {
+		return nil, domain.ErrPromotionNotFound
+	}
+	return s.SetActive(ctx, id, active)
+}
+
+// apply works out the discounts of an order for items costing subtotal at
+// the business, placed at now. A code that is unknown, has ended or does not
+// apply is an error, so the customer learns why; automatic promotions that
+// do not apply are skipped.
+func (s *PromotionService) apply(
+	ctx context.Context,
+	customerID uuid.UUID,
+	business *domain.Business,
+	items []domain.OrderItem,
+	subtotal float64,
+	code string,
+	now time.Time,
+) ([]domain.OrderDiscount, error) {
+	now = now.In(s.location)
+	automatic, err := s.promotions.ListAutomatic(ctx, business.ID)
+	if err != nil {
+		return nil, err
+	}
+	var best *domain.Promotion
+	var bestAmount float64
+	for _, p := range automatic {
+		reason, err := s.ineligible(ctx, p, customerID, business, subtotal, now)
+		if err != nil {
+			return nil, err
+		}
+		if amount := p.Discount(items, subtotal); reason == "" && amount > bestAmount {
+			best, bestAmount = p, amount
+		}
+	}
+
+	var discounts []domain.OrderDiscount
+	if best != nil {
+		discounts = append(discounts, discountLine(best, bestAmount))
+	}
+	if code = normalizeCode(code); code == "" {
+		return discounts, nil
+	}
+
+	p, err := s.promotions.GetByCode(ctx, code)
+	if errors.Is(err, domain.ErrPromotionNotFound) {
+		return nil, domain.ErrInvalidPromoCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !p.IsActive || (p.Rules.EndsAt != nil && !now.Before(*p.Rules.EndsAt)) {
+		return nil, domain.ErrInvalidPromoCode
+	}
+	reason, err := s.ineligible(ctx, p, customerID, business, subtotal, now)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		return nil, domain.ErrPromotionNotApplicable.WithMessage(reason)
+	}
+	amount := roundCents(min(p.Discount(items, subtotal), subtotal-bestAmount))
+	if amount <= 0 {
+		return nil, domain.ErrPromotionNotApplicable.WithMessage(fmt.Sprintf("%s gives no discount on this order", code))
+	}
+	return append(discounts, discountLine(p, amount)), nil
+}
+
+// ineligible explains why p cannot apply to the order, or returns "" when
+// it can.
+func (s *PromotionService) ineligible(
+	ctx context.Context,
+	p *domain.Promotion,
+	customerID uuid.UUID,
+	business *domain.Business,
+	subtotal float64,
+	now time.Time,
+) (string, error) {
+	if p.BusinessID != nil && *p.BusinessID != business.ID {
+		return fmt.Sprintf("%q is not valid at %s", p.Name, business.Name), nil
+	}
+	if !p.Running(now) {
+		return fmt.Sprintf("%q is not valid right now", p.Name), nil
+	}
+	if subtotal < p.Rules.MinSubtotal {
+		return fmt.Sprintf("%q needs a subtotal of at least $%.2f", p.Name, p.Rules.MinSubtotal), nil
+	}
+	if p.Rules.Category != "" {
+		categories, err := s.categories.Subtree(ctx, p.Rules.Category)
+		if err != nil && !errors.Is(err, domain.ErrUnknownCategory) {
+			return "", err
+		}
+		if !slices.Contains(categories, business.Category) {
+			return fmt.Sprintf("%q is not valid at %s", p.Name, business.Name), nil
+		}
+	}
+	if p.MaxRedemptions != nil && p.RedemptionCount >= *p.MaxRedemptions {
+		return fmt.Sprintf("%q is no longer available", p.Name), nil
+	}
+	if p.MaxPerCustomer != nil {
+		used, err := s.promotions.CustomerRedemptions(ctx, p.ID, customerID)
+		if err != nil {
+			return "", err
+		}
+		if used >= *p.MaxPerCustomer {
+			return fmt.Sprintf("you have already used %q", p.Name), nil
+		}
+	}
+	if p.Rules.FirstOrder {
+		orders, err := s.orders.ListByCustomer(ctx, customerID)
+		if err != nil {
+			return "", err
+		}
+		for _, o := range orders {
+			if o.Status != domain.OrderStatusCancelled {
+				return fmt.Sprintf("%q is only valid on your first order", p.Name), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func discountLine(p *domain.Promotion, amount float64) domain.OrderDiscount {
+	id := p.ID
+	d := domain.OrderDiscount{PromotionID: &id, Name: p.Name, Amount: amount, FundedBy: p.FundedBy()}
+	if p.Code != nil {
+		d.Code = *p.Code
+	}
+	return d
+}
+
+// totalDiscount adds up the discount lines of an order.
+func totalDiscount(discounts []domain.OrderDiscount) float64 {
+	var total float64
+	for _, d := range discounts {
+		total += d.Amount
+	}
+	return roundCents(total)
+}
+
+// redeem takes one use of each promotion applied to an order.
+func (s *PromotionService) redeem(ctx context.Context, orderID, customerID uuid.UUID, discounts []domain.OrderDiscount) error {
+	if len(discounts) == 0 {
+		return nil
+	}
+	return s.promotions.Redeem(ctx, orderID, customerID, discounts)
+}
+
+// release gives back the uses taken by an order that failed or was
+// cancelled. Failures are only logged: at worst a limited promotion runs
+// out one use early.
+func (s *PromotionService) release(ctx context.Context, orderID uuid.UUID) {
+	if err := s.promotions.Release(context.WithoutCancel(ctx), orderID); err != nil {
+		s.logger.ErrorContext(ctx, "failed to release promotion redemptions", logging.Error, err)
+	}
+}
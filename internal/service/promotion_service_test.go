@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+)
+
+func (f *orderFixture) addPromotion(t *testing.T, req domain.PromotionRequest) *domain.Promotion {
+	t.Helper()
+	p, err := f.promotions.Create(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("create promotion %q: %v", req.Name, err)
+	}
+	return p
+}
+
+// order places 2 conchas and 2 bolillos ($4.50) for customerID.
+func (f *orderFixture) order(customerID uuid.UUID, code string) (*domain.OrderResponse, error) {
+	return f.svc.Create(context.Background(), customerID, &domain.CreateOrderRequest{
+		BusinessID: f.business.ID,
+		Items: []domain.CreateOrderItemReq{
+			{ProductID: f.concha.ID, Quantity: 2},
+			{ProductID: f.bolillo.ID, Quantity: 2},
+		},
+		PromoCode: code,
+	})
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestPromotionServiceCreate(t *testing.T) {
+	f := newOrderFixture(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		req     domain.PromotionRequest
+		wantErr error
+	}{
+		{name: "percent", req: domain.PromotionRequest{Name: "10%", Kind: domain.PromotionPercent, Value: 10}},
+		{name: "percent above 100", req: domain.PromotionRequest{Name: "x", Kind: domain.PromotionPercent, Value: 120}, wantErr: domain.ErrInvalidPromotion},
+		{name: "fixed without value", req: domain.PromotionRequest{Name: "x", Kind: domain.PromotionFixed}, wantErr: domain.ErrInvalidPromotion},
+		{name: "fixed with cap", req: domain.PromotionRequest{Name: "x", Kind: domain.PromotionFixed, Value: 1, MaxDiscount: ptr(2.0)}, wantErr: domain.ErrInvalidPromotion},
+		{name: "buy x get y without quantities", req: domain.PromotionRequest{Name: "x", Kind: domain.PromotionBuyXGetY}, wantErr: domain.ErrInvalidPromotion},
+		{
+			name: "ends before it starts",
+			req: domain.PromotionRequest{Name: "x", Kind: domain.PromotionFixed, Value: 1,
+				Rules: domain.PromotionRules{StartsAt: &now, EndsAt: ptr(now.Add(-time.Hour))}},
+			wantErr: domain.ErrInvalidPromotion,
+		},
+		{
+			name:    "unknown category",
+			req:     domain.PromotionRequest{Name: "x", Kind: domain.PromotionFixed, Value: 1, Rules: domain.PromotionRules{Category: "cars"}},
+			wantErr: domain.ErrUnknownCategory,
+		},
+		{name: "unknown business", req: domain.PromotionRequest{Name: "x", Kind: domain.PromotionFixed, Value: 1, BusinessID: ptr(uuid.New())}, wantErr: domain.ErrBusinessNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.promotions.Create(ctx, &tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	coupon, err := f.promotions.CreateForBusiness(ctx, f.ownerID, f.business.ID, &domain.PromotionRequest{
+		Code: ptr(" rosa10"), Name: "Rosa 10", Kind: domain.PromotionPercent, Value: 10, BusinessID: ptr(uuid.New()),
+	})
+	if err != nil {
+		t.Fatalf("CreateForBusiness() error: %v", err)
+	}
+	if *coupon.Code != "ROSA10" || *coupon.BusinessID != f.business.ID || coupon.FundedBy() != domain.FundedByBusiness {
+		t.Errorf("CreateForBusiness() = %+v, want code ROSA10 funded by the business", coupon)
+	}
+	dup := domain.PromotionRequest{Code: ptr("Rosa10"), Name: "again", Kind: domain.PromotionFixed, Value: 1}
+	if _, err := f.promotions.Create(ctx, &dup); !errors.Is(err, domain.ErrPromoCodeTaken) {
+		t.Errorf("Create(same code) error = %v, want ErrPromoCodeTaken", err)
+	}
+	if _, err := f.promotions.CreateForBusiness(ctx, uuid.New(), f.business.ID, &dup); !errors.Is(err, domain.ErrBusinessForbidden) {
+		t.Errorf("CreateForBusiness() by another owner error = %v, want ErrBusinessForbidden", err)
+	}
+
+	listed, err := f.promotions.ListForBusiness(ctx, f.ownerID, f.business.ID)
+	if err != nil || len(listed) != 1 || listed[0].ID != coupon.ID {
+		t.Errorf("ListForBusiness() = %v, %v, want only the coupon", listed, err)
+	}
+	platform := f.addPromotion(t, domain.PromotionRequest{Name: "platform", Kind: domain.PromotionFixed, Value: 1})
+	if _, err := f.promotions.SetActiveForBusiness(ctx, f.ownerID, f.business.ID, platform.ID, false); !errors.Is(err, domain.ErrPromotionNotFound) {
+		t.Errorf("SetActiveForBusiness(platform promotion) error = %v, want ErrPromotionNotFound", err)
+	}
+	paused, err := f.promotions.SetActiveForBusiness(ctx, f.ownerID, f.business.ID, coupon.ID, false)
+	if err != nil || paused.IsActive {
+		t.Errorf("SetActiveForBusiness() = %+v, %v, want it paused", paused, err)
+	}
+}
+
+func TestOrderServiceCreatePromotions(t *testing.T) {
+	ctx := context.Background()
+	today := time.Now().UTC().Weekday()
+
+	t.Run("first order automatic discount", func(t *testing.T) {
+		f := newOrderFixture(t)
+		f.addPromotion(t, domain.PromotionRequest{
+			Name: "10% off your first order", Kind: domain.PromotionPercent, Value: 10,
+			Rules: domain.PromotionRules{FirstOrder: true},
+		})
+		first, err := f.order(f.customerID, "")
+		if err != nil {
+			t.Fatalf("Create() error: %v", err)
+		}
+		if first.Subtotal != 4.5 || first.Discount != 0.45 || first.TotalAmount != 4.05 || len(first.Discounts) != 1 {
+			t.Fatalf("first order = %+v, want 4.50 - 0.45 = 4.05 with one discount line", first.Order)
+		}
+		if d := first.Discounts[0]; d.FundedBy != domain.FundedByPlatform || d.Amount != 0.45 || d.PromotionID == nil {
+			t.Errorf("discount line = %+v, want 0.45 funded by the platform", d)
+		}
+		if f.payments.charges[0] != 4.05 {
+			t.Errorf("charged %v, want the discounted 4.05", f.payments.charges[0])
+		}
+		stored, _ := f.orders.GetByID(ctx, first.ID)
+		if len(stored.Discounts) != 1 || stored.Discount != 0.45 {
+			t.Errorf("stored order discounts = %+v, want the line kept", stored.Discounts)
+		}
+
+		second, err := f.order(f.customerID, "")
+		if err != nil || second.Discount != 0 || second.TotalAmount != 4.5 {
+			t.Errorf("second order = %+v, %v, want no discount", second, err)
+		}
+	})
+
+	t.Run("best automatic promotion and a code", func(t *testing.T) {
+		f := newOrderFixture(t)
+		f.addPromotion(t, domain.PromotionRequest{
+			Name: "2x1 today", Kind: domain.PromotionBuyXGetY, BuyQuantity: 1, GetQuantity: 1,
+			Rules: domain.PromotionRules{Weekdays: []time.Weekday{today}},
+		})
+		f.addPromotion(t, domain.PromotionRequest{
+			Name: "2x1 tomorrow", Kind: domain.PromotionBuyXGetY, BuyQuantity: 1, GetQuantity: 2,
+			Rules: domain.PromotionRules{Weekdays: []time.Weekday{(today + 1) % 7}},
+		})
+		f.addPromotion(t, domain.PromotionRequest{Name: "smaller", Kind: domain.PromotionFixed, Value: 0.5})
+		f.addPromotion(t, domain.PromotionRequest{
+			Code: ptr("BIG"), Name: "big spenders", Kind: domain.PromotionFixed, Value: 1,
+			Rules: domain.PromotionRules{MinSubtotal: 10},
+		})
+		f.addPromotion(t, domain.PromotionRequest{Code: ptr("PAN"), Name: "bakeries", Kind: domain.PromotionPercent, Value: 50, Rules: domain.PromotionRules{Category: "food"}})
+		f.addPromotion(t, domain.PromotionRequest{Code: ptr("FARMA"), Name: "pharmacies", Kind: domain.PromotionFixed, Value: 1, Rules: domain.PromotionRules{Category: "pharmacy"}})
+
+		// The two cheapest of the four units are free: 2 bolillos.
+		resp, err := f.order(f.customerID, "pan")
+		if err != nil {
+			t.Fatalf("Create() error: %v", err)
+		}
+		if len(resp.Discounts) != 2 || resp.Discounts[0].Name != "2x1 today" || resp.Discounts[0].Amount != 1.5 ||
+			resp.Discounts[1].Code != "PAN" || resp.Discounts[1].Amount != 2.25 {
+			t.Errorf("discounts = %+v, want 2x1 for 1.50 then PAN for 2.25", resp.Discounts)
+		}
+		if resp.TotalAmount != 0.75 {
+			t.Errorf("total = %v, want 4.50 - 1.50 - 2.25 = 0.75", resp.TotalAmount)
+		}
+
+		for code, want := range map[string]error{
+			"BIG":   domain.ErrPromotionNotApplicable,
+			"FARMA": domain.ErrPromotionNotApplicable,
+			"NOPE":  domain.ErrInvalidPromoCode,
+		} {
+			if _, err := f.order(f.customerID, code); !errors.Is(err, want) {
+				t.Errorf("Create(%s) error = %v, want %v", code, err, want)
+			}
+		}
+		if got := f.stockOf(t, f.bolillo); got != 8 {
+			t.Errorf("bolillo stock = %d, want 8 (failed orders reserve nothing)", got)
+		}
+	})
+
+	t.Run("business coupon elsewhere", func(t *testing.T) {
+		f := newOrderFixture(t)
+		other := &domain.Business{ID: uuid.New(), OwnerID: uuid.New(), IsActive: true}
+		if err := f.businesses.Create(ctx, other); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.promotions.CreateForBusiness(ctx, other.OwnerID, other.ID, &domain.PromotionRequest{
+			Code: ptr("OTRA"), Name: "otra", Kind: domain.PromotionFixed, Value: 1,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.order(f.customerID, "OTRA"); !errors.Is(err, domain.ErrPromotionNotApplicable) {
+			t.Errorf("Create() with another business's coupon error = %v, want ErrPromotionNotApplicable", err)
+		}
+	})
+
+	t.Run("redemption limits", func(t *testing.T) {
+		f := newOrderFixture(t)
+		f.addPromotion(t, domain.PromotionRequest{Code: ptr("ONCE"), Name: "once each", Kind: domain.PromotionFixed, Value: 1, MaxPerCustomer: ptr(1)})
+		first, err := f.order(f.customerID, "ONCE")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.order(f.customerID, "ONCE"); !errors.Is(err, domain.ErrPromotionNotApplicable) {
+			t.Errorf("second use error = %v, want ErrPromotionNotApplicable", err)
+		}
+		if _, err := f.svc.Cancel(ctx, first.ID, f.customerID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.order(f.customerID, "ONCE"); err != nil {
+			t.Errorf("use after cancelling error = %v, want the use given back", err)
+		}
+
+		// Ten customers race for three uses.
+		f.addPromotion(t, domain.PromotionRequest{Code: ptr("THREE"), Name: "first three", Kind: domain.PromotionFixed, Value: 1, MaxRedemptions: ptr(3)})
+		f.payments.charges = nil
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		succeeded := 0
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := f.svc.Create(ctx, uuid.New(), &domain.CreateOrderRequest{
+					BusinessID: f.business.ID,
+					Items:      []domain.CreateOrderItemReq{{ProductID: f.concha.ID, Quantity: 1}},
+					PromoCode:  "THREE",
+				})
+				if err == nil && resp.Discount == 1 {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		if succeeded != 3 {
+			t.Errorf("%d orders got the discount, want 3", succeeded)
+		}
+	})
+
+	t.Run("free order is not charged", func(t *testing.T) {
+		f := newOrderFixture(t)
+		f.addPromotion(t, domain.PromotionRequest{Code: ptr("GRATIS"), Name: "on the house", Kind: domain.PromotionFixed, Value: 10})
+		resp, err := f.order(f.customerID, "GRATIS")
+		if err != nil {
+			t.Fatalf("Create() error: %v", err)
+		}
+		if resp.TotalAmount != 0 || resp.Discount != 4.5 || resp.StripePaymentID != "" || len(f.payments.charges) != 0 {
+			t.Errorf("order = %+v, charges = %v, want a free order with no charge", resp.Order, f.payments.charges)
+		}
+		if _, err := f.svc.Cancel(ctx, resp.ID, f.customerID); err != nil || len(f.payments.refunds) != 0 {
+			t.Errorf("Cancel() = %v with refunds %v, want no refund", err, f.payments.refunds)
+		}
+	})
+
+	t.Run("discount trimmed to the minimum charge", func(t *testing.T) {
+		f := newOrderFixture(t)
+		f.addPromotion(t, domain.PromotionRequest{Name: "almost free", Kind: domain.PromotionFixed, Value: 4.4})
+		f.addPromotion(t, domain.PromotionRequest{Code: ptr("NICKEL"), Name: "nickel", Kind: domain.PromotionFixed, Value: 0.05, MaxRedemptions: ptr(1)})
+
+		// 4.50 - 4.40 - 0.05 leaves 0.05: the code's line is dropped and the
+		// automatic one trimmed so the customer pays the $0.50 minimum.
+		quote, err := f.svc.Quote(ctx, f.customerID, f.business.ID, []domain.CreateOrderItemReq{
+			{ProductID: f.concha.ID, Quantity: 2},
+			{ProductID: f.bolillo.ID, Quantity: 2},
+		}, "NICKEL")
+		if err != nil {
+			t.Fatalf("Quote() error: %v", err)
+		}
+		resp, err := f.order(f.customerID, "NICKEL")
+		if err != nil {
+			t.Fatalf("Create() error = %v, want the discount trimmed", err)
+		}
+		if resp.TotalAmount != 0.5 || resp.Discount != 4 || len(resp.Discounts) != 1 || resp.Discounts[0].Amount != 4 {
+			t.Errorf("order = %+v, discounts %+v, want 4.00 off and 0.50 to pay", resp.Order, resp.Discounts)
+		}
+		if quote.Total != resp.TotalAmount || len(quote.Discounts) != 1 {
+			t.Errorf("quote = %+v, want the same trimmed discount as the order", quote)
+		}
+		if len(f.payments.charges) != 1 || f.payments.charges[0] != 0.5 {
+			t.Errorf("charges = %v, want one of 0.50", f.payments.charges)
+		}
+		if p, _ := f.promotions.promotions.GetByCode(ctx, "NICKEL"); p.RedemptionCount != 0 {
+			t.Errorf("NICKEL redemptions = %d, want 0 for a dropped line", p.RedemptionCount)
+		}
+
+		// With tax, taking a discount back costs its tax too.
+		f = newOrderFixture(t)
+		f.svc = NewOrderService(f.orders, f.businesses, f.products, f.payments, f.notifier, f.pins, f.promotions, Pricing{TaxRate: 0.1}, logging.Nop())
+		f.addPromotion(t, domain.PromotionRequest{Code: ptr("CASI"), Name: "casi", Kind: domain.PromotionFixed, Value: 4.3})
+		resp, err = f.order(f.customerID, "CASI")
+		if err != nil {
+			t.Fatalf("Create() with tax error = %v, want the discount trimmed", err)
+		}
+		if resp.TotalAmount != 0.5 || resp.Discount != 4.05 || resp.Tax != 0.05 {
+			t.Errorf("order = %+v, want 4.05 off, 0.05 tax and 0.50 to pay", resp.Order)
+		}
+	})
+
+	t.Run("redemption released when payment fails", func(t *testing.T) {
+		f := newOrderFixture(t)
+		f.addPromotion(t, domain.PromotionRequest{Code: ptr("ONE"), Name: "one", Kind: domain.PromotionFixed, Value: 1, MaxRedemptions: ptr(1)})
+		f.payments.err = domain.ErrPaymentFailed
+		if _, err := f.order(f.customerID, "ONE"); !errors.Is(err, domain.ErrPaymentFailed) {
+			t.Fatalf("Create() error = %v, want ErrPaymentFailed", err)
+		}
+		f.payments.err = nil
+		if _, err := f.order(f.customerID, "ONE"); err != nil {
+			t.Errorf("Create() after a failed payment error = %v, want the use available", err)
+		}
+	})
+}
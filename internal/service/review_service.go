@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+)
+
+// Page sizes for review listings.
+const (
+	defaultReviewLimit = 20
+	maxFlaggedReviews  = 100
+)
+
+// ReviewService lets customers review the businesses they picked up from,
+// businesses reply, and admins moderate what other users flag.
+type ReviewService struct {
+	reviews    ReviewStore
+	orders     OrderStore
+	businesses BusinessStore
+	logger     *slog.Logger
+}
+
+func NewReviewService(reviews ReviewStore, orders OrderStore, businesses BusinessStore, logger *slog.Logger) *ReviewService {
+	return &ReviewService{reviews: reviews, orders: orders, businesses: businesses, logger: logger}
+}
+
+// Create reviews the business of one of the customer's completed orders. Each
+// order can be reviewed once.
+func (s *ReviewService) Create(ctx context.Context, customerID, orderID uuid.UUID, req *domain.CreateReviewRequest) (*domain.Review, error) {
+	ctx = logging.With(ctx, logging.OrderID, orderID.String())
+	order, err := s.orders.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.CustomerID != customerID {
+		return nil, domain.ErrOrderForbidden.WithMessage("order does not belong to you")
+	}
+	if order.Status != domain.OrderStatusCompleted {
+		return nil, domain.ErrOrderNotReviewable
+	}
+
+	now := time.Now().UTC()
+	review := &domain.Review{
+		ID:         uuid.New(),
+		OrderID:    orderID,
+		BusinessID: order.BusinessID,
+		CustomerID: customerID,
+		Rating:     req.Rating,
+		Comment:    req.Comment,
+		Status:     domain.ReviewPublished,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.reviews.Create(ctx, review); err != nil {
+		return nil, err
+	}
+	ctx = logging.With(ctx, logging.BusinessID, order.BusinessID.String())
+	s.logger.InfoContext(ctx, "review created", "review_id", review.ID, "rating", review.Rating)
+	return review, nil
+}
+
+// ListByBusiness returns one page of a business's published reviews, newest
+// first.
+func (s *ReviewService) ListByBusiness(ctx context.Context, businessID uuid.UUID, q *domain.ReviewQuery) (*domain.ReviewPage, error) {
+	if _, err := s.businesses.GetByID(ctx, businessID); err != nil {
+		return nil, err
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultReviewLimit
+	}
+	var after *domain.ReviewCursor
+	if q.Cursor != "" {
+		c, err := decodeReviewCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = c
+	}
+
+	// Ask for one extra review to learn whether there is a next page.
+	reviews, err := s.reviews.ListByBusiness(ctx, businessID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &domain.ReviewPage{Data: reviews}
+	if len(reviews) > limit {
+		page.Data = reviews[:limit]
+		last := page.Data[limit-1]
+		page.NextCursor = encodeReviewCursor(domain.ReviewCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	if page.Data == nil {
+		page.Data = []*domain.Review{}
+	}
+	page.Count = len(page.Data)
+	return page, nil
+}
+
+func encodeReviewCursor(c domain.ReviewCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeReviewCursor(s string) (*domain.ReviewCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor.WithCause(err)
+	}
+	var c domain.ReviewCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, domain.ErrInvalidCursor.WithCause(err)
+	}
+	if c.ID == uuid.Nil || c.CreatedAt.IsZero() {
+		return nil, domain.ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Reply sets the business's answer to a review of it, replacing any earlier
+// one.
+func (s *ReviewService) Reply(ctx context.Context, ownerID, reviewID uuid.UUID, req *domain.ReviewReplyRequest) (*domain.Review, error) {
+	review, err := s.published(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	ctx = logging.With(ctx, logging.BusinessID, review.BusinessID.String())
+	business, err := s.businesses.GetByID(ctx, review.BusinessID)
+	if err != nil {
+		return nil, err
+	}
+	if business.OwnerID != ownerID {
+		return nil, domain.ErrReviewForbidden
+	}
+
+	now := time.Now().UTC()
+	if err := s.reviews.SetReply(ctx, reviewID, req.Text, now); err != nil {
+		return nil, err
+	}
+	review.Reply = &domain.ReviewReply{Text: req.Text, CreatedAt: now}
+	review.UpdatedAt = now
+	s.logger.InfoContext(ctx, "review replied", "review_id", reviewID)
+	return review, nil
+}
+
+// Flag reports a review for moderation. Flagging the same review twice counts
+// once.
+func (s *ReviewService) Flag(ctx context.Context, userID, reviewID uuid.UUID, req *domain.ReviewFlagRequest) error {
+	if _, err := s.published(ctx, reviewID); err != nil {
+		return err
+	}
+	added, err := s.reviews.Flag(ctx, reviewID, userID, req.Reason)
+	if err != nil {
+		return err
+	}
+	if added {
+		s.logger.InfoContext(ctx, "review flagged", "review_id", reviewID, "reason", req.Reason)
+	}
+	return nil
+}
+
+// Moderate publishes or hides a review on an admin's decision; either way its
+// pending flags are dismissed.
+func (s *ReviewService) Moderate(ctx context.Context, reviewID uuid.UUID, req *domain.ReviewModerationRequest) (*domain.Review, error) {
+	review, err := s.reviews.SetStatus(ctx, reviewID, req.Status)
+	if err != nil {
+		return nil, err
+	}
+	ctx = logging.With(ctx, logging.BusinessID, review.BusinessID.String())
+	s.logger.InfoContext(ctx, "review moderated", "review_id", reviewID, "status", review.Status)
+	return review, nil
+}
+
+// Flagged is the moderation queue.
+func (s *ReviewService) Flagged(ctx context.Context) ([]*domain.Review, error) {
+	reviews, err := s.reviews.ListFlagged(ctx, maxFlaggedReviews)
+	if err != nil {
+		return nil, err
+	}
+	if reviews == nil {
+		reviews = []*domain.Review{}
+	}
+	return reviews, nil
+}
+
+// published loads a review that is visible to the public; hidden ones are
+// reported as not found.
+func (s *ReviewService) published(ctx context.Context, id uuid.UUID) (*domain.Review, error) {
+	review, err := s.reviews.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != domain.ReviewPublished {
+		return nil, domain.ErrReviewNotFound
+	}
+	return review, nil
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/repository/memory"
+)
+
+type reviewFixture struct {
+	svc        *ReviewService
+	orders     *memory.OrderRepository
+	businesses *memory.BusinessRepository
+	shop       *domain.Business
+	customer   uuid.UUID
+}
+
+func newReviewFixture(t *testing.T) *reviewFixture {
+	t.Helper()
+	f := &reviewFixture{
+		orders:     memory.NewOrderRepository(),
+		businesses: memory.NewBusinessRepository(),
+		shop:       &domain.Business{ID: uuid.New(), OwnerID: uuid.New(), Name: "Panadería", IsActive: true},
+		customer:   uuid.New(),
+	}
+	if err := f.businesses.Create(context.Background(), f.shop); err != nil {
+		t.Fatal(err)
+	}
+	f.svc = NewReviewService(memory.NewReviewRepository(f.businesses), f.orders, f.businesses, logging.Nop())
+	return f
+}
+
+func (f *reviewFixture) order(t *testing.T, status domain.OrderStatus) uuid.UUID {
+	t.Helper()
+	o := &domain.Order{ID: uuid.New(), CustomerID: f.customer, BusinessID: f.shop.ID, Status: status}
+	if err := f.orders.Create(context.Background(), o); err != nil {
+		t.Fatal(err)
+	}
+	return o.ID
+}
+
+func (f *reviewFixture) rating(t *testing.T) (*float64, int) {
+	t.Helper()
+	b, err := f.businesses.GetByID(context.Background(), f.shop.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b.Rating, b.RatingCount
+}
+
+func TestReviewServiceCreate(t *testing.T) {
+	ctx := context.Background()
+	f := newReviewFixture(t)
+	if avg, n := f.rating(t); avg != nil || n != 0 {
+		t.Fatalf("new business rating = %v over %d, want none", avg, n)
+	}
+
+	first := f.order(t, domain.OrderStatusCompleted)
+	review, err := f.svc.Create(ctx, f.customer, first, &domain.CreateReviewRequest{Rating: 5, Comment: "Las mejores conchas"})
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if review.BusinessID != f.shop.ID || review.Status != domain.ReviewPublished {
+		t.Errorf("review = %+v, want a published review of the shop", review)
+	}
+	if _, err := f.svc.Create(ctx, f.customer, f.order(t, domain.OrderStatusCompleted), &domain.CreateReviewRequest{Rating: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if avg, n := f.rating(t); avg == nil || *avg != 4.5 || n != 2 {
+		t.Errorf("rating = %v over %d, want 4.5 over 2", avg, n)
+	}
+
+	tests := []struct {
+		name     string
+		customer uuid.UUID
+		order    uuid.UUID
+		want     error
+	}{
+		{name: "same order twice", customer: f.customer, order: first, want: domain.ErrReviewExists},
+		{name: "order not picked up yet", customer: f.customer, order: f.order(t, domain.OrderStatusReady), want: domain.ErrOrderNotReviewable},
+		{name: "someone else's order", customer: uuid.New(), order: f.order(t, domain.OrderStatusCompleted), want: domain.ErrOrderForbidden},
+		{name: "unknown order", customer: f.customer, order: uuid.New(), want: domain.ErrOrderNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := f.svc.Create(ctx, tt.customer, tt.order, &domain.CreateReviewRequest{Rating: 1}); !errors.Is(err, tt.want) {
+				t.Errorf("Create() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if _, n := f.rating(t); n != 2 {
+		t.Errorf("rating count after rejected reviews = %d, want 2", n)
+	}
+}
+
+func TestReviewServiceListByBusiness(t *testing.T) {
+	ctx := context.Background()
+	f := newReviewFixture(t)
+	for rating := 1; rating <= 5; rating++ {
+		if _, err := f.svc.Create(ctx, f.customer, f.order(t, domain.OrderStatusCompleted), &domain.CreateReviewRequest{Rating: rating}); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	var got []int
+	q := &domain.ReviewQuery{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination does not terminate")
+		}
+		page, err := f.svc.ListByBusiness(ctx, f.shop.ID, q)
+		if err != nil {
+			t.Fatalf("ListByBusiness() error: %v", err)
+		}
+		for _, r := range page.Data {
+			got = append(got, r.Rating)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	if want := []int{5, 4, 3, 2, 1}; !slices.Equal(got, want) {
+		t.Errorf("ratings across pages = %v, want %v (newest first)", got, want)
+	}
+
+	if _, err := f.svc.ListByBusiness(ctx, f.shop.ID, &domain.ReviewQuery{Cursor: "garbage"}); !errors.Is(err, domain.ErrInvalidCursor) {
+		t.Errorf("ListByBusiness(bad cursor) error = %v, want ErrInvalidCursor", err)
+	}
+	if _, err := f.svc.ListByBusiness(ctx, uuid.New(), &domain.ReviewQuery{}); !errors.Is(err, domain.ErrBusinessNotFound) {
+		t.Errorf("ListByBusiness(unknown business) error = %v, want ErrBusinessNotFound", err)
+	}
+}
+
+func TestReviewServiceReplyAndModeration(t *testing.T) {
+	ctx := context.Background()
+	f := newReviewFixture(t)
+	kept, err := f.svc.Create(ctx, f.customer, f.order(t, domain.OrderStatusCompleted), &domain.CreateReviewRequest{Rating: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spam, err := f.svc.Create(ctx, f.customer, f.order(t, domain.OrderStatusCompleted), &domain.CreateReviewRequest{Rating: 1, Comment: "visit my site"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replied, err := f.svc.Reply(ctx, f.shop.OwnerID, kept.ID, &domain.ReviewReplyRequest{Text: "¡Gracias!"})
+	if err != nil {
+		t.Fatalf("Reply() error: %v", err)
+	}
+	if replied.Reply == nil || replied.Reply.Text != "¡Gracias!" {
+		t.Errorf("reply = %+v, want the owner's text", replied.Reply)
+	}
+	if _, err := f.svc.Reply(ctx, f.customer, kept.ID, &domain.ReviewReplyRequest{Text: "me too"}); !errors.Is(err, domain.ErrReviewForbidden) {
+		t.Errorf("Reply() by the customer error = %v, want ErrReviewForbidden", err)
+	}
+
+	reporter := uuid.New()
+	for _, user := range []uuid.UUID{reporter, reporter, uuid.New()} {
+		if err := f.svc.Flag(ctx, user, spam.ID, &domain.ReviewFlagRequest{Reason: "spam"}); err != nil {
+			t.Fatalf("Flag() error: %v", err)
+		}
+	}
+	queue, err := f.svc.Flagged(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue) != 1 || queue[0].ID != spam.ID || queue[0].FlagCount != 2 {
+		t.Fatalf("flagged = %+v, want the spam review with 2 flags", queue)
+	}
+
+	hidden, err := f.svc.Moderate(ctx, spam.ID, &domain.ReviewModerationRequest{Status: domain.ReviewHidden})
+	if err != nil {
+		t.Fatalf("Moderate() error: %v", err)
+	}
+	if hidden.Status != domain.ReviewHidden || hidden.FlagCount != 0 {
+		t.Errorf("moderated review = %+v, want hidden without flags", hidden)
+	}
+	if avg, n := f.rating(t); avg == nil || *avg != 5 || n != 1 {
+		t.Errorf("rating after hiding = %v over %d, want 5 over 1", avg, n)
+	}
+	if queue, _ := f.svc.Flagged(ctx); len(queue) != 0 {
+		t.Errorf("flagged after moderation = %+v, want none", queue)
+	}
+	page, _ := f.svc.ListByBusiness(ctx, f.shop.ID, &domain.ReviewQuery{})
+	if page.Count != 1 || page.Data[0].ID != kept.ID {
+		t.Errorf("listed reviews = %+v, want only the published one", page.Data)
+	}
+	if err := f.svc.Flag(ctx, reporter, spam.ID, &domain.ReviewFlagRequest{Reason: "spam"}); !errors.Is(err, domain.ErrReviewNotFound) {
+		t.Errorf("Flag(hidden) error = %v, want ErrReviewNotFound", err)
+	}
+	if _, err := f.svc.Reply(ctx, f.shop.OwnerID, spam.ID, &domain.ReviewReplyRequest{Text: "?"}); !errors.Is(err, domain.ErrReviewNotFound) {
+		t.Errorf("Reply(hidden) error = %v, want ErrReviewNotFound", err)
+	}
+
+	// Restoring puts the rating back; repeating a decision changes nothing.
+	for range 2 {
+		if _, err := f.svc.Moderate(ctx, spam.ID, &domain.ReviewModerationRequest{Status: domain.ReviewPublished}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if avg, n := f.rating(t); avg == nil || *avg != 3 || n != 2 {
+		t.Errorf("rating after restoring = %v over %d, want 3 over 2", avg, n)
+	}
+}
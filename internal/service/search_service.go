@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+)
+
+const (
+	defaultSearchLimit  = 20
+	maxSearchLimit      = 50
+	defaultSearchRadius = 10.0
+)
+
+// SearchService answers free-text discovery queries ("tacos", "farmacia").
+type SearchService struct {
+	searcher Searcher
+	images   ImageStorage
+	logger   *slog.Logger
+}
+
+func NewSearchService(searcher Searcher, images ImageStorage, logger *slog.Logger) *SearchService {
+	return &SearchService{searcher: searcher, images: images, logger: logger}
+}
+
+func (s *SearchService) Search(ctx context.Context, q *domain.SearchQuery) (*domain.SearchPage, error) {
+	query := *q
+	query.Query = strings.Join(strings.Fields(query.Query), " ")
+	if len([]rune(query.Query)) < 2 {
+		return nil, domain.ErrSearchQueryTooShort
+	}
+	if query.RadiusKm <= 0 {
+		query.RadiusKm = defaultSearchRadius
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchLimit
+	}
+	query.Limit = min(query.Limit, maxSearchLimit)
+
+	results, err := s.searcher.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		results = []*domain.SearchResult{}
+	}
+	for _, r := range results {
+		attachBusinessImages(s.images, &r.Business)
+	}
+	s.logger.DebugContext(ctx, "search", "query", query.Query, "results", len(results))
+	return &domain.SearchPage{Data: results, Count: len(results)}, nil
+}
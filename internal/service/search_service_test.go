@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/repository/memory"
+)
+
+type recordingSearcher struct{ got domain.SearchQuery }
+
+func (r *recordingSearcher) Search(_ context.Context, q domain.SearchQuery) ([]*domain.SearchResult, error) {
+	r.got = q
+	return nil, nil
+}
+
+func TestSearchServiceNormalisesQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      domain.SearchQuery
+		want    domain.SearchQuery
+		wantErr error
+	}{
+		{
+			name: "defaults",
+			in:   domain.SearchQuery{Query: "  tacos   al pastor "},
+			want: domain.SearchQuery{Query: "tacos al pastor", RadiusKm: defaultSearchRadius, Limit: defaultSearchLimit},
+		},
+		{
+			name: "explicit values kept",
+			in:   domain.SearchQuery{Query: "farmacia", Latitude: 19.4, Longitude: -99.1, RadiusKm: 3, Limit: 5},
+			want: domain.SearchQuery{Query: "farmacia", Latitude: 19.4, Longitude: -99.1, RadiusKm: 3, Limit: 5},
+		},
+		{
+			name:    "blank after trimming",
+			in:      domain.SearchQuery{Query: " a  "},
+			wantErr: domain.ErrSearchQueryTooShort,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			searcher := &recordingSearcher{}
+			page, err := NewSearchService(searcher, memory.NewStorage(), logging.Nop()).Search(context.Background(), &tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if searcher.got != tt.want {
+				t.Errorf("searcher got %+v, want %+v", searcher.got, tt.want)
+			}
+			if page.Data == nil || page.Count != 0 {
+				t.Errorf("empty page = %+v, want non-nil data and zero count", page)
+			}
+		})
+	}
+}
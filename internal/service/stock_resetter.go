@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/heptapegon/localpickup/internal/logging"
+)
+
+// StockResetter refills products that have a daily stock at midnight in the
+// configured time zone.
+type StockResetter struct {
+	products StockStore
+	loc      *time.Location
+	logger   *slog.Logger
+}
+
+func NewStockResetter(products StockStore, loc *time.Location, logger *slog.Logger) *StockResetter {
+	return &StockResetter{products: products, loc: loc, logger: logger}
+}
+
+// Reset refills every product not yet reset today and returns how many were.
+func (r *StockResetter) Reset(ctx context.Context, now time.Time) (int64, error) {
+	local := now.In(r.loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, r.loc)
+	return r.products.ResetDailyStock(ctx, midnight)
+}
+
+// Run resets immediately and then every interval until ctx is cancelled, so
+// stock is refilled at most interval after midnight. Every replica may run
+// it; a product is only reset once per day.
+func (r *StockResetter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := r.Reset(ctx, time.Now())
+		switch {
+		case err != nil && ctx.Err() == nil:
+			r.logger.ErrorContext(ctx, "daily stock reset failed", logging.Error, err)
+		case n > 0:
+			r.logger.InfoContext(ctx, "daily stock reset", "products", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/heptapegon/localpickup/internal/domain"
+	"github.com/heptapegon/localpickup/internal/logging"
+	"github.com/heptapegon/localpickup/internal/repository/memory"
+)
+
+func TestStockResetterReset(t *testing.T) {
+	ctx := context.Background()
+	products := memory.NewProductRepository()
+	loc := time.FixedZone("CST", -6*3600)
+	resetter := NewStockResetter(products, loc, logging.Nop())
+
+	three, twelve := 3, 12
+	tray := &domain.Product{ID: uuid.New(), Name: "Croissant", Price: 35, Stock: &three, DailyStock: &twelve}
+	unlimited := &domain.Product{ID: uuid.New(), Name: "Café", Price: 30}
+	for _, p := range []*domain.Product{tray, unlimited} {
+		if err := products.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stock := func() int {
+		p, _ := products.GetByID(ctx, tray.ID)
+		return *p.Stock
+	}
+
+	// Created today: nothing to reset until tomorrow.
+	if n, err := resetter.Reset(ctx, time.Now()); err != nil || n != 0 || stock() != 3 {
+		t.Errorf("Reset(today) = %d, %v; stock %d, want no reset", n, err, stock())
+	}
+	tomorrow := time.Now().Add(24 * time.Hour)
+	if n, err := resetter.Reset(ctx, tomorrow); err != nil || n != 1 || stock() != 12 {
+		t.Errorf("Reset(tomorrow) = %d, %v; stock %d, want 1 reset to 12", n, err, stock())
+	}
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config locates a bucket on an S3-compatible service.
+type S3Config struct {
+	// Endpoint is host[:port], e.g. s3.amazonaws.com or localhost:9000.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	// PublicURL is where clients download objects, e.g. a CDN in front of
+	// the bucket. Empty means the bucket's path-style URL on Endpoint.
+	PublicURL string
+}
+
+// S3 stores objects in a bucket. Clients download them straight from
+// PublicURL, so the bucket (or the CDN in front of it) must allow anonymous
+// reads; the server never proxies them.
+type S3 struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+func NewS3(cfg S3Config) (*S3, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: s3 client: %w", err)
+	}
+	public := cfg.PublicURL
+	if public == "" {
+		u := url.URL{Scheme: "http", Host: cfg.Endpoint, Path: "/" + cfg.Bucket}
+		if cfg.UseSSL {
+			u.Scheme = "https"
+		}
+		public = u.String()
+	}
+	return &S3{client: client, bucket: cfg.Bucket, publicURL: public}, nil
+}
+
+// Check fails when the bucket is missing or the credentials cannot see it.
+func (s *S3) Check(ctx context.Context) error {
+	ok, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		return fmt.Errorf("storage: bucket %s: %w", s.bucket, err)
+	}
+	if !ok {
+		return fmt.Errorf("storage: bucket %s does not exist", s.bucket)
+	}
+	return nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType,
+		// Keys are never reused for different content.
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	if err != nil {
+		return fmt.Errorf("storage: put %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	// RemoveObject succeeds for missing keys.
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("storage: delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3) URL(key string) string {
+	return joinURL(s.publicURL, key)
+}
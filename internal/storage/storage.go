@@ -0,0 +1,52 @@
+// Package storage keeps uploaded files behind a small interface with a local
+// filesystem implementation, for development and single-node deployments,
+// and an S3-compatible one (AWS S3, MinIO, R2, …).
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Storage saves objects under slash-separated keys and tells where clients
+// can download them.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Delete removes an object; a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL is the public address of key.
+	URL(key string) string
+	// Check reports whether the storage is reachable.
+	Check(ctx context.Context) error
+}
+
+// checkKey rejects keys that could escape the storage root or that object
+// stores treat specially.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return fmt.Errorf("storage: invalid key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("storage: invalid key %q", key)
+		}
+	}
+	return nil
+}
+
+// New builds the storage a driver name selects: local or s3.
+func New(driver, localDir, baseURL string, s3 S3Config) (Storage, error) {
+	switch driver {
+	case "local":
+		return NewLocal(localDir, baseURL)
+	case "s3":
+		s3.PublicURL = baseURL
+		return NewS3(s3)
+	}
+	return nil, fmt.Errorf("storage: %q is not local or s3", driver)
+}
+
+func joinURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + key
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// testStorage runs the behaviour every implementation shares. read returns
+// the stored object or an error wrapping fs.ErrNotExist.
+func testStorage(t *testing.T, s Storage, read func(key string) ([]byte, error)) {
+	t.Helper()
+	ctx := context.Background()
+	key := "tests/" + uuid.NewString() + "/thumbnail.jpg"
+
+	if err := s.Put(ctx, key, []byte("first"), "image/jpeg"); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if err := s.Put(ctx, key, []byte("second"), "image/jpeg"); err != nil {
+		t.Fatalf("Put() overwrite error: %v", err)
+	}
+	if got, err := read(key); err != nil || string(got) != "second" {
+		t.Fatalf("stored object = %q, %v, want %q", got, err, "second")
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if _, err := read(key); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("object still readable after Delete(): %v", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() of a missing object error: %v", err)
+	}
+
+	for _, bad := range []string{"", "/abs", "a/../../etc/passwd", "a//b", `a\b`} {
+		if err := s.Put(ctx, bad, []byte("x"), "text/plain"); err == nil {
+			t.Errorf("Put(%q) succeeded, want invalid key", bad)
+		}
+	}
+}
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocal(filepath.Join(dir, "media"), "http://localhost:8080/media/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, s, func(key string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(s.Dir(), filepath.FromSlash(key)))
+	})
+	if got, want := s.URL("businesses/1/thumbnail.jpg"), "http://localhost:8080/media/businesses/1/thumbnail.jpg"; got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+}
+
+// TestS3 runs against an S3-compatible server such as a local MinIO:
+//
+//	docker compose up -d minio
+//	TEST_S3_ENDPOINT=localhost:9000 TEST_S3_BUCKET=localpickup \
+//	TEST_S3_ACCESS_KEY=minioadmin TEST_S3_SECRET_KEY=minioadmin go test ./internal/storage
+func TestS3(t *testing.T) {
+	endpoint := os.Getenv("TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("TEST_S3_ENDPOINT not set")
+	}
+	useSSL, _ := strconv.ParseBool(os.Getenv("TEST_S3_USE_SSL"))
+	cfg := S3Config{
+		Endpoint:  endpoint,
+		Region:    os.Getenv("TEST_S3_REGION"),
+		Bucket:    os.Getenv("TEST_S3_BUCKET"),
+		AccessKey: os.Getenv("TEST_S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("TEST_S3_SECRET_KEY"),
+		UseSSL:    useSSL,
+	}
+	s, err := NewS3(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if ok, err := s.client.BucketExists(ctx, cfg.Bucket); err != nil {
+		t.Fatalf("S3 unreachable: %v", err)
+	} else if !ok {
+		if err := s.client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			t.Fatalf("create bucket: %v", err)
+		}
+	}
+	if err := s.Check(ctx); err != nil {
+		t.Fatalf("Check() error: %v", err)
+	}
+
+	testStorage(t, s, func(key string) ([]byte, error) {
+		obj, err := s.client.GetObject(ctx, cfg.Bucket, key, minio.GetObjectOptions{})
+		if err != nil {
+			return nil, err
+		}
+		defer obj.Close()
+		data, err := io.ReadAll(obj)
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, fs.ErrNotExist
+		}
+		return data, err
+	})
+}
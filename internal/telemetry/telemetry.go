@@ -0,0 +1,99 @@
+// Package telemetry configures OpenTelemetry tracing.
+//
+// Setup installs the global TracerProvider and W3C propagators; instrumented
+// libraries (otelecho, otelpgx, redisotel) and the spans started via Tracer
+// pick it up from there. With the "none" exporter the global no-op provider
+// stays in place and tracing costs nothing.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies this service in traces.
+const ServiceName = "localpickup-api"
+
+const instrumentationName = "github.com/heptapegon/localpickup"
+
+// Exporters accepted by Setup.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"   // OTLP/HTTP; endpoint and headers come from the standard OTEL_EXPORTER_OTLP_* env vars
+	ExporterStdout = "stdout" // pretty-printed spans on stdout, for local debugging
+)
+
+type Config struct {
+	Exporter string
+	// SampleRatio is the fraction of new traces to record (0–1). Incoming
+	// sampled parent spans are always honoured.
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider. The returned func flushes
+// pending spans and must be called on shutdown.
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("telemetry: unknown exporter %q (want otlp, stdout or none)", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("telemetry: create %s exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Tracer returns the tracer for spans created by this service's own code.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// InMemory installs a provider that records every span synchronously and
+// returns its exporter, so tests can assert on spans. The returned func
+// restores the previous provider.
+func InMemory() (*tracetest.InMemoryExporter, func()) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return exporter, func() {
+		_ = tp.Shutdown(context.Background())
+		otel.SetTracerProvider(prev)
+	}
+}
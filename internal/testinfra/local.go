@@ -0,0 +1,107 @@
+package testinfra
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// startLocalPostgres initialises a throwaway cluster in a temp dir and starts
+// it on a free port. The returned stop func shuts it down and removes the data.
+func startLocalPostgres() (url string, stop func(), err error) {
+	initdb, err := exec.LookPath("initdb")
+	if err != nil {
+		return "", nil, err
+	}
+	pgCtl, err := exec.LookPath("pg_ctl")
+	if err != nil {
+		return "", nil, err
+	}
+	if os.Geteuid() == 0 {
+		return "", nil, errors.New("postgres refuses to run as root")
+	}
+
+	dir, err := os.MkdirTemp("", "testinfra-pg-")
+	if err != nil {
+		return "", nil, err
+	}
+	data := filepath.Join(dir, "data")
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+
+	if out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "--auth=trust", "--no-sync").CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("initdb: %v: %s", err, out)
+	}
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -c fsync=off", port, dir)
+	if out, err := exec.Command(pgCtl, "-D", data, "-o", opts, "-l", filepath.Join(dir, "log"), "-w", "start").CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("pg_ctl start: %v: %s", err, out)
+	}
+
+	stop = func() {
+		_ = exec.Command(pgCtl, "-D", data, "-m", "immediate", "stop").Run()
+		os.RemoveAll(dir)
+	}
+	return fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port), stop, nil
+}
+
+// startLocalRedis runs a non-persistent redis-server on a free port.
+func startLocalRedis() (addr string, stop func(), err error) {
+	bin, err := exec.LookPath("redis-server")
+	if err != nil {
+		return "", nil, err
+	}
+	port, err := freePort()
+	if err != nil {
+		return "", nil, err
+	}
+
+	cmd := exec.Command(bin, "--port", strconv.Itoa(port), "--bind", "127.0.0.1", "--save", "", "--appendonly", "no")
+	if err := cmd.Start(); err != nil {
+		return "", nil, err
+	}
+	addr = net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	if err := waitForPort(addr, 5*time.Second); err != nil {
+		_ = cmd.Process.Kill()
+		return "", nil, err
+	}
+
+	stop = func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}
+	return addr, stop, nil
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func waitForPort(addr string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s not reachable after %s: %w", addr, timeout, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
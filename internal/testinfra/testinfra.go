@@ -0,0 +1,251 @@
+// Package testinfra provides disposable Postgres/PostGIS and Redis instances
+// for integration tests.
+//
+// Servers are located in this order:
+//  1. TEST_DATABASE_URL / TEST_REDIS_URL, e.g. the docker-compose services.
+//  2. Throwaway local processes started from initdb/pg_ctl and redis-server
+//     found on PATH (Postgres needs the PostGIS extension installed and
+//     refuses to run as root).
+//  3. Otherwise the calling test is skipped.
+//
+// Each test binary migrates its own randomly named Postgres schema and its own
+// Redis logical database, so packages can run concurrently. Tables and keys
+// are wiped before every test, so within a package tests share them and must
+// run one at a time: Postgres and Redis fail a test that calls t.Parallel(),
+// before or after asking for a server.
+//
+// Packages using this harness must hand control to Run from TestMain so that
+// schemas are dropped and local processes stopped afterwards:
+//
+//	func TestMain(m *testing.M) { os.Exit(testinfra.Run(m)) }
+package testinfra
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/heptapegon/localpickup/internal/migrations"
+)
+
+var (
+	pgOnce   sync.Once
+	pgPool   *pgxpool.Pool
+	pgSchema string
+	pgAdmin  string // URL without search_path, used to drop the schema
+	pgErr    error
+
+	redisOnce   sync.Once
+	redisClient *redis.Client
+	redisErr    error
+
+	cleanups []func()
+)
+
+// Run executes the tests and tears down everything the harness created.
+func Run(m *testing.M) int {
+	code := m.Run()
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		cleanups[i]()
+	}
+	return code
+}
+
+// Postgres returns a pool whose search_path points at a migrated schema that
+// is private to this test binary. All tables are truncated before returning.
+func Postgres(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+	serial(t)
+	pgOnce.Do(func() { pgPool, pgErr = setupPostgres() })
+	if pgErr != nil {
+		t.Skipf("testinfra: postgres unavailable: %v", pgErr)
+	}
+	Truncate(t, pgPool)
+	return pgPool
+}
+
+// Redis returns a client bound to a logical database private to this test
+// binary. The database is flushed before returning.
+func Redis(t testing.TB) *redis.Client {
+	t.Helper()
+	serial(t)
+	redisOnce.Do(func() { redisClient, redisErr = setupRedis() })
+	if redisErr != nil {
+		t.Skipf("testinfra: redis unavailable: %v", redisErr)
+	}
+	if err := redisClient.FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("testinfra: flush redis: %v", err)
+	}
+	return redisClient
+}
+
+// serial makes t panic if it, or a test it belongs to, runs in parallel:
+// testing forbids Setenv in parallel tests. Wiping the shared schema under a
+// concurrent test would otherwise make failures depend on scheduling.
+func serial(t testing.TB) {
+	t.Helper()
+	t.Setenv("TESTINFRA_SERIAL", "1")
+}
+
+// Truncate empties every application table in the test schema. categories is
+// reference data seeded by migrations and is left alone.
+func Truncate(t testing.TB, db *pgxpool.Pool) {
+	t.Helper()
+	ctx := context.Background()
+	rows, err := db.Query(ctx, `
+		SELECT quote_ident(table_name) FROM information_schema.tables
+		WHERE table_schema = $1 AND table_type = 'BASE TABLE' AND table_name NOT IN ('schema_migrations', 'categories')`,
+		pgSchema,
+	)
+	if err != nil {
+		t.Fatalf("testinfra: list tables: %v", err)
+	}
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		t.Fatalf("testinfra: list tables: %v", err)
+	}
+	if len(tables) == 0 {
+		return
+	}
+	stmt := "TRUNCATE "
+	for i, name := range tables {
+		if i > 0 {
+			stmt += ", "
+		}
+		stmt += pgSchema + "." + name
+	}
+	if _, err := db.Exec(ctx, stmt+" CASCADE"); err != nil {
+		t.Fatalf("testinfra: truncate: %v", err)
+	}
+}
+
+// InsertUser creates a user row (needed to satisfy foreign keys) and returns its ID.
+func InsertUser(t testing.TB, db *pgxpool.Pool, role string) uuid.UUID {
+	t.Helper()
+	id := uuid.New()
+	_, err := db.Exec(context.Background(),
+		`INSERT INTO users (id, name, email, password_hash, role) VALUES ($1, $2, $3, 'x', $4)`,
+		id, "Test "+role, id.String()+"@example.test", role,
+	)
+	if err != nil {
+		t.Fatalf("testinfra: insert user: %v", err)
+	}
+	return id
+}
+
+func setupPostgres() (*pgxpool.Pool, error) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		local, stop, err := startLocalPostgres()
+		if err != nil {
+			return nil, fmt.Errorf("TEST_DATABASE_URL not set and no local server could be started: %w", err)
+		}
+		cleanups = append(cleanups, stop)
+		url = local
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	admin, err := pgx.Connect(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer admin.Close(ctx)
+
+	// Extensions are database-wide; create them up front in public so every
+	// test schema can share them.
+	for _, ext := range []string{"postgis", `"uuid-ossp"`, "unaccent", "pg_trgm"} {
+		if _, err := admin.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS "+ext+" WITH SCHEMA public"); err != nil {
+			return nil, fmt.Errorf("create extension %s: %w", ext, err)
+		}
+	}
+
+	schema := "test_" + randomSuffix()
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		return nil, err
+	}
+	pgAdmin, pgSchema = url, schema
+	cleanups = append(cleanups, dropSchema)
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema + ",public"
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	cleanups = append(cleanups, pool.Close)
+
+	migrator, err := migrations.New(pool)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+func dropSchema() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := pgx.Connect(ctx, pgAdmin)
+	if err != nil {
+		return
+	}
+	defer conn.Close(ctx)
+	_, _ = conn.Exec(ctx, "DROP SCHEMA IF EXISTS "+pgSchema+" CASCADE")
+}
+
+func setupRedis() (*redis.Client, error) {
+	addr := os.Getenv("TEST_REDIS_URL")
+	if addr == "" {
+		local, stop, err := startLocalRedis()
+		if err != nil {
+			return nil, fmt.Errorf("TEST_REDIS_URL not set and no local server could be started: %w", err)
+		}
+		cleanups = append(cleanups, stop)
+		addr = local
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Hand out logical databases 1–15 round-robin so concurrently running
+	// test binaries don't share the geo index.
+	control := redis.NewClient(&redis.Options{Addr: addr})
+	defer control.Close()
+	n, err := control.Incr(ctx, "testinfra:next-db").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr, DB: int(n%15) + 1})
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, err
+	}
+	cleanups = append(cleanups, func() {
+		client.FlushDB(context.Background())
+		client.Close()
+	})
+	return client, nil
+}
+
+func randomSuffix() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -46,12 +46,15 @@ class Order with _$Order {
 
 // ─── Request models ──────────────────────────────────────────────────────────
 
+// Orders reference catalog products; the server takes names and prices from
+// the catalog.
 @freezed
 class CreateOrderItemRequest with _$CreateOrderItemRequest {
   const factory CreateOrderItemRequest({
-    @JsonKey(name: 'product_name') required String productName,
+    @JsonKey(name: 'product_id') required String productId,
     required int quantity,
-    @JsonKey(name: 'unit_price') required double unitPrice,
+    // IDs of the chosen modifier options.
+    @Default(<String>[]) List<String> modifiers,
   }) = _CreateOrderItemRequest;
 
   factory CreateOrderItemRequest.fromJson(Map<String, dynamic> json) =>
@@ -2,13 +2,19 @@ package fcm
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/messaging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/option"
 )
 
+const instrumentationName = "github.com/heptapegon/localpickup/pkg/fcm"
+
 type Notification struct {
 	Title string
 	Body  string
@@ -21,30 +27,48 @@ type Message struct {
 }
 
 type Client struct {
-	msg *messaging.Client
+	msg    *messaging.Client
+	logger *slog.Logger
 }
 
 // NewClient initialises the Firebase Admin SDK.
 // If the credentials file is missing, the client degrades gracefully
 // (notifications are skipped instead of crashing).
-func NewClient(credentialsPath string) *Client {
+func NewClient(credentialsPath string, logger *slog.Logger) *Client {
 	ctx := context.Background()
 	app, err := firebase.NewApp(ctx, nil, option.WithCredentialsFile(credentialsPath))
 	if err != nil {
-		log.Printf("fcm: failed to init app (%v) — push notifications disabled", err)
-		return &Client{}
+		logger.Warn("fcm: failed to init app, push notifications disabled", "error", err)
+		return &Client{logger: logger}
 	}
 	msgClient, err := app.Messaging(ctx)
 	if err != nil {
-		log.Printf("fcm: failed to get messaging client (%v) — push notifications disabled", err)
-		return &Client{}
+		logger.Warn("fcm: failed to get messaging client, push notifications disabled", "error", err)
+		return &Client{logger: logger}
 	}
-	return &Client{msg: msgClient}
+	return &Client{msg: msgClient, logger: logger}
 }
 
-func (c *Client) Send(ctx context.Context, m *Message) error {
+// Enabled reports whether the client was initialised with valid credentials.
+func (c *Client) Enabled() bool { return c.msg != nil }
+
+func (c *Client) Send(ctx context.Context, m *Message) (err error) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, "fcm.send", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "fcm"),
+			attribute.String("fcm.message_type", m.Data["type"]),
+			attribute.Bool("fcm.enabled", c.msg != nil),
+		))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "send failed")
+		}
+		span.End()
+	}()
+
 	if c.msg == nil {
-		log.Printf("fcm: skipping notification to %s (client not initialised)", m.Token)
+		c.logger.DebugContext(ctx, "fcm: skipping notification, client not initialised", "type", m.Data["type"])
 		return nil
 	}
 
@@ -59,6 +83,10 @@ func (c *Client) Send(ctx context.Context, m *Message) error {
 		}
 	}
 
-	_, err := c.msg.Send(ctx, fcmMsg)
-	return err
+	id, err := c.msg.Send(ctx, fcmMsg)
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.String("messaging.message.id", id))
+	return nil
 }